@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/wager-api/internal/repositories"
+	"github.com/wager-api/internal/services"
+	"github.com/wager-api/libs/database"
+	"github.com/wager-api/libs/logs"
+)
+
+// runExport is the export command, it writes the wagers or the purchases to a file:
+//
+//	go run ./cmd export -format ndjson -from 2024-01-01T00:00:00Z -status settled wagers
+//
+// The logs are written to the standard output as well, mind them with `-o -`.
+func runExport(ctx context.Context, db database.Ext, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := flags.String("format", services.ExportFormatCSV, "the format of the export, csv or ndjson")
+	from := flags.String("from", "", "only the rows since this RFC 3339 date")
+	to := flags.String("to", "", "only the rows before this RFC 3339 date")
+	status := flags.String("status", "", "only the rows of the markets in this status")
+	output := flags.String("o", "", "the file written, <kind>.<format> by default, - for the standard output")
+	fetchSize := flags.Uint("fetch-size", 0, "the number of rows read from the database at a time")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: export [flags] wagers|purchases")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("the export must be one of %s, %s", services.ExportWagers, services.ExportPurchases)
+	}
+	kind := flags.Arg(0)
+	filter, err := services.NewExportFilter(*from, *to, *status)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *output == "" {
+		*output = fmt.Sprintf("%s.%s", kind, *format)
+	}
+	if *output != "-" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("unable to create the export file: %w", err)
+		}
+		defer file.Close()
+		w = file
+	}
+	buffered := bufio.NewWriter(w)
+	exportService := &services.ExportService{
+		DB:         db,
+		ExportRepo: &repositories.ExportRepo{},
+		FetchSize:  uint32(*fetchSize),
+	}
+	written, err := exportService.Export(ctx, buffered, kind, *format, filter)
+	if err != nil {
+		return err
+	}
+	if err := buffered.Flush(); err != nil {
+		return fmt.Errorf("unable to write the export: %w", err)
+	}
+	if file, ok := w.(*os.File); ok && file != os.Stdout {
+		if err := file.Close(); err != nil {
+			return fmt.Errorf("unable to write the export: %w", err)
+		}
+	}
+	logs.Logger.Infow("export written", "export", kind, "format", *format, "rows", written, "output", *output)
+	return nil
+}
@@ -3,8 +3,12 @@ package main
 import (
 	"context"
 	"log"
+	"net"
 	"net/http"
+	"os"
+	"time"
 
+	"github.com/wager-api/internal/openapi"
 	"github.com/wager-api/internal/repositories"
 	"github.com/wager-api/internal/services"
 	"github.com/wager-api/libs/configs"
@@ -15,6 +19,17 @@ import (
 	"go.uber.org/zap"
 )
 
+var (
+	defaultMarketCloseInterval         = 10 * time.Second
+	defaultPriceHistoryCleanupInterval = time.Hour
+	defaultBuyOrderExpireInterval      = time.Minute
+	defaultBuyOrderMatchInterval       = time.Minute
+	defaultOutboxInterval              = time.Second
+	defaultOutboxTimeout               = 5 * time.Second
+	defaultWebhookInterval             = 5 * time.Second
+	defaultWebhookTimeout              = 10 * time.Second
+)
+
 func main() {
 	var err error
 	ctx := context.Background()
@@ -34,19 +49,263 @@ func main() {
 	// connect DB
 
 	pool := database.NewConnectionPool(ctx, logs.Logger.Desugar(), cfg.Postgres)
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		if err := runExport(ctx, pool, os.Args[2:]); err != nil {
+			logs.Logger.Fatalw("unable to export", "error", err)
+		}
+		return
+	}
+	reconciliationService := &services.ReconciliationService{
+		DB:                 pool,
+		ReconciliationRepo: &repositories.ReconciliationRepo{},
+		WagerRepo:          &repositories.WagerRepo{},
+		AuditLogRepo:       &repositories.AuditLogRepo{},
+		BatchSize:          cfg.Reconciliation.BatchSize,
+	}
+	if len(os.Args) > 1 && os.Args[1] == "reconcile" {
+		if err := runReconcile(ctx, reconciliationService, os.Args[2:]); err != nil {
+			logs.Logger.Fatalw("unable to reconcile", "error", err)
+		}
+		return
+	}
 
+	if cfg.Webhook.Timeout <= 0 {
+		cfg.Webhook.Timeout = defaultWebhookTimeout
+	}
+	webhookClient := services.NewWebhookClient(cfg.Webhook.Timeout)
+	if cfg.Webhook.AllowPrivateURLs {
+		webhookClient = &http.Client{Timeout: cfg.Webhook.Timeout}
+	}
+	webhookService := &services.WebhookService{
+		DB:                  pool,
+		WebhookRepo:         &repositories.WebhookRepo{},
+		WebhookDeliveryRepo: &repositories.WebhookDeliveryRepo{},
+		AuditLogRepo:        &repositories.AuditLogRepo{},
+		Client:              webhookClient,
+		BatchSize:           cfg.Webhook.BatchSize,
+		MaxAttempts:         cfg.Webhook.MaxAttempts,
+		DisableAfter:        cfg.Webhook.DisableAfter,
+		MinBackoff:          cfg.Webhook.MinBackoff,
+		MaxBackoff:          cfg.Webhook.MaxBackoff,
+		Lease:               cfg.Webhook.Lease,
+		AllowPrivateURLs:    cfg.Webhook.AllowPrivateURLs,
+	}
+	fees := newFeeModel(cfg.Fees)
+	if err := fees.Validate(); err != nil {
+		logs.Logger.Fatalw("invalid fee model", "error", err)
+	}
+	risk := &services.RiskLimits{
+		MaxSellerExposure: cfg.Risk.MaxSellerExposure,
+		MaxMarketVolume:   cfg.Risk.MaxMarketVolume,
+		MaxPurchase:       cfg.Risk.MaxPurchase,
+	}
+	if err := risk.Validate(); err != nil {
+		logs.Logger.Fatalw("invalid risk limits", "error", err)
+	}
+	surveillance := &services.SurveillanceRules{
+		Window:       cfg.Surveillance.Window,
+		SelfTrade:    services.SurveillanceRule(cfg.Surveillance.SelfTrade),
+		ResellLoop:   services.SurveillanceRule(cfg.Surveillance.ResellLoop),
+		PriceWalking: services.SurveillanceRule(cfg.Surveillance.PriceWalking),
+	}
+	if err := surveillance.Validate(); err != nil {
+		logs.Logger.Fatalw("invalid surveillance rules", "error", err)
+	}
+	if cfg.BaseCurrency != "" {
+		if cfg.BaseCurrency, err = services.ParseCurrency(cfg.BaseCurrency); err != nil {
+			logs.Logger.Fatalw("invalid base currency", "error", err)
+		}
+	}
 	// wagerService := services
 	wagerService := &services.WagerService{
-		DB:           pool,
-		WagerRepo:    &repositories.WagerRepo{},
-		PurchaseRepo: &repositories.PurchaseRepo{},
+		DB:                      pool,
+		WagerRepo:               &repositories.WagerRepo{},
+		PurchaseRepo:            &repositories.PurchaseRepo{},
+		MarketRepo:              &repositories.MarketRepo{},
+		EventRepo:               &repositories.EventRepo{},
+		PriceHistoryRepo:        &repositories.PriceHistoryRepo{},
+		WagerUpdateRepo:         &repositories.WagerUpdateRepo{},
+		OutboxRepo:              &repositories.OutboxRepo{},
+		AuditLogRepo:            &repositories.AuditLogRepo{},
+		Stream:                  services.NewWagerStream(),
+		Webhooks:                webhookService,
+		BuyOrderRepo:            &repositories.BuyOrderRepo{},
+		StakeHoldingRepo:        &repositories.StakeHoldingRepo{},
+		ResaleListingRepo:       &repositories.ResaleListingRepo{},
+		StakeTransferRepo:       &repositories.StakeTransferRepo{},
+		PositionRepo:            &repositories.PositionRepo{},
+		Fees:                    fees,
+		PurchaseFeeRepo:         &repositories.PurchaseFeeRepo{},
+		AccountRepo:             &repositories.AccountRepo{},
+		ExchangeRateRepo:        &repositories.ExchangeRateRepo{},
+		BaseCurrency:            cfg.BaseCurrency,
+		Risk:                    risk,
+		RiskRepo:                &repositories.RiskRepo{},
+		SpendLimitIncreaseDelay: cfg.ResponsibleGambling.LimitIncreaseDelay,
+		RequireBuyer:            cfg.ResponsibleGambling.RequireBuyer,
+		SpendLimitRepo:          &repositories.SpendLimitRepo{},
+		AccountRestrictionRepo:  &repositories.AccountRestrictionRepo{},
+		Surveillance:            surveillance,
+		SurveillanceRepo:        &repositories.SurveillanceRepo{},
+		TradeAlertRepo:          &repositories.TradeAlertRepo{},
+		FairPriceSpread:         cfg.Pricing.FairPriceSpread,
+	}
+	eventService := &services.EventService{
+		DB:               pool,
+		EventRepo:        &repositories.EventRepo{},
+		MarketRepo:       &repositories.MarketRepo{},
+		WagerRepo:        &repositories.WagerRepo{},
+		OutboxRepo:       &repositories.OutboxRepo{},
+		StakeHoldingRepo: &repositories.StakeHoldingRepo{},
+		AuditLogRepo:     &repositories.AuditLogRepo{},
+	}
+	if cfg.MarketCloseInterval <= 0 {
+		cfg.MarketCloseInterval = defaultMarketCloseInterval
+	}
+	go eventService.RunMarketCloser(ctx, cfg.MarketCloseInterval)
+	if cfg.PriceHistory.CleanupInterval <= 0 {
+		cfg.PriceHistory.CleanupInterval = defaultPriceHistoryCleanupInterval
+	}
+	go wagerService.Stream.Listen(ctx, pool)
+	go wagerService.RunPriceHistoryCleaner(ctx, cfg.PriceHistory.Retention, cfg.PriceHistory.CleanupInterval)
+	if cfg.BuyOrderExpireInterval <= 0 {
+		cfg.BuyOrderExpireInterval = defaultBuyOrderExpireInterval
+	}
+	go wagerService.RunBuyOrderExpirer(ctx, cfg.BuyOrderExpireInterval)
+	if cfg.BuyOrderMatchInterval <= 0 {
+		cfg.BuyOrderMatchInterval = defaultBuyOrderMatchInterval
+	}
+	go wagerService.RunBuyOrderMatcher(ctx, cfg.BuyOrderMatchInterval)
+	if cfg.Webhook.Interval <= 0 {
+		cfg.Webhook.Interval = defaultWebhookInterval
+	}
+	go webhookService.Run(ctx, cfg.Webhook.Interval)
+	go reconciliationService.Run(ctx, cfg.Reconciliation.Interval, cfg.Reconciliation.Repair)
+	if publisher := newOutboxPublisher(cfg.Outbox); publisher != nil {
+		if cfg.Outbox.Interval <= 0 {
+			cfg.Outbox.Interval = defaultOutboxInterval
+		}
+		relay := &services.OutboxRelay{
+			DB:         pool,
+			OutboxRepo: &repositories.OutboxRepo{},
+			Publisher:  publisher,
+			BatchSize:  cfg.Outbox.BatchSize,
+			MinBackoff: cfg.Outbox.MinBackoff,
+			MaxBackoff: cfg.Outbox.MaxBackoff,
+			Lease:      cfg.Outbox.Lease,
+		}
+		go relay.Run(ctx, cfg.Outbox.Interval)
 	}
 
 	mux := mux.InitWithLogger(logs.Logger.Desugar())
 	services.NewWagerHandler(mux, wagerService)
+	services.NewEventHandler(mux, eventService)
+	services.NewWagerSocketHandler(mux, services.NewWagerSocket(wagerService, cfg.WebSocket.Tokens))
+	services.NewWebhookHandler(mux, webhookService, cfg.Admin.Tokens)
+	services.NewAuditHandler(mux, &services.AuditService{
+		DB:           pool,
+		AuditLogRepo: &repositories.AuditLogRepo{},
+	}, cfg.Admin.Tokens)
+	services.NewExportHandler(mux, &services.ExportService{
+		DB:         pool,
+		ExportRepo: &repositories.ExportRepo{},
+	}, cfg.Admin.Tokens)
+	services.NewFeeHandler(mux, &services.FeeService{
+		DB:              pool,
+		PurchaseFeeRepo: &repositories.PurchaseFeeRepo{},
+	}, cfg.Admin.Tokens)
+	services.NewCurrencyHandler(mux, &services.CurrencyService{
+		DB:               pool,
+		ExchangeRateRepo: &repositories.ExchangeRateRepo{},
+		PurchaseRepo:     &repositories.PurchaseRepo{},
+		AuditLogRepo:     &repositories.AuditLogRepo{},
+		BaseCurrency:     cfg.BaseCurrency,
+	}, cfg.Admin.Tokens)
+	services.NewRiskHandler(mux, &services.RiskService{
+		DB:           pool,
+		RiskRepo:     &repositories.RiskRepo{},
+		Limits:       risk,
+		BaseCurrency: cfg.BaseCurrency,
+	}, cfg.Admin.Tokens)
+	services.NewSurveillanceHandler(mux, &services.SurveillanceService{
+		DB:              pool,
+		TradeAlertRepo:  &repositories.TradeAlertRepo{},
+		AccountLinkRepo: &repositories.AccountLinkRepo{},
+		AuditLogRepo:    &repositories.AuditLogRepo{},
+	}, cfg.Admin.Tokens)
+	doc, err := openapi.Load()
+	if err != nil {
+		logs.Logger.Fatalw("unable to load the openapi document", "error", err)
+	}
+	openapi.NewHandler(mux, doc)
+	var handler http.Handler = mux
+	if cfg.OpenAPI.ValidateRequests {
+		validate, err := openapi.ValidationMiddleware(doc)
+		if err != nil {
+			logs.Logger.Fatalw("unable to validate requests", "error", err)
+		}
+		handler = validate(mux)
+	}
+	if cfg.GRPCAddress != "" {
+		listener, err := net.Listen("tcp", cfg.GRPCAddress)
+		if err != nil {
+			logs.Logger.Fatalw("unable to listen for gRPC", "address", cfg.GRPCAddress, "error", err)
+		}
+		go func() {
+			if err := services.NewWagerGRPCServer(wagerService, cfg.GRPC.Tokens).Serve(listener); err != nil {
+				logs.Logger.Fatalw("gRPC server crashing...", "error", err)
+			}
+		}()
+	}
 	// logging.Logger.Infof("Listening at %s", cfg.Address)
-	err = http.ListenAndServe(cfg.Address, mux)
+	err = http.ListenAndServe(cfg.Address, handler)
 	if err != nil {
 		logs.Logger.Fatal("service crashing... :8080")
 	}
 }
+
+// newOutboxPublisher returns the publisher the outbox events are relayed to, nil when
+// the relay is disabled.
+func newOutboxPublisher(cfg configs.Outbox) services.Publisher {
+	switch cfg.Publisher {
+	case "":
+		return nil
+	case "stdout":
+		return services.NewWriterPublisher(os.Stdout)
+	case "file":
+		file, err := os.OpenFile(cfg.FilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			logs.Logger.Fatalw("unable to open the outbox file", "path", cfg.FilePath, "error", err)
+		}
+		return services.NewWriterPublisher(file)
+	case "http":
+		if cfg.Timeout <= 0 {
+			cfg.Timeout = defaultOutboxTimeout
+		}
+		return services.NewHTTPPublisher(cfg.URL, cfg.Timeout)
+	default:
+		logs.Logger.Fatalw("unknown outbox publisher, it must be one of stdout, file, http", "publisher", cfg.Publisher)
+		return nil
+	}
+}
+
+func newFeeSchedule(cfg configs.FeeSchedule) services.FeeSchedule {
+	schedule := services.FeeSchedule{Percentage: cfg.Percentage, Flat: cfg.Flat}
+	for _, tier := range cfg.Tiers {
+		schedule.Tiers = append(schedule.Tiers, services.FeeTier{MinVolume: tier.MinVolume, Percentage: tier.Percentage})
+	}
+	return schedule
+}
+
+// newFeeModel converts the fees of the config to the fee model of the purchases.
+func newFeeModel(cfg configs.Fees) *services.FeeModel {
+	fees := &services.FeeModel{
+		Default:      newFeeSchedule(cfg.FeeSchedule),
+		Markets:      make(map[int32]services.FeeSchedule, len(cfg.Markets)),
+		VolumeWindow: cfg.VolumeWindow,
+	}
+	for marketID, schedule := range cfg.Markets {
+		fees.Markets[marketID] = newFeeSchedule(schedule)
+	}
+	return fees
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/wager-api/internal/services"
+	"github.com/wager-api/libs/logs"
+)
+
+// runReconcile is the reconcile command, it checks the amount_sold and percentage_sold of
+// every wager against its purchases and writes the report to the standard output:
+//
+//	go run ./cmd reconcile -repair
+//
+// It fails when drifted wagers are left unrepaired, so a scheduler notices them.
+func runReconcile(ctx context.Context, reconciliationService *services.ReconciliationService, args []string) error {
+	flags := flag.NewFlagSet("reconcile", flag.ContinueOnError)
+	repair := flags.Bool("repair", false, "set the drifted wagers back to what their purchases sum up to")
+	batchSize := flags.Uint("batch-size", 0, "the number of wagers checked at a time")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: reconcile [flags]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *batchSize > 0 {
+		reconciliationService.BatchSize = uint32(*batchSize)
+	}
+	report, err := reconciliationService.Reconcile(ctx, *repair)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return fmt.Errorf("unable to write the report: %w", err)
+	}
+	logs.Logger.Infow("wager aggregates reconciled", "checked", report.Checked,
+		"discrepancies", len(report.Discrepancies), "repaired", report.Repaired)
+	if unrepaired := len(report.Discrepancies) - report.Repaired; unrepaired > 0 {
+		return fmt.Errorf("%d wagers drifted from their purchases", unrepaired)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package integrationtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/wager-api/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test_BuyOrder
+// Step 1: init Wager by call PlaceWager
+// Step 2: place a buy order below the price, it stays open
+// Step 3: a purchase drops the price under the limit, the order is filled
+// Step 4: a filled order can't be cancelled, nor by anyone but its buyer
+func Test_BuyOrder(t *testing.T) {
+	// Step 1: init Wager by call PlaceWager
+	rec := httptest.NewRecorder()
+	chiMux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/wagers", bytes.NewBuffer([]byte(`{"total_wager_value": 50, "odds": 30,"selling_percentage": 30,"selling_price": 50}`))))
+	assert.Equal(t, http.StatusCreated, rec.Code, "status code must be 201")
+	placeWagerResponse := models.PlaceWagerResponse{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&placeWagerResponse))
+
+	// Step 2: place a buy order below the price, it stays open
+	placeBuyOrderReq, err := json.Marshal(models.PlaceBuyOrderRequest{
+		BuyerID:    "buyer-1",
+		LimitPrice: 30,
+		ExpiresAt:  func() *time.Time { t := time.Now().Add(time.Hour); return &t }(),
+	})
+	assert.NoError(t, err)
+	rec = httptest.NewRecorder()
+	chiMux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, fmt.Sprintf("/wagers/%d/buy-orders", placeWagerResponse.ID), bytes.NewBuffer(placeBuyOrderReq)))
+	assert.Equal(t, http.StatusCreated, rec.Code, "status code must be 201")
+	buyOrder := models.BuyOrder{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&buyOrder))
+	assert.Equal(t, "open", buyOrder.Status)
+
+	// Step 3: a purchase drops the price under the limit, the order is filled
+	rec = httptest.NewRecorder()
+	chiMux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, fmt.Sprintf("/buy/%d", placeWagerResponse.ID), bytes.NewBuffer([]byte(`{"buying_price": 25}`))))
+	assert.Equal(t, http.StatusCreated, rec.Code, "status code must be 201")
+	rec = httptest.NewRecorder()
+	chiMux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/buy-orders/%d", buyOrder.ID), nil))
+	assert.Equal(t, http.StatusOK, rec.Code, "status code must be 200")
+	buyOrder = models.BuyOrder{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&buyOrder))
+	assert.Equal(t, "filled", buyOrder.Status)
+	assert.NotNil(t, buyOrder.PurchaseID, "purchase_id must be set")
+	assert.NotNil(t, buyOrder.FilledAt, "filled_at must be set")
+
+	// Step 4: a filled order can't be cancelled, nor by anyone but its buyer
+	rec = httptest.NewRecorder()
+	chiMux.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/buy-orders/%d?buyer_id=buyer-2", buyOrder.ID), nil))
+	assert.Equal(t, http.StatusForbidden, rec.Code, "status code must be 403")
+	rec = httptest.NewRecorder()
+	chiMux.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/buy-orders/%d?buyer_id=buyer-1", buyOrder.ID), nil))
+	assert.Equal(t, http.StatusConflict, rec.Code, "status code must be 409")
+}
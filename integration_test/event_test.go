@@ -0,0 +1,70 @@
+package integrationtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/wager-api/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test_BuyWager_ClosedMarket
+// Step 1: create an event and a market on it
+// Step 2: place a wager on the market
+// Step 3: close the market, then the wager can't be bought anymore
+func Test_BuyWager_ClosedMarket(t *testing.T) {
+	// Step 1: create an event and a market on it
+	createEventDataReq, err := json.Marshal(models.CreateEventRequest{
+		Sport:    "football",
+		HomeTeam: "Arsenal",
+		AwayTeam: "Chelsea",
+		StartAt:  time.Now().Add(time.Hour),
+	})
+	assert.NoError(t, err)
+	rec := httptest.NewRecorder()
+	chiMux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/events", bytes.NewBuffer(createEventDataReq)))
+	assert.Equal(t, http.StatusCreated, rec.Code, "status code must be 201")
+	event := models.Event{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&event))
+	assert.Equal(t, "scheduled", event.Status)
+
+	rec = httptest.NewRecorder()
+	chiMux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, fmt.Sprintf("/events/%d/markets", event.ID), bytes.NewBuffer([]byte(`{"market_type": "match_winner"}`))))
+	assert.Equal(t, http.StatusCreated, rec.Code, "status code must be 201")
+	market := models.Market{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&market))
+	assert.Equal(t, "open", market.Status)
+
+	// Step 2: place a wager on the market
+	placeWagerDataReq := []byte(fmt.Sprintf(`{"market_id": %d, "total_wager_value": 50, "odds": 30,"selling_percentage": 30,"selling_price": 50}`, market.ID))
+	rec = httptest.NewRecorder()
+	chiMux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/wagers", bytes.NewBuffer(placeWagerDataReq)))
+	assert.Equal(t, http.StatusCreated, rec.Code, "status code must be 201")
+	placeWagerResponse := models.PlaceWagerResponse{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&placeWagerResponse))
+	if assert.NotNil(t, placeWagerResponse.MarketID) {
+		assert.Equal(t, market.ID, *placeWagerResponse.MarketID)
+	}
+
+	rec = httptest.NewRecorder()
+	chiMux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/wagers?page=1&limit=10&event_id=%d", event.ID), nil))
+	assert.Equal(t, http.StatusOK, rec.Code, "status code must be 200")
+	wagers := []models.Wager{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&wagers))
+	assert.Len(t, wagers, 1, "only the wager of the event must be listed")
+
+	// Step 3: close the market, then the wager can't be bought anymore
+	rec = httptest.NewRecorder()
+	chiMux.ServeHTTP(rec, httptest.NewRequest(http.MethodPatch, fmt.Sprintf("/markets/%d", market.ID), bytes.NewBuffer([]byte(`{"status": "closed"}`))))
+	assert.Equal(t, http.StatusOK, rec.Code, "status code must be 200")
+
+	rec = httptest.NewRecorder()
+	chiMux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, fmt.Sprintf("/buy/%d", placeWagerResponse.ID), bytes.NewBuffer([]byte(`{"buying_price": 40}`))))
+	assert.Equal(t, http.StatusConflict, rec.Code, "status code must be 409")
+}
@@ -0,0 +1,77 @@
+package integrationtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/wager-api/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test_ListPositions
+// Step 1: init Wager by call PlaceWager and buy it with a buyer_id
+// Step 2: the buyer resells a part of its stake to another account
+// Step 3: the positions of both accounts are valued at the current price of the wager, their
+// cost includes the fees of the purchase and of the resale
+func Test_ListPositions(t *testing.T) {
+	// Step 1: init Wager by call PlaceWager and buy it with a buyer_id
+	rec := httptest.NewRecorder()
+	chiMux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/wagers", bytes.NewBuffer([]byte(`{"total_wager_value": 50, "odds": 30,"selling_percentage": 30,"selling_price": 50}`))))
+	assert.Equal(t, http.StatusCreated, rec.Code, "status code must be 201")
+	placeWagerResponse := models.PlaceWagerResponse{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&placeWagerResponse))
+	rec = httptest.NewRecorder()
+	chiMux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, fmt.Sprintf("/buy/%d", placeWagerResponse.ID), bytes.NewBuffer([]byte(`{"buying_price": 20, "buyer_id": "position-holder-1"}`))))
+	assert.Equal(t, http.StatusCreated, rec.Code, "status code must be 201")
+	buyWagerResponse := models.BuyWagerResponse{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&buyWagerResponse))
+
+	// Step 2: the buyer resells a part of its stake to another account
+	rec = httptest.NewRecorder()
+	chiMux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, fmt.Sprintf("/purchases/%d/listings", buyWagerResponse.PurchaseID), bytes.NewBuffer([]byte(`{"seller_id": "position-holder-1", "stake": 5, "asking_price": 8}`))))
+	assert.Equal(t, http.StatusCreated, rec.Code, "status code must be 201")
+	listing := models.Listing{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&listing))
+	rec = httptest.NewRecorder()
+	chiMux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, fmt.Sprintf("/listings/%d/buy", listing.ID), bytes.NewBuffer([]byte(`{"buyer_id": "position-holder-2"}`))))
+	assert.Equal(t, http.StatusCreated, rec.Code, "status code must be 201")
+
+	// Step 3: the positions of both accounts are valued at the current price of the wager
+	rec = httptest.NewRecorder()
+	chiMux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/accounts/position-holder-1/positions?page=1&limit=10", nil))
+	assert.Equal(t, http.StatusOK, rec.Code, "status code must be 200")
+	positions := models.AccountPositions{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&positions))
+	assert.Len(t, positions.Positions, 1)
+	assert.Equal(t, &models.Position{
+		WagerID: placeWagerResponse.ID, Status: models.PositionStatusOpen, Stake: 15, TotalCost: 20.5, ImpliedStake: 4.5,
+		CurrentSellingPrice: 20, MarketValue: 6, UnrealizedPnL: -9.38, RealizedPnL: 2.88, Currency: "USD",
+	}, positions.Positions[0])
+	assert.Len(t, positions.Summary, 1)
+	assert.Equal(t, float32(2.88), positions.Summary[0].RealizedPnL)
+	// a page past the positions still has the summary of all of them
+	rec = httptest.NewRecorder()
+	chiMux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/accounts/position-holder-1/positions?page=2&limit=10", nil))
+	assert.Equal(t, http.StatusOK, rec.Code, "status code must be 200")
+	positions = models.AccountPositions{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&positions))
+	assert.Empty(t, positions.Positions)
+	assert.Equal(t, []*models.PositionSummary{{
+		Currency: "USD", Positions: 1, OpenPositions: 1, TotalCost: 20.5, MarketValue: 6, UnrealizedPnL: -9.38, RealizedPnL: 2.88,
+	}}, positions.Summary)
+
+	rec = httptest.NewRecorder()
+	chiMux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/accounts/position-holder-2/positions?page=1&limit=10", nil))
+	assert.Equal(t, http.StatusOK, rec.Code, "status code must be 200")
+	positions = models.AccountPositions{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&positions))
+	assert.Len(t, positions.Positions, 1)
+	assert.Equal(t, float32(5), positions.Positions[0].Stake)
+	assert.Equal(t, float32(8.26), positions.Positions[0].TotalCost)
+	assert.Equal(t, float32(-6.26), positions.Positions[0].UnrealizedPnL)
+}
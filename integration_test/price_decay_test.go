@@ -0,0 +1,55 @@
+package integrationtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/wager-api/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test_PriceDecay
+// Step 1: place a wager decaying to its floor within a second
+// Step 2: once decayed, the wager is read and bought at its floor price
+// Step 3: a buy order at the decayed price is filled at once
+func Test_PriceDecay(t *testing.T) {
+	// Step 1: place a wager decaying to its floor within a second
+	rec := httptest.NewRecorder()
+	chiMux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/wagers", bytes.NewBuffer([]byte(`{"total_wager_value": 50, "odds": 30, "selling_percentage": 30, "selling_price": 50,
+		"price_decay": {"type": "linear", "floor_price": 20, "duration": "1s"}}`))))
+	assert.Equal(t, http.StatusCreated, rec.Code, "status code must be 201")
+	placeWagerResponse := models.PlaceWagerResponse{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&placeWagerResponse))
+	assert.Equal(t, float32(50), placeWagerResponse.CurrentSellingPrice)
+	assert.Equal(t, &models.PriceDecay{Type: "linear", FloorPrice: 20, Duration: "1s"}, placeWagerResponse.PriceDecay)
+
+	// Step 2: once decayed, the wager is read and bought at its floor price
+	time.Sleep(1100 * time.Millisecond)
+	rec = httptest.NewRecorder()
+	chiMux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/wagers/%d", placeWagerResponse.ID), nil))
+	assert.Equal(t, http.StatusOK, rec.Code, "status code must be 200")
+	wager := models.Wager{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&wager))
+	assert.Equal(t, float32(20), wager.CurrentSellingPrice)
+	rec = httptest.NewRecorder()
+	chiMux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, fmt.Sprintf("/buy/%d", placeWagerResponse.ID), bytes.NewBuffer([]byte(`{"buying_price": 30}`))))
+	assert.NotEqual(t, http.StatusCreated, rec.Code, "the buying_price is above the decayed price")
+	rec = httptest.NewRecorder()
+	chiMux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, fmt.Sprintf("/buy/%d", placeWagerResponse.ID), bytes.NewBuffer([]byte(`{"buying_price": 15}`))))
+	assert.Equal(t, http.StatusCreated, rec.Code, "status code must be 201")
+
+	// Step 3: a buy order at the decayed price is filled at once
+	rec = httptest.NewRecorder()
+	chiMux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, fmt.Sprintf("/wagers/%d/buy-orders", placeWagerResponse.ID), bytes.NewBuffer([]byte(fmt.Sprintf(
+		`{"buyer_id": "buyer-1", "limit_price": 15, "expires_at": %q}`, time.Now().Add(time.Hour).Format(time.RFC3339))))))
+	assert.Equal(t, http.StatusCreated, rec.Code, "status code must be 201")
+	buyOrder := models.BuyOrder{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&buyOrder))
+	assert.Equal(t, "filled", buyOrder.Status)
+}
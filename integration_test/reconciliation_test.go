@@ -0,0 +1,82 @@
+package integrationtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/wager-api/internal/models"
+	"github.com/wager-api/internal/repositories"
+	"github.com/wager-api/internal/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test_ReconcileWagerAggregates
+// Step 1: init Wager by call PlaceWager and buy it twice
+// Step 2: the amount_sold of the wager drifts from its purchases
+// Step 3: the reconciliation reports the drift, then repairs it
+func Test_ReconcileWagerAggregates(t *testing.T) {
+	ctx := context.Background()
+	// Step 1: init Wager by call PlaceWager and buy it twice
+	rec := httptest.NewRecorder()
+	chiMux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/wagers", bytes.NewBuffer([]byte(`{"total_wager_value": 50, "odds": 30,"selling_percentage": 30,"selling_price": 50}`))))
+	assert.Equal(t, http.StatusCreated, rec.Code, "status code must be 201")
+	placeWagerResponse := models.PlaceWagerResponse{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&placeWagerResponse))
+	for _, buyingPrice := range []string{"10.2", "10.1"} {
+		rec = httptest.NewRecorder()
+		chiMux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, fmt.Sprintf("/buy/%d", placeWagerResponse.ID), bytes.NewBuffer([]byte(`{"buying_price": `+buyingPrice+`}`))))
+		assert.Equal(t, http.StatusCreated, rec.Code, "status code must be 201")
+	}
+
+	// Step 2: the amount_sold of the wager drifts from its purchases
+	_, err := DB.Exec(ctx, `UPDATE wager SET amount_sold = 25, percentage_sold = 50 WHERE wager_id = $1`, placeWagerResponse.ID)
+	assert.NoError(t, err)
+
+	// Step 3: the reconciliation reports the drift, then repairs it
+	reconciliationService := &services.ReconciliationService{
+		DB:                 DB,
+		ReconciliationRepo: &repositories.ReconciliationRepo{},
+		WagerRepo:          &repositories.WagerRepo{},
+		AuditLogRepo:       &repositories.AuditLogRepo{},
+		BatchSize:          2,
+	}
+	find := func(report *models.ReconciliationReport) *models.WagerDiscrepancy {
+		for _, discrepancy := range report.Discrepancies {
+			if discrepancy.WagerID == placeWagerResponse.ID {
+				return discrepancy
+			}
+		}
+		return nil
+	}
+	report, err := reconciliationService.Reconcile(ctx, false)
+	assert.NoError(t, err)
+	if discrepancy := find(report); assert.NotNil(t, discrepancy) {
+		assert.Equal(t, int64(2), discrepancy.Purchases)
+		assert.Equal(t, float32(20.3), discrepancy.ExpectedAmountSold)
+		assert.Equal(t, float32(4.7), discrepancy.AmountSoldDiff)
+		assert.Equal(t, float32(40.6), discrepancy.ExpectedPercentageSold)
+		assert.False(t, discrepancy.Repaired)
+	}
+
+	report, err = reconciliationService.Reconcile(ctx, true)
+	assert.NoError(t, err)
+	if discrepancy := find(report); assert.NotNil(t, discrepancy) {
+		assert.True(t, discrepancy.Repaired)
+	}
+	rec = httptest.NewRecorder()
+	chiMux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/wagers/%d", placeWagerResponse.ID), nil))
+	wager := models.Wager{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&wager))
+	assert.Equal(t, float32(20.3), wager.AmountSold)
+	assert.Equal(t, float32(40.6), wager.PercentageSold)
+
+	report, err = reconciliationService.Reconcile(ctx, false)
+	assert.NoError(t, err)
+	assert.Nil(t, find(report))
+}
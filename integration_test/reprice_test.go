@@ -0,0 +1,70 @@
+package integrationtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/wager-api/internal/models"
+	"github.com/wager-api/internal/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test_RepriceWager
+// Step 1: init Wager by call PlaceWager
+// Step 2: raise the selling_price, the current price follows as nothing is sold
+// Step 3: lowering the current price fills the buy order it satisfies
+// Step 4: the selling_price can't drop below the amount sold
+// Step 5: no one but the seller can reprice the wager
+func Test_RepriceWager(t *testing.T) {
+	// Step 1: init Wager by call PlaceWager
+	rec := httptest.NewRecorder()
+	chiMux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/wagers", bytes.NewBuffer([]byte(`{"seller_id": "reprice-seller-1", "total_wager_value": 50, "odds": 30,"selling_percentage": 30,"selling_price": 50}`))))
+	assert.Equal(t, http.StatusCreated, rec.Code, "status code must be 201")
+	placeWagerResponse := models.PlaceWagerResponse{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&placeWagerResponse))
+	wagerURL := fmt.Sprintf("/wagers/%d", placeWagerResponse.ID)
+
+	// Step 2: raise the selling_price, the current price follows as nothing is sold
+	rec = httptest.NewRecorder()
+	chiMux.ServeHTTP(rec, httptest.NewRequest(http.MethodPatch, wagerURL, bytes.NewBuffer([]byte(`{"seller_id": "reprice-seller-1", "selling_price": 60}`))))
+	assert.Equal(t, http.StatusOK, rec.Code, "status code must be 200")
+	wager := models.Wager{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&wager))
+	assert.Equal(t, float32(60), wager.SellingPrice)
+	assert.Equal(t, float32(60), wager.CurrentSellingPrice)
+
+	// Step 3: lowering the current price fills the buy order it satisfies
+	rec = httptest.NewRecorder()
+	chiMux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, wagerURL+"/buy-orders", bytes.NewBuffer([]byte(fmt.Sprintf(
+		`{"buyer_id": "buyer-1", "limit_price": 40, "expires_at": %q}`, time.Now().Add(time.Hour).Format(time.RFC3339))))))
+	assert.Equal(t, http.StatusCreated, rec.Code, "status code must be 201")
+	buyOrder := models.BuyOrder{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&buyOrder))
+	assert.Equal(t, "open", buyOrder.Status)
+	rec = httptest.NewRecorder()
+	chiMux.ServeHTTP(rec, httptest.NewRequest(http.MethodPatch, wagerURL, bytes.NewBuffer([]byte(`{"seller_id": "reprice-seller-1", "current_selling_price": 40}`))))
+	assert.Equal(t, http.StatusOK, rec.Code, "status code must be 200")
+	rec = httptest.NewRecorder()
+	chiMux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/buy-orders/%d", buyOrder.ID), nil))
+	buyOrder = models.BuyOrder{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&buyOrder))
+	assert.Equal(t, "filled", buyOrder.Status)
+
+	// Step 4: the selling_price can't drop below the amount sold
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPatch, wagerURL, bytes.NewBuffer([]byte(`{"selling_price": 35}`)))
+	req.Header.Set(services.ActorHeader, "reprice-seller-1")
+	chiMux.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code, "status code must be 400")
+
+	// Step 5: no one but the seller can reprice the wager
+	rec = httptest.NewRecorder()
+	chiMux.ServeHTTP(rec, httptest.NewRequest(http.MethodPatch, wagerURL, bytes.NewBuffer([]byte(`{"seller_id": "reprice-seller-2", "selling_price": 45}`))))
+	assert.Equal(t, http.StatusForbidden, rec.Code, "status code must be 403")
+}
@@ -0,0 +1,76 @@
+package integrationtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/wager-api/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test_Resale
+// Step 1: init Wager by call PlaceWager and buy it with a buyer_id
+// Step 2: the buyer lists a part of its stake, it can't list more than it holds
+// Step 3: another buyer buys the listing, the stake moves to it
+// Step 4: the sold listing can't be bought or cancelled again
+func Test_Resale(t *testing.T) {
+	// Step 1: init Wager by call PlaceWager and buy it with a buyer_id
+	rec := httptest.NewRecorder()
+	chiMux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/wagers", bytes.NewBuffer([]byte(`{"total_wager_value": 50, "odds": 30,"selling_percentage": 30,"selling_price": 50}`))))
+	assert.Equal(t, http.StatusCreated, rec.Code, "status code must be 201")
+	placeWagerResponse := models.PlaceWagerResponse{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&placeWagerResponse))
+	rec = httptest.NewRecorder()
+	chiMux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, fmt.Sprintf("/buy/%d", placeWagerResponse.ID), bytes.NewBuffer([]byte(`{"buying_price": 20, "buyer_id": "holder-1"}`))))
+	assert.Equal(t, http.StatusCreated, rec.Code, "status code must be 201")
+	buyWagerResponse := models.BuyWagerResponse{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&buyWagerResponse))
+	assert.Equal(t, "holder-1", buyWagerResponse.BuyerID)
+	listingsURL := fmt.Sprintf("/purchases/%d/listings", buyWagerResponse.PurchaseID)
+
+	// Step 2: the buyer lists a part of its stake, it can't list more than it holds
+	rec = httptest.NewRecorder()
+	chiMux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, listingsURL, bytes.NewBuffer([]byte(`{"seller_id": "holder-1", "stake": 15, "asking_price": 18}`))))
+	assert.Equal(t, http.StatusCreated, rec.Code, "status code must be 201")
+	listing := models.Listing{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&listing))
+	assert.Equal(t, "open", listing.Status)
+	rec = httptest.NewRecorder()
+	chiMux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, listingsURL, bytes.NewBuffer([]byte(`{"seller_id": "holder-1", "stake": 10, "asking_price": 12}`))))
+	assert.Equal(t, http.StatusBadRequest, rec.Code, "status code must be 400")
+
+	// Step 3: another buyer buys the listing, the stake moves to it
+	listingURL := fmt.Sprintf("/listings/%d", listing.ID)
+	rec = httptest.NewRecorder()
+	chiMux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, listingURL+"/buy", bytes.NewBuffer([]byte(`{"buyer_id": "holder-2"}`))))
+	assert.Equal(t, http.StatusCreated, rec.Code, "status code must be 201")
+	transfer := models.StakeTransfer{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&transfer))
+	assert.Equal(t, "holder-1", transfer.FromHolderID)
+	assert.Equal(t, "holder-2", transfer.ToHolderID)
+	assert.Equal(t, float32(15), transfer.Stake)
+	assert.Equal(t, float32(18), transfer.Price)
+	assert.Equal(t, float32(0.46), transfer.TotalFee)
+	rec = httptest.NewRecorder()
+	chiMux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, listingURL, nil))
+	listing = models.Listing{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&listing))
+	assert.Equal(t, "sold", listing.Status)
+	assert.Equal(t, "holder-2", listing.BuyerID)
+
+	// Step 4: the sold listing can't be bought or cancelled again, nor by anyone but its seller
+	rec = httptest.NewRecorder()
+	chiMux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, listingURL+"/buy", bytes.NewBuffer([]byte(`{"buyer_id": "holder-3"}`))))
+	assert.Equal(t, http.StatusConflict, rec.Code, "status code must be 409")
+	rec = httptest.NewRecorder()
+	chiMux.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, listingURL+"?seller_id=holder-2", nil))
+	assert.Equal(t, http.StatusForbidden, rec.Code, "status code must be 403")
+	rec = httptest.NewRecorder()
+	chiMux.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, listingURL+"?seller_id=holder-1", nil))
+	assert.Equal(t, http.StatusConflict, rec.Code, "status code must be 409")
+}
@@ -0,0 +1,54 @@
+package integrationtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/wager-api/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test_PlaceWagerBatch
+// Step 1: an atomic batch with an invalid wager places nothing
+// Step 2: the same batch in best effort places the valid wagers, they can be fetched
+func Test_PlaceWagerBatch(t *testing.T) {
+	batch := []byte(`[
+		{"total_wager_value": 50, "odds": 30, "selling_percentage": 30, "selling_price": 50},
+		{"total_wager_value": 0, "odds": 30, "selling_percentage": 30, "selling_price": 50},
+		{"total_wager_value": 60, "odds": "5/2", "odds_format": "fractional", "selling_percentage": 20, "selling_price": 40}
+	]`)
+
+	// Step 1: an atomic batch with an invalid wager places nothing
+	rec := httptest.NewRecorder()
+	chiMux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/wagers/batch", bytes.NewBuffer(batch)))
+	assert.Equal(t, http.StatusBadRequest, rec.Code, "status code must be 400")
+	response := models.PlaceWagerBatchResponse{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+	assert.Equal(t, 0, response.Placed)
+	if assert.Len(t, response.Results, 3) {
+		assert.Equal(t, "aborted", response.Results[0].Status)
+		assert.Equal(t, "failed", response.Results[1].Status)
+	}
+
+	// Step 2: the same batch in best effort places the valid wagers, they can be fetched
+	rec = httptest.NewRecorder()
+	chiMux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/wagers/batch?mode=best_effort", bytes.NewBuffer(batch)))
+	assert.Equal(t, http.StatusMultiStatus, rec.Code, "status code must be 207")
+	response = models.PlaceWagerBatchResponse{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+	assert.Equal(t, 2, response.Placed)
+	assert.Equal(t, 1, response.Failed)
+	if assert.Len(t, response.Results, 3) && assert.NotNil(t, response.Results[2].Wager) {
+		assert.Greater(t, response.Results[2].Wager.ID, response.Results[0].Wager.ID)
+		assert.Equal(t, models.OddsValue("5/2"), response.Results[2].Wager.Odds)
+
+		rec = httptest.NewRecorder()
+		chiMux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/wagers/%d", response.Results[2].Wager.ID), nil))
+		assert.Equal(t, http.StatusOK, rec.Code, "status code must be 200")
+	}
+}
@@ -12,9 +12,11 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/wager-api/internal/entities"
 	"github.com/wager-api/internal/models"
+	"github.com/wager-api/internal/openapi"
 	"github.com/wager-api/internal/repositories"
 	"github.com/wager-api/internal/services"
 
@@ -32,8 +34,20 @@ import (
 )
 
 var (
-	DB     database.Ext
-	chiMux *chi.Mux
+	DB             database.Ext
+	chiMux         *chi.Mux
+	webhookService *services.WebhookService
+	// riskMux serves the wagers within the riskLimits, which require a seller, the other
+	// tests place and buy without one through chiMux
+	riskMux    *chi.Mux
+	riskLimits = &services.RiskLimits{MaxSellerExposure: 500}
+	// surveillanceRules only block the self trades, the other rules raise alerts
+	surveillanceRules = &services.SurveillanceRules{
+		Window:       time.Hour,
+		SelfTrade:    services.SurveillanceRule{Enabled: true, Block: true},
+		ResellLoop:   services.SurveillanceRule{Enabled: true, Threshold: 2},
+		PriceWalking: services.SurveillanceRule{Enabled: true, Threshold: 3},
+	}
 )
 
 func Test_PlaceWager(t *testing.T) {
@@ -51,7 +65,8 @@ func Test_PlaceWager(t *testing.T) {
 			201,
 			models.PlaceWagerResponse{
 				TotalWagerValue:     50,
-				Odds:                30,
+				Odds:                "30",
+				OddsFormat:          models.OddsFormatDecimal,
 				SellingPercentage:   30,
 				SellingPrice:        50,
 				CurrentSellingPrice: 50,
@@ -124,6 +139,29 @@ func Test_BuyWager_HappyCase(t *testing.T) {
 	// first time so AmountSold = CurrentSellingPrice
 	assert.Equal(t, wagerEnt.CurrentSellingPrice.Float, wagerEnt.AmountSold.Float)
 	assert.Equal(t, roundFloat((wagerEnt.AmountSold.Float/wagerEnt.SellingPrice.Float)*100), wagerEnt.PercentageSold.Float)
+
+	// Step 3 (plus) the price the wager was placed at and the new one are recorded in the price history
+	rec = httptest.NewRecorder()
+	chiMux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/wagers/%d/prices", placeWagerResponse.ID), nil))
+	assert.Equal(t, http.StatusOK, rec.Code, "status code must be 200")
+	priceHistoryResponse := models.PriceHistoryResponse{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&priceHistoryResponse))
+	if assert.Len(t, priceHistoryResponse.Points, 2) {
+		assert.Equal(t, placeWagerResponse.CurrentSellingPrice, priceHistoryResponse.Points[0].Price)
+		assert.Equal(t, buyWagerDataReq.BuyingPrice, priceHistoryResponse.Points[1].Price)
+	}
+
+	// Step 3 (plus) both events are waiting in the outbox, in order
+	rows, err := DB.Query(ctx, `SELECT event_type FROM outbox WHERE wager_id = $1 ORDER BY outbox_id`, placeWagerResponse.ID)
+	assert.NoError(t, err)
+	defer rows.Close()
+	eventTypes := []string{}
+	for rows.Next() {
+		var eventType string
+		assert.NoError(t, rows.Scan(&eventType))
+		eventTypes = append(eventTypes, eventType)
+	}
+	assert.Equal(t, []string{entities.OutboxWagerPlaced, entities.OutboxWagerPurchased}, eventTypes)
 }
 
 // roundFloat ensure round to two decimal places
@@ -154,15 +192,101 @@ func TestMain(m *testing.M) {
 		if err := migrate.Up(); err != nil {
 			return err
 		}
+		webhookService = &services.WebhookService{
+			DB:                  pool,
+			WebhookRepo:         &repositories.WebhookRepo{},
+			WebhookDeliveryRepo: &repositories.WebhookDeliveryRepo{},
+			AuditLogRepo:        &repositories.AuditLogRepo{},
+			// the receivers of the tests listen on the loopback
+			AllowPrivateURLs: true,
+		}
 		wagerService := &services.WagerService{
-			DB:           pool,
-			WagerRepo:    &repositories.WagerRepo{},
-			PurchaseRepo: &repositories.PurchaseRepo{},
+			DB:                     pool,
+			WagerRepo:              &repositories.WagerRepo{},
+			PurchaseRepo:           &repositories.PurchaseRepo{},
+			MarketRepo:             &repositories.MarketRepo{},
+			EventRepo:              &repositories.EventRepo{},
+			PriceHistoryRepo:       &repositories.PriceHistoryRepo{},
+			WagerUpdateRepo:        &repositories.WagerUpdateRepo{},
+			OutboxRepo:             &repositories.OutboxRepo{},
+			AuditLogRepo:           &repositories.AuditLogRepo{},
+			Stream:                 services.NewWagerStream(),
+			Webhooks:               webhookService,
+			BuyOrderRepo:           &repositories.BuyOrderRepo{},
+			StakeHoldingRepo:       &repositories.StakeHoldingRepo{},
+			ResaleListingRepo:      &repositories.ResaleListingRepo{},
+			StakeTransferRepo:      &repositories.StakeTransferRepo{},
+			PositionRepo:           &repositories.PositionRepo{},
+			Fees:                   &services.FeeModel{Default: services.FeeSchedule{Percentage: 2, Flat: 0.1}},
+			PurchaseFeeRepo:        &repositories.PurchaseFeeRepo{},
+			AccountRepo:            &repositories.AccountRepo{},
+			ExchangeRateRepo:       &repositories.ExchangeRateRepo{},
+			BaseCurrency:           "USD",
+			RiskRepo:               &repositories.RiskRepo{},
+			SpendLimitRepo:         &repositories.SpendLimitRepo{},
+			AccountRestrictionRepo: &repositories.AccountRestrictionRepo{},
+			Surveillance:           surveillanceRules,
+			SurveillanceRepo:       &repositories.SurveillanceRepo{},
+			TradeAlertRepo:         &repositories.TradeAlertRepo{},
+		}
+		eventService := &services.EventService{
+			DB:               pool,
+			EventRepo:        &repositories.EventRepo{},
+			MarketRepo:       &repositories.MarketRepo{},
+			WagerRepo:        &repositories.WagerRepo{},
+			OutboxRepo:       &repositories.OutboxRepo{},
+			StakeHoldingRepo: &repositories.StakeHoldingRepo{},
+			AuditLogRepo:     &repositories.AuditLogRepo{},
 		}
 		DB = pool
+		go wagerService.Stream.Listen(context.Background(), pool)
 
 		chiMux = mux.InitWithLogger((zap.NewNop()))
 		services.NewWagerHandler(chiMux, wagerService)
+		riskWagerService := *wagerService
+		riskWagerService.Risk = riskLimits
+		riskMux = mux.InitWithLogger((zap.NewNop()))
+		services.NewWagerHandler(riskMux, &riskWagerService)
+		services.NewEventHandler(chiMux, eventService)
+		services.NewWagerSocketHandler(chiMux, services.NewWagerSocket(wagerService, []string{"integration-test-token"}))
+		services.NewWebhookHandler(chiMux, webhookService, []string{"integration-admin-token"})
+		services.NewAuditHandler(chiMux, &services.AuditService{
+			DB:           pool,
+			AuditLogRepo: &repositories.AuditLogRepo{},
+		}, []string{"integration-admin-token"})
+		services.NewExportHandler(chiMux, &services.ExportService{
+			DB:         pool,
+			ExportRepo: &repositories.ExportRepo{},
+			FetchSize:  2,
+		}, []string{"integration-admin-token"})
+		services.NewFeeHandler(chiMux, &services.FeeService{
+			DB:              pool,
+			PurchaseFeeRepo: &repositories.PurchaseFeeRepo{},
+		}, []string{"integration-admin-token"})
+		services.NewCurrencyHandler(chiMux, &services.CurrencyService{
+			DB:               pool,
+			ExchangeRateRepo: &repositories.ExchangeRateRepo{},
+			PurchaseRepo:     &repositories.PurchaseRepo{},
+			AuditLogRepo:     &repositories.AuditLogRepo{},
+			BaseCurrency:     "USD",
+		}, []string{"integration-admin-token"})
+		services.NewRiskHandler(chiMux, &services.RiskService{
+			DB:           pool,
+			RiskRepo:     &repositories.RiskRepo{},
+			Limits:       riskLimits,
+			BaseCurrency: "USD",
+		}, []string{"integration-admin-token"})
+		services.NewSurveillanceHandler(chiMux, &services.SurveillanceService{
+			DB:              pool,
+			TradeAlertRepo:  &repositories.TradeAlertRepo{},
+			AccountLinkRepo: &repositories.AccountLinkRepo{},
+			AuditLogRepo:    &repositories.AuditLogRepo{},
+		}, []string{"integration-admin-token"})
+		doc, err := openapi.Load()
+		if err != nil {
+			return err
+		}
+		openapi.NewHandler(chiMux, doc)
 		if err != nil {
 			log.Print("Could not migrate", err)
 			return err
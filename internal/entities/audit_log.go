@@ -0,0 +1,99 @@
+package entities
+
+import (
+	"github.com/wager-api/libs/database"
+
+	"github.com/jackc/pgtype"
+)
+
+const (
+	AuditActionWagerPlace     = "wager.place"
+	AuditActionWagerBuy       = "wager.buy"
+	AuditActionWagerReprice   = "wager.reprice"
+	AuditActionWagerReconcile = "wager.reconcile"
+	AuditActionStakeResale    = "stake.resale"
+
+	AuditActionEventCreate        = "event.create"
+	AuditActionEventStatus        = "event.status"
+	AuditActionMarketCreate       = "market.create"
+	AuditActionMarketStatus       = "market.status"
+	AuditActionWebhookCreate      = "webhook.create"
+	AuditActionWebhookStatus      = "webhook.status"
+	AuditActionBuyOrderPlace      = "buy_order.place"
+	AuditActionBuyOrderCancel     = "buy_order.cancel"
+	AuditActionListingCreate      = "listing.create"
+	AuditActionListingCancel      = "listing.cancel"
+	AuditActionExchangeRateCreate = "exchange_rate.create"
+	AuditActionAccountLinkCreate  = "account_link.create"
+	AuditActionAccountUpdate      = "account.update"
+
+	AuditActionAccountSpendLimit    = "account.spend_limit"
+	AuditActionAccountCoolOff       = "account.cool_off"
+	AuditActionAccountSelfExclusion = "account.self_exclusion"
+)
+
+type AuditLog struct {
+	AuditID    pgtype.Int8
+	Actor      pgtype.Text
+	Action     pgtype.Text
+	WagerID    pgtype.Int4
+	PurchaseID pgtype.Int4
+	Before     pgtype.JSONB
+	After      pgtype.JSONB
+	RequestID  pgtype.Text
+	ClientIP   pgtype.Text
+	CreatedAt  pgtype.Timestamptz
+	// AccountID is the account the change is about, when it isn't about a wager
+	AccountID pgtype.Text
+	// EntityID is the record the change is about when it is neither a wager nor an
+	// account, the action tells which kind of record it is
+	EntityID pgtype.Text
+	// ActorVerified tells whether the request was authenticated, otherwise the actor is
+	// only what the client claimed
+	ActorVerified pgtype.Bool
+}
+
+func (e *AuditLog) FieldMap() (fields []string, values []interface{}) {
+	fields = []string{
+		"audit_id",
+		"actor",
+		"action",
+		"wager_id",
+		"purchase_id",
+		"before",
+		"after",
+		"request_id",
+		"client_ip",
+		"created_at",
+		"account_id",
+		"entity_id",
+		"actor_verified",
+	}
+	values = []interface{}{
+		&e.AuditID,
+		&e.Actor,
+		&e.Action,
+		&e.WagerID,
+		&e.PurchaseID,
+		&e.Before,
+		&e.After,
+		&e.RequestID,
+		&e.ClientIP,
+		&e.CreatedAt,
+		&e.AccountID,
+		&e.EntityID,
+		&e.ActorVerified,
+	}
+	return
+}
+func (e *AuditLog) TableName() string {
+	return "audit_log"
+}
+
+type AuditLogs []*AuditLog
+
+func (es *AuditLogs) Add() database.Entity {
+	e := &AuditLog{}
+	*es = append(*es, e)
+	return e
+}
@@ -0,0 +1,68 @@
+package entities
+
+import (
+	"github.com/wager-api/libs/database"
+
+	"github.com/jackc/pgtype"
+)
+
+const (
+	BuyOrderStatusOpen      = "open"
+	BuyOrderStatusFilled    = "filled"
+	BuyOrderStatusCancelled = "cancelled"
+	BuyOrderStatusExpired   = "expired"
+)
+
+// BuyOrder buys its wager at the current selling price as soon as it is at most
+// LimitPrice, unless it expires first.
+type BuyOrder struct {
+	BuyOrderID pgtype.Int4
+	WagerID    pgtype.Int4
+	BuyerID    pgtype.Text
+	LimitPrice pgtype.Float4
+	Status     pgtype.Text
+	PurchaseID pgtype.Int4
+	ExpiresAt  pgtype.Timestamptz
+	FilledAt   pgtype.Timestamptz
+	CreatedAt  pgtype.Timestamptz
+	UpdatedAt  pgtype.Timestamptz
+}
+
+func (e *BuyOrder) FieldMap() (fields []string, values []interface{}) {
+	fields = []string{
+		"buy_order_id",
+		"wager_id",
+		"buyer_id",
+		"limit_price",
+		"status",
+		"purchase_id",
+		"expires_at",
+		"filled_at",
+		"created_at",
+		"updated_at",
+	}
+	values = []interface{}{
+		&e.BuyOrderID,
+		&e.WagerID,
+		&e.BuyerID,
+		&e.LimitPrice,
+		&e.Status,
+		&e.PurchaseID,
+		&e.ExpiresAt,
+		&e.FilledAt,
+		&e.CreatedAt,
+		&e.UpdatedAt,
+	}
+	return
+}
+func (e *BuyOrder) TableName() string {
+	return "buy_order"
+}
+
+type BuyOrders []*BuyOrder
+
+func (es *BuyOrders) Add() database.Entity {
+	e := &BuyOrder{}
+	*es = append(*es, e)
+	return e
+}
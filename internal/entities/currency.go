@@ -0,0 +1,76 @@
+package entities
+
+import (
+	"github.com/wager-api/libs/database"
+
+	"github.com/jackc/pgtype"
+)
+
+// Account is the currency a buyer pays in, AccountID is the buyer_id of its purchases.
+type Account struct {
+	AccountID pgtype.Text
+	Currency  pgtype.Text
+	CreatedAt pgtype.Timestamptz
+	UpdatedAt pgtype.Timestamptz
+}
+
+func (e *Account) FieldMap() (fields []string, values []interface{}) {
+	fields = []string{
+		"account_id",
+		"currency",
+		"created_at",
+		"updated_at",
+	}
+	values = []interface{}{
+		&e.AccountID,
+		&e.Currency,
+		&e.CreatedAt,
+		&e.UpdatedAt,
+	}
+	return
+}
+func (e *Account) TableName() string {
+	return "account"
+}
+
+// ExchangeRate tells 1 BaseCurrency is worth Rate QuoteCurrency from EffectiveFrom until
+// the next rate of the pair.
+type ExchangeRate struct {
+	RateID        pgtype.Int4
+	BaseCurrency  pgtype.Text
+	QuoteCurrency pgtype.Text
+	Rate          pgtype.Float8
+	EffectiveFrom pgtype.Timestamptz
+	CreatedAt     pgtype.Timestamptz
+}
+
+func (e *ExchangeRate) FieldMap() (fields []string, values []interface{}) {
+	fields = []string{
+		"rate_id",
+		"base_currency",
+		"quote_currency",
+		"rate",
+		"effective_from",
+		"created_at",
+	}
+	values = []interface{}{
+		&e.RateID,
+		&e.BaseCurrency,
+		&e.QuoteCurrency,
+		&e.Rate,
+		&e.EffectiveFrom,
+		&e.CreatedAt,
+	}
+	return
+}
+func (e *ExchangeRate) TableName() string {
+	return "exchange_rate"
+}
+
+type ExchangeRates []*ExchangeRate
+
+func (es *ExchangeRates) Add() database.Entity {
+	e := &ExchangeRate{}
+	*es = append(*es, e)
+	return e
+}
@@ -0,0 +1,63 @@
+package entities
+
+import (
+	"github.com/wager-api/libs/database"
+
+	"github.com/jackc/pgtype"
+)
+
+const (
+	EventStatusScheduled = "scheduled"
+	EventStatusInPlay    = "in_play"
+	EventStatusFinished  = "finished"
+	EventStatusCancelled = "cancelled"
+)
+
+type Event struct {
+	EventID   pgtype.Int4
+	Sport     pgtype.Text
+	HomeTeam  pgtype.Text
+	AwayTeam  pgtype.Text
+	StartAt   pgtype.Timestamptz
+	Status    pgtype.Text
+	CreatedAt pgtype.Timestamptz
+	UpdatedAt pgtype.Timestamptz
+	DeletedAt pgtype.Timestamptz
+}
+
+func (e *Event) FieldMap() (fields []string, values []interface{}) {
+	fields = []string{
+		"event_id",
+		"sport",
+		"home_team",
+		"away_team",
+		"start_at",
+		"status",
+		"created_at",
+		"updated_at",
+		"deleted_at",
+	}
+	values = []interface{}{
+		&e.EventID,
+		&e.Sport,
+		&e.HomeTeam,
+		&e.AwayTeam,
+		&e.StartAt,
+		&e.Status,
+		&e.CreatedAt,
+		&e.UpdatedAt,
+		&e.DeletedAt,
+	}
+	return
+}
+func (e *Event) TableName() string {
+	return "event"
+}
+
+type Events []*Event
+
+func (es *Events) Add() database.Entity {
+	e := &Event{}
+	*es = append(*es, e)
+	return e
+}
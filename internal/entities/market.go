@@ -0,0 +1,57 @@
+package entities
+
+import (
+	"github.com/wager-api/libs/database"
+
+	"github.com/jackc/pgtype"
+)
+
+const (
+	MarketStatusOpen      = "open"
+	MarketStatusSuspended = "suspended"
+	MarketStatusClosed    = "closed"
+	MarketStatusSettled   = "settled"
+)
+
+type Market struct {
+	MarketID   pgtype.Int4
+	EventID    pgtype.Int4
+	MarketType pgtype.Text
+	Status     pgtype.Text
+	CreatedAt  pgtype.Timestamptz
+	UpdatedAt  pgtype.Timestamptz
+	DeletedAt  pgtype.Timestamptz
+}
+
+func (e *Market) FieldMap() (fields []string, values []interface{}) {
+	fields = []string{
+		"market_id",
+		"event_id",
+		"market_type",
+		"status",
+		"created_at",
+		"updated_at",
+		"deleted_at",
+	}
+	values = []interface{}{
+		&e.MarketID,
+		&e.EventID,
+		&e.MarketType,
+		&e.Status,
+		&e.CreatedAt,
+		&e.UpdatedAt,
+		&e.DeletedAt,
+	}
+	return
+}
+func (e *Market) TableName() string {
+	return "market"
+}
+
+type Markets []*Market
+
+func (es *Markets) Add() database.Entity {
+	e := &Market{}
+	*es = append(*es, e)
+	return e
+}
@@ -0,0 +1,66 @@
+package entities
+
+import (
+	"github.com/wager-api/libs/database"
+
+	"github.com/jackc/pgtype"
+)
+
+const (
+	OutboxWagerPlaced    = "WagerPlaced"
+	OutboxWagerPurchased = "WagerPurchased"
+	OutboxWagerSettled   = "WagerSettled"
+)
+
+type OutboxMessage struct {
+	OutboxID      pgtype.Int8
+	EventType     pgtype.Text
+	WagerID       pgtype.Int4
+	Payload       pgtype.JSONB
+	CreatedAt     pgtype.Timestamptz
+	Attempts      pgtype.Int4
+	NextAttemptAt pgtype.Timestamptz
+	LastError     pgtype.Text
+	SentAt        pgtype.Timestamptz
+	// LockedUntil is the end of the lease of the relay publishing the message
+	LockedUntil pgtype.Timestamptz
+}
+
+func (e *OutboxMessage) FieldMap() (fields []string, values []interface{}) {
+	fields = []string{
+		"outbox_id",
+		"event_type",
+		"wager_id",
+		"payload",
+		"created_at",
+		"attempts",
+		"next_attempt_at",
+		"last_error",
+		"sent_at",
+		"locked_until",
+	}
+	values = []interface{}{
+		&e.OutboxID,
+		&e.EventType,
+		&e.WagerID,
+		&e.Payload,
+		&e.CreatedAt,
+		&e.Attempts,
+		&e.NextAttemptAt,
+		&e.LastError,
+		&e.SentAt,
+		&e.LockedUntil,
+	}
+	return
+}
+func (e *OutboxMessage) TableName() string {
+	return "outbox"
+}
+
+type OutboxMessages []*OutboxMessage
+
+func (es *OutboxMessages) Add() database.Entity {
+	e := &OutboxMessage{}
+	*es = append(*es, e)
+	return e
+}
@@ -0,0 +1,44 @@
+package entities
+
+import (
+	"github.com/wager-api/libs/database"
+
+	"github.com/jackc/pgtype"
+)
+
+type WagerPriceHistory struct {
+	PriceHistoryID pgtype.Int4
+	WagerID        pgtype.Int4
+	Price          pgtype.Float4
+	RecordedAt     pgtype.Timestamptz
+	CreatedAt      pgtype.Timestamptz
+}
+
+func (e *WagerPriceHistory) FieldMap() (fields []string, values []interface{}) {
+	fields = []string{
+		"price_history_id",
+		"wager_id",
+		"price",
+		"recorded_at",
+		"created_at",
+	}
+	values = []interface{}{
+		&e.PriceHistoryID,
+		&e.WagerID,
+		&e.Price,
+		&e.RecordedAt,
+		&e.CreatedAt,
+	}
+	return
+}
+func (e *WagerPriceHistory) TableName() string {
+	return "wager_price_history"
+}
+
+type WagerPriceHistories []*WagerPriceHistory
+
+func (es *WagerPriceHistories) Add() database.Entity {
+	e := &WagerPriceHistory{}
+	*es = append(*es, e)
+	return e
+}
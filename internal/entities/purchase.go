@@ -14,6 +14,13 @@ type Purchase struct {
 	CreatedAt   pgtype.Timestamptz
 	UpdatedAt   pgtype.Timestamptz
 	DeletedAt   pgtype.Timestamptz
+	// BuyerID holds the stake of the purchase first, null when the buyer is not known
+	BuyerID pgtype.Text
+	// PaidAmount is the buying price converted to PaidCurrency, the currency of the buyer,
+	// at ExchangeRate, which is null when the buyer pays in the currency of the wager
+	PaidCurrency pgtype.Text
+	PaidAmount   pgtype.Float4
+	ExchangeRate pgtype.Float8
 }
 
 func (e *Purchase) FieldMap() (fields []string, values []interface{}) {
@@ -25,6 +32,10 @@ func (e *Purchase) FieldMap() (fields []string, values []interface{}) {
 		"created_at",
 		"updated_at",
 		"deleted_at",
+		"buyer_id",
+		"paid_currency",
+		"paid_amount",
+		"exchange_rate",
 	}
 	values = []interface{}{
 		&e.PurchaseID,
@@ -33,8 +44,11 @@ func (e *Purchase) FieldMap() (fields []string, values []interface{}) {
 		&e.BoughtAt,
 		&e.CreatedAt,
 		&e.UpdatedAt,
-		&e.UpdatedAt,
 		&e.DeletedAt,
+		&e.BuyerID,
+		&e.PaidCurrency,
+		&e.PaidAmount,
+		&e.ExchangeRate,
 	}
 	return
 }
@@ -0,0 +1,64 @@
+package entities
+
+import (
+	"github.com/wager-api/libs/database"
+
+	"github.com/jackc/pgtype"
+)
+
+const (
+	PurchaseFeePercentage = "percentage"
+	PurchaseFeeFlat       = "flat"
+)
+
+// PurchaseFee is a fee charged on a purchase, Rate is the percentage of the buying price
+// or the flat amount it is computed from. The fees of a resale of the stake of a purchase
+// have the TransferID of the resale.
+type PurchaseFee struct {
+	FeeID      pgtype.Int4
+	PurchaseID pgtype.Int4
+	WagerID    pgtype.Int4
+	MarketID   pgtype.Int4
+	FeeType    pgtype.Text
+	Rate       pgtype.Float4
+	Amount     pgtype.Float4
+	CreatedAt  pgtype.Timestamptz
+	TransferID pgtype.Int4
+}
+
+func (e *PurchaseFee) FieldMap() (fields []string, values []interface{}) {
+	fields = []string{
+		"fee_id",
+		"purchase_id",
+		"wager_id",
+		"market_id",
+		"fee_type",
+		"rate",
+		"amount",
+		"created_at",
+		"transfer_id",
+	}
+	values = []interface{}{
+		&e.FeeID,
+		&e.PurchaseID,
+		&e.WagerID,
+		&e.MarketID,
+		&e.FeeType,
+		&e.Rate,
+		&e.Amount,
+		&e.CreatedAt,
+		&e.TransferID,
+	}
+	return
+}
+func (e *PurchaseFee) TableName() string {
+	return "purchase_fee"
+}
+
+type PurchaseFees []*PurchaseFee
+
+func (es *PurchaseFees) Add() database.Entity {
+	e := &PurchaseFee{}
+	*es = append(*es, e)
+	return e
+}
@@ -0,0 +1,161 @@
+package entities
+
+import (
+	"github.com/wager-api/libs/database"
+
+	"github.com/jackc/pgtype"
+)
+
+const (
+	ResaleListingStatusOpen      = "open"
+	ResaleListingStatusSold      = "sold"
+	ResaleListingStatusCancelled = "cancelled"
+)
+
+// StakeHolding is the part of the stake of a purchase HolderID holds, the whole
+// buying price of the purchase at first.
+type StakeHolding struct {
+	HoldingID  pgtype.Int4
+	PurchaseID pgtype.Int4
+	WagerID    pgtype.Int4
+	HolderID   pgtype.Text
+	Stake      pgtype.Float4
+	CreatedAt  pgtype.Timestamptz
+	UpdatedAt  pgtype.Timestamptz
+}
+
+func (e *StakeHolding) FieldMap() (fields []string, values []interface{}) {
+	fields = []string{
+		"holding_id",
+		"purchase_id",
+		"wager_id",
+		"holder_id",
+		"stake",
+		"created_at",
+		"updated_at",
+	}
+	values = []interface{}{
+		&e.HoldingID,
+		&e.PurchaseID,
+		&e.WagerID,
+		&e.HolderID,
+		&e.Stake,
+		&e.CreatedAt,
+		&e.UpdatedAt,
+	}
+	return
+}
+func (e *StakeHolding) TableName() string {
+	return "stake_holding"
+}
+
+type StakeHoldings []*StakeHolding
+
+func (es *StakeHoldings) Add() database.Entity {
+	e := &StakeHolding{}
+	*es = append(*es, e)
+	return e
+}
+
+// ResaleListing puts Stake of the holding of SellerID in a purchase up for resale at
+// AskingPrice, it is sold whole.
+type ResaleListing struct {
+	ListingID   pgtype.Int4
+	PurchaseID  pgtype.Int4
+	WagerID     pgtype.Int4
+	SellerID    pgtype.Text
+	Stake       pgtype.Float4
+	AskingPrice pgtype.Float4
+	Status      pgtype.Text
+	BuyerID     pgtype.Text
+	SoldAt      pgtype.Timestamptz
+	CreatedAt   pgtype.Timestamptz
+	UpdatedAt   pgtype.Timestamptz
+}
+
+func (e *ResaleListing) FieldMap() (fields []string, values []interface{}) {
+	fields = []string{
+		"listing_id",
+		"purchase_id",
+		"wager_id",
+		"seller_id",
+		"stake",
+		"asking_price",
+		"status",
+		"buyer_id",
+		"sold_at",
+		"created_at",
+		"updated_at",
+	}
+	values = []interface{}{
+		&e.ListingID,
+		&e.PurchaseID,
+		&e.WagerID,
+		&e.SellerID,
+		&e.Stake,
+		&e.AskingPrice,
+		&e.Status,
+		&e.BuyerID,
+		&e.SoldAt,
+		&e.CreatedAt,
+		&e.UpdatedAt,
+	}
+	return
+}
+func (e *ResaleListing) TableName() string {
+	return "resale_listing"
+}
+
+// StakeTransfer records a stake changing holder through the sale of a listing.
+type StakeTransfer struct {
+	TransferID    pgtype.Int4
+	ListingID     pgtype.Int4
+	PurchaseID    pgtype.Int4
+	WagerID       pgtype.Int4
+	FromHolderID  pgtype.Text
+	ToHolderID    pgtype.Text
+	Stake         pgtype.Float4
+	Price         pgtype.Float4
+	TransferredAt pgtype.Timestamptz
+	CreatedAt     pgtype.Timestamptz
+	PaidCurrency  pgtype.Text
+	PaidAmount    pgtype.Float4
+	ExchangeRate  pgtype.Float8
+}
+
+func (e *StakeTransfer) FieldMap() (fields []string, values []interface{}) {
+	fields = []string{
+		"transfer_id",
+		"listing_id",
+		"purchase_id",
+		"wager_id",
+		"from_holder_id",
+		"to_holder_id",
+		"stake",
+		"price",
+		"transferred_at",
+		"created_at",
+		"paid_currency",
+		"paid_amount",
+		"exchange_rate",
+	}
+	values = []interface{}{
+		&e.TransferID,
+		&e.ListingID,
+		&e.PurchaseID,
+		&e.WagerID,
+		&e.FromHolderID,
+		&e.ToHolderID,
+		&e.Stake,
+		&e.Price,
+		&e.TransferredAt,
+		&e.CreatedAt,
+		&e.PaidCurrency,
+		&e.PaidAmount,
+		&e.ExchangeRate,
+	}
+	return
+}
+func (e *StakeTransfer) TableName() string {
+	return "stake_transfer"
+}
@@ -0,0 +1,100 @@
+package entities
+
+import (
+	"github.com/wager-api/libs/database"
+
+	"github.com/jackc/pgtype"
+)
+
+const (
+	SpendLimitPeriodDay   = "day"
+	SpendLimitPeriodWeek  = "week"
+	SpendLimitPeriodMonth = "month"
+
+	AccountRestrictionCoolOff       = "cool_off"
+	AccountRestrictionSelfExclusion = "self_exclusion"
+)
+
+// SpendLimit bounds what an account spends over Period from EffectiveFrom until its next
+// limit of the period takes effect, a null Amount removes the limit.
+type SpendLimit struct {
+	LimitID       pgtype.Int4
+	AccountID     pgtype.Text
+	Period        pgtype.Text
+	Amount        pgtype.Float4
+	EffectiveFrom pgtype.Timestamptz
+	CreatedAt     pgtype.Timestamptz
+}
+
+func (e *SpendLimit) FieldMap() (fields []string, values []interface{}) {
+	fields = []string{
+		"limit_id",
+		"account_id",
+		"period",
+		"amount",
+		"effective_from",
+		"created_at",
+	}
+	values = []interface{}{
+		&e.LimitID,
+		&e.AccountID,
+		&e.Period,
+		&e.Amount,
+		&e.EffectiveFrom,
+		&e.CreatedAt,
+	}
+	return
+}
+func (e *SpendLimit) TableName() string {
+	return "spend_limit"
+}
+
+type SpendLimits []*SpendLimit
+
+func (es *SpendLimits) Add() database.Entity {
+	e := &SpendLimit{}
+	*es = append(*es, e)
+	return e
+}
+
+// AccountRestriction keeps an account from buying and selling from StartsAt until EndsAt,
+// for ever when EndsAt is null.
+type AccountRestriction struct {
+	RestrictionID pgtype.Int4
+	AccountID     pgtype.Text
+	Kind          pgtype.Text
+	StartsAt      pgtype.Timestamptz
+	EndsAt        pgtype.Timestamptz
+	CreatedAt     pgtype.Timestamptz
+}
+
+func (e *AccountRestriction) FieldMap() (fields []string, values []interface{}) {
+	fields = []string{
+		"restriction_id",
+		"account_id",
+		"kind",
+		"starts_at",
+		"ends_at",
+		"created_at",
+	}
+	values = []interface{}{
+		&e.RestrictionID,
+		&e.AccountID,
+		&e.Kind,
+		&e.StartsAt,
+		&e.EndsAt,
+		&e.CreatedAt,
+	}
+	return
+}
+func (e *AccountRestriction) TableName() string {
+	return "account_restriction"
+}
+
+type AccountRestrictions []*AccountRestriction
+
+func (es *AccountRestrictions) Add() database.Entity {
+	e := &AccountRestriction{}
+	*es = append(*es, e)
+	return e
+}
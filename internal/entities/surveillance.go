@@ -0,0 +1,110 @@
+package entities
+
+import (
+	"github.com/wager-api/libs/database"
+
+	"github.com/jackc/pgtype"
+)
+
+const (
+	TradeAlertRuleSelfTrade    = "self_trade"
+	TradeAlertRuleResellLoop   = "resell_loop"
+	TradeAlertRulePriceWalking = "price_walking"
+)
+
+// AccountLink records that two accounts are held by the same party, AccountID is the
+// lowest of the two.
+type AccountLink struct {
+	LinkID          pgtype.Int4
+	AccountID       pgtype.Text
+	LinkedAccountID pgtype.Text
+	Reason          pgtype.Text
+	CreatedAt       pgtype.Timestamptz
+}
+
+func (e *AccountLink) FieldMap() (fields []string, values []interface{}) {
+	fields = []string{
+		"link_id",
+		"account_id",
+		"linked_account_id",
+		"reason",
+		"created_at",
+	}
+	values = []interface{}{
+		&e.LinkID,
+		&e.AccountID,
+		&e.LinkedAccountID,
+		&e.Reason,
+		&e.CreatedAt,
+	}
+	return
+}
+func (e *AccountLink) TableName() string {
+	return "account_link"
+}
+
+type AccountLinks []*AccountLink
+
+func (es *AccountLinks) Add() database.Entity {
+	e := &AccountLink{}
+	*es = append(*es, e)
+	return e
+}
+
+// TradeAlert is a purchase flagged by a surveillance rule, PurchaseID is null when the
+// rule blocked it. The alerts raised on the purchase of a listing have its ListingID and
+// no PurchaseID.
+type TradeAlert struct {
+	AlertID     pgtype.Int4
+	Rule        pgtype.Text
+	WagerID     pgtype.Int4
+	PurchaseID  pgtype.Int4
+	BuyerID     pgtype.Text
+	SellerID    pgtype.Text
+	BuyingPrice pgtype.Float4
+	Details     pgtype.JSONB
+	Blocked     pgtype.Bool
+	CreatedAt   pgtype.Timestamptz
+	ListingID   pgtype.Int4
+}
+
+func (e *TradeAlert) FieldMap() (fields []string, values []interface{}) {
+	fields = []string{
+		"alert_id",
+		"rule",
+		"wager_id",
+		"purchase_id",
+		"buyer_id",
+		"seller_id",
+		"buying_price",
+		"details",
+		"blocked",
+		"created_at",
+		"listing_id",
+	}
+	values = []interface{}{
+		&e.AlertID,
+		&e.Rule,
+		&e.WagerID,
+		&e.PurchaseID,
+		&e.BuyerID,
+		&e.SellerID,
+		&e.BuyingPrice,
+		&e.Details,
+		&e.Blocked,
+		&e.CreatedAt,
+		&e.ListingID,
+	}
+	return
+}
+func (e *TradeAlert) TableName() string {
+	return "trade_alert"
+}
+
+type TradeAlerts []*TradeAlert
+
+func (es *TradeAlerts) Add() database.Entity {
+	e := &TradeAlert{}
+	*es = append(*es, e)
+	return e
+}
@@ -6,10 +6,19 @@ import (
 	"github.com/jackc/pgtype"
 )
 
+const (
+	// WagerDecayLinear lowers the price of a wager continuously, WagerDecayStep by
+	// equal steps at a fixed interval
+	WagerDecayLinear = "linear"
+	WagerDecayStep   = "step"
+)
+
 type Wager struct {
 	WagerID             pgtype.Int4
+	MarketID            pgtype.Int4
+	SellerID            pgtype.Text
 	TotalWagerValue     pgtype.Float4
-	Odds                pgtype.Int4
+	Odds                pgtype.Float8
 	SellingPercentage   pgtype.Int4
 	SellingPrice        pgtype.Float4
 	CurrentSellingPrice pgtype.Float4
@@ -19,11 +28,20 @@ type Wager struct {
 	CreatedAt           pgtype.Timestamptz
 	UpdatedAt           pgtype.Timestamptz
 	DeletedAt           pgtype.Timestamptz
+	// the decay of the price, all null when the price doesn't decay
+	DecayType            pgtype.Text
+	DecayFloorPrice      pgtype.Float4
+	DecayDurationSeconds pgtype.Int4
+	DecayStepSeconds     pgtype.Int4
+	// Currency is the ISO-4217 code of the amounts of the wager
+	Currency pgtype.Text
 }
 
 func (e *Wager) FieldMap() (fields []string, values []interface{}) {
 	fields = []string{
 		"wager_id",
+		"market_id",
+		"seller_id",
 		"total_wager_value",
 		"odds",
 		"selling_percentage",
@@ -35,9 +53,16 @@ func (e *Wager) FieldMap() (fields []string, values []interface{}) {
 		"created_at",
 		"updated_at",
 		"deleted_at",
+		"decay_type",
+		"decay_floor_price",
+		"decay_duration_seconds",
+		"decay_step_seconds",
+		"currency",
 	}
 	values = []interface{}{
 		&e.WagerID,
+		&e.MarketID,
+		&e.SellerID,
 		&e.TotalWagerValue,
 		&e.Odds,
 		&e.SellingPercentage,
@@ -49,6 +74,11 @@ func (e *Wager) FieldMap() (fields []string, values []interface{}) {
 		&e.CreatedAt,
 		&e.UpdatedAt,
 		&e.DeletedAt,
+		&e.DecayType,
+		&e.DecayFloorPrice,
+		&e.DecayDurationSeconds,
+		&e.DecayStepSeconds,
+		&e.Currency,
 	}
 	return
 }
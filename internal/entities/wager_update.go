@@ -0,0 +1,50 @@
+package entities
+
+import (
+	"github.com/wager-api/libs/database"
+
+	"github.com/jackc/pgtype"
+)
+
+const (
+	WagerUpdateWagerPlaced  = "wager-placed"
+	WagerUpdatePurchaseMade = "purchase-made"
+	WagerUpdatePriceChanged = "price-changed"
+)
+
+type WagerUpdate struct {
+	UpdateID   pgtype.Int8
+	UpdateType pgtype.Text
+	WagerID    pgtype.Int4
+	Payload    pgtype.JSONB
+	CreatedAt  pgtype.Timestamptz
+}
+
+func (e *WagerUpdate) FieldMap() (fields []string, values []interface{}) {
+	fields = []string{
+		"update_id",
+		"update_type",
+		"wager_id",
+		"payload",
+		"created_at",
+	}
+	values = []interface{}{
+		&e.UpdateID,
+		&e.UpdateType,
+		&e.WagerID,
+		&e.Payload,
+		&e.CreatedAt,
+	}
+	return
+}
+func (e *WagerUpdate) TableName() string {
+	return "wager_update"
+}
+
+type WagerUpdates []*WagerUpdate
+
+func (es *WagerUpdates) Add() database.Entity {
+	e := &WagerUpdate{}
+	*es = append(*es, e)
+	return e
+}
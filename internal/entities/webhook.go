@@ -0,0 +1,129 @@
+package entities
+
+import (
+	"github.com/wager-api/libs/database"
+
+	"github.com/jackc/pgtype"
+)
+
+const (
+	WebhookStatusActive   = "active"
+	WebhookStatusDisabled = "disabled"
+
+	WebhookDeliveryStatusPending   = "pending"
+	WebhookDeliveryStatusSucceeded = "succeeded"
+	WebhookDeliveryStatusFailed    = "failed"
+
+	WebhookEventWagerBought = "wager.bought"
+)
+
+type Webhook struct {
+	WebhookID           pgtype.Int4
+	SellerID            pgtype.Text
+	URL                 pgtype.Text
+	Secret              pgtype.Text
+	EventTypes          pgtype.TextArray
+	Status              pgtype.Text
+	ConsecutiveFailures pgtype.Int4
+	DisabledAt          pgtype.Timestamptz
+	CreatedAt           pgtype.Timestamptz
+	UpdatedAt           pgtype.Timestamptz
+}
+
+func (e *Webhook) FieldMap() (fields []string, values []interface{}) {
+	fields = []string{
+		"webhook_id",
+		"seller_id",
+		"url",
+		"secret",
+		"event_types",
+		"status",
+		"consecutive_failures",
+		"disabled_at",
+		"created_at",
+		"updated_at",
+	}
+	values = []interface{}{
+		&e.WebhookID,
+		&e.SellerID,
+		&e.URL,
+		&e.Secret,
+		&e.EventTypes,
+		&e.Status,
+		&e.ConsecutiveFailures,
+		&e.DisabledAt,
+		&e.CreatedAt,
+		&e.UpdatedAt,
+	}
+	return
+}
+func (e *Webhook) TableName() string {
+	return "webhook"
+}
+
+type Webhooks []*Webhook
+
+func (es *Webhooks) Add() database.Entity {
+	e := &Webhook{}
+	*es = append(*es, e)
+	return e
+}
+
+type WebhookDelivery struct {
+	DeliveryID     pgtype.Int8
+	WebhookID      pgtype.Int4
+	EventType      pgtype.Text
+	Payload        pgtype.JSONB
+	Status         pgtype.Text
+	Attempts       pgtype.Int4
+	NextAttemptAt  pgtype.Timestamptz
+	ResponseStatus pgtype.Int4
+	LastError      pgtype.Text
+	CreatedAt      pgtype.Timestamptz
+	DeliveredAt    pgtype.Timestamptz
+	// LockedUntil is the end of the lease of the dispatcher posting the delivery
+	LockedUntil pgtype.Timestamptz
+}
+
+func (e *WebhookDelivery) FieldMap() (fields []string, values []interface{}) {
+	fields = []string{
+		"delivery_id",
+		"webhook_id",
+		"event_type",
+		"payload",
+		"status",
+		"attempts",
+		"next_attempt_at",
+		"response_status",
+		"last_error",
+		"created_at",
+		"delivered_at",
+		"locked_until",
+	}
+	values = []interface{}{
+		&e.DeliveryID,
+		&e.WebhookID,
+		&e.EventType,
+		&e.Payload,
+		&e.Status,
+		&e.Attempts,
+		&e.NextAttemptAt,
+		&e.ResponseStatus,
+		&e.LastError,
+		&e.CreatedAt,
+		&e.DeliveredAt,
+		&e.LockedUntil,
+	}
+	return
+}
+func (e *WebhookDelivery) TableName() string {
+	return "webhook_delivery"
+}
+
+type WebhookDeliveries []*WebhookDelivery
+
+func (es *WebhookDeliveries) Add() database.Entity {
+	e := &WebhookDelivery{}
+	*es = append(*es, e)
+	return e
+}
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+type Event struct {
+	ID       int        `json:"id"`
+	Sport    string     `json:"sport"`
+	HomeTeam string     `json:"home_team"`
+	AwayTeam string     `json:"away_team"`
+	StartAt  *time.Time `json:"start_at"`
+	Status   string     `json:"status"`
+}
+
+type CreateEventRequest struct {
+	Sport    string    `json:"sport"`
+	HomeTeam string    `json:"home_team"`
+	AwayTeam string    `json:"away_team"`
+	StartAt  time.Time `json:"start_at"`
+}
+
+type Market struct {
+	ID         int    `json:"id"`
+	EventID    int    `json:"event_id"`
+	MarketType string `json:"market_type"`
+	Status     string `json:"status"`
+}
+
+type CreateMarketRequest struct {
+	MarketType string `json:"market_type"`
+}
+
+type UpdateStatusRequest struct {
+	Status string `json:"status"`
+}
@@ -1,44 +1,579 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type Wager struct {
-	ID                  int        `json:"id,omitempty"`
-	TotalWagerValue     float32    `json:"total_wager_value"`
-	Odds                int        `json:"odds"`
-	SellingPercentage   int        `json:"selling_percentage"`
-	SellingPrice        float32    `json:"selling_price"`
-	CurrentSellingPrice float32    `json:"current_selling_price"`
-	PercentageSold      float32    `json:"percentage_sold"`
-	AmountSold          float32    `json:"amount_sold"`
-	PlacedAt            *time.Time `json:"placed_at"`
+	ID                  int         `json:"id,omitempty"`
+	MarketID            *int        `json:"market_id,omitempty"`
+	SellerID            string      `json:"seller_id,omitempty"`
+	TotalWagerValue     float32     `json:"total_wager_value"`
+	Odds                OddsValue   `json:"odds"`
+	OddsFormat          OddsFormat  `json:"odds_format"`
+	SellingPercentage   int         `json:"selling_percentage"`
+	SellingPrice        float32     `json:"selling_price"`
+	CurrentSellingPrice float32     `json:"current_selling_price"`
+	PercentageSold      float32     `json:"percentage_sold"`
+	AmountSold          float32     `json:"amount_sold"`
+	PlacedAt            *time.Time  `json:"placed_at"`
+	PriceDecay          *PriceDecay `json:"price_decay,omitempty"`
+	Currency            string      `json:"currency,omitempty"`
+	Quote               *WagerQuote `json:"quote,omitempty"`
 }
 
 type PlaceWagerRequest struct {
-	TotalWagerValue   float32 `json:"total_wager_value"`
-	Odds              int     `json:"odds"`
-	SellingPercentage int     `json:"selling_percentage"`
-	SellingPrice      float32 `json:"selling_price"`
+	MarketID          *int        `json:"market_id,omitempty"`
+	SellerID          string      `json:"seller_id,omitempty"`
+	TotalWagerValue   float32     `json:"total_wager_value"`
+	Odds              OddsValue   `json:"odds"`
+	OddsFormat        string      `json:"odds_format,omitempty"`
+	SellingPercentage int         `json:"selling_percentage"`
+	SellingPrice      float32     `json:"selling_price"`
+	PriceDecay        *PriceDecay `json:"price_decay,omitempty"`
+	// Currency is the ISO 4217 code of the amounts of the wager, the base currency
+	// when it is empty
+	Currency string `json:"currency,omitempty"`
+}
+
+// QuoteWagerRequest is a wager about to be placed, SellingPrice is the price the seller
+// has in mind, if any
+type QuoteWagerRequest struct {
+	TotalWagerValue   float32   `json:"total_wager_value"`
+	Odds              OddsValue `json:"odds"`
+	OddsFormat        string    `json:"odds_format,omitempty"`
+	SellingPercentage int       `json:"selling_percentage"`
+	SellingPrice      *float32  `json:"selling_price,omitempty"`
+}
+
+const (
+	QuoteAssessmentUnderpriced = "underpriced"
+	QuoteAssessmentFair        = "fair"
+	QuoteAssessmentOverpriced  = "overpriced"
+)
+
+// WagerQuote is the fair value of the share of a wager on sale at the probability its
+// odds imply. Premium and Assessment compare a price to it, they are omitted without one.
+type WagerQuote struct {
+	DecimalOdds        float64 `json:"decimal_odds"`
+	ImpliedProbability float64 `json:"implied_probability"`
+	// PotentialPayout is what the share on sale pays out when the wager wins
+	PotentialPayout float32 `json:"potential_payout"`
+	ExpectedValue   float32 `json:"expected_value"`
+	// MinSellingPrice is the lowest selling_price a wager can be placed at
+	MinSellingPrice float32 `json:"min_selling_price"`
+	FairPriceLow    float32 `json:"fair_price_low"`
+	FairPriceHigh   float32 `json:"fair_price_high"`
+	Price           float32 `json:"price,omitempty"`
+	// Premium is the percentage the price is above the expected value, negative below it
+	Premium    *float32 `json:"premium,omitempty"`
+	Assessment string   `json:"assessment,omitempty"`
+}
+
+// PriceDecay lowers the price of an unsold wager from its selling_price down to
+// FloorPrice over Duration, continuously when Type is linear or every Step when it is
+// step. The durations are Go durations, e.g. 48h or 90m.
+type PriceDecay struct {
+	Type       string  `json:"type"`
+	FloorPrice float32 `json:"floor_price"`
+	Duration   string  `json:"duration"`
+	Step       string  `json:"step,omitempty"`
 }
 
 type PlaceWagerResponse struct {
-	ID                  int        `json:"id"`
-	TotalWagerValue     float32    `json:"total_wager_value"`
-	Odds                int        `json:"odds"`
-	SellingPercentage   int        `json:"selling_percentage"`
-	SellingPrice        float32    `json:"selling_price"`
-	CurrentSellingPrice float32    `json:"current_selling_price"`
-	PercentageSold      float32    `json:"percentage_sold"`
-	AmountSold          float32    `json:"amount_sold"`
-	PlacedAt            *time.Time `json:"placed_at"`
+	ID                  int         `json:"id"`
+	MarketID            *int        `json:"market_id,omitempty"`
+	SellerID            string      `json:"seller_id,omitempty"`
+	TotalWagerValue     float32     `json:"total_wager_value"`
+	Odds                OddsValue   `json:"odds"`
+	OddsFormat          OddsFormat  `json:"odds_format"`
+	SellingPercentage   int         `json:"selling_percentage"`
+	SellingPrice        float32     `json:"selling_price"`
+	CurrentSellingPrice float32     `json:"current_selling_price"`
+	PercentageSold      float32     `json:"percentage_sold"`
+	AmountSold          float32     `json:"amount_sold"`
+	PlacedAt            *time.Time  `json:"placed_at"`
+	PriceDecay          *PriceDecay `json:"price_decay,omitempty"`
+	Currency            string      `json:"currency,omitempty"`
+	Quote               *WagerQuote `json:"quote,omitempty"`
+}
+
+// PlaceWagerBatchResult is the outcome of one wager of a batch, Index is its position
+// in the batch and Status one of placed, failed or aborted (valid but not placed as
+// another wager of an all-or-nothing batch failed).
+type PlaceWagerBatchResult struct {
+	Index  int                 `json:"index"`
+	Status string              `json:"status"`
+	Wager  *PlaceWagerResponse `json:"wager,omitempty"`
+	Error  string              `json:"error,omitempty"`
+}
+
+// PlaceWagerBatchResponse reports a batch, Error is the reason an atomic batch failed.
+type PlaceWagerBatchResponse struct {
+	Mode    string                   `json:"mode"`
+	Error   string                   `json:"error,omitempty"`
+	Placed  int                      `json:"placed"`
+	Failed  int                      `json:"failed"`
+	Results []*PlaceWagerBatchResult `json:"results"`
+}
+
+// RepriceWagerRequest sets the asking prices of a wager, a nil price is left as it is.
+type RepriceWagerRequest struct {
+	// SellerID is who reprices, the X-Actor-ID header when it is empty, only the
+	// seller of the wager can reprice it
+	SellerID            string   `json:"seller_id,omitempty"`
+	SellingPrice        *float32 `json:"selling_price,omitempty"`
+	CurrentSellingPrice *float32 `json:"current_selling_price,omitempty"`
 }
 
 type BuyWagerRequest struct {
 	BuyingPrice float32 `json:"buying_price"`
+	BuyerID     string  `json:"buyer_id,omitempty"`
 }
+
+// PlaceBuyOrderRequest buys the wager as soon as its current_selling_price is at most
+// limit_price, until expires_at.
+type PlaceBuyOrderRequest struct {
+	BuyerID    string     `json:"buyer_id,omitempty"`
+	LimitPrice float32    `json:"limit_price"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+}
+
+type BuyOrder struct {
+	ID         int        `json:"id"`
+	WagerID    int        `json:"wager_id"`
+	BuyerID    string     `json:"buyer_id,omitempty"`
+	LimitPrice float32    `json:"limit_price"`
+	Status     string     `json:"status"`
+	PurchaseID *int       `json:"purchase_id,omitempty"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	FilledAt   *time.Time `json:"filled_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// BuyWagerCommand is a purchase of a wager, whatever the transport it comes from
+type BuyWagerCommand struct {
+	WagerID     int
+	BuyingPrice float32
+	// BuyerID holds the stake bought, a purchase without one can't be resold
+	BuyerID string
+}
+
+// ListWagersQuery lists at most Limit wagers whose id is greater than AfterID, the first
+// Offset of them skipped, zero EventID and MarketID don't filter
+type ListWagersQuery struct {
+	AfterID    int
+	Offset     int
+	Limit      int
+	EventID    int
+	MarketID   int
+	OddsFormat OddsFormat
+	// Quote adds the fair value of each wager, its current selling price assessed
+	Quote bool
+}
+
 type BuyWagerResponse struct {
 	PurchaseID  int        `json:"purchase_id"`
 	WagerID     int        `json:"wager_id"`
 	BuyingPrice float32    `json:"buying_price"`
 	BoughtAt    *time.Time `json:"bought_at"`
+	BuyerID     string     `json:"buyer_id,omitempty"`
+	// Fees are charged on top of the buying price, one line per kind of fee
+	Fees     []*Fee  `json:"fees,omitempty"`
+	TotalFee float32 `json:"total_fee,omitempty"`
+	// PaidAmount is the buying price in PaidCurrency, the currency of the account of the
+	// buyer, at ExchangeRate which is omitted when no conversion was needed
+	PaidCurrency string  `json:"paid_currency,omitempty"`
+	PaidAmount   float32 `json:"paid_amount,omitempty"`
+	ExchangeRate float64 `json:"exchange_rate,omitempty"`
+}
+
+// Fee is a fee charged on a purchase, Rate is the percentage of the buying price or the
+// flat amount it is computed from
+type Fee struct {
+	Type   string  `json:"type"`
+	Rate   float32 `json:"rate"`
+	Amount float32 `json:"amount"`
+}
+
+// FeeReportRow is what one kind of fee brought in over a period
+type FeeReportRow struct {
+	PeriodStart time.Time `json:"period_start"`
+	FeeType     string    `json:"fee_type"`
+	Purchases   int64     `json:"purchases"`
+	Amount      float32   `json:"amount"`
+}
+
+// Account holds the currency a buyer pays in, an ISO 4217 code
+type Account struct {
+	AccountID string     `json:"account_id"`
+	Currency  string     `json:"currency"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// UpsertAccountRequest sets the currency of an account, creating it when needed
+type UpsertAccountRequest struct {
+	Currency string `json:"currency"`
+}
+
+// SpendLimit bounds what an account spends over the rolling Period, a day, a week or 30
+// days. Amount is nil when there is no limit, Pending is the change awaiting its delay.
+type SpendLimit struct {
+	Period        string             `json:"period"`
+	Amount        *float32           `json:"amount"`
+	Spent         float32            `json:"spent"`
+	EffectiveFrom *time.Time         `json:"effective_from,omitempty"`
+	Pending       *PendingSpendLimit `json:"pending,omitempty"`
+}
+
+// PendingSpendLimit is an increase or a removal of a limit, in force from EffectiveFrom
+type PendingSpendLimit struct {
+	Amount        *float32  `json:"amount"`
+	EffectiveFrom time.Time `json:"effective_from"`
+}
+
+// SetSpendLimitRequest sets the limit of a period, a null amount removes it
+type SetSpendLimitRequest struct {
+	Amount *float32 `json:"amount"`
+}
+
+// AccountRestriction keeps an account from buying and placing wagers until EndsAt, for
+// ever when it is nil
+type AccountRestriction struct {
+	ID       int        `json:"id"`
+	Kind     string     `json:"kind"`
+	StartsAt time.Time  `json:"starts_at"`
+	EndsAt   *time.Time `json:"ends_at,omitempty"`
+}
+
+// CoolOffRequest starts a cool-off period of Duration, a Go duration such as 72h
+type CoolOffRequest struct {
+	Duration string `json:"duration"`
+}
+
+// SelfExclusionRequest excludes an account for Duration, a Go duration, or for ever
+type SelfExclusionRequest struct {
+	Duration  string `json:"duration,omitempty"`
+	Permanent bool   `json:"permanent,omitempty"`
+}
+
+// AccountLimits are the spend limits of an account and its restrictions in force, what
+// it spent is converted into Currency, the one of the account
+type AccountLimits struct {
+	AccountID    string                `json:"account_id"`
+	Currency     string                `json:"currency"`
+	Limits       []*SpendLimit         `json:"limits"`
+	Restrictions []*AccountRestriction `json:"restrictions"`
+}
+
+// ExchangeRate is what one unit of BaseCurrency is worth in QuoteCurrency from
+// EffectiveFrom until the next rate of the pair takes effect
+type ExchangeRate struct {
+	ID            int       `json:"id"`
+	BaseCurrency  string    `json:"base_currency"`
+	QuoteCurrency string    `json:"quote_currency"`
+	Rate          float64   `json:"rate"`
+	EffectiveFrom time.Time `json:"effective_from"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// CreateExchangeRateRequest adds a rate, effective at once when EffectiveFrom is nil
+type CreateExchangeRateRequest struct {
+	BaseCurrency  string     `json:"base_currency"`
+	QuoteCurrency string     `json:"quote_currency"`
+	Rate          float64    `json:"rate"`
+	EffectiveFrom *time.Time `json:"effective_from,omitempty"`
+}
+
+// VolumeReportRow is the volume of the wagers in one currency over a period, BaseVolume
+// is its value in the base currency without the UnconvertedPurchases no rate applied to
+type VolumeReportRow struct {
+	PeriodStart          time.Time `json:"period_start"`
+	Currency             string    `json:"currency"`
+	Purchases            int64     `json:"purchases"`
+	Volume               float32   `json:"volume"`
+	BaseVolume           float32   `json:"base_volume"`
+	UnconvertedPurchases int64     `json:"unconverted_purchases"`
+}
+
+// VolumeReport is the volume bought by period and currency
+type VolumeReport struct {
+	BaseCurrency string             `json:"base_currency"`
+	Rows         []*VolumeReportRow `json:"rows"`
+}
+
+// RiskLimits are the exposure limits in force, 0 when there is none
+type RiskLimits struct {
+	MaxSellerExposure float32 `json:"max_seller_exposure"`
+	MaxMarketVolume   float32 `json:"max_market_volume"`
+	MaxPurchase       float32 `json:"max_purchase"`
+}
+
+// SellerExposure is the open selling value of a seller in the base currency, without
+// the UnconvertedWagers no rate converts. Utilization is the percentage of the limit it
+// reaches, omitted without a limit
+type SellerExposure struct {
+	SellerID          string   `json:"seller_id"`
+	OpenWagers        int64    `json:"open_wagers"`
+	OpenSellingValue  float32  `json:"open_selling_value"`
+	Utilization       *float32 `json:"utilization,omitempty"`
+	UnconvertedWagers int64    `json:"unconverted_wagers,omitempty"`
+}
+
+// MarketExposure is the volume bought in a market in the base currency, without the
+// UnconvertedWagers no rate converts. Utilization is the percentage of the limit it
+// reaches, omitted without a limit
+type MarketExposure struct {
+	MarketID          int      `json:"market_id"`
+	Wagers            int64    `json:"wagers"`
+	Volume            float32  `json:"volume"`
+	Utilization       *float32 `json:"utilization,omitempty"`
+	UnconvertedWagers int64    `json:"unconverted_wagers,omitempty"`
+}
+
+// WagerDiscrepancy is a wager whose amount_sold or percentage_sold drifted from what its
+// purchases sum up to, the diffs are the stored values minus the expected ones
+type WagerDiscrepancy struct {
+	WagerID                int     `json:"wager_id"`
+	Purchases              int64   `json:"purchases"`
+	AmountSold             float32 `json:"amount_sold"`
+	ExpectedAmountSold     float32 `json:"expected_amount_sold"`
+	AmountSoldDiff         float32 `json:"amount_sold_diff"`
+	PercentageSold         float32 `json:"percentage_sold"`
+	ExpectedPercentageSold float32 `json:"expected_percentage_sold"`
+	PercentageSoldDiff     float32 `json:"percentage_sold_diff"`
+	Repaired               bool    `json:"repaired"`
+}
+
+// ReconciliationReport is the outcome of a reconciliation of the wager aggregates
+type ReconciliationReport struct {
+	StartedAt     time.Time           `json:"started_at"`
+	FinishedAt    time.Time           `json:"finished_at"`
+	Checked       int                 `json:"checked"`
+	Repaired      int                 `json:"repaired"`
+	Discrepancies []*WagerDiscrepancy `json:"discrepancies"`
+}
+
+// TradeAlert is a purchase flagged by a surveillance rule, PurchaseID is omitted when
+// the rule blocked it
+type TradeAlert struct {
+	ID          int             `json:"id"`
+	Rule        string          `json:"rule"`
+	WagerID     int             `json:"wager_id"`
+	PurchaseID  *int            `json:"purchase_id,omitempty"`
+	ListingID   *int            `json:"listing_id,omitempty"`
+	BuyerID     string          `json:"buyer_id,omitempty"`
+	SellerID    string          `json:"seller_id,omitempty"`
+	BuyingPrice float32         `json:"buying_price"`
+	Details     json.RawMessage `json:"details,omitempty"`
+	Blocked     bool            `json:"blocked"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+type LinkAccountsRequest struct {
+	AccountID       string `json:"account_id"`
+	LinkedAccountID string `json:"linked_account_id"`
+	Reason          string `json:"reason"`
+}
+
+// AccountLink records that two accounts are held by the same party
+type AccountLink struct {
+	ID              int       `json:"id"`
+	AccountID       string    `json:"account_id"`
+	LinkedAccountID string    `json:"linked_account_id"`
+	Reason          string    `json:"reason,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// RiskSummary is the current exposure against the limits, in Currency
+type RiskSummary struct {
+	Currency string            `json:"currency"`
+	Limits   RiskLimits        `json:"limits"`
+	Sellers  []*SellerExposure `json:"sellers"`
+	Markets  []*MarketExposure `json:"markets"`
+}
+
+type PricePoint struct {
+	Price      float32    `json:"price"`
+	RecordedAt *time.Time `json:"recorded_at"`
+}
+
+type PriceCandle struct {
+	BucketStart *time.Time `json:"bucket_start"`
+	Open        float32    `json:"open"`
+	High        float32    `json:"high"`
+	Low         float32    `json:"low"`
+	Close       float32    `json:"close"`
+	Count       int64      `json:"count"`
+}
+
+// PriceHistoryQuery bounds the price history with optional From and To, a positive
+// Interval buckets it into candles
+type PriceHistoryQuery struct {
+	From     *time.Time
+	To       *time.Time
+	Interval time.Duration
+}
+
+type PriceHistoryResponse struct {
+	WagerID  int            `json:"wager_id"`
+	Interval string         `json:"interval,omitempty"`
+	Points   []*PricePoint  `json:"points,omitempty"`
+	Candles  []*PriceCandle `json:"candles,omitempty"`
+}
+
+// WagerUpdate is one event of the wager stream
+type WagerUpdate struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	WagerID   int             `json:"wager_id"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type PriceChanged struct {
+	WagerID             int     `json:"wager_id"`
+	PreviousPrice       float32 `json:"previous_price"`
+	CurrentSellingPrice float32 `json:"current_selling_price"`
+}
+
+// OutboxEvent is a domain event relayed to the downstream systems, ID increases with
+// the order of the events and lets consumers drop the ones delivered twice
+type OutboxEvent struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	WagerID   int             `json:"wager_id"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type WagerSettled struct {
+	WagerID   int       `json:"wager_id"`
+	MarketID  int       `json:"market_id"`
+	SettledAt time.Time `json:"settled_at"`
+	Payouts   []*Payout `json:"payouts"`
+}
+
+// Payout is the stake of a purchase a holder is paid for when its wager is settled,
+// the holder is the current one, whoever bought the stake first. HolderID is omitted for
+// the purchases made without a buyer.
+type Payout struct {
+	HolderID   string  `json:"holder_id,omitempty"`
+	PurchaseID int     `json:"purchase_id"`
+	Stake      float32 `json:"stake"`
+}
+
+// CreateListingRequest puts Stake of the stake SellerID holds in a purchase up for
+// resale at AskingPrice.
+type CreateListingRequest struct {
+	SellerID    string  `json:"seller_id"`
+	Stake       float32 `json:"stake"`
+	AskingPrice float32 `json:"asking_price"`
+}
+
+type Listing struct {
+	ID          int        `json:"id"`
+	PurchaseID  int        `json:"purchase_id"`
+	WagerID     int        `json:"wager_id"`
+	SellerID    string     `json:"seller_id"`
+	Stake       float32    `json:"stake"`
+	AskingPrice float32    `json:"asking_price"`
+	Status      string     `json:"status"`
+	BuyerID     string     `json:"buyer_id,omitempty"`
+	SoldAt      *time.Time `json:"sold_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type BuyListingRequest struct {
+	BuyerID string `json:"buyer_id"`
+}
+
+// StakeTransfer is a stake of a purchase sold through a listing, FromHolderID doesn't
+// hold it anymore.
+type StakeTransfer struct {
+	ID            int       `json:"id"`
+	ListingID     int       `json:"listing_id"`
+	PurchaseID    int       `json:"purchase_id"`
+	WagerID       int       `json:"wager_id"`
+	FromHolderID  string    `json:"from_holder_id"`
+	ToHolderID    string    `json:"to_holder_id"`
+	Stake         float32   `json:"stake"`
+	Price         float32   `json:"price"`
+	TransferredAt time.Time `json:"transferred_at"`
+	Fees          []*Fee    `json:"fees,omitempty"`
+	TotalFee      float32   `json:"total_fee,omitempty"`
+	// PaidAmount is the price in PaidCurrency, the currency of the account of the buyer,
+	// at ExchangeRate which is omitted when no conversion was needed
+	PaidCurrency string  `json:"paid_currency,omitempty"`
+	PaidAmount   float32 `json:"paid_amount,omitempty"`
+	ExchangeRate float64 `json:"exchange_rate,omitempty"`
+}
+
+// AuditLog is who changed what and when, Before and After are the columns of the
+// changed record
+type AuditLog struct {
+	ID    int64  `json:"id"`
+	Actor string `json:"actor,omitempty"`
+	// ActorVerified is false when the actor was only claimed by an anonymous request
+	ActorVerified bool            `json:"actor_verified"`
+	Action        string          `json:"action"`
+	WagerID       *int            `json:"wager_id,omitempty"`
+	PurchaseID    *int            `json:"purchase_id,omitempty"`
+	AccountID     string          `json:"account_id,omitempty"`
+	EntityID      string          `json:"entity_id,omitempty"`
+	Before        json.RawMessage `json:"before,omitempty"`
+	After         json.RawMessage `json:"after,omitempty"`
+	RequestID     string          `json:"request_id,omitempty"`
+	ClientIP      string          `json:"client_ip,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+const (
+	PositionStatusOpen    = "open"
+	PositionStatusClosed  = "closed"
+	PositionStatusSettled = "settled"
+)
+
+// Position is what an account owns of a wager, its stake is valued at the
+// current_selling_price of the wager relative to its selling_price. A position is closed
+// once its whole stake is resold, the ones of a settled market are closed at their last
+// value and only have a realized P&L: the wagers back no selection and the settlement
+// records no outcome, the P&L is never a payout.
+type Position struct {
+	WagerID             int     `json:"wager_id"`
+	MarketID            int     `json:"market_id,omitempty"`
+	Status              string  `json:"status"`
+	Stake               float32 `json:"stake"`
+	TotalCost           float32 `json:"total_cost"`
+	ImpliedStake        float32 `json:"implied_stake"`
+	CurrentSellingPrice float32 `json:"current_selling_price"`
+	MarketValue         float32 `json:"market_value"`
+	UnrealizedPnL       float32 `json:"unrealized_pnl"`
+	RealizedPnL         float32 `json:"realized_pnl"`
+	// Currency is the one of the wager, every amount of the position is in it
+	Currency string `json:"currency"`
+}
+
+// PositionSummary sums up every position of an account in the wagers of Currency, not
+// only the listed page
+type PositionSummary struct {
+	Currency      string  `json:"currency"`
+	Positions     int     `json:"positions"`
+	OpenPositions int     `json:"open_positions"`
+	TotalCost     float32 `json:"total_cost"`
+	MarketValue   float32 `json:"market_value"`
+	UnrealizedPnL float32 `json:"unrealized_pnl"`
+	RealizedPnL   float32 `json:"realized_pnl"`
+}
+
+// AccountPositions lists a page of the positions of an account, its Summary has one entry
+// per currency of the wagers traded: the amounts of different currencies aren't added up.
+type AccountPositions struct {
+	AccountID string             `json:"account_id"`
+	Page      int                `json:"page"`
+	Limit     int                `json:"limit"`
+	Positions []*Position        `json:"positions"`
+	Summary   []*PositionSummary `json:"summary"`
 }
@@ -0,0 +1,169 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// OddsFormat is the notation used to express odds, the canonical one stored in
+// database is decimal.
+type OddsFormat string
+
+const (
+	OddsFormatDecimal    OddsFormat = "decimal"
+	OddsFormatFractional OddsFormat = "fractional"
+	OddsFormatAmerican   OddsFormat = "american"
+)
+
+// maxFractionalDenominator bounds the denominator when rendering fractional odds,
+// 100 is enough for every price a bookmaker would quote.
+const maxFractionalDenominator = 100
+
+// ParseOddsFormat returns the odds format of raw, an empty value means decimal.
+func ParseOddsFormat(raw string) (OddsFormat, error) {
+	switch format := OddsFormat(strings.ToLower(strings.TrimSpace(raw))); format {
+	case "":
+		return OddsFormatDecimal, nil
+	case OddsFormatDecimal, OddsFormatFractional, OddsFormatAmerican:
+		return format, nil
+	default:
+		return "", fmt.Errorf("the odds_format must be one of decimal, fractional, american")
+	}
+}
+
+// OddsValue is odds written in one of the OddsFormat, it accepts both JSON numbers
+// (2.5, -200) and strings ("5/2", "+150").
+type OddsValue string
+
+func (v *OddsValue) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*v = OddsValue(strings.TrimSpace(text))
+		return nil
+	}
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return fmt.Errorf("the odds must be a number or a string")
+	}
+	*v = OddsValue(number.String())
+	return nil
+}
+
+// MarshalJSON renders numeric odds (decimal, american) as a JSON number and
+// fractional odds as a string, like the odds which aren't a finite number.
+func (v OddsValue) MarshalJSON() ([]byte, error) {
+	if _, ok := parseFinite(string(v)); ok {
+		return []byte(strings.TrimPrefix(string(v), "+")), nil
+	}
+	return json.Marshal(string(v))
+}
+
+// plainDecimalPattern is a number written with digits and an optional fraction, the
+// way a JSON number without exponent is, with an optional sign.
+var plainDecimalPattern = regexp.MustCompile(`^[+-]?(0|[1-9][0-9]*)(\.[0-9]+)?$`)
+
+// parseFinite parses raw as a float written as a plain decimal, refusing what ParseFloat
+// accepts besides: NaN, the infinities, exponents, hexadecimal floats and the fractions
+// without a digit before or after the point, so that MarshalJSON can emit raw as it is.
+func parseFinite(raw string) (float64, bool) {
+	if !plainDecimalPattern.MatchString(raw) {
+		return 0, false
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, false
+	}
+	return value, true
+}
+
+// ToDecimal converts the odds written in format to decimal odds.
+func (v OddsValue) ToDecimal(format OddsFormat) (float64, error) {
+	raw := strings.TrimSpace(string(v))
+	switch format {
+	case OddsFormatDecimal:
+		decimal, ok := parseFinite(raw)
+		if !ok || decimal <= 1 {
+			return 0, fmt.Errorf("the decimal odds must be a number greater than 1")
+		}
+		return decimal, nil
+	case OddsFormatFractional:
+		parts := strings.Split(raw, "/")
+		if len(parts) != 2 {
+			return 0, fmt.Errorf("the fractional odds must be written as numerator/denominator")
+		}
+		numerator, ok1 := parseFinite(strings.TrimSpace(parts[0]))
+		denominator, ok2 := parseFinite(strings.TrimSpace(parts[1]))
+		if !ok1 || !ok2 || numerator <= 0 || denominator <= 0 || math.IsInf(numerator/denominator, 0) {
+			return 0, fmt.Errorf("the fractional odds must have a positive numerator and denominator")
+		}
+		return 1 + numerator/denominator, nil
+	case OddsFormatAmerican:
+		american, ok := parseFinite(raw)
+		if !ok || math.Abs(american) < 100 {
+			return 0, fmt.Errorf("the american odds must be a number greater than +100 or lesser than -100")
+		}
+		if american > 0 {
+			return 1 + american/100, nil
+		}
+		return 1 + 100/-american, nil
+	default:
+		return 0, fmt.Errorf("the odds_format must be one of decimal, fractional, american")
+	}
+}
+
+// FormatOdds renders decimal odds in format. Decimal odds which are not greater
+// than 1 can't be converted and are kept as they are.
+func FormatOdds(decimal float64, format OddsFormat) OddsValue {
+	if decimal <= 1 {
+		format = OddsFormatDecimal
+	}
+	switch format {
+	case OddsFormatFractional:
+		numerator, denominator := approximateFraction(decimal-1, maxFractionalDenominator)
+		// odds too short for the denominator are rendered as the closest unit fraction
+		// rather than as 0/1, which would be no odds at all
+		if numerator < 1 {
+			numerator, denominator = 1, int64(math.Round(1/(decimal-1)))
+		}
+		return OddsValue(fmt.Sprintf("%d/%d", numerator, denominator))
+	case OddsFormatAmerican:
+		if decimal >= 2 {
+			return OddsValue(fmt.Sprintf("+%d", int64(math.Round((decimal-1)*100))))
+		}
+		return OddsValue(strconv.FormatInt(int64(math.Round(-100/(decimal-1))), 10))
+	default:
+		return OddsValue(strconv.FormatFloat(math.Round(decimal*10000)/10000, 'f', -1, 64))
+	}
+}
+
+// approximateFraction returns the closest fraction of x whose denominator is not
+// greater than maxDenominator, using continued fractions.
+func approximateFraction(x float64, maxDenominator int64) (int64, int64) {
+	var (
+		prevNumerator, numerator     int64 = 0, 1
+		prevDenominator, denominator int64 = 1, 0
+		rest                               = x
+	)
+	for {
+		whole := int64(math.Floor(rest))
+		nextDenominator := whole*denominator + prevDenominator
+		if nextDenominator > maxDenominator {
+			break
+		}
+		prevNumerator, numerator = numerator, whole*numerator+prevNumerator
+		prevDenominator, denominator = denominator, nextDenominator
+		fraction := rest - float64(whole)
+		if fraction < 1e-9 {
+			break
+		}
+		rest = 1 / fraction
+	}
+	if denominator == 0 {
+		return int64(math.Round(x)), 1
+	}
+	return numerator, denominator
+}
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOddsValue_ToDecimal(t *testing.T) {
+	t.Parallel()
+	type testcase struct {
+		name            string
+		odds            OddsValue
+		format          OddsFormat
+		expectedDecimal float64
+		expectErr       bool
+	}
+	tests := []testcase{
+		{name: "decimal", odds: "3.5", format: OddsFormatDecimal, expectedDecimal: 3.5},
+		{name: "decimal not greater than 1", odds: "1", format: OddsFormatDecimal, expectErr: true},
+		{name: "fractional", odds: "5/2", format: OddsFormatFractional, expectedDecimal: 3.5},
+		{name: "fractional odds-on", odds: "1/4", format: OddsFormatFractional, expectedDecimal: 1.25},
+		{name: "fractional without denominator", odds: "5", format: OddsFormatFractional, expectErr: true},
+		{name: "fractional zero denominator", odds: "5/0", format: OddsFormatFractional, expectErr: true},
+		{name: "american positive", odds: "+250", format: OddsFormatAmerican, expectedDecimal: 3.5},
+		{name: "american negative", odds: "-400", format: OddsFormatAmerican, expectedDecimal: 1.25},
+		{name: "american between -100 and 100", odds: "50", format: OddsFormatAmerican, expectErr: true},
+		{name: "decimal NaN", odds: "NaN", format: OddsFormatDecimal, expectErr: true},
+		{name: "decimal infinity", odds: "+Inf", format: OddsFormatDecimal, expectErr: true},
+		{name: "decimal infinity spelled out", odds: "Infinity", format: OddsFormatDecimal, expectErr: true},
+		{name: "fractional NaN numerator", odds: "NaN/2", format: OddsFormatFractional, expectErr: true},
+		{name: "fractional infinite numerator", odds: "Inf/2", format: OddsFormatFractional, expectErr: true},
+		{name: "fractional infinite denominator", odds: "5/Inf", format: OddsFormatFractional, expectErr: true},
+		{name: "fractional overflowing ratio", odds: "1e308/1e-308", format: OddsFormatFractional, expectErr: true},
+		{name: "decimal hexadecimal", odds: "0x1p1", format: OddsFormatDecimal, expectErr: true},
+		{name: "decimal without fraction digits", odds: "2.", format: OddsFormatDecimal, expectErr: true},
+		{name: "decimal without integer digits", odds: ".5", format: OddsFormatDecimal, expectErr: true},
+		{name: "decimal with exponent", odds: "2e0", format: OddsFormatDecimal, expectErr: true},
+		{name: "american with leading zeros", odds: "+0250", format: OddsFormatAmerican, expectErr: true},
+		{name: "american NaN", odds: "NaN", format: OddsFormatAmerican, expectErr: true},
+		{name: "american infinity", odds: "-Inf", format: OddsFormatAmerican, expectErr: true},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			decimal, err := tc.odds.ToDecimal(tc.format)
+			if tc.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.InDelta(t, tc.expectedDecimal, decimal, 1e-9)
+		})
+	}
+}
+
+func TestFormatOdds(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, OddsValue("3.5"), FormatOdds(3.5, OddsFormatDecimal))
+	assert.Equal(t, OddsValue("5/2"), FormatOdds(3.5, OddsFormatFractional))
+	assert.Equal(t, OddsValue("1/3"), FormatOdds(4.0/3, OddsFormatFractional))
+	// below 1/100 the denominator goes past the max rather than rendering 0/1
+	assert.Equal(t, OddsValue("1/1000"), FormatOdds(1.001, OddsFormatFractional))
+	assert.Equal(t, OddsValue("+250"), FormatOdds(3.5, OddsFormatAmerican))
+	assert.Equal(t, OddsValue("-400"), FormatOdds(1.25, OddsFormatAmerican))
+	assert.Equal(t, OddsValue("+100"), FormatOdds(2, OddsFormatAmerican))
+	// odds which can't be converted are rendered as decimal
+	assert.Equal(t, OddsValue("0"), FormatOdds(0, OddsFormatAmerican))
+}
+
+func TestOddsValue_JSON(t *testing.T) {
+	t.Parallel()
+	req := PlaceWagerRequest{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"odds": 2.5}`), &req))
+	assert.Equal(t, OddsValue("2.5"), req.Odds)
+	assert.NoError(t, json.Unmarshal([]byte(`{"odds": "5/2", "odds_format": "fractional"}`), &req))
+	assert.Equal(t, OddsValue("5/2"), req.Odds)
+	assert.Error(t, json.Unmarshal([]byte(`{"odds": true}`), &req))
+
+	data, err := json.Marshal(Wager{Odds: "+250", OddsFormat: OddsFormatAmerican})
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"odds":250,"odds_format":"american"`)
+	data, err = json.Marshal(Wager{Odds: "5/2", OddsFormat: OddsFormatFractional})
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"odds":"5/2","odds_format":"fractional"`)
+	// odds which aren't a finite number, or a plain decimal one, are still valid JSON
+	data, err = json.Marshal(Wager{Odds: "NaN"})
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"odds":"NaN"`)
+	for _, odds := range []OddsValue{"0x1p1", "2.", ".5"} {
+		data, err = json.Marshal(Wager{Odds: odds})
+		assert.NoError(t, err)
+		assert.Contains(t, string(data), `"odds":"`+string(odds)+`"`)
+	}
+}
@@ -0,0 +1,46 @@
+package models
+
+import "encoding/json"
+
+// Types of the messages a client sends over the websocket
+const (
+	SocketSubscribe   = "subscribe"
+	SocketUnsubscribe = "unsubscribe"
+	SocketPlace       = "place"
+	SocketBuy         = "buy"
+	SocketPing        = "ping"
+)
+
+// Types of the messages the server sends over the websocket
+const (
+	SocketSubscribed   = "subscribed"
+	SocketUnsubscribed = "unsubscribed"
+	SocketPlaced       = "placed"
+	SocketBought       = "bought"
+	SocketPong         = "pong"
+	SocketUpdate       = "update"
+	SocketError        = "error"
+)
+
+// SocketRequest is a message sent by a client, the server echoes its ID in the response
+// so the client can match them.
+type SocketRequest struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Channel string          `json:"channel,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+type SocketResponse struct {
+	ID      string      `json:"id,omitempty"`
+	Type    string      `json:"type"`
+	Channel string      `json:"channel,omitempty"`
+	Data    interface{} `json:"data,omitempty"`
+	Error   string      `json:"error,omitempty"`
+}
+
+type SocketBuyWagerRequest struct {
+	WagerID     int     `json:"wager_id"`
+	BuyingPrice float32 `json:"buying_price"`
+	BuyerID     string  `json:"buyer_id,omitempty"`
+}
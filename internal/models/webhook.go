@@ -0,0 +1,59 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type CreateWebhookRequest struct {
+	SellerID string `json:"seller_id"`
+	URL      string `json:"url"`
+	// Secret signs the deliveries, one is generated when it is empty
+	Secret     string   `json:"secret,omitempty"`
+	EventTypes []string `json:"event_types"`
+}
+
+// Webhook is an endpoint of a seller, the secret is only returned when it is created
+type Webhook struct {
+	ID                  int        `json:"id"`
+	SellerID            string     `json:"seller_id"`
+	URL                 string     `json:"url"`
+	Secret              string     `json:"secret,omitempty"`
+	EventTypes          []string   `json:"event_types"`
+	Status              string     `json:"status"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	DisabledAt          *time.Time `json:"disabled_at,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+}
+
+type WebhookDelivery struct {
+	ID             int64           `json:"id"`
+	WebhookID      int             `json:"webhook_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at,omitempty"`
+	ResponseStatus *int            `json:"response_status,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+}
+
+// WebhookEvent is the body posted to a webhook
+type WebhookEvent struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+type WagerBought struct {
+	WagerID             int        `json:"wager_id"`
+	PurchaseID          int        `json:"purchase_id"`
+	BuyingPrice         float32    `json:"buying_price"`
+	BoughtAt            *time.Time `json:"bought_at"`
+	CurrentSellingPrice float32    `json:"current_selling_price"`
+	PercentageSold      float32    `json:"percentage_sold"`
+	AmountSold          float32    `json:"amount_sold"`
+}
@@ -0,0 +1,128 @@
+// Package openapi serves the OpenAPI document of the API and validates the requests
+// against it.
+package openapi
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/go-chi/chi/v5"
+)
+
+// spec is maintained by hand, every route registered on the mux must be described in it.
+//
+//go:embed openapi.yaml
+var spec []byte
+
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8" />
+  <title>Wager API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@4/swagger-ui.css" />
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@4/swagger-ui-bundle.js"></script>
+  <script>
+    window.onload = () => {
+      window.ui = SwaggerUIBundle({ url: "/openapi.json", dom_id: "#swagger-ui" });
+    };
+  </script>
+</body>
+</html>
+`
+
+// Load parses and validates the OpenAPI document.
+func Load() (*openapi3.T, error) {
+	doc, err := openapi3.NewLoader().LoadFromData(spec)
+	if err != nil {
+		return nil, fmt.Errorf("openapi3.LoadFromData: %w", err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("doc.Validate: %w", err)
+	}
+	return doc, nil
+}
+
+// NewHandler registers the routes serving the document as JSON and its Swagger UI, it
+// must be called after services.NewWagerHandler which sets up the shared middlewares.
+func NewHandler(mux *chi.Mux, doc *openapi3.T) {
+	mux.Get("/openapi.json", func(resp http.ResponseWriter, req *http.Request) {
+		resp.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(resp).Encode(doc)
+	})
+	mux.Get("/docs", func(resp http.ResponseWriter, req *http.Request) {
+		resp.Header().Set("Content-Type", "text/html; charset=utf-8")
+		resp.WriteHeader(http.StatusOK)
+		_, _ = fmt.Fprint(resp, swaggerUIPage)
+	})
+}
+
+// ValidationMiddleware rejects with a 400 the requests whose params or body don't match
+// the document. The requests of routes the document doesn't know are left to the mux.
+func ValidationMiddleware(doc *openapi3.T) (func(http.Handler) http.Handler, error) {
+	// match the paths only, the servers of the document are examples for its readers
+	routerDoc := *doc
+	routerDoc.Servers = nil
+	router, err := legacy.NewRouter(&routerDoc)
+	if err != nil {
+		return nil, fmt.Errorf("legacy.NewRouter: %w", err)
+	}
+	options := &openapi3filter.Options{
+		// the handlers authenticate the requests themselves
+		AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+		MultiError:         false,
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route, pathParams, err := router.FindRoute(r)
+			if err != nil {
+				var routeErr *routers.RouteError
+				if errors.As(err, &routeErr) {
+					next.ServeHTTP(w, r)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusInternalServerError)
+				_ = json.NewEncoder(w).Encode(map[string]string{
+					"error": "unable to validate request",
+				})
+				return
+			}
+			if err := openapi3filter.ValidateRequest(r.Context(), &openapi3filter.RequestValidationInput{
+				Request:    r,
+				PathParams: pathParams,
+				Route:      route,
+				Options:    options,
+			}); err != nil {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(map[string]string{
+					"error": validationMessage(err),
+				})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+// validationMessage keeps the first line of a validation error, the following ones
+// dump the schema which is already in the document.
+func validationMessage(err error) string {
+	message := err.Error()
+	if i := strings.Index(message, "\n"); i >= 0 {
+		message = message[:i]
+	}
+	return "invalid request: " + message
+}
@@ -0,0 +1,143 @@
+package openapi
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wager-api/internal/services"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func newMux(t *testing.T) *chi.Mux {
+	t.Helper()
+	doc, err := Load()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	mux := chi.NewMux()
+	wagerService := &services.WagerService{Stream: services.NewWagerStream()}
+	services.NewWagerHandler(mux, wagerService)
+	services.NewEventHandler(mux, &services.EventService{})
+	services.NewWagerSocketHandler(mux, services.NewWagerSocket(wagerService, nil))
+	services.NewWebhookHandler(mux, &services.WebhookService{}, nil)
+	services.NewAuditHandler(mux, &services.AuditService{}, nil)
+	services.NewExportHandler(mux, &services.ExportService{}, nil)
+	services.NewFeeHandler(mux, &services.FeeService{}, nil)
+	services.NewCurrencyHandler(mux, &services.CurrencyService{}, nil)
+	services.NewRiskHandler(mux, &services.RiskService{}, nil)
+	services.NewSurveillanceHandler(mux, &services.SurveillanceService{}, nil)
+	NewHandler(mux, doc)
+	return mux
+}
+
+func Test_SpecCoversEveryRoute(t *testing.T) {
+	t.Parallel()
+	doc, err := Load()
+	if !assert.NoError(t, err) {
+		return
+	}
+	err = chi.Walk(newMux(t), func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		pathItem := doc.Paths.Find(route)
+		if assert.NotNil(t, pathItem, "%s is not in the openapi document", route) {
+			assert.NotNil(t, pathItem.GetOperation(method), "%s %s is not in the openapi document", method, route)
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func Test_ServeSpec(t *testing.T) {
+	t.Parallel()
+	mux := newMux(t)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"openapi":"3.0.3"`)
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/html; charset=utf-8", rec.Header().Get("Content-Type"))
+}
+
+func Test_ValidationMiddleware(t *testing.T) {
+	t.Parallel()
+	doc, err := Load()
+	if !assert.NoError(t, err) {
+		return
+	}
+	validate, err := ValidationMiddleware(doc)
+	if !assert.NoError(t, err) {
+		return
+	}
+	reached := false
+	handler := validate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	type testcase struct {
+		name           string
+		method         string
+		url            string
+		body           string
+		expectedStatus int
+	}
+	tests := []testcase{
+		{
+			name:           "valid wager",
+			method:         http.MethodPost,
+			url:            "/wagers",
+			body:           `{"total_wager_value": 50, "odds": "5/2", "odds_format": "fractional", "selling_percentage": 30, "selling_price": 50}`,
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "missing selling_price",
+			method:         http.MethodPost,
+			url:            "/wagers",
+			body:           `{"total_wager_value": 50, "odds": 2, "selling_percentage": 30}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "wager id is not an integer",
+			method:         http.MethodPost,
+			url:            "/buy/abc",
+			body:           `{"buying_price": 10}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "page with a colon",
+			method:         http.MethodGet,
+			url:            "/wagers?page=:1&limit=:10",
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "unknown odds format",
+			method:         http.MethodGet,
+			url:            "/wagers?page=1&limit=10&odds_format=roman",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "route not in the document",
+			method:         http.MethodGet,
+			url:            "/unknown",
+			expectedStatus: http.StatusNoContent,
+		},
+	}
+	for _, tc := range tests {
+		reached = false
+		req := httptest.NewRequest(tc.method, tc.url, bytes.NewBufferString(tc.body))
+		if tc.body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		assert.Equal(t, tc.expectedStatus, rec.Code, tc.name)
+		assert.Equal(t, tc.expectedStatus == http.StatusNoContent, reached, tc.name)
+	}
+}
@@ -0,0 +1,763 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.31.0
+// 	protoc        (unknown)
+// source: wager.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type PlaceWagerRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	MarketId          int32   `protobuf:"varint,1,opt,name=market_id,json=marketId,proto3" json:"market_id,omitempty"`
+	TotalWagerValue   float32 `protobuf:"fixed32,2,opt,name=total_wager_value,json=totalWagerValue,proto3" json:"total_wager_value,omitempty"`
+	Odds              string  `protobuf:"bytes,3,opt,name=odds,proto3" json:"odds,omitempty"`
+	OddsFormat        string  `protobuf:"bytes,4,opt,name=odds_format,json=oddsFormat,proto3" json:"odds_format,omitempty"`
+	SellingPercentage int32   `protobuf:"varint,5,opt,name=selling_percentage,json=sellingPercentage,proto3" json:"selling_percentage,omitempty"`
+	SellingPrice      float32 `protobuf:"fixed32,6,opt,name=selling_price,json=sellingPrice,proto3" json:"selling_price,omitempty"`
+	SellerId          string  `protobuf:"bytes,7,opt,name=seller_id,json=sellerId,proto3" json:"seller_id,omitempty"`
+}
+
+func (x *PlaceWagerRequest) Reset() {
+	*x = PlaceWagerRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_wager_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PlaceWagerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlaceWagerRequest) ProtoMessage() {}
+
+func (x *PlaceWagerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_wager_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlaceWagerRequest.ProtoReflect.Descriptor instead.
+func (*PlaceWagerRequest) Descriptor() ([]byte, []int) {
+	return file_wager_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *PlaceWagerRequest) GetMarketId() int32 {
+	if x != nil {
+		return x.MarketId
+	}
+	return 0
+}
+
+func (x *PlaceWagerRequest) GetTotalWagerValue() float32 {
+	if x != nil {
+		return x.TotalWagerValue
+	}
+	return 0
+}
+
+func (x *PlaceWagerRequest) GetOdds() string {
+	if x != nil {
+		return x.Odds
+	}
+	return ""
+}
+
+func (x *PlaceWagerRequest) GetOddsFormat() string {
+	if x != nil {
+		return x.OddsFormat
+	}
+	return ""
+}
+
+func (x *PlaceWagerRequest) GetSellingPercentage() int32 {
+	if x != nil {
+		return x.SellingPercentage
+	}
+	return 0
+}
+
+func (x *PlaceWagerRequest) GetSellingPrice() float32 {
+	if x != nil {
+		return x.SellingPrice
+	}
+	return 0
+}
+
+func (x *PlaceWagerRequest) GetSellerId() string {
+	if x != nil {
+		return x.SellerId
+	}
+	return ""
+}
+
+type BuyWagerRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	WagerId     int32   `protobuf:"varint,1,opt,name=wager_id,json=wagerId,proto3" json:"wager_id,omitempty"`
+	BuyingPrice float32 `protobuf:"fixed32,2,opt,name=buying_price,json=buyingPrice,proto3" json:"buying_price,omitempty"`
+	BuyerId     string  `protobuf:"bytes,3,opt,name=buyer_id,json=buyerId,proto3" json:"buyer_id,omitempty"`
+}
+
+func (x *BuyWagerRequest) Reset() {
+	*x = BuyWagerRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_wager_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BuyWagerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BuyWagerRequest) ProtoMessage() {}
+
+func (x *BuyWagerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_wager_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BuyWagerRequest.ProtoReflect.Descriptor instead.
+func (*BuyWagerRequest) Descriptor() ([]byte, []int) {
+	return file_wager_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *BuyWagerRequest) GetWagerId() int32 {
+	if x != nil {
+		return x.WagerId
+	}
+	return 0
+}
+
+func (x *BuyWagerRequest) GetBuyingPrice() float32 {
+	if x != nil {
+		return x.BuyingPrice
+	}
+	return 0
+}
+
+func (x *BuyWagerRequest) GetBuyerId() string {
+	if x != nil {
+		return x.BuyerId
+	}
+	return ""
+}
+
+type GetWagerRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	WagerId    int32  `protobuf:"varint,1,opt,name=wager_id,json=wagerId,proto3" json:"wager_id,omitempty"`
+	OddsFormat string `protobuf:"bytes,2,opt,name=odds_format,json=oddsFormat,proto3" json:"odds_format,omitempty"`
+}
+
+func (x *GetWagerRequest) Reset() {
+	*x = GetWagerRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_wager_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetWagerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetWagerRequest) ProtoMessage() {}
+
+func (x *GetWagerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_wager_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetWagerRequest.ProtoReflect.Descriptor instead.
+func (*GetWagerRequest) Descriptor() ([]byte, []int) {
+	return file_wager_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetWagerRequest) GetWagerId() int32 {
+	if x != nil {
+		return x.WagerId
+	}
+	return 0
+}
+
+func (x *GetWagerRequest) GetOddsFormat() string {
+	if x != nil {
+		return x.OddsFormat
+	}
+	return ""
+}
+
+type ListWagersRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	AfterId    int32  `protobuf:"varint,1,opt,name=after_id,json=afterId,proto3" json:"after_id,omitempty"`
+	Limit      uint32 `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	EventId    int32  `protobuf:"varint,3,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	MarketId   int32  `protobuf:"varint,4,opt,name=market_id,json=marketId,proto3" json:"market_id,omitempty"`
+	OddsFormat string `protobuf:"bytes,5,opt,name=odds_format,json=oddsFormat,proto3" json:"odds_format,omitempty"`
+}
+
+func (x *ListWagersRequest) Reset() {
+	*x = ListWagersRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_wager_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListWagersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListWagersRequest) ProtoMessage() {}
+
+func (x *ListWagersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_wager_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListWagersRequest.ProtoReflect.Descriptor instead.
+func (*ListWagersRequest) Descriptor() ([]byte, []int) {
+	return file_wager_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListWagersRequest) GetAfterId() int32 {
+	if x != nil {
+		return x.AfterId
+	}
+	return 0
+}
+
+func (x *ListWagersRequest) GetLimit() uint32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListWagersRequest) GetEventId() int32 {
+	if x != nil {
+		return x.EventId
+	}
+	return 0
+}
+
+func (x *ListWagersRequest) GetMarketId() int32 {
+	if x != nil {
+		return x.MarketId
+	}
+	return 0
+}
+
+func (x *ListWagersRequest) GetOddsFormat() string {
+	if x != nil {
+		return x.OddsFormat
+	}
+	return ""
+}
+
+type Wager struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id                  int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	MarketId            int32                  `protobuf:"varint,2,opt,name=market_id,json=marketId,proto3" json:"market_id,omitempty"`
+	TotalWagerValue     float32                `protobuf:"fixed32,3,opt,name=total_wager_value,json=totalWagerValue,proto3" json:"total_wager_value,omitempty"`
+	Odds                string                 `protobuf:"bytes,4,opt,name=odds,proto3" json:"odds,omitempty"`
+	OddsFormat          string                 `protobuf:"bytes,5,opt,name=odds_format,json=oddsFormat,proto3" json:"odds_format,omitempty"`
+	SellingPercentage   int32                  `protobuf:"varint,6,opt,name=selling_percentage,json=sellingPercentage,proto3" json:"selling_percentage,omitempty"`
+	SellingPrice        float32                `protobuf:"fixed32,7,opt,name=selling_price,json=sellingPrice,proto3" json:"selling_price,omitempty"`
+	CurrentSellingPrice float32                `protobuf:"fixed32,8,opt,name=current_selling_price,json=currentSellingPrice,proto3" json:"current_selling_price,omitempty"`
+	PercentageSold      float32                `protobuf:"fixed32,9,opt,name=percentage_sold,json=percentageSold,proto3" json:"percentage_sold,omitempty"`
+	AmountSold          float32                `protobuf:"fixed32,10,opt,name=amount_sold,json=amountSold,proto3" json:"amount_sold,omitempty"`
+	PlacedAt            *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=placed_at,json=placedAt,proto3" json:"placed_at,omitempty"`
+	SellerId            string                 `protobuf:"bytes,12,opt,name=seller_id,json=sellerId,proto3" json:"seller_id,omitempty"`
+}
+
+func (x *Wager) Reset() {
+	*x = Wager{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_wager_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Wager) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Wager) ProtoMessage() {}
+
+func (x *Wager) ProtoReflect() protoreflect.Message {
+	mi := &file_wager_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Wager.ProtoReflect.Descriptor instead.
+func (*Wager) Descriptor() ([]byte, []int) {
+	return file_wager_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Wager) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Wager) GetMarketId() int32 {
+	if x != nil {
+		return x.MarketId
+	}
+	return 0
+}
+
+func (x *Wager) GetTotalWagerValue() float32 {
+	if x != nil {
+		return x.TotalWagerValue
+	}
+	return 0
+}
+
+func (x *Wager) GetOdds() string {
+	if x != nil {
+		return x.Odds
+	}
+	return ""
+}
+
+func (x *Wager) GetOddsFormat() string {
+	if x != nil {
+		return x.OddsFormat
+	}
+	return ""
+}
+
+func (x *Wager) GetSellingPercentage() int32 {
+	if x != nil {
+		return x.SellingPercentage
+	}
+	return 0
+}
+
+func (x *Wager) GetSellingPrice() float32 {
+	if x != nil {
+		return x.SellingPrice
+	}
+	return 0
+}
+
+func (x *Wager) GetCurrentSellingPrice() float32 {
+	if x != nil {
+		return x.CurrentSellingPrice
+	}
+	return 0
+}
+
+func (x *Wager) GetPercentageSold() float32 {
+	if x != nil {
+		return x.PercentageSold
+	}
+	return 0
+}
+
+func (x *Wager) GetAmountSold() float32 {
+	if x != nil {
+		return x.AmountSold
+	}
+	return 0
+}
+
+func (x *Wager) GetPlacedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.PlacedAt
+	}
+	return nil
+}
+
+func (x *Wager) GetSellerId() string {
+	if x != nil {
+		return x.SellerId
+	}
+	return ""
+}
+
+type Purchase struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	PurchaseId  int32                  `protobuf:"varint,1,opt,name=purchase_id,json=purchaseId,proto3" json:"purchase_id,omitempty"`
+	WagerId     int32                  `protobuf:"varint,2,opt,name=wager_id,json=wagerId,proto3" json:"wager_id,omitempty"`
+	BuyingPrice float32                `protobuf:"fixed32,3,opt,name=buying_price,json=buyingPrice,proto3" json:"buying_price,omitempty"`
+	BoughtAt    *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=bought_at,json=boughtAt,proto3" json:"bought_at,omitempty"`
+}
+
+func (x *Purchase) Reset() {
+	*x = Purchase{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_wager_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Purchase) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Purchase) ProtoMessage() {}
+
+func (x *Purchase) ProtoReflect() protoreflect.Message {
+	mi := &file_wager_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Purchase.ProtoReflect.Descriptor instead.
+func (*Purchase) Descriptor() ([]byte, []int) {
+	return file_wager_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Purchase) GetPurchaseId() int32 {
+	if x != nil {
+		return x.PurchaseId
+	}
+	return 0
+}
+
+func (x *Purchase) GetWagerId() int32 {
+	if x != nil {
+		return x.WagerId
+	}
+	return 0
+}
+
+func (x *Purchase) GetBuyingPrice() float32 {
+	if x != nil {
+		return x.BuyingPrice
+	}
+	return 0
+}
+
+func (x *Purchase) GetBoughtAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.BoughtAt
+	}
+	return nil
+}
+
+var File_wager_proto protoreflect.FileDescriptor
+
+var file_wager_proto_rawDesc = []byte{
+	0x0a, 0x0b, 0x77, 0x61, 0x67, 0x65, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x08, 0x77,
+	0x61, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x1a, 0x1f, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61,
+	0x6d, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x82, 0x02, 0x0a, 0x11, 0x50, 0x6c, 0x61,
+	0x63, 0x65, 0x57, 0x61, 0x67, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1b,
+	0x0a, 0x09, 0x6d, 0x61, 0x72, 0x6b, 0x65, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x08, 0x6d, 0x61, 0x72, 0x6b, 0x65, 0x74, 0x49, 0x64, 0x12, 0x2a, 0x0a, 0x11, 0x74,
+	0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x77, 0x61, 0x67, 0x65, 0x72, 0x5f, 0x76, 0x61, 0x6c, 0x75, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x02, 0x52, 0x0f, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x57, 0x61, 0x67,
+	0x65, 0x72, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6f, 0x64, 0x64, 0x73, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6f, 0x64, 0x64, 0x73, 0x12, 0x1f, 0x0a, 0x0b, 0x6f,
+	0x64, 0x64, 0x73, 0x5f, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0a, 0x6f, 0x64, 0x64, 0x73, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x12, 0x2d, 0x0a, 0x12,
+	0x73, 0x65, 0x6c, 0x6c, 0x69, 0x6e, 0x67, 0x5f, 0x70, 0x65, 0x72, 0x63, 0x65, 0x6e, 0x74, 0x61,
+	0x67, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x05, 0x52, 0x11, 0x73, 0x65, 0x6c, 0x6c, 0x69, 0x6e,
+	0x67, 0x50, 0x65, 0x72, 0x63, 0x65, 0x6e, 0x74, 0x61, 0x67, 0x65, 0x12, 0x23, 0x0a, 0x0d, 0x73,
+	0x65, 0x6c, 0x6c, 0x69, 0x6e, 0x67, 0x5f, 0x70, 0x72, 0x69, 0x63, 0x65, 0x18, 0x06, 0x20, 0x01,
+	0x28, 0x02, 0x52, 0x0c, 0x73, 0x65, 0x6c, 0x6c, 0x69, 0x6e, 0x67, 0x50, 0x72, 0x69, 0x63, 0x65,
+	0x12, 0x1b, 0x0a, 0x09, 0x73, 0x65, 0x6c, 0x6c, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x07, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x08, 0x73, 0x65, 0x6c, 0x6c, 0x65, 0x72, 0x49, 0x64, 0x22, 0x6a, 0x0a,
+	0x0f, 0x42, 0x75, 0x79, 0x57, 0x61, 0x67, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x19, 0x0a, 0x08, 0x77, 0x61, 0x67, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x07, 0x77, 0x61, 0x67, 0x65, 0x72, 0x49, 0x64, 0x12, 0x21, 0x0a, 0x0c, 0x62,
+	0x75, 0x79, 0x69, 0x6e, 0x67, 0x5f, 0x70, 0x72, 0x69, 0x63, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x02, 0x52, 0x0b, 0x62, 0x75, 0x79, 0x69, 0x6e, 0x67, 0x50, 0x72, 0x69, 0x63, 0x65, 0x12, 0x19,
+	0x0a, 0x08, 0x62, 0x75, 0x79, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x07, 0x62, 0x75, 0x79, 0x65, 0x72, 0x49, 0x64, 0x22, 0x4d, 0x0a, 0x0f, 0x47, 0x65, 0x74,
+	0x57, 0x61, 0x67, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x19, 0x0a, 0x08,
+	0x77, 0x61, 0x67, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x07,
+	0x77, 0x61, 0x67, 0x65, 0x72, 0x49, 0x64, 0x12, 0x1f, 0x0a, 0x0b, 0x6f, 0x64, 0x64, 0x73, 0x5f,
+	0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x6f, 0x64,
+	0x64, 0x73, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x22, 0x9d, 0x01, 0x0a, 0x11, 0x4c, 0x69, 0x73,
+	0x74, 0x57, 0x61, 0x67, 0x65, 0x72, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x19,
+	0x0a, 0x08, 0x61, 0x66, 0x74, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05,
+	0x52, 0x07, 0x61, 0x66, 0x74, 0x65, 0x72, 0x49, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x6c, 0x69, 0x6d,
+	0x69, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x12,
+	0x19, 0x0a, 0x08, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x07, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x49, 0x64, 0x12, 0x1b, 0x0a, 0x09, 0x6d, 0x61,
+	0x72, 0x6b, 0x65, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x6d,
+	0x61, 0x72, 0x6b, 0x65, 0x74, 0x49, 0x64, 0x12, 0x1f, 0x0a, 0x0b, 0x6f, 0x64, 0x64, 0x73, 0x5f,
+	0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x6f, 0x64,
+	0x64, 0x73, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x22, 0xbd, 0x03, 0x0a, 0x05, 0x57, 0x61, 0x67,
+	0x65, 0x72, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x02,
+	0x69, 0x64, 0x12, 0x1b, 0x0a, 0x09, 0x6d, 0x61, 0x72, 0x6b, 0x65, 0x74, 0x5f, 0x69, 0x64, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x6d, 0x61, 0x72, 0x6b, 0x65, 0x74, 0x49, 0x64, 0x12,
+	0x2a, 0x0a, 0x11, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x77, 0x61, 0x67, 0x65, 0x72, 0x5f, 0x76,
+	0x61, 0x6c, 0x75, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x02, 0x52, 0x0f, 0x74, 0x6f, 0x74, 0x61,
+	0x6c, 0x57, 0x61, 0x67, 0x65, 0x72, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6f,
+	0x64, 0x64, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6f, 0x64, 0x64, 0x73, 0x12,
+	0x1f, 0x0a, 0x0b, 0x6f, 0x64, 0x64, 0x73, 0x5f, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x6f, 0x64, 0x64, 0x73, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74,
+	0x12, 0x2d, 0x0a, 0x12, 0x73, 0x65, 0x6c, 0x6c, 0x69, 0x6e, 0x67, 0x5f, 0x70, 0x65, 0x72, 0x63,
+	0x65, 0x6e, 0x74, 0x61, 0x67, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x05, 0x52, 0x11, 0x73, 0x65,
+	0x6c, 0x6c, 0x69, 0x6e, 0x67, 0x50, 0x65, 0x72, 0x63, 0x65, 0x6e, 0x74, 0x61, 0x67, 0x65, 0x12,
+	0x23, 0x0a, 0x0d, 0x73, 0x65, 0x6c, 0x6c, 0x69, 0x6e, 0x67, 0x5f, 0x70, 0x72, 0x69, 0x63, 0x65,
+	0x18, 0x07, 0x20, 0x01, 0x28, 0x02, 0x52, 0x0c, 0x73, 0x65, 0x6c, 0x6c, 0x69, 0x6e, 0x67, 0x50,
+	0x72, 0x69, 0x63, 0x65, 0x12, 0x32, 0x0a, 0x15, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x74, 0x5f,
+	0x73, 0x65, 0x6c, 0x6c, 0x69, 0x6e, 0x67, 0x5f, 0x70, 0x72, 0x69, 0x63, 0x65, 0x18, 0x08, 0x20,
+	0x01, 0x28, 0x02, 0x52, 0x13, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x74, 0x53, 0x65, 0x6c, 0x6c,
+	0x69, 0x6e, 0x67, 0x50, 0x72, 0x69, 0x63, 0x65, 0x12, 0x27, 0x0a, 0x0f, 0x70, 0x65, 0x72, 0x63,
+	0x65, 0x6e, 0x74, 0x61, 0x67, 0x65, 0x5f, 0x73, 0x6f, 0x6c, 0x64, 0x18, 0x09, 0x20, 0x01, 0x28,
+	0x02, 0x52, 0x0e, 0x70, 0x65, 0x72, 0x63, 0x65, 0x6e, 0x74, 0x61, 0x67, 0x65, 0x53, 0x6f, 0x6c,
+	0x64, 0x12, 0x1f, 0x0a, 0x0b, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x5f, 0x73, 0x6f, 0x6c, 0x64,
+	0x18, 0x0a, 0x20, 0x01, 0x28, 0x02, 0x52, 0x0a, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x53, 0x6f,
+	0x6c, 0x64, 0x12, 0x37, 0x0a, 0x09, 0x70, 0x6c, 0x61, 0x63, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18,
+	0x0b, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d,
+	0x70, 0x52, 0x08, 0x70, 0x6c, 0x61, 0x63, 0x65, 0x64, 0x41, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x73,
+	0x65, 0x6c, 0x6c, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08,
+	0x73, 0x65, 0x6c, 0x6c, 0x65, 0x72, 0x49, 0x64, 0x22, 0xa2, 0x01, 0x0a, 0x08, 0x50, 0x75, 0x72,
+	0x63, 0x68, 0x61, 0x73, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x70, 0x75, 0x72, 0x63, 0x68, 0x61, 0x73,
+	0x65, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0a, 0x70, 0x75, 0x72, 0x63,
+	0x68, 0x61, 0x73, 0x65, 0x49, 0x64, 0x12, 0x19, 0x0a, 0x08, 0x77, 0x61, 0x67, 0x65, 0x72, 0x5f,
+	0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x07, 0x77, 0x61, 0x67, 0x65, 0x72, 0x49,
+	0x64, 0x12, 0x21, 0x0a, 0x0c, 0x62, 0x75, 0x79, 0x69, 0x6e, 0x67, 0x5f, 0x70, 0x72, 0x69, 0x63,
+	0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x02, 0x52, 0x0b, 0x62, 0x75, 0x79, 0x69, 0x6e, 0x67, 0x50,
+	0x72, 0x69, 0x63, 0x65, 0x12, 0x37, 0x0a, 0x09, 0x62, 0x6f, 0x75, 0x67, 0x68, 0x74, 0x5f, 0x61,
+	0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74,
+	0x61, 0x6d, 0x70, 0x52, 0x08, 0x62, 0x6f, 0x75, 0x67, 0x68, 0x74, 0x41, 0x74, 0x32, 0xfb, 0x01,
+	0x0a, 0x0c, 0x57, 0x61, 0x67, 0x65, 0x72, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x3a,
+	0x0a, 0x0a, 0x50, 0x6c, 0x61, 0x63, 0x65, 0x57, 0x61, 0x67, 0x65, 0x72, 0x12, 0x1b, 0x2e, 0x77,
+	0x61, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x6c, 0x61, 0x63, 0x65, 0x57, 0x61, 0x67,
+	0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0f, 0x2e, 0x77, 0x61, 0x67, 0x65,
+	0x72, 0x2e, 0x76, 0x31, 0x2e, 0x57, 0x61, 0x67, 0x65, 0x72, 0x12, 0x39, 0x0a, 0x08, 0x42, 0x75,
+	0x79, 0x57, 0x61, 0x67, 0x65, 0x72, 0x12, 0x19, 0x2e, 0x77, 0x61, 0x67, 0x65, 0x72, 0x2e, 0x76,
+	0x31, 0x2e, 0x42, 0x75, 0x79, 0x57, 0x61, 0x67, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x12, 0x2e, 0x77, 0x61, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x75, 0x72,
+	0x63, 0x68, 0x61, 0x73, 0x65, 0x12, 0x36, 0x0a, 0x08, 0x47, 0x65, 0x74, 0x57, 0x61, 0x67, 0x65,
+	0x72, 0x12, 0x19, 0x2e, 0x77, 0x61, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74,
+	0x57, 0x61, 0x67, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0f, 0x2e, 0x77,
+	0x61, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x57, 0x61, 0x67, 0x65, 0x72, 0x12, 0x3c, 0x0a,
+	0x0a, 0x4c, 0x69, 0x73, 0x74, 0x57, 0x61, 0x67, 0x65, 0x72, 0x73, 0x12, 0x1b, 0x2e, 0x77, 0x61,
+	0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x57, 0x61, 0x67, 0x65, 0x72,
+	0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0f, 0x2e, 0x77, 0x61, 0x67, 0x65, 0x72,
+	0x2e, 0x76, 0x31, 0x2e, 0x57, 0x61, 0x67, 0x65, 0x72, 0x30, 0x01, 0x42, 0x25, 0x5a, 0x23, 0x67,
+	0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x77, 0x61, 0x67, 0x65, 0x72, 0x2d,
+	0x61, 0x70, 0x69, 0x2f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x2f, 0x70, 0x62, 0x3b,
+	0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_wager_proto_rawDescOnce sync.Once
+	file_wager_proto_rawDescData = file_wager_proto_rawDesc
+)
+
+func file_wager_proto_rawDescGZIP() []byte {
+	file_wager_proto_rawDescOnce.Do(func() {
+		file_wager_proto_rawDescData = protoimpl.X.CompressGZIP(file_wager_proto_rawDescData)
+	})
+	return file_wager_proto_rawDescData
+}
+
+var file_wager_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_wager_proto_goTypes = []interface{}{
+	(*PlaceWagerRequest)(nil),     // 0: wager.v1.PlaceWagerRequest
+	(*BuyWagerRequest)(nil),       // 1: wager.v1.BuyWagerRequest
+	(*GetWagerRequest)(nil),       // 2: wager.v1.GetWagerRequest
+	(*ListWagersRequest)(nil),     // 3: wager.v1.ListWagersRequest
+	(*Wager)(nil),                 // 4: wager.v1.Wager
+	(*Purchase)(nil),              // 5: wager.v1.Purchase
+	(*timestamppb.Timestamp)(nil), // 6: google.protobuf.Timestamp
+}
+var file_wager_proto_depIdxs = []int32{
+	6, // 0: wager.v1.Wager.placed_at:type_name -> google.protobuf.Timestamp
+	6, // 1: wager.v1.Purchase.bought_at:type_name -> google.protobuf.Timestamp
+	0, // 2: wager.v1.WagerService.PlaceWager:input_type -> wager.v1.PlaceWagerRequest
+	1, // 3: wager.v1.WagerService.BuyWager:input_type -> wager.v1.BuyWagerRequest
+	2, // 4: wager.v1.WagerService.GetWager:input_type -> wager.v1.GetWagerRequest
+	3, // 5: wager.v1.WagerService.ListWagers:input_type -> wager.v1.ListWagersRequest
+	4, // 6: wager.v1.WagerService.PlaceWager:output_type -> wager.v1.Wager
+	5, // 7: wager.v1.WagerService.BuyWager:output_type -> wager.v1.Purchase
+	4, // 8: wager.v1.WagerService.GetWager:output_type -> wager.v1.Wager
+	4, // 9: wager.v1.WagerService.ListWagers:output_type -> wager.v1.Wager
+	6, // [6:10] is the sub-list for method output_type
+	2, // [2:6] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_wager_proto_init() }
+func file_wager_proto_init() {
+	if File_wager_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_wager_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PlaceWagerRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_wager_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BuyWagerRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_wager_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetWagerRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_wager_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListWagersRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_wager_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Wager); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_wager_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Purchase); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_wager_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   6,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_wager_proto_goTypes,
+		DependencyIndexes: file_wager_proto_depIdxs,
+		MessageInfos:      file_wager_proto_msgTypes,
+	}.Build()
+	File_wager_proto = out.File
+	file_wager_proto_rawDesc = nil
+	file_wager_proto_goTypes = nil
+	file_wager_proto_depIdxs = nil
+}
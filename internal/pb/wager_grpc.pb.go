@@ -0,0 +1,248 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: wager.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	WagerService_PlaceWager_FullMethodName = "/wager.v1.WagerService/PlaceWager"
+	WagerService_BuyWager_FullMethodName   = "/wager.v1.WagerService/BuyWager"
+	WagerService_GetWager_FullMethodName   = "/wager.v1.WagerService/GetWager"
+	WagerService_ListWagers_FullMethodName = "/wager.v1.WagerService/ListWagers"
+)
+
+// WagerServiceClient is the client API for WagerService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type WagerServiceClient interface {
+	PlaceWager(ctx context.Context, in *PlaceWagerRequest, opts ...grpc.CallOption) (*Wager, error)
+	BuyWager(ctx context.Context, in *BuyWagerRequest, opts ...grpc.CallOption) (*Purchase, error)
+	GetWager(ctx context.Context, in *GetWagerRequest, opts ...grpc.CallOption) (*Wager, error)
+	ListWagers(ctx context.Context, in *ListWagersRequest, opts ...grpc.CallOption) (WagerService_ListWagersClient, error)
+}
+
+type wagerServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewWagerServiceClient(cc grpc.ClientConnInterface) WagerServiceClient {
+	return &wagerServiceClient{cc}
+}
+
+func (c *wagerServiceClient) PlaceWager(ctx context.Context, in *PlaceWagerRequest, opts ...grpc.CallOption) (*Wager, error) {
+	out := new(Wager)
+	err := c.cc.Invoke(ctx, WagerService_PlaceWager_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *wagerServiceClient) BuyWager(ctx context.Context, in *BuyWagerRequest, opts ...grpc.CallOption) (*Purchase, error) {
+	out := new(Purchase)
+	err := c.cc.Invoke(ctx, WagerService_BuyWager_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *wagerServiceClient) GetWager(ctx context.Context, in *GetWagerRequest, opts ...grpc.CallOption) (*Wager, error) {
+	out := new(Wager)
+	err := c.cc.Invoke(ctx, WagerService_GetWager_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *wagerServiceClient) ListWagers(ctx context.Context, in *ListWagersRequest, opts ...grpc.CallOption) (WagerService_ListWagersClient, error) {
+	stream, err := c.cc.NewStream(ctx, &WagerService_ServiceDesc.Streams[0], WagerService_ListWagers_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &wagerServiceListWagersClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type WagerService_ListWagersClient interface {
+	Recv() (*Wager, error)
+	grpc.ClientStream
+}
+
+type wagerServiceListWagersClient struct {
+	grpc.ClientStream
+}
+
+func (x *wagerServiceListWagersClient) Recv() (*Wager, error) {
+	m := new(Wager)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// WagerServiceServer is the server API for WagerService service.
+// All implementations must embed UnimplementedWagerServiceServer
+// for forward compatibility
+type WagerServiceServer interface {
+	PlaceWager(context.Context, *PlaceWagerRequest) (*Wager, error)
+	BuyWager(context.Context, *BuyWagerRequest) (*Purchase, error)
+	GetWager(context.Context, *GetWagerRequest) (*Wager, error)
+	ListWagers(*ListWagersRequest, WagerService_ListWagersServer) error
+	mustEmbedUnimplementedWagerServiceServer()
+}
+
+// UnimplementedWagerServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedWagerServiceServer struct {
+}
+
+func (UnimplementedWagerServiceServer) PlaceWager(context.Context, *PlaceWagerRequest) (*Wager, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PlaceWager not implemented")
+}
+func (UnimplementedWagerServiceServer) BuyWager(context.Context, *BuyWagerRequest) (*Purchase, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BuyWager not implemented")
+}
+func (UnimplementedWagerServiceServer) GetWager(context.Context, *GetWagerRequest) (*Wager, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetWager not implemented")
+}
+func (UnimplementedWagerServiceServer) ListWagers(*ListWagersRequest, WagerService_ListWagersServer) error {
+	return status.Errorf(codes.Unimplemented, "method ListWagers not implemented")
+}
+func (UnimplementedWagerServiceServer) mustEmbedUnimplementedWagerServiceServer() {}
+
+// UnsafeWagerServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to WagerServiceServer will
+// result in compilation errors.
+type UnsafeWagerServiceServer interface {
+	mustEmbedUnimplementedWagerServiceServer()
+}
+
+func RegisterWagerServiceServer(s grpc.ServiceRegistrar, srv WagerServiceServer) {
+	s.RegisterService(&WagerService_ServiceDesc, srv)
+}
+
+func _WagerService_PlaceWager_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PlaceWagerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WagerServiceServer).PlaceWager(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WagerService_PlaceWager_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WagerServiceServer).PlaceWager(ctx, req.(*PlaceWagerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WagerService_BuyWager_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BuyWagerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WagerServiceServer).BuyWager(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WagerService_BuyWager_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WagerServiceServer).BuyWager(ctx, req.(*BuyWagerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WagerService_GetWager_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetWagerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WagerServiceServer).GetWager(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WagerService_GetWager_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WagerServiceServer).GetWager(ctx, req.(*GetWagerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WagerService_ListWagers_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListWagersRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(WagerServiceServer).ListWagers(m, &wagerServiceListWagersServer{stream})
+}
+
+type WagerService_ListWagersServer interface {
+	Send(*Wager) error
+	grpc.ServerStream
+}
+
+type wagerServiceListWagersServer struct {
+	grpc.ServerStream
+}
+
+func (x *wagerServiceListWagersServer) Send(m *Wager) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// WagerService_ServiceDesc is the grpc.ServiceDesc for WagerService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var WagerService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "wager.v1.WagerService",
+	HandlerType: (*WagerServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "PlaceWager",
+			Handler:    _WagerService_PlaceWager_Handler,
+		},
+		{
+			MethodName: "BuyWager",
+			Handler:    _WagerService_BuyWager_Handler,
+		},
+		{
+			MethodName: "GetWager",
+			Handler:    _WagerService_GetWager_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ListWagers",
+			Handler:       _WagerService_ListWagers_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "wager.proto",
+}
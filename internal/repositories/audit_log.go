@@ -0,0 +1,63 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/libs/database"
+
+	"github.com/jackc/pgtype"
+)
+
+type AuditLogRepo struct{}
+
+func (r *AuditLogRepo) Create(ctx context.Context, db database.Ext, auditLog *entities.AuditLog) error {
+	command := `INSERT INTO %s (%s) VALUES (%s) RETURNING audit_id`
+	fieldNames := database.GetFieldNamesExcepts(auditLog, []string{"audit_id"})
+	placeHolders := database.GeneratePlaceholders(len(fieldNames))
+	ultimateCmd := fmt.Sprintf(command, auditLog.TableName(), strings.Join(fieldNames, ","), placeHolders)
+	args := database.GetScanFields(auditLog, fieldNames)
+	if err := db.QueryRow(ctx, ultimateCmd, args...).Scan(&auditLog.AuditID); err != nil {
+		return err
+	}
+	return nil
+}
+
+// AuditLogFilter narrows down the entries returned by List, a null field means no filter.
+type AuditLogFilter struct {
+	Actor      pgtype.Text
+	Action     pgtype.Text
+	WagerID    pgtype.Int4
+	PurchaseID pgtype.Int4
+	AccountID  pgtype.Text
+	EntityID   pgtype.Text
+	From       pgtype.Timestamptz
+	To         pgtype.Timestamptz
+}
+
+// List returns the entries matching filter, latest first, from the entry before
+// beforeID when it is set.
+func (r *AuditLogRepo) List(ctx context.Context, db database.Ext, filter AuditLogFilter, beforeID pgtype.Int8, limit uint32) ([]*entities.AuditLog, error) {
+	e := &entities.AuditLog{}
+	fieldName, _ := e.FieldMap()
+	query := fmt.Sprintf(`SELECT %s FROM %s
+		WHERE ($1::BIGINT IS NULL OR audit_id<$1)
+		  AND ($3::TEXT IS NULL OR actor=$3)
+		  AND ($4::TEXT IS NULL OR action=$4)
+		  AND ($5::INT IS NULL OR wager_id=$5)
+		  AND ($6::INT IS NULL OR purchase_id=$6)
+		  AND ($7::TIMESTAMPTZ IS NULL OR created_at>=$7)
+		  AND ($8::TIMESTAMPTZ IS NULL OR created_at<$8)
+		  AND ($9::TEXT IS NULL OR account_id=$9)
+		  AND ($10::TEXT IS NULL OR entity_id=$10)
+		ORDER BY audit_id DESC LIMIT $2 `, strings.Join(fieldName, ", "), e.TableName())
+	auditLogs := entities.AuditLogs{}
+	if err := database.Select(ctx, db, query, beforeID, limit,
+		filter.Actor, filter.Action, filter.WagerID, filter.PurchaseID, filter.From, filter.To, filter.AccountID, filter.EntityID).ScanAll(&auditLogs); err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	return auditLogs, nil
+}
@@ -0,0 +1,128 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/libs/database"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgtype"
+)
+
+type BuyOrderRepo struct{}
+
+func (r *BuyOrderRepo) Create(ctx context.Context, db database.Ext, buyOrder *entities.BuyOrder) error {
+	command := `INSERT INTO %s (%s) VALUES (%s) RETURNING buy_order_id`
+	fieldNames := database.GetFieldNamesExcepts(buyOrder, []string{"buy_order_id"})
+	placeHolders := database.GeneratePlaceholders(len(fieldNames))
+	ultimateCmd := fmt.Sprintf(command, buyOrder.TableName(), strings.Join(fieldNames, ","), placeHolders)
+	args := database.GetScanFields(buyOrder, fieldNames)
+	if err := db.QueryRow(ctx, ultimateCmd, args...).Scan(&buyOrder.BuyOrderID); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *BuyOrderRepo) Get(ctx context.Context, db database.Ext, buyOrderID pgtype.Int4, queryEnhancers ...QueryEnhancer) (*entities.BuyOrder, error) {
+	getBuyOrderCmd := `SELECT %s FROM %s WHERE buy_order_id = $1`
+	buyOrderEnt := &entities.BuyOrder{}
+	fields, values := buyOrderEnt.FieldMap()
+	for _, e := range queryEnhancers {
+		e(&getBuyOrderCmd)
+	}
+
+	err := db.QueryRow(ctx, fmt.Sprintf(getBuyOrderCmd, strings.Join(fields, ", "), buyOrderEnt.TableName()), &buyOrderID).Scan(values...)
+	if err != nil {
+		return nil, err
+	}
+
+	return buyOrderEnt, nil
+}
+
+// ListMatching locks and returns the open orders of a wager which buy at price and are
+// not expired at now, the highest limit first then the oldest.
+func (r *BuyOrderRepo) ListMatching(ctx context.Context, db database.Ext, wagerID pgtype.Int4, price pgtype.Float4, now pgtype.Timestamptz) ([]*entities.BuyOrder, error) {
+	b := &entities.BuyOrder{}
+	fieldName, _ := b.FieldMap()
+	query := fmt.Sprintf(`SELECT %s FROM %s
+		WHERE wager_id = $1 AND status = '%s' AND limit_price >= $2 AND expires_at > $3
+		ORDER BY limit_price DESC, buy_order_id
+		FOR UPDATE`, strings.Join(fieldName, ", "), b.TableName(), entities.BuyOrderStatusOpen)
+	buyOrders := entities.BuyOrders{}
+	if err := database.Select(ctx, db, query, wagerID, price, now).ScanAll(&buyOrders); err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	return buyOrders, nil
+}
+
+// Fill records the purchase an open order made.
+func (r *BuyOrderRepo) Fill(ctx context.Context, db database.Ext, buyOrderID, purchaseID pgtype.Int4, filledAt pgtype.Timestamptz) (pgconn.CommandTag, error) {
+	b := &entities.BuyOrder{}
+	query := fmt.Sprintf(`UPDATE %s SET status = '%s', purchase_id = $2, filled_at = $3, updated_at = $3
+		WHERE buy_order_id = $1 AND status = '%s'`, b.TableName(), entities.BuyOrderStatusFilled, entities.BuyOrderStatusOpen)
+	cmdTag, err := db.Exec(ctx, query, buyOrderID, purchaseID, filledAt)
+	if err != nil {
+		return cmdTag, fmt.Errorf("db.Exec: %w", err)
+	}
+
+	return cmdTag, nil
+}
+
+// Cancel cancels an order which is still open, no row is affected otherwise.
+func (r *BuyOrderRepo) Cancel(ctx context.Context, db database.Ext, buyOrderID pgtype.Int4, now pgtype.Timestamptz) (pgconn.CommandTag, error) {
+	b := &entities.BuyOrder{}
+	query := fmt.Sprintf(`UPDATE %s SET status = '%s', updated_at = $2
+		WHERE buy_order_id = $1 AND status = '%s'`, b.TableName(), entities.BuyOrderStatusCancelled, entities.BuyOrderStatusOpen)
+	cmdTag, err := db.Exec(ctx, query, buyOrderID, now)
+	if err != nil {
+		return cmdTag, fmt.Errorf("db.Exec: %w", err)
+	}
+
+	return cmdTag, nil
+}
+
+// ExpireDue marks the open orders expired at now.
+func (r *BuyOrderRepo) ExpireDue(ctx context.Context, db database.Ext, now pgtype.Timestamptz) (pgconn.CommandTag, error) {
+	b := &entities.BuyOrder{}
+	query := fmt.Sprintf(`UPDATE %s SET status = '%s', updated_at = $1
+		WHERE status = '%s' AND expires_at <= $1`, b.TableName(), entities.BuyOrderStatusExpired, entities.BuyOrderStatusOpen)
+	cmdTag, err := db.Exec(ctx, query, now)
+	if err != nil {
+		return cmdTag, fmt.Errorf("db.Exec: %w", err)
+	}
+
+	return cmdTag, nil
+}
+
+// ListDecayingWagerIDs returns the ids of the wagers with a decaying price which have
+// open orders not expired at now.
+func (r *BuyOrderRepo) ListDecayingWagerIDs(ctx context.Context, db database.Ext, now pgtype.Timestamptz) ([]int32, error) {
+	b := &entities.BuyOrder{}
+	w := &entities.Wager{}
+	query := fmt.Sprintf(`SELECT DISTINCT b.wager_id FROM %s b
+		JOIN %s w ON w.wager_id = b.wager_id
+		WHERE b.status = '%s' AND b.expires_at > $1 AND w.decay_type IS NOT NULL
+		ORDER BY b.wager_id`, b.TableName(), w.TableName(), entities.BuyOrderStatusOpen)
+	rows, err := db.Query(ctx, query, now)
+	if err != nil {
+		return nil, fmt.Errorf("db.Query: %w", err)
+	}
+	defer rows.Close()
+	wagerIDs := []int32{}
+	for rows.Next() {
+		var wagerID int32
+		if err := rows.Scan(&wagerID); err != nil {
+			return nil, fmt.Errorf("rows.Scan: %w", err)
+		}
+		wagerIDs = append(wagerIDs, wagerID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return wagerIDs, nil
+}
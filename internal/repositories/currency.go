@@ -0,0 +1,87 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/libs/database"
+
+	"github.com/jackc/pgtype"
+)
+
+type AccountRepo struct{}
+
+// Upsert creates the account or sets its currency, created_at is kept for an account
+// which exists already.
+func (r *AccountRepo) Upsert(ctx context.Context, db database.Ext, account *entities.Account) error {
+	fieldNames, _ := account.FieldMap()
+	placeHolders := database.GeneratePlaceholders(len(fieldNames))
+	command := fmt.Sprintf(`INSERT INTO %s (%s) VALUES (%s)
+		ON CONFLICT (account_id) DO UPDATE SET currency = EXCLUDED.currency, updated_at = EXCLUDED.updated_at
+		RETURNING created_at`, account.TableName(), strings.Join(fieldNames, ","), placeHolders)
+	args := database.GetScanFields(account, fieldNames)
+	if err := db.QueryRow(ctx, command, args...).Scan(&account.CreatedAt); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *AccountRepo) Get(ctx context.Context, db database.Ext, accountID pgtype.Text) (*entities.Account, error) {
+	account := &entities.Account{}
+	fields, values := account.FieldMap()
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE account_id = $1`, strings.Join(fields, ", "), account.TableName())
+	if err := db.QueryRow(ctx, query, &accountID).Scan(values...); err != nil {
+		return nil, err
+	}
+
+	return account, nil
+}
+
+type ExchangeRateRepo struct{}
+
+func (r *ExchangeRateRepo) Create(ctx context.Context, db database.Ext, rate *entities.ExchangeRate) error {
+	command := `INSERT INTO %s (%s) VALUES (%s) RETURNING rate_id`
+	fieldNames := database.GetFieldNamesExcepts(rate, []string{"rate_id"})
+	placeHolders := database.GeneratePlaceholders(len(fieldNames))
+	ultimateCmd := fmt.Sprintf(command, rate.TableName(), strings.Join(fieldNames, ","), placeHolders)
+	args := database.GetScanFields(rate, fieldNames)
+	if err := db.QueryRow(ctx, ultimateCmd, args...).Scan(&rate.RateID); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetEffective returns the rate of the pair in effect at at, the latest one which took
+// effect before it.
+func (r *ExchangeRateRepo) GetEffective(ctx context.Context, db database.Ext, baseCurrency, quoteCurrency pgtype.Text, at pgtype.Timestamptz) (*entities.ExchangeRate, error) {
+	rate := &entities.ExchangeRate{}
+	fields, values := rate.FieldMap()
+	query := fmt.Sprintf(`SELECT %s FROM %s
+		WHERE base_currency = $1 AND quote_currency = $2 AND effective_from <= $3
+		ORDER BY effective_from DESC, rate_id DESC
+		LIMIT 1`, strings.Join(fields, ", "), rate.TableName())
+	if err := db.QueryRow(ctx, query, &baseCurrency, &quoteCurrency, &at).Scan(values...); err != nil {
+		return nil, err
+	}
+
+	return rate, nil
+}
+
+// List returns the rates of the currencies, latest effective first, a null currency
+// matches every one.
+func (r *ExchangeRateRepo) List(ctx context.Context, db database.Ext, baseCurrency, quoteCurrency pgtype.Text) ([]*entities.ExchangeRate, error) {
+	e := &entities.ExchangeRate{}
+	fieldName, _ := e.FieldMap()
+	query := fmt.Sprintf(`SELECT %s FROM %s
+		WHERE ($1::TEXT IS NULL OR base_currency = $1)
+		  AND ($2::TEXT IS NULL OR quote_currency = $2)
+		ORDER BY effective_from DESC, rate_id DESC`, strings.Join(fieldName, ", "), e.TableName())
+	rates := entities.ExchangeRates{}
+	if err := database.Select(ctx, db, query, baseCurrency, quoteCurrency).ScanAll(&rates); err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	return rates, nil
+}
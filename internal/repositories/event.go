@@ -0,0 +1,90 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/libs/database"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgtype"
+)
+
+type EventRepo struct{}
+
+func (r *EventRepo) Create(ctx context.Context, db database.Ext, event *entities.Event) error {
+	command := `INSERT INTO %s (%s) VALUES (%s) RETURNING event_id`
+	fieldNames := database.GetFieldNamesExcepts(event, []string{"event_id"})
+	placeHolders := database.GeneratePlaceholders(len(fieldNames))
+	ultimateCmd := fmt.Sprintf(command, event.TableName(), strings.Join(fieldNames, ","), placeHolders)
+	args := database.GetScanFields(event, fieldNames)
+	if err := db.QueryRow(ctx, ultimateCmd, args...).Scan(&event.EventID); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *EventRepo) UpdateStatus(ctx context.Context, db database.Ext, event *entities.Event) (pgconn.CommandTag, error) {
+	query := fmt.Sprintf(
+		`
+		   UPDATE %s
+		   SET status = $1, updated_at = now()
+		   WHERE
+		     event_id = $2 AND
+		     deleted_at IS NULL
+	       `,
+		event.TableName(),
+	)
+	cmdTag, err := db.Exec(ctx, query, event.Status, event.EventID)
+	if err != nil {
+		return cmdTag, fmt.Errorf("db.Exec: %w", err)
+	}
+
+	return cmdTag, nil
+}
+
+func (r *EventRepo) Get(ctx context.Context, db database.Ext, eventID pgtype.Int4, queryEnhancers ...QueryEnhancer) (*entities.Event, error) {
+	getEventCmd := `SELECT %s FROM %s WHERE event_id = $1 AND deleted_at IS NULL`
+	eventEnt := &entities.Event{}
+	fields, values := eventEnt.FieldMap()
+	for _, e := range queryEnhancers {
+		e(&getEventCmd)
+	}
+
+	err := db.QueryRow(ctx, fmt.Sprintf(getEventCmd, strings.Join(fields, ", "), eventEnt.TableName()), &eventID).Scan(values...)
+	if err != nil {
+		return nil, err
+	}
+
+	return eventEnt, nil
+}
+
+func (r *EventRepo) List(ctx context.Context, db database.Ext, offset, limit uint32) ([]*entities.Event, error) {
+	e := &entities.Event{}
+	fieldName, _ := e.FieldMap()
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE deleted_at IS NULL ORDER BY event_id LIMIT $1 OFFSET $2", strings.Join(fieldName, ", "), e.TableName())
+	events := entities.Events{}
+	if err := database.Select(ctx, db, query, limit, offset).ScanAll(&events); err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	return events, nil
+}
+
+// ListStartDue returns the scheduled events whose start time has passed, locked for
+// update so that they are put in play once.
+func (r *EventRepo) ListStartDue(ctx context.Context, db database.Ext, now pgtype.Timestamptz) ([]*entities.Event, error) {
+	e := &entities.Event{}
+	fieldName, _ := e.FieldMap()
+	query := fmt.Sprintf(`SELECT %s FROM %s
+		WHERE status = '%s' AND start_at <= $1 AND deleted_at IS NULL
+		ORDER BY event_id FOR UPDATE`, strings.Join(fieldName, ", "), e.TableName(), entities.EventStatusScheduled)
+	events := entities.Events{}
+	if err := database.Select(ctx, db, query, now).ScanAll(&events); err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	return events, nil
+}
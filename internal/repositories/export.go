@@ -0,0 +1,87 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/libs/database"
+
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+)
+
+// ExportFilter narrows down the rows of an export, a null field means no filter.
+type ExportFilter struct {
+	// From and To bound the time a wager is placed or a purchase is made, To excluded
+	From pgtype.Timestamptz
+	To   pgtype.Timestamptz
+	// MarketStatus is the status of the market the wager is placed on
+	MarketStatus pgtype.Text
+}
+
+// ExportRepo reads the wagers and the purchases through server-side cursors, the rows
+// are fetched a batch at a time however many match. The cursors only live in the
+// transaction they are declared in.
+type ExportRepo struct{}
+
+// DeclareWagerCursor opens the cursor named cursor over the wagers matching filter, in
+// the order of their ids.
+func (r *ExportRepo) DeclareWagerCursor(ctx context.Context, db database.Ext, cursor string, filter ExportFilter) error {
+	b := &entities.Wager{}
+	fieldNames, _ := b.FieldMap()
+	query := fmt.Sprintf(`DECLARE %s NO SCROLL CURSOR FOR SELECT w.%s FROM %s w
+		LEFT JOIN market m ON m.market_id = w.market_id
+		WHERE ($1::TIMESTAMPTZ IS NULL OR w.place_at >= $1)
+		  AND ($2::TIMESTAMPTZ IS NULL OR w.place_at < $2)
+		  AND ($3::TEXT IS NULL OR m.status = $3)
+		ORDER BY w.wager_id`, pgx.Identifier{cursor}.Sanitize(), strings.Join(fieldNames, ", w."), b.TableName())
+	if _, err := db.Exec(ctx, query, filter.From, filter.To, filter.MarketStatus); err != nil {
+		return fmt.Errorf("db.Exec: %w", err)
+	}
+	return nil
+}
+
+// DeclarePurchaseCursor opens the cursor named cursor over the purchases matching
+// filter, in the order of their ids.
+func (r *ExportRepo) DeclarePurchaseCursor(ctx context.Context, db database.Ext, cursor string, filter ExportFilter) error {
+	b := &entities.Purchase{}
+	fieldNames, _ := b.FieldMap()
+	query := fmt.Sprintf(`DECLARE %s NO SCROLL CURSOR FOR SELECT p.%s FROM %s p
+		JOIN wager w ON w.wager_id = p.wager_id
+		LEFT JOIN market m ON m.market_id = w.market_id
+		WHERE ($1::TIMESTAMPTZ IS NULL OR p.bought_at >= $1)
+		  AND ($2::TIMESTAMPTZ IS NULL OR p.bought_at < $2)
+		  AND ($3::TEXT IS NULL OR m.status = $3)
+		ORDER BY p.purchase_id`, pgx.Identifier{cursor}.Sanitize(), strings.Join(fieldNames, ", p."), b.TableName())
+	if _, err := db.Exec(ctx, query, filter.From, filter.To, filter.MarketStatus); err != nil {
+		return fmt.Errorf("db.Exec: %w", err)
+	}
+	return nil
+}
+
+// FetchWagers returns the next count wagers of a cursor opened by DeclareWagerCursor,
+// fewer once it is exhausted.
+func (r *ExportRepo) FetchWagers(ctx context.Context, db database.Ext, cursor string, count uint32) ([]*entities.Wager, error) {
+	wagers := entities.Wagers{}
+	if err := database.Select(ctx, db, fetchQuery(cursor, count)).ScanAll(&wagers); err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+	return wagers, nil
+}
+
+// FetchPurchases returns the next count purchases of a cursor opened by
+// DeclarePurchaseCursor, fewer once it is exhausted.
+func (r *ExportRepo) FetchPurchases(ctx context.Context, db database.Ext, cursor string, count uint32) ([]*entities.Purchase, error) {
+	purchases := entities.Purchases{}
+	if err := database.Select(ctx, db, fetchQuery(cursor, count)).ScanAll(&purchases); err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+	return purchases, nil
+}
+
+// fetchQuery is built rather than bound, FETCH takes no parameter.
+func fetchQuery(cursor string, count uint32) string {
+	return fmt.Sprintf(`FETCH FORWARD %d FROM %s`, count, pgx.Identifier{cursor}.Sanitize())
+}
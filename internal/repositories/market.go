@@ -0,0 +1,74 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/libs/database"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgtype"
+)
+
+type MarketRepo struct{}
+
+func (r *MarketRepo) Create(ctx context.Context, db database.Ext, market *entities.Market) error {
+	command := `INSERT INTO %s (%s) VALUES (%s) RETURNING market_id`
+	fieldNames := database.GetFieldNamesExcepts(market, []string{"market_id"})
+	placeHolders := database.GeneratePlaceholders(len(fieldNames))
+	ultimateCmd := fmt.Sprintf(command, market.TableName(), strings.Join(fieldNames, ","), placeHolders)
+	args := database.GetScanFields(market, fieldNames)
+	if err := db.QueryRow(ctx, ultimateCmd, args...).Scan(&market.MarketID); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *MarketRepo) UpdateStatus(ctx context.Context, db database.Ext, market *entities.Market) (pgconn.CommandTag, error) {
+	query := fmt.Sprintf(
+		`
+		   UPDATE %s
+		   SET status = $1, updated_at = now()
+		   WHERE
+		     market_id = $2 AND
+		     deleted_at IS NULL
+	       `,
+		market.TableName(),
+	)
+	cmdTag, err := db.Exec(ctx, query, market.Status, market.MarketID)
+	if err != nil {
+		return cmdTag, fmt.Errorf("db.Exec: %w", err)
+	}
+
+	return cmdTag, nil
+}
+
+func (r *MarketRepo) Get(ctx context.Context, db database.Ext, marketID pgtype.Int4, queryEnhancers ...QueryEnhancer) (*entities.Market, error) {
+	getMarketCmd := `SELECT %s FROM %s WHERE market_id = $1 AND deleted_at IS NULL`
+	marketEnt := &entities.Market{}
+	fields, values := marketEnt.FieldMap()
+	for _, e := range queryEnhancers {
+		e(&getMarketCmd)
+	}
+
+	err := db.QueryRow(ctx, fmt.Sprintf(getMarketCmd, strings.Join(fields, ", "), marketEnt.TableName()), &marketID).Scan(values...)
+	if err != nil {
+		return nil, err
+	}
+
+	return marketEnt, nil
+}
+
+func (r *MarketRepo) ListByEvent(ctx context.Context, db database.Ext, eventID pgtype.Int4) ([]*entities.Market, error) {
+	m := &entities.Market{}
+	fieldName, _ := m.FieldMap()
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE event_id = $1 AND deleted_at IS NULL ORDER BY market_id", strings.Join(fieldName, ", "), m.TableName())
+	markets := entities.Markets{}
+	if err := database.Select(ctx, db, query, eventID).ScanAll(&markets); err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	return markets, nil
+}
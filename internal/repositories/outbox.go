@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/libs/database"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgtype"
+)
+
+type OutboxRepo struct{}
+
+// Create writes message to the outbox. The transactions writing to it are serialized
+// until the end of the transaction of db, so the messages are committed in the order of
+// their ids and the relay never finds one committed after a later one it published.
+func (r *OutboxRepo) Create(ctx context.Context, db database.Ext, message *entities.OutboxMessage) error {
+	if _, err := db.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('outbox'))`); err != nil {
+		return fmt.Errorf("db.Exec: %w", err)
+	}
+	command := `INSERT INTO %s (%s) VALUES (%s) RETURNING outbox_id`
+	fieldNames := database.GetFieldNamesExcepts(message, []string{"outbox_id"})
+	placeHolders := database.GeneratePlaceholders(len(fieldNames))
+	ultimateCmd := fmt.Sprintf(command, message.TableName(), strings.Join(fieldNames, ","), placeHolders)
+	args := database.GetScanFields(message, fieldNames)
+	if err := db.QueryRow(ctx, ultimateCmd, args...).Scan(&message.OutboxID); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ListPending returns the oldest messages which are not sent yet, locked until the end
+// of the transaction so concurrent relays claim them one after another and in order.
+func (r *OutboxRepo) ListPending(ctx context.Context, db database.Ext, limit uint32) ([]*entities.OutboxMessage, error) {
+	e := &entities.OutboxMessage{}
+	fieldName, _ := e.FieldMap()
+	query := fmt.Sprintf(`SELECT %s FROM %s
+		WHERE sent_at IS NULL
+		ORDER BY outbox_id LIMIT $1 FOR UPDATE`, strings.Join(fieldName, ", "), e.TableName())
+	messages := entities.OutboxMessages{}
+	if err := database.Select(ctx, db, query, limit).ScanAll(&messages); err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	return messages, nil
+}
+
+// Claim leases the pending messages of outboxIDs until lockedUntil, the other relays
+// skip them meanwhile.
+func (r *OutboxRepo) Claim(ctx context.Context, db database.Ext, outboxIDs []int64, lockedUntil pgtype.Timestamptz) (pgconn.CommandTag, error) {
+	command := `UPDATE outbox SET locked_until = $2 WHERE outbox_id = ANY($1) AND sent_at IS NULL`
+	return db.Exec(ctx, command, outboxIDs, lockedUntil)
+}
+
+// Release ends the lease of the messages of outboxIDs which are not sent yet.
+func (r *OutboxRepo) Release(ctx context.Context, db database.Ext, outboxIDs []int64) (pgconn.CommandTag, error) {
+	command := `UPDATE outbox SET locked_until = NULL WHERE outbox_id = ANY($1) AND sent_at IS NULL`
+	return db.Exec(ctx, command, outboxIDs)
+}
+
+func (r *OutboxRepo) MarkSent(ctx context.Context, db database.Ext, outboxID pgtype.Int8, sentAt pgtype.Timestamptz) (pgconn.CommandTag, error) {
+	command := `UPDATE outbox SET sent_at = $2, attempts = attempts + 1, last_error = NULL, locked_until = NULL WHERE outbox_id = $1`
+	return db.Exec(ctx, command, outboxID, sentAt)
+}
+
+// MarkFailed records a failed delivery, the message is retried from nextAttemptAt.
+func (r *OutboxRepo) MarkFailed(ctx context.Context, db database.Ext, outboxID pgtype.Int8, nextAttemptAt pgtype.Timestamptz, lastError pgtype.Text) (pgconn.CommandTag, error) {
+	command := `UPDATE outbox SET attempts = attempts + 1, next_attempt_at = $2, last_error = $3, locked_until = NULL WHERE outbox_id = $1`
+	return db.Exec(ctx, command, outboxID, nextAttemptAt, lastError)
+}
@@ -0,0 +1,151 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/libs/database"
+
+	"github.com/jackc/pgtype"
+)
+
+type PositionRepo struct{}
+
+// Position is what an account traded of a wager: the stakes it acquired by buying the
+// wager or its listings and what they cost along their fees, the stakes it resold and
+// what they brought.
+type Position struct {
+	Wager         *entities.Wager
+	MarketStatus  pgtype.Text
+	AcquiredStake float32
+	Cost          float32
+	SoldStake     float32
+	Proceeds      float32
+}
+
+// PositionTotals sums up every position of a holder in the wagers of Currency.
+type PositionTotals struct {
+	Currency      string
+	Positions     int32
+	OpenPositions int32
+	TotalCost     float32
+	MarketValue   float32
+	UnrealizedPnL float32
+	RealizedPnL   float32
+}
+
+// positionsQuery selects what the holder $1 traded of each wager it traded, its trades
+// summed up in t and joined to its wager w and the market m of the wager.
+func positionsQuery(selection string) string {
+	w, p, t, m, f := &entities.Wager{}, &entities.Purchase{}, &entities.StakeTransfer{}, &entities.Market{}, &entities.PurchaseFee{}
+	return fmt.Sprintf(`SELECT %s
+		FROM (
+		  SELECT wager_id,
+		    SUM(acquired_stake)::REAL AS acquired_stake, SUM(cost)::REAL AS cost,
+		    SUM(sold_stake)::REAL AS sold_stake, SUM(proceeds)::REAL AS proceeds
+		  FROM (
+		    SELECT wager_id, buying_price AS acquired_stake,
+		      buying_price + COALESCE((SELECT SUM(amount) FROM %s
+		        WHERE purchase_id = purchases.purchase_id AND transfer_id IS NULL), 0) AS cost,
+		      0::REAL AS sold_stake, 0::REAL AS proceeds
+		      FROM %s purchases WHERE buyer_id = $1 AND deleted_at IS NULL
+		    UNION ALL
+		    SELECT wager_id, stake,
+		      price + COALESCE((SELECT SUM(amount) FROM %s WHERE transfer_id = transfers.transfer_id), 0),
+		      0::REAL, 0::REAL
+		      FROM %s transfers WHERE to_holder_id = $1
+		    UNION ALL
+		    SELECT wager_id, 0::REAL, 0::REAL, stake, price FROM %s WHERE from_holder_id = $1
+		  ) trades
+		  GROUP BY wager_id
+		) t
+		JOIN %s w ON w.wager_id = t.wager_id
+		LEFT JOIN %s m ON m.market_id = w.market_id`,
+		selection, f.TableName(), p.TableName(), f.TableName(), t.TableName(), t.TableName(), w.TableName(), m.TableName())
+}
+
+// ListByHolder returns a page of the positions of holderID in every wager it traded, in
+// wager id order. MarketStatus is null for the wagers placed without a market.
+func (r *PositionRepo) ListByHolder(ctx context.Context, db database.Ext, holderID pgtype.Text, offset, limit uint32) ([]*Position, error) {
+	w := &entities.Wager{}
+	fieldNames, _ := w.FieldMap()
+	wagerFields := make([]string, 0, len(fieldNames))
+	for _, fieldName := range fieldNames {
+		wagerFields = append(wagerFields, "w."+fieldName)
+	}
+	query := positionsQuery(strings.Join(wagerFields, ", ")+", m.status, t.acquired_stake, t.cost, t.sold_stake, t.proceeds") +
+		" ORDER BY t.wager_id LIMIT $2 OFFSET $3"
+	rows, err := db.Query(ctx, query, holderID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("db.Query: %w", err)
+	}
+	defer rows.Close()
+	positions := []*Position{}
+	for rows.Next() {
+		position := &Position{Wager: &entities.Wager{}}
+		_, values := position.Wager.FieldMap()
+		values = append(values, &position.MarketStatus, &position.AcquiredStake, &position.Cost, &position.SoldStake, &position.Proceeds)
+		if err := rows.Scan(values...); err != nil {
+			return nil, fmt.Errorf("rows.Scan: %w", err)
+		}
+		positions = append(positions, position)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return positions, nil
+}
+
+// SumByHolder sums up every position of holderID valued at now as convertPositionPg2Domain
+// values one: the stake held at its share of the decayed price of the wager, the resold
+// stakes at the average cost of the acquired ones and a settled position at its last value.
+// The amounts of the wagers of each currency are summed apart, in currency order.
+func (r *PositionRepo) SumByHolder(ctx context.Context, db database.Ext, holderID pgtype.Text, now pgtype.Timestamptz) ([]*PositionTotals, error) {
+	// the price of the wager at $2 along its decay, the elapsed seconds are floored to a
+	// step for a stepwise one
+	decayedPrice := fmt.Sprintf(`CASE
+		  WHEN w.decay_type IS NULL OR w.place_at IS NULL OR COALESCE(w.decay_duration_seconds, 0) <= 0 OR $2 <= w.place_at
+		    THEN w.current_selling_price
+		  ELSE LEAST(w.current_selling_price, ROUND((w.selling_price - (w.selling_price - w.decay_floor_price) * LEAST(
+		    CASE WHEN w.decay_type = '%s' AND w.decay_step_seconds > 0
+		      THEN FLOOR(EXTRACT(EPOCH FROM $2 - w.place_at) / w.decay_step_seconds) * w.decay_step_seconds
+		      ELSE EXTRACT(EPOCH FROM $2 - w.place_at) END / w.decay_duration_seconds, 1))::NUMERIC, 2))
+		END`, entities.WagerDecayStep)
+	valued := positionsQuery(fmt.Sprintf(`w.currency, COALESCE(m.status = '%s', FALSE) AS settled,
+		  t.acquired_stake - t.sold_stake AS held, t.cost, t.sold_stake, t.proceeds,
+		  CASE WHEN t.acquired_stake > 0 THEN t.cost / t.acquired_stake ELSE 0 END AS average_cost,
+		  CASE WHEN w.selling_price > 0 THEN (t.acquired_stake - t.sold_stake) / w.selling_price ELSE 0 END * (%s) AS market_value`,
+		entities.MarketStatusSettled, decayedPrice))
+	query := fmt.Sprintf(`WITH valued AS (%s),
+		  positions AS (SELECT *, NOT settled AND ROUND(held::NUMERIC, 2) > 0 AS open FROM valued)
+		SELECT currency, COUNT(*)::INT, (COUNT(*) FILTER (WHERE open))::INT,
+		  COALESCE(SUM(cost), 0)::REAL,
+		  COALESCE(SUM(market_value) FILTER (WHERE NOT settled), 0)::REAL,
+		  COALESCE(SUM(market_value - average_cost * held) FILTER (WHERE open), 0)::REAL,
+		  COALESCE(SUM(proceeds - average_cost * sold_stake + CASE WHEN settled THEN market_value - average_cost * held ELSE 0 END), 0)::REAL
+		FROM positions
+		GROUP BY currency
+		ORDER BY currency`, valued)
+	rows, err := db.Query(ctx, query, holderID, now)
+	if err != nil {
+		return nil, fmt.Errorf("db.Query: %w", err)
+	}
+	defer rows.Close()
+	totals := []*PositionTotals{}
+	for rows.Next() {
+		total := &PositionTotals{}
+		if err := rows.Scan(&total.Currency, &total.Positions, &total.OpenPositions, &total.TotalCost,
+			&total.MarketValue, &total.UnrealizedPnL, &total.RealizedPnL); err != nil {
+			return nil, fmt.Errorf("rows.Scan: %w", err)
+		}
+		totals = append(totals, total)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return totals, nil
+}
@@ -0,0 +1,104 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/libs/database"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgtype"
+)
+
+type PriceHistoryRepo struct{}
+
+// PriceCandle is the OHLC summary of the prices recorded in one bucket of time.
+type PriceCandle struct {
+	BucketStart time.Time
+	Open        float32
+	High        float32
+	Low         float32
+	Close       float32
+	Count       int64
+}
+
+func (r *PriceHistoryRepo) Create(ctx context.Context, db database.Ext, priceHistory *entities.WagerPriceHistory) error {
+	command := `INSERT INTO %s (%s) VALUES (%s) RETURNING price_history_id`
+	fieldNames := database.GetFieldNamesExcepts(priceHistory, []string{"price_history_id"})
+	placeHolders := database.GeneratePlaceholders(len(fieldNames))
+	ultimateCmd := fmt.Sprintf(command, priceHistory.TableName(), strings.Join(fieldNames, ","), placeHolders)
+	args := database.GetScanFields(priceHistory, fieldNames)
+	if err := db.QueryRow(ctx, ultimateCmd, args...).Scan(&priceHistory.PriceHistoryID); err != nil {
+		return err
+	}
+	return nil
+}
+
+// List returns the prices of a wager recorded in [from, to), a null bound means unbounded.
+func (r *PriceHistoryRepo) List(ctx context.Context, db database.Ext, wagerID pgtype.Int4, from, to pgtype.Timestamptz) ([]*entities.WagerPriceHistory, error) {
+	e := &entities.WagerPriceHistory{}
+	fieldName, _ := e.FieldMap()
+	query := fmt.Sprintf(`SELECT %s FROM %s
+		WHERE wager_id = $1
+		  AND ($2::TIMESTAMPTZ IS NULL OR recorded_at >= $2)
+		  AND ($3::TIMESTAMPTZ IS NULL OR recorded_at < $3)
+		ORDER BY recorded_at, price_history_id`, strings.Join(fieldName, ", "), e.TableName())
+	priceHistories := entities.WagerPriceHistories{}
+	if err := database.Select(ctx, db, query, wagerID, from, to).ScanAll(&priceHistories); err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	return priceHistories, nil
+}
+
+// ListCandles buckets the prices of a wager recorded in [from, to) by interval and
+// returns the open, high, low and close price of every bucket which has prices.
+func (r *PriceHistoryRepo) ListCandles(ctx context.Context, db database.Ext, wagerID pgtype.Int4, from, to pgtype.Timestamptz, interval time.Duration) ([]*PriceCandle, error) {
+	e := &entities.WagerPriceHistory{}
+	query := fmt.Sprintf(`SELECT
+		  date_bin($4::INTERVAL, recorded_at, TIMESTAMPTZ 'epoch') AS bucket_start,
+		  (array_agg(price ORDER BY recorded_at, price_history_id))[1] AS open,
+		  MAX(price) AS high,
+		  MIN(price) AS low,
+		  (array_agg(price ORDER BY recorded_at DESC, price_history_id DESC))[1] AS close,
+		  COUNT(*) AS count
+		FROM %s
+		WHERE wager_id = $1
+		  AND ($2::TIMESTAMPTZ IS NULL OR recorded_at >= $2)
+		  AND ($3::TIMESTAMPTZ IS NULL OR recorded_at < $3)
+		GROUP BY bucket_start
+		ORDER BY bucket_start`, e.TableName())
+	rows, err := db.Query(ctx, query, wagerID, from, to, fmt.Sprintf("%d microseconds", interval.Microseconds()))
+	if err != nil {
+		return nil, fmt.Errorf("db.Query: %w", err)
+	}
+	defer rows.Close()
+	candles := []*PriceCandle{}
+	for rows.Next() {
+		candle := &PriceCandle{}
+		if err := rows.Scan(&candle.BucketStart, &candle.Open, &candle.High, &candle.Low, &candle.Close, &candle.Count); err != nil {
+			return nil, fmt.Errorf("rows.Scan: %w", err)
+		}
+		candles = append(candles, candle)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return candles, nil
+}
+
+// DeleteBefore removes the prices recorded before the given time.
+func (r *PriceHistoryRepo) DeleteBefore(ctx context.Context, db database.Ext, before pgtype.Timestamptz) (pgconn.CommandTag, error) {
+	e := &entities.WagerPriceHistory{}
+	query := fmt.Sprintf(`DELETE FROM %s WHERE recorded_at < $1`, e.TableName())
+	cmdTag, err := db.Exec(ctx, query, before)
+	if err != nil {
+		return cmdTag, fmt.Errorf("db.Exec: %w", err)
+	}
+
+	return cmdTag, nil
+}
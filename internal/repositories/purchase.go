@@ -4,9 +4,12 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/wager-api/internal/entities"
 	"github.com/wager-api/libs/database"
+
+	"github.com/jackc/pgtype"
 )
 
 type PurchaseRepo struct{}
@@ -29,3 +32,124 @@ func (r *PurchaseRepo) Create(ctx context.Context, db database.Ext, purchase *en
 	}
 	return nil
 }
+
+// SumVolume is what buyerID spent on the purchases made since since.
+func (r *PurchaseRepo) SumVolume(ctx context.Context, db database.Ext, buyerID pgtype.Text, since pgtype.Timestamptz) (pgtype.Float4, error) {
+	p := &entities.Purchase{}
+	var volume pgtype.Float4
+	query := fmt.Sprintf(`SELECT COALESCE(SUM(buying_price), 0)::REAL FROM %s
+		WHERE buyer_id = $1 AND bought_at >= $2 AND deleted_at IS NULL`, p.TableName())
+	if err := db.QueryRow(ctx, query, buyerID, since).Scan(&volume); err != nil {
+		return volume, err
+	}
+	return volume, nil
+}
+
+// CurrencySpend is what a buyer spent in one currency.
+type CurrencySpend struct {
+	Currency pgtype.Text
+	Amount   pgtype.Float4
+}
+
+// SumSpend is what buyerID spent on the purchases made since since by currency they paid
+// in: the paid amounts along their fees, converted at the rate of the purchase, and the
+// paid prices of the listings they bought along their fees, converted the same way. The
+// purchases paid before the currencies were recorded are in the currency of the wager.
+func (r *PurchaseRepo) SumSpend(ctx context.Context, db database.Ext, buyerID pgtype.Text, since pgtype.Timestamptz) ([]*CurrencySpend, error) {
+	p, f, t, w := &entities.Purchase{}, &entities.PurchaseFee{}, &entities.StakeTransfer{}, &entities.Wager{}
+	query := fmt.Sprintf(`SELECT currency, SUM(spend)::REAL FROM (
+		  SELECT COALESCE(p.paid_currency, w.currency) AS currency,
+		      COALESCE(p.paid_amount, p.buying_price) + COALESCE(fees.amount, 0) * COALESCE(p.exchange_rate, 1) AS spend
+		    FROM %s p
+		    JOIN %s w ON w.wager_id = p.wager_id
+		    LEFT JOIN LATERAL (SELECT SUM(amount) AS amount FROM %s WHERE purchase_id = p.purchase_id AND transfer_id IS NULL) fees ON TRUE
+		    WHERE p.buyer_id = $1 AND p.bought_at >= $2 AND p.deleted_at IS NULL
+		  UNION ALL
+		  SELECT COALESCE(t.paid_currency, w.currency),
+		      COALESCE(t.paid_amount, t.price) + COALESCE(fees.amount, 0) * COALESCE(t.exchange_rate, 1)
+		    FROM %s t
+		    JOIN %s w ON w.wager_id = t.wager_id
+		    LEFT JOIN LATERAL (SELECT SUM(amount) AS amount FROM %s WHERE transfer_id = t.transfer_id) fees ON TRUE
+		    WHERE t.to_holder_id = $1 AND t.transferred_at >= $2
+		) spends
+		GROUP BY currency
+		ORDER BY currency`, p.TableName(), w.TableName(), f.TableName(), t.TableName(), w.TableName(), f.TableName())
+	rows, err := db.Query(ctx, query, buyerID, since)
+	if err != nil {
+		return nil, fmt.Errorf("db.Query: %w", err)
+	}
+	defer rows.Close()
+	spends := []*CurrencySpend{}
+	for rows.Next() {
+		spend := &CurrencySpend{}
+		if err := rows.Scan(&spend.Currency, &spend.Amount); err != nil {
+			return nil, fmt.Errorf("rows.Scan: %w", err)
+		}
+		spends = append(spends, spend)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+	return spends, nil
+}
+
+// VolumeReportRow is the volume bought of the wagers in one currency over one period,
+// BaseVolume is its value in the base currency at the rates in effect when the purchases
+// were made, without the UnconvertedPurchases made when no rate was.
+type VolumeReportRow struct {
+	PeriodStart          time.Time
+	Currency             string
+	Purchases            int64
+	Volume               float32
+	BaseVolume           float32
+	UnconvertedPurchases int64
+}
+
+// ReportVolume sums up the buying prices of the purchases made in [from, to) by period
+// (day, week or month, in UTC) and currency of the wager, a null bound means unbounded.
+// A currency is converted to baseCurrency with the rate of the pair in either direction.
+func (r *PurchaseRepo) ReportVolume(ctx context.Context, db database.Ext, from, to pgtype.Timestamptz, period string, baseCurrency pgtype.Text) ([]*VolumeReportRow, error) {
+	p, w, e := &entities.Purchase{}, &entities.Wager{}, &entities.ExchangeRate{}
+	query := fmt.Sprintf(`SELECT
+		  date_trunc($3, p.bought_at, 'UTC') AS period_start,
+		  w.currency,
+		  COUNT(*) AS purchases,
+		  SUM(p.buying_price)::REAL AS volume,
+		  COALESCE(SUM(p.buying_price * r.rate), 0)::REAL AS base_volume,
+		  COUNT(*) - COUNT(r.rate) AS unconverted_purchases
+		FROM %s p
+		JOIN %s w ON w.wager_id = p.wager_id
+		LEFT JOIN LATERAL (
+		  SELECT CASE WHEN w.currency = $4 THEN 1::DOUBLE PRECISION ELSE COALESCE(
+		    (SELECT rate FROM %s
+		      WHERE base_currency = w.currency AND quote_currency = $4 AND effective_from <= p.bought_at
+		      ORDER BY effective_from DESC, rate_id DESC LIMIT 1),
+		    (SELECT 1 / rate FROM %s
+		      WHERE base_currency = $4 AND quote_currency = w.currency AND effective_from <= p.bought_at
+		      ORDER BY effective_from DESC, rate_id DESC LIMIT 1)
+		  ) END AS rate
+		) r ON TRUE
+		WHERE p.deleted_at IS NULL
+		  AND ($1::TIMESTAMPTZ IS NULL OR p.bought_at >= $1)
+		  AND ($2::TIMESTAMPTZ IS NULL OR p.bought_at < $2)
+		GROUP BY period_start, w.currency
+		ORDER BY period_start, w.currency`, p.TableName(), w.TableName(), e.TableName(), e.TableName())
+	rows, err := db.Query(ctx, query, from, to, period, baseCurrency)
+	if err != nil {
+		return nil, fmt.Errorf("db.Query: %w", err)
+	}
+	defer rows.Close()
+	reportRows := []*VolumeReportRow{}
+	for rows.Next() {
+		row := &VolumeReportRow{}
+		if err := rows.Scan(&row.PeriodStart, &row.Currency, &row.Purchases, &row.Volume, &row.BaseVolume, &row.UnconvertedPurchases); err != nil {
+			return nil, fmt.Errorf("rows.Scan: %w", err)
+		}
+		reportRows = append(reportRows, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return reportRows, nil
+}
@@ -0,0 +1,70 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/libs/database"
+
+	"github.com/jackc/pgtype"
+)
+
+type PurchaseFeeRepo struct{}
+
+// FeeReportRow is what one kind of fee brought in over one period, Purchases counts the
+// resales as well.
+type FeeReportRow struct {
+	PeriodStart time.Time
+	FeeType     string
+	Purchases   int64
+	Amount      float32
+}
+
+func (r *PurchaseFeeRepo) Create(ctx context.Context, db database.Ext, fee *entities.PurchaseFee) error {
+	command := `INSERT INTO %s (%s) VALUES (%s) RETURNING fee_id`
+	fieldNames := database.GetFieldNamesExcepts(fee, []string{"fee_id"})
+	placeHolders := database.GeneratePlaceholders(len(fieldNames))
+	ultimateCmd := fmt.Sprintf(command, fee.TableName(), strings.Join(fieldNames, ","), placeHolders)
+	args := database.GetScanFields(fee, fieldNames)
+	if err := db.QueryRow(ctx, ultimateCmd, args...).Scan(&fee.FeeID); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Report sums up the fees charged in [from, to) by period (day, week or month, in UTC)
+// and kind of fee, a null bound means unbounded.
+func (r *PurchaseFeeRepo) Report(ctx context.Context, db database.Ext, from, to pgtype.Timestamptz, period string) ([]*FeeReportRow, error) {
+	e := &entities.PurchaseFee{}
+	query := fmt.Sprintf(`SELECT
+		  date_trunc($3, created_at, 'UTC') AS period_start,
+		  fee_type,
+		  COUNT(DISTINCT (purchase_id, transfer_id)) AS purchases,
+		  SUM(amount)::REAL AS amount
+		FROM %s
+		WHERE ($1::TIMESTAMPTZ IS NULL OR created_at >= $1)
+		  AND ($2::TIMESTAMPTZ IS NULL OR created_at < $2)
+		GROUP BY period_start, fee_type
+		ORDER BY period_start, fee_type`, e.TableName())
+	rows, err := db.Query(ctx, query, from, to, period)
+	if err != nil {
+		return nil, fmt.Errorf("db.Query: %w", err)
+	}
+	defer rows.Close()
+	reportRows := []*FeeReportRow{}
+	for rows.Next() {
+		row := &FeeReportRow{}
+		if err := rows.Scan(&row.PeriodStart, &row.FeeType, &row.Purchases, &row.Amount); err != nil {
+			return nil, fmt.Errorf("rows.Scan: %w", err)
+		}
+		reportRows = append(reportRows, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return reportRows, nil
+}
@@ -0,0 +1,71 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/libs/database"
+
+	"github.com/jackc/pgtype"
+)
+
+// ReconciliationRepo recomputes the aggregates of the wagers from their purchases, the
+// buying prices are summed up as decimals so the sums hold no float error.
+type ReconciliationRepo struct{}
+
+// WagerAggregate is what a wager stores of its purchases along what they sum up to
+type WagerAggregate struct {
+	WagerID         int32
+	SellingPrice    float32
+	AmountSold      float32
+	PercentageSold  float32
+	Purchases       int64
+	PurchasedAmount float32
+}
+
+// ListWagerAggregates returns the aggregates of limit wagers after afterID, in id order.
+func (r *ReconciliationRepo) ListWagerAggregates(ctx context.Context, db database.Ext, afterID pgtype.Int4, limit uint32) ([]*WagerAggregate, error) {
+	w, p := &entities.Wager{}, &entities.Purchase{}
+	query := fmt.Sprintf(`SELECT w.wager_id, w.selling_price, COALESCE(w.amount_sold, 0), COALESCE(w.percentage_sold, 0),
+		  purchases.count, purchases.amount
+		FROM %s w
+		CROSS JOIN LATERAL (
+		  SELECT COUNT(*) AS count, COALESCE(ROUND(SUM(buying_price::NUMERIC), 2), 0)::REAL AS amount
+		  FROM %s WHERE wager_id = w.wager_id AND deleted_at IS NULL
+		) purchases
+		WHERE w.wager_id > $1 AND w.deleted_at IS NULL
+		ORDER BY w.wager_id
+		LIMIT $2`, w.TableName(), p.TableName())
+	rows, err := db.Query(ctx, query, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("db.Query: %w", err)
+	}
+	defer rows.Close()
+	aggregates := []*WagerAggregate{}
+	for rows.Next() {
+		aggregate := &WagerAggregate{}
+		if err := rows.Scan(&aggregate.WagerID, &aggregate.SellingPrice, &aggregate.AmountSold, &aggregate.PercentageSold,
+			&aggregate.Purchases, &aggregate.PurchasedAmount); err != nil {
+			return nil, fmt.Errorf("rows.Scan: %w", err)
+		}
+		aggregates = append(aggregates, aggregate)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return aggregates, nil
+}
+
+// SumPurchases is what the purchases of wagerID sum up to.
+func (r *ReconciliationRepo) SumPurchases(ctx context.Context, db database.Ext, wagerID pgtype.Int4) (pgtype.Float4, error) {
+	p := &entities.Purchase{}
+	var amount pgtype.Float4
+	query := fmt.Sprintf(`SELECT COALESCE(ROUND(SUM(buying_price::NUMERIC), 2), 0)::REAL FROM %s
+		WHERE wager_id = $1 AND deleted_at IS NULL`, p.TableName())
+	if err := db.QueryRow(ctx, query, wagerID).Scan(&amount); err != nil {
+		return amount, err
+	}
+	return amount, nil
+}
@@ -0,0 +1,172 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/libs/database"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgtype"
+)
+
+type StakeHoldingRepo struct{}
+
+// Add adds the stake of holding to what its holder holds in the purchase already, the
+// holding is created on the first stake. Its id and its whole stake are read back.
+func (r *StakeHoldingRepo) Add(ctx context.Context, db database.Ext, holding *entities.StakeHolding) error {
+	fieldNames := database.GetFieldNamesExcepts(holding, []string{"holding_id"})
+	placeHolders := database.GeneratePlaceholders(len(fieldNames))
+	command := fmt.Sprintf(`INSERT INTO %s (%s) VALUES (%s)
+		ON CONFLICT (purchase_id, holder_id) DO UPDATE SET stake = %s.stake + EXCLUDED.stake, updated_at = EXCLUDED.updated_at
+		RETURNING holding_id, stake`, holding.TableName(), strings.Join(fieldNames, ","), placeHolders, holding.TableName())
+	args := database.GetScanFields(holding, fieldNames)
+	if err := db.QueryRow(ctx, command, args...).Scan(&holding.HoldingID, &holding.Stake); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *StakeHoldingRepo) Get(ctx context.Context, db database.Ext, purchaseID pgtype.Int4, holderID pgtype.Text, queryEnhancers ...QueryEnhancer) (*entities.StakeHolding, error) {
+	getHoldingCmd := `SELECT %s FROM %s WHERE purchase_id = $1 AND holder_id = $2`
+	holdingEnt := &entities.StakeHolding{}
+	fields, values := holdingEnt.FieldMap()
+	for _, e := range queryEnhancers {
+		e(&getHoldingCmd)
+	}
+
+	err := db.QueryRow(ctx, fmt.Sprintf(getHoldingCmd, strings.Join(fields, ", "), holdingEnt.TableName()), &purchaseID, &holderID).Scan(values...)
+	if err != nil {
+		return nil, err
+	}
+
+	return holdingEnt, nil
+}
+
+// Reduce takes stake off a holding, no row is affected when it holds less.
+func (r *StakeHoldingRepo) Reduce(ctx context.Context, db database.Ext, holdingID pgtype.Int4, stake pgtype.Float4, now pgtype.Timestamptz) (pgconn.CommandTag, error) {
+	h := &entities.StakeHolding{}
+	query := fmt.Sprintf(`UPDATE %s SET stake = stake - $2, updated_at = $3
+		WHERE holding_id = $1 AND stake >= $2`, h.TableName())
+	cmdTag, err := db.Exec(ctx, query, holdingID, stake, now)
+	if err != nil {
+		return cmdTag, fmt.Errorf("db.Exec: %w", err)
+	}
+
+	return cmdTag, nil
+}
+
+// ListByWager returns the holdings of a stake in a wager, in id order.
+func (r *StakeHoldingRepo) ListByWager(ctx context.Context, db database.Ext, wagerID pgtype.Int4) ([]*entities.StakeHolding, error) {
+	h := &entities.StakeHolding{}
+	fieldName, _ := h.FieldMap()
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE wager_id = $1 AND stake > 0 ORDER BY holding_id`,
+		strings.Join(fieldName, ", "), h.TableName())
+	holdings := entities.StakeHoldings{}
+	if err := database.Select(ctx, db, query, wagerID).ScanAll(&holdings); err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	return holdings, nil
+}
+
+// ListUnheldPurchases returns the purchases of a wager nobody holds a stake of, the ones
+// made without a buyer, in id order.
+func (r *StakeHoldingRepo) ListUnheldPurchases(ctx context.Context, db database.Ext, wagerID pgtype.Int4) ([]*entities.Purchase, error) {
+	p, h := &entities.Purchase{}, &entities.StakeHolding{}
+	fieldName, _ := p.FieldMap()
+	query := fmt.Sprintf(`SELECT p.%s FROM %s p
+		WHERE p.wager_id = $1 AND p.deleted_at IS NULL
+		  AND NOT EXISTS (SELECT 1 FROM %s h WHERE h.purchase_id = p.purchase_id)
+		ORDER BY p.purchase_id`, strings.Join(fieldName, ", p."), p.TableName(), h.TableName())
+	purchases := entities.Purchases{}
+	if err := database.Select(ctx, db, query, wagerID).ScanAll(&purchases); err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	return purchases, nil
+}
+
+type ResaleListingRepo struct{}
+
+func (r *ResaleListingRepo) Create(ctx context.Context, db database.Ext, listing *entities.ResaleListing) error {
+	command := `INSERT INTO %s (%s) VALUES (%s) RETURNING listing_id`
+	fieldNames := database.GetFieldNamesExcepts(listing, []string{"listing_id"})
+	placeHolders := database.GeneratePlaceholders(len(fieldNames))
+	ultimateCmd := fmt.Sprintf(command, listing.TableName(), strings.Join(fieldNames, ","), placeHolders)
+	args := database.GetScanFields(listing, fieldNames)
+	if err := db.QueryRow(ctx, ultimateCmd, args...).Scan(&listing.ListingID); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *ResaleListingRepo) Get(ctx context.Context, db database.Ext, listingID pgtype.Int4, queryEnhancers ...QueryEnhancer) (*entities.ResaleListing, error) {
+	getListingCmd := `SELECT %s FROM %s WHERE listing_id = $1`
+	listingEnt := &entities.ResaleListing{}
+	fields, values := listingEnt.FieldMap()
+	for _, e := range queryEnhancers {
+		e(&getListingCmd)
+	}
+
+	err := db.QueryRow(ctx, fmt.Sprintf(getListingCmd, strings.Join(fields, ", "), listingEnt.TableName()), &listingID).Scan(values...)
+	if err != nil {
+		return nil, err
+	}
+
+	return listingEnt, nil
+}
+
+// SumOpenStake is the stake sellerID lists for resale in the purchase.
+func (r *ResaleListingRepo) SumOpenStake(ctx context.Context, db database.Ext, purchaseID pgtype.Int4, sellerID pgtype.Text) (pgtype.Float4, error) {
+	l := &entities.ResaleListing{}
+	var stake pgtype.Float4
+	query := fmt.Sprintf(`SELECT COALESCE(SUM(stake), 0)::REAL FROM %s WHERE purchase_id = $1 AND seller_id = $2 AND status = '%s'`,
+		l.TableName(), entities.ResaleListingStatusOpen)
+	if err := db.QueryRow(ctx, query, purchaseID, sellerID).Scan(&stake); err != nil {
+		return stake, err
+	}
+	return stake, nil
+}
+
+// Sell marks an open listing sold to buyerID.
+func (r *ResaleListingRepo) Sell(ctx context.Context, db database.Ext, listingID pgtype.Int4, buyerID pgtype.Text, soldAt pgtype.Timestamptz) (pgconn.CommandTag, error) {
+	l := &entities.ResaleListing{}
+	query := fmt.Sprintf(`UPDATE %s SET status = '%s', buyer_id = $2, sold_at = $3, updated_at = $3
+		WHERE listing_id = $1 AND status = '%s'`, l.TableName(), entities.ResaleListingStatusSold, entities.ResaleListingStatusOpen)
+	cmdTag, err := db.Exec(ctx, query, listingID, buyerID, soldAt)
+	if err != nil {
+		return cmdTag, fmt.Errorf("db.Exec: %w", err)
+	}
+
+	return cmdTag, nil
+}
+
+// Cancel cancels a listing which is still open, no row is affected otherwise.
+func (r *ResaleListingRepo) Cancel(ctx context.Context, db database.Ext, listingID pgtype.Int4, now pgtype.Timestamptz) (pgconn.CommandTag, error) {
+	l := &entities.ResaleListing{}
+	query := fmt.Sprintf(`UPDATE %s SET status = '%s', updated_at = $2
+		WHERE listing_id = $1 AND status = '%s'`, l.TableName(), entities.ResaleListingStatusCancelled, entities.ResaleListingStatusOpen)
+	cmdTag, err := db.Exec(ctx, query, listingID, now)
+	if err != nil {
+		return cmdTag, fmt.Errorf("db.Exec: %w", err)
+	}
+
+	return cmdTag, nil
+}
+
+type StakeTransferRepo struct{}
+
+func (r *StakeTransferRepo) Create(ctx context.Context, db database.Ext, transfer *entities.StakeTransfer) error {
+	command := `INSERT INTO %s (%s) VALUES (%s) RETURNING transfer_id`
+	fieldNames := database.GetFieldNamesExcepts(transfer, []string{"transfer_id"})
+	placeHolders := database.GeneratePlaceholders(len(fieldNames))
+	ultimateCmd := fmt.Sprintf(command, transfer.TableName(), strings.Join(fieldNames, ","), placeHolders)
+	args := database.GetScanFields(transfer, fieldNames)
+	if err := db.QueryRow(ctx, ultimateCmd, args...).Scan(&transfer.TransferID); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/libs/database"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgtype"
+)
+
+type SpendLimitRepo struct{}
+
+// LockAccount serializes the transactions changing or checking the spend limits of
+// accountID until the end of the transaction of db, an account may have no row to lock.
+func (r *SpendLimitRepo) LockAccount(ctx context.Context, db database.Ext, accountID pgtype.Text) error {
+	if _, err := db.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('account:' || $1))`, accountID); err != nil {
+		return fmt.Errorf("db.Exec: %w", err)
+	}
+	return nil
+}
+
+func (r *SpendLimitRepo) Create(ctx context.Context, db database.Ext, limit *entities.SpendLimit) error {
+	command := `INSERT INTO %s (%s) VALUES (%s) RETURNING limit_id`
+	fieldNames := database.GetFieldNamesExcepts(limit, []string{"limit_id"})
+	placeHolders := database.GeneratePlaceholders(len(fieldNames))
+	ultimateCmd := fmt.Sprintf(command, limit.TableName(), strings.Join(fieldNames, ","), placeHolders)
+	args := database.GetScanFields(limit, fieldNames)
+	if err := db.QueryRow(ctx, ultimateCmd, args...).Scan(&limit.LimitID); err != nil {
+		return err
+	}
+	return nil
+}
+
+// DeletePending drops the limits of the period which have not taken effect at now, a new
+// limit replaces them.
+func (r *SpendLimitRepo) DeletePending(ctx context.Context, db database.Ext, accountID, period pgtype.Text, now pgtype.Timestamptz) (pgconn.CommandTag, error) {
+	e := &entities.SpendLimit{}
+	query := fmt.Sprintf(`DELETE FROM %s WHERE account_id = $1 AND period = $2 AND effective_from > $3`, e.TableName())
+	cmdTag, err := db.Exec(ctx, query, accountID, period, now)
+	if err != nil {
+		return cmdTag, fmt.Errorf("db.Exec: %w", err)
+	}
+	return cmdTag, nil
+}
+
+// ListCurrent returns, period by period, the limit in force at now and the one pending
+// after it, if any.
+func (r *SpendLimitRepo) ListCurrent(ctx context.Context, db database.Ext, accountID pgtype.Text, now pgtype.Timestamptz) ([]*entities.SpendLimit, error) {
+	e := &entities.SpendLimit{}
+	fieldName, _ := e.FieldMap()
+	query := fmt.Sprintf(`SELECT DISTINCT ON (period, effective_from > $2) %s FROM %s
+		WHERE account_id = $1
+		ORDER BY period, effective_from > $2, effective_from DESC, limit_id DESC`, strings.Join(fieldName, ", "), e.TableName())
+	limits := entities.SpendLimits{}
+	if err := database.Select(ctx, db, query, accountID, now).ScanAll(&limits); err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	return limits, nil
+}
+
+type AccountRestrictionRepo struct{}
+
+func (r *AccountRestrictionRepo) Create(ctx context.Context, db database.Ext, restriction *entities.AccountRestriction) error {
+	command := `INSERT INTO %s (%s) VALUES (%s) RETURNING restriction_id`
+	fieldNames := database.GetFieldNamesExcepts(restriction, []string{"restriction_id"})
+	placeHolders := database.GeneratePlaceholders(len(fieldNames))
+	ultimateCmd := fmt.Sprintf(command, restriction.TableName(), strings.Join(fieldNames, ","), placeHolders)
+	args := database.GetScanFields(restriction, fieldNames)
+	if err := db.QueryRow(ctx, ultimateCmd, args...).Scan(&restriction.RestrictionID); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ListActive returns the restrictions of the accounts in force at now, the longest first.
+func (r *AccountRestrictionRepo) ListActive(ctx context.Context, db database.Ext, accountIDs []string, now pgtype.Timestamptz) ([]*entities.AccountRestriction, error) {
+	e := &entities.AccountRestriction{}
+	fieldName, _ := e.FieldMap()
+	query := fmt.Sprintf(`SELECT %s FROM %s
+		WHERE account_id = ANY($1) AND starts_at <= $2 AND (ends_at IS NULL OR ends_at > $2)
+		ORDER BY ends_at DESC NULLS FIRST, restriction_id`, strings.Join(fieldName, ", "), e.TableName())
+	restrictions := entities.AccountRestrictions{}
+	if err := database.Select(ctx, db, query, accountIDs, now).ScanAll(&restrictions); err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	return restrictions, nil
+}
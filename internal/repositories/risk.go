@@ -0,0 +1,180 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/libs/database"
+
+	"github.com/jackc/pgtype"
+)
+
+// RiskRepo sums up the exposure of the sellers and the markets, the open selling value
+// of a wager is what is left of its selling price to sell while its market isn't settled.
+type RiskRepo struct{}
+
+// SellerExposure is the open selling value of the wagers of a seller in the base
+// currency, without the UnconvertedWagers in a currency no rate converts
+type SellerExposure struct {
+	SellerID          string
+	OpenWagers        int64
+	OpenSellingValue  float32
+	UnconvertedWagers int64
+}
+
+// MarketVolume is what was bought of the wagers of a market in the base currency,
+// without the UnconvertedWagers in a currency no rate converts
+type MarketVolume struct {
+	MarketID          int32
+	Wagers            int64
+	Volume            float32
+	UnconvertedWagers int64
+}
+
+// CurrencyAmount is an amount in one currency
+type CurrencyAmount struct {
+	Currency string
+	Amount   float32
+}
+
+// baseRate is the lateral rate converting the currency of the wagers w into the currency
+// of the parameter base at now, with the rate of the pair in either direction, null when
+// no rate is in effect.
+func baseRate(base string) string {
+	e := &entities.ExchangeRate{}
+	return fmt.Sprintf(`LEFT JOIN LATERAL (
+		  SELECT CASE WHEN w.currency = %[1]s THEN 1::DOUBLE PRECISION ELSE COALESCE(
+		    (SELECT rate FROM %[2]s
+		      WHERE base_currency = w.currency AND quote_currency = %[1]s AND effective_from <= now()
+		      ORDER BY effective_from DESC, rate_id DESC LIMIT 1),
+		    (SELECT 1 / rate FROM %[2]s
+		      WHERE base_currency = %[1]s AND quote_currency = w.currency AND effective_from <= now()
+		      ORDER BY effective_from DESC, rate_id DESC LIMIT 1)
+		  ) END AS rate
+		) r ON TRUE`, base, e.TableName())
+}
+
+// LockSeller serializes the transactions placing wagers of sellerID until the end of
+// the transaction of db, a seller has no row to lock.
+func (r *RiskRepo) LockSeller(ctx context.Context, db database.Ext, sellerID pgtype.Text) error {
+	if _, err := db.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('seller:' || $1))`, sellerID); err != nil {
+		return fmt.Errorf("db.Exec: %w", err)
+	}
+	return nil
+}
+
+// SumSellerExposure sums up the open selling value of the wagers of sellerID by currency.
+func (r *RiskRepo) SumSellerExposure(ctx context.Context, db database.Ext, sellerID pgtype.Text) ([]*CurrencyAmount, error) {
+	w, m := &entities.Wager{}, &entities.Market{}
+	query := fmt.Sprintf(`SELECT w.currency, SUM(GREATEST(w.selling_price - COALESCE(w.amount_sold, 0), 0))::REAL
+		FROM %s w
+		LEFT JOIN %s m ON m.market_id = w.market_id
+		WHERE w.seller_id = $1 AND w.deleted_at IS NULL AND m.status IS DISTINCT FROM $2
+		GROUP BY w.currency
+		ORDER BY w.currency`, w.TableName(), m.TableName())
+	return queryCurrencyAmounts(ctx, db, query, sellerID, entities.MarketStatusSettled)
+}
+
+// SumMarketVolume sums up what was bought of the wagers of marketID by currency.
+func (r *RiskRepo) SumMarketVolume(ctx context.Context, db database.Ext, marketID pgtype.Int4) ([]*CurrencyAmount, error) {
+	w := &entities.Wager{}
+	query := fmt.Sprintf(`SELECT currency, SUM(amount_sold)::REAL FROM %s
+		WHERE market_id = $1 AND deleted_at IS NULL AND amount_sold > 0
+		GROUP BY currency
+		ORDER BY currency`, w.TableName())
+	return queryCurrencyAmounts(ctx, db, query, marketID)
+}
+
+func queryCurrencyAmounts(ctx context.Context, db database.Ext, query string, args ...interface{}) ([]*CurrencyAmount, error) {
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("db.Query: %w", err)
+	}
+	defer rows.Close()
+	amounts := []*CurrencyAmount{}
+	for rows.Next() {
+		amount := &CurrencyAmount{}
+		if err := rows.Scan(&amount.Currency, &amount.Amount); err != nil {
+			return nil, fmt.Errorf("rows.Scan: %w", err)
+		}
+		amounts = append(amounts, amount)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return amounts, nil
+}
+
+// ListSellerExposures returns the sellers with an open selling value, the highest first,
+// a null sellerID lists every one. The values are converted to baseCurrency at the rates
+// in effect.
+func (r *RiskRepo) ListSellerExposures(ctx context.Context, db database.Ext, sellerID pgtype.Text, baseCurrency pgtype.Text, limit uint32) ([]*SellerExposure, error) {
+	w, m := &entities.Wager{}, &entities.Market{}
+	query := fmt.Sprintf(`SELECT w.seller_id, COUNT(*),
+		  COALESCE(SUM(GREATEST(w.selling_price - COALESCE(w.amount_sold, 0), 0) * r.rate), 0)::REAL AS open_selling_value,
+		  COUNT(*) - COUNT(r.rate) AS unconverted_wagers
+		FROM %s w
+		LEFT JOIN %s m ON m.market_id = w.market_id
+		%s
+		WHERE w.seller_id IS NOT NULL AND ($1::TEXT IS NULL OR w.seller_id = $1)
+		  AND w.deleted_at IS NULL AND m.status IS DISTINCT FROM $2
+		  AND COALESCE(w.amount_sold, 0) < w.selling_price
+		GROUP BY w.seller_id
+		ORDER BY open_selling_value DESC, w.seller_id
+		LIMIT $3`, w.TableName(), m.TableName(), baseRate("$4"))
+	rows, err := db.Query(ctx, query, sellerID, entities.MarketStatusSettled, limit, baseCurrency)
+	if err != nil {
+		return nil, fmt.Errorf("db.Query: %w", err)
+	}
+	defer rows.Close()
+	exposures := []*SellerExposure{}
+	for rows.Next() {
+		exposure := &SellerExposure{}
+		if err := rows.Scan(&exposure.SellerID, &exposure.OpenWagers, &exposure.OpenSellingValue, &exposure.UnconvertedWagers); err != nil {
+			return nil, fmt.Errorf("rows.Scan: %w", err)
+		}
+		exposures = append(exposures, exposure)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return exposures, nil
+}
+
+// ListMarketVolumes returns the markets with a volume, the highest first, a null
+// marketID lists every one. The volumes are converted to baseCurrency at the rates in
+// effect.
+func (r *RiskRepo) ListMarketVolumes(ctx context.Context, db database.Ext, marketID pgtype.Int4, baseCurrency pgtype.Text, limit uint32) ([]*MarketVolume, error) {
+	w := &entities.Wager{}
+	query := fmt.Sprintf(`SELECT w.market_id, COUNT(*),
+		  COALESCE(SUM(w.amount_sold * r.rate), 0)::REAL AS volume,
+		  COUNT(*) - COUNT(r.rate) AS unconverted_wagers
+		FROM %s w
+		%s
+		WHERE w.market_id IS NOT NULL AND ($1::INT IS NULL OR w.market_id = $1)
+		  AND w.deleted_at IS NULL AND w.amount_sold > 0
+		GROUP BY w.market_id
+		ORDER BY volume DESC, w.market_id
+		LIMIT $2`, w.TableName(), baseRate("$3"))
+	rows, err := db.Query(ctx, query, marketID, limit, baseCurrency)
+	if err != nil {
+		return nil, fmt.Errorf("db.Query: %w", err)
+	}
+	defer rows.Close()
+	volumes := []*MarketVolume{}
+	for rows.Next() {
+		volume := &MarketVolume{}
+		if err := rows.Scan(&volume.MarketID, &volume.Wagers, &volume.Volume, &volume.UnconvertedWagers); err != nil {
+			return nil, fmt.Errorf("rows.Scan: %w", err)
+		}
+		volumes = append(volumes, volume)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return volumes, nil
+}
@@ -0,0 +1,149 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/libs/database"
+
+	"github.com/jackc/pgtype"
+)
+
+type AccountLinkRepo struct{}
+
+// Upsert links the two accounts of link, the reason of a pair linked already is updated.
+func (r *AccountLinkRepo) Upsert(ctx context.Context, db database.Ext, link *entities.AccountLink) error {
+	command := `INSERT INTO %s (%s) VALUES (%s)
+		ON CONFLICT ON CONSTRAINT account_link_pair_key DO UPDATE SET reason = EXCLUDED.reason
+		RETURNING link_id, created_at`
+	fieldNames := database.GetFieldNamesExcepts(link, []string{"link_id"})
+	placeHolders := database.GeneratePlaceholders(len(fieldNames))
+	ultimateCmd := fmt.Sprintf(command, link.TableName(), strings.Join(fieldNames, ","), placeHolders)
+	args := database.GetScanFields(link, fieldNames)
+	if err := db.QueryRow(ctx, ultimateCmd, args...).Scan(&link.LinkID, &link.CreatedAt); err != nil {
+		return err
+	}
+	return nil
+}
+
+// List returns the links of accountID, a null accountID lists every one, latest first.
+func (r *AccountLinkRepo) List(ctx context.Context, db database.Ext, accountID pgtype.Text) ([]*entities.AccountLink, error) {
+	e := &entities.AccountLink{}
+	fieldName, _ := e.FieldMap()
+	query := fmt.Sprintf(`SELECT %s FROM %s
+		WHERE $1::TEXT IS NULL OR account_id = $1 OR linked_account_id = $1
+		ORDER BY link_id DESC`, strings.Join(fieldName, ", "), e.TableName())
+	links := entities.AccountLinks{}
+	if err := database.Select(ctx, db, query, accountID).ScanAll(&links); err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	return links, nil
+}
+
+type TradeAlertRepo struct{}
+
+func (r *TradeAlertRepo) Create(ctx context.Context, db database.Ext, alert *entities.TradeAlert) error {
+	command := `INSERT INTO %s (%s) VALUES (%s) RETURNING alert_id`
+	fieldNames := database.GetFieldNamesExcepts(alert, []string{"alert_id"})
+	placeHolders := database.GeneratePlaceholders(len(fieldNames))
+	ultimateCmd := fmt.Sprintf(command, alert.TableName(), strings.Join(fieldNames, ","), placeHolders)
+	args := database.GetScanFields(alert, fieldNames)
+	if err := db.QueryRow(ctx, ultimateCmd, args...).Scan(&alert.AlertID); err != nil {
+		return err
+	}
+	return nil
+}
+
+// TradeAlertFilter narrows down the alerts returned by List, a null field means no filter.
+type TradeAlertFilter struct {
+	Rule    pgtype.Text
+	WagerID pgtype.Int4
+	// AccountID matches the buyer and the seller of the alerts
+	AccountID pgtype.Text
+	Blocked   pgtype.Bool
+}
+
+// List returns the alerts matching filter, latest first, from the alert before beforeID
+// when it is set.
+func (r *TradeAlertRepo) List(ctx context.Context, db database.Ext, filter TradeAlertFilter, beforeID pgtype.Int4, limit uint32) ([]*entities.TradeAlert, error) {
+	e := &entities.TradeAlert{}
+	fieldName, _ := e.FieldMap()
+	query := fmt.Sprintf(`SELECT %s FROM %s
+		WHERE ($1::INT IS NULL OR alert_id<$1)
+		  AND ($3::TEXT IS NULL OR rule=$3)
+		  AND ($4::INT IS NULL OR wager_id=$4)
+		  AND ($5::TEXT IS NULL OR buyer_id=$5 OR seller_id=$5)
+		  AND ($6::BOOLEAN IS NULL OR blocked=$6)
+		ORDER BY alert_id DESC LIMIT $2 `, strings.Join(fieldName, ", "), e.TableName())
+	alerts := entities.TradeAlerts{}
+	if err := database.Select(ctx, db, query, beforeID, limit,
+		filter.Rule, filter.WagerID, filter.AccountID, filter.Blocked).ScanAll(&alerts); err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	return alerts, nil
+}
+
+// SurveillanceRepo reads the trading history the surveillance rules look at.
+type SurveillanceRepo struct{}
+
+// RecentPurchase is a purchase of a wager as the surveillance rules see it
+type RecentPurchase struct {
+	BuyerID     pgtype.Text
+	BuyingPrice float32
+}
+
+// AreLinked reports whether the two accounts are linked.
+func (r *SurveillanceRepo) AreLinked(ctx context.Context, db database.Ext, accountID, otherAccountID pgtype.Text) (bool, error) {
+	e := &entities.AccountLink{}
+	var linked bool
+	query := fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %s
+		WHERE account_id = LEAST($1::TEXT, $2::TEXT) AND linked_account_id = GREATEST($1::TEXT, $2::TEXT))`, e.TableName())
+	if err := db.QueryRow(ctx, query, accountID, otherAccountID).Scan(&linked); err != nil {
+		return false, err
+	}
+	return linked, nil
+}
+
+// CountResales counts the stakes of wagerID holderID resold since.
+func (r *SurveillanceRepo) CountResales(ctx context.Context, db database.Ext, wagerID pgtype.Int4, holderID pgtype.Text, since pgtype.Timestamptz) (int64, error) {
+	e := &entities.StakeTransfer{}
+	var resales int64
+	query := fmt.Sprintf(`SELECT COUNT(*) FROM %s
+		WHERE wager_id = $1 AND from_holder_id = $2 AND transferred_at >= $3`, e.TableName())
+	if err := db.QueryRow(ctx, query, wagerID, holderID, since).Scan(&resales); err != nil {
+		return 0, err
+	}
+	return resales, nil
+}
+
+// ListRecentPurchases returns the purchases of wagerID made since, the latest first, at
+// most limit of them.
+func (r *SurveillanceRepo) ListRecentPurchases(ctx context.Context, db database.Ext, wagerID pgtype.Int4, since pgtype.Timestamptz, limit uint32) ([]*RecentPurchase, error) {
+	e := &entities.Purchase{}
+	query := fmt.Sprintf(`SELECT buyer_id, buying_price FROM %s
+		WHERE wager_id = $1 AND bought_at >= $2 AND deleted_at IS NULL
+		ORDER BY bought_at DESC, purchase_id DESC
+		LIMIT $3`, e.TableName())
+	rows, err := db.Query(ctx, query, wagerID, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("db.Query: %w", err)
+	}
+	defer rows.Close()
+	purchases := []*RecentPurchase{}
+	for rows.Next() {
+		purchase := &RecentPurchase{}
+		if err := rows.Scan(&purchase.BuyerID, &purchase.BuyingPrice); err != nil {
+			return nil, fmt.Errorf("rows.Scan: %w", err)
+		}
+		purchases = append(purchases, purchase)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return purchases, nil
+}
@@ -26,6 +26,52 @@ func (r *WagerRepo) Create(ctx context.Context, db database.Ext, wager *entities
 	return nil
 }
 
+// CreateBatch inserts the wagers with a single multi-row insert. Their ids are taken
+// from the sequence beforehand, RETURNING doesn't promise the order of the rows.
+func (r *WagerRepo) CreateBatch(ctx context.Context, db database.Ext, wagers []*entities.Wager) error {
+	if len(wagers) == 0 {
+		return nil
+	}
+	rows, err := db.Query(ctx, `SELECT nextval('wager_id_seq')::INT FROM generate_series(1, $1)`, len(wagers))
+	if err != nil {
+		return fmt.Errorf("db.Query: %w", err)
+	}
+	defer rows.Close()
+	for _, wager := range wagers {
+		if !rows.Next() {
+			return fmt.Errorf("unable to reserve wager ids: %w", rows.Err())
+		}
+		if err := rows.Scan(&wager.WagerID); err != nil {
+			return fmt.Errorf("rows.Scan: %w", err)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("rows.Err: %w", err)
+	}
+
+	fieldNames := database.GetFieldNames(wagers[0])
+	values := make([]string, 0, len(wagers))
+	args := make([]interface{}, 0, len(wagers)*len(fieldNames))
+	for i, wager := range wagers {
+		placeHolders := make([]string, 0, len(fieldNames))
+		for j := range fieldNames {
+			placeHolders = append(placeHolders, fmt.Sprintf("$%d", i*len(fieldNames)+j+1))
+		}
+		values = append(values, "("+strings.Join(placeHolders, ", ")+")")
+		args = append(args, database.GetScanFields(wager, fieldNames)...)
+	}
+	command := fmt.Sprintf(`INSERT INTO %s (%s) VALUES %s`, wagers[0].TableName(), strings.Join(fieldNames, ","), strings.Join(values, ", "))
+	cmdTag, err := db.Exec(ctx, command, args...)
+	if err != nil {
+		return fmt.Errorf("db.Exec: %w", err)
+	}
+	if cmdTag.RowsAffected() != int64(len(wagers)) {
+		return fmt.Errorf("unable to insert wagers: %d of %d inserted", cmdTag.RowsAffected(), len(wagers))
+	}
+	return nil
+}
+
 func (r *WagerRepo) Update(ctx context.Context, db database.Ext, wager *entities.Wager) (pgconn.CommandTag, error) {
 	query := fmt.Sprintf(
 		`
@@ -45,6 +91,30 @@ func (r *WagerRepo) Update(ctx context.Context, db database.Ext, wager *entities
 	return cmdTag, nil
 }
 
+// Reprice stores the prices set by the seller of a wager along its decay, which a new
+// price ends.
+func (r *WagerRepo) Reprice(ctx context.Context, db database.Ext, wager *entities.Wager) (pgconn.CommandTag, error) {
+	query := fmt.Sprintf(
+		`
+		   UPDATE %s
+		   SET selling_price = $1, current_selling_price = $2, percentage_sold = $3,
+		     decay_type = $4, decay_floor_price = $5, decay_duration_seconds = $6, decay_step_seconds = $7,
+		     updated_at = now()
+		   WHERE
+		     wager_id = $8 AND
+		     deleted_at IS NULL
+	       `,
+		wager.TableName(),
+	)
+	cmdTag, err := db.Exec(ctx, query, wager.SellingPrice, wager.CurrentSellingPrice, wager.PercentageSold,
+		wager.DecayType, wager.DecayFloorPrice, wager.DecayDurationSeconds, wager.DecayStepSeconds, wager.WagerID)
+	if err != nil {
+		return cmdTag, fmt.Errorf("db.Exec: %w", err)
+	}
+
+	return cmdTag, nil
+}
+
 func (r *WagerRepo) Get(ctx context.Context, db database.Ext, wagerID pgtype.Int4, queryEnhancers ...QueryEnhancer) (*entities.Wager, error) {
 	getWagerCmd := `SELECT %s FROM %s WHERE wager_id = $1 AND deleted_at IS NULL`
 	wagerEnt := &entities.Wager{}
@@ -61,14 +131,49 @@ func (r *WagerRepo) Get(ctx context.Context, db database.Ext, wagerID pgtype.Int
 	return wagerEnt, nil
 }
 
-func (r *WagerRepo) List(ctx context.Context, db database.Ext, lastID pgtype.Int4, limit uint32) ([]*entities.Wager, error) {
+// WagerFilter narrows down the wagers returned by List, a null field means no filter.
+type WagerFilter struct {
+	EventID  pgtype.Int4
+	MarketID pgtype.Int4
+}
+
+// List returns at most limit wagers of the filter after the wager lastID, skipping the
+// first offset of them.
+func (r *WagerRepo) List(ctx context.Context, db database.Ext, filter WagerFilter, lastID pgtype.Int4, offset, limit uint32) ([]*entities.Wager, error) {
 	b := &entities.Wager{}
 	fieldName, _ := b.FieldMap()
-	query := fmt.Sprintf("SELECT %s FROM %s WHERE ($1::INT IS NULL OR wager_id>$1) ORDER BY wager_id LIMIT $2 ", strings.Join(fieldName, ", "), b.TableName())
+	query := fmt.Sprintf(`SELECT %s FROM %s
+		WHERE ($1::INT IS NULL OR wager_id>$1)
+		  AND ($3::INT IS NULL OR market_id=$3)
+		  AND ($4::INT IS NULL OR market_id IN (SELECT market_id FROM market WHERE event_id=$4))
+		ORDER BY wager_id LIMIT $2 OFFSET $5`, strings.Join(fieldName, ", "), b.TableName())
 	wagers := entities.Wagers{}
-	if err := database.Select(ctx, db, query, lastID, limit).ScanAll(&wagers); err != nil {
+	if err := database.Select(ctx, db, query, lastID, limit, filter.MarketID, filter.EventID, offset).ScanAll(&wagers); err != nil {
 		return nil, fmt.Errorf("%w", err)
 	}
 
 	return wagers, nil
 }
+
+// ListIDsByMarket returns the ids of every wager placed on a market.
+func (r *WagerRepo) ListIDsByMarket(ctx context.Context, db database.Ext, marketID pgtype.Int4) ([]int32, error) {
+	b := &entities.Wager{}
+	query := fmt.Sprintf(`SELECT wager_id FROM %s WHERE market_id = $1 ORDER BY wager_id`, b.TableName())
+	rows, err := db.Query(ctx, query, marketID)
+	if err != nil {
+		return nil, fmt.Errorf("db.Query: %w", err)
+	}
+	defer rows.Close()
+	wagerIDs := []int32{}
+	for rows.Next() {
+		var wagerID int32
+		if err := rows.Scan(&wagerID); err != nil {
+			return nil, fmt.Errorf("rows.Scan: %w", err)
+		}
+		wagerIDs = append(wagerIDs, wagerID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+	return wagerIDs, nil
+}
@@ -0,0 +1,52 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/libs/database"
+
+	"github.com/jackc/pgtype"
+)
+
+// WagerUpdateChannel is the channel the wager_update rows are notified on.
+const WagerUpdateChannel = "wager_updates"
+
+type WagerUpdateRepo struct{}
+
+// Create appends update to the feed. The transactions appending updates are serialized
+// until the end of the transaction of db, so the updates are committed, and notified, in
+// the order of their ids and a client resuming after an id misses none.
+func (r *WagerUpdateRepo) Create(ctx context.Context, db database.Ext, update *entities.WagerUpdate) error {
+	if _, err := db.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('wager_update'))`); err != nil {
+		return fmt.Errorf("db.Exec: %w", err)
+	}
+	command := `INSERT INTO %s (%s) VALUES (%s) RETURNING update_id`
+	fieldNames := database.GetFieldNamesExcepts(update, []string{"update_id"})
+	placeHolders := database.GeneratePlaceholders(len(fieldNames))
+	ultimateCmd := fmt.Sprintf(command, update.TableName(), strings.Join(fieldNames, ","), placeHolders)
+	args := database.GetScanFields(update, fieldNames)
+	if err := db.QueryRow(ctx, ultimateCmd, args...).Scan(&update.UpdateID); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ListAfter returns the updates which come after lastID, of one wager when wagerID
+// is not null.
+func (r *WagerUpdateRepo) ListAfter(ctx context.Context, db database.Ext, lastID pgtype.Int8, wagerID pgtype.Int4, limit uint32) ([]*entities.WagerUpdate, error) {
+	e := &entities.WagerUpdate{}
+	fieldName, _ := e.FieldMap()
+	query := fmt.Sprintf(`SELECT %s FROM %s
+		WHERE update_id > $1
+		  AND ($2::INT IS NULL OR wager_id = $2)
+		ORDER BY update_id LIMIT $3`, strings.Join(fieldName, ", "), e.TableName())
+	updates := entities.WagerUpdates{}
+	if err := database.Select(ctx, db, query, lastID, wagerID, limit).ScanAll(&updates); err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	return updates, nil
+}
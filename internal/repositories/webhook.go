@@ -0,0 +1,108 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/libs/database"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgtype"
+)
+
+type WebhookRepo struct{}
+
+func (r *WebhookRepo) Create(ctx context.Context, db database.Ext, webhook *entities.Webhook) error {
+	command := `INSERT INTO %s (%s) VALUES (%s) RETURNING webhook_id`
+	fieldNames := database.GetFieldNamesExcepts(webhook, []string{"webhook_id"})
+	placeHolders := database.GeneratePlaceholders(len(fieldNames))
+	ultimateCmd := fmt.Sprintf(command, webhook.TableName(), strings.Join(fieldNames, ","), placeHolders)
+	args := database.GetScanFields(webhook, fieldNames)
+	if err := db.QueryRow(ctx, ultimateCmd, args...).Scan(&webhook.WebhookID); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *WebhookRepo) Get(ctx context.Context, db database.Ext, webhookID pgtype.Int4, queryEnhancers ...QueryEnhancer) (*entities.Webhook, error) {
+	getWebhookCmd := `SELECT %s FROM %s WHERE webhook_id = $1`
+	webhookEnt := &entities.Webhook{}
+	fields, values := webhookEnt.FieldMap()
+	for _, e := range queryEnhancers {
+		e(&getWebhookCmd)
+	}
+
+	err := db.QueryRow(ctx, fmt.Sprintf(getWebhookCmd, strings.Join(fields, ", "), webhookEnt.TableName()), &webhookID).Scan(values...)
+	if err != nil {
+		return nil, err
+	}
+
+	return webhookEnt, nil
+}
+
+// ListActiveBySeller returns the active webhooks of a seller subscribed to eventType.
+func (r *WebhookRepo) ListActiveBySeller(ctx context.Context, db database.Ext, sellerID pgtype.Text, eventType pgtype.Text) ([]*entities.Webhook, error) {
+	e := &entities.Webhook{}
+	fieldName, _ := e.FieldMap()
+	query := fmt.Sprintf(`SELECT %s FROM %s
+		WHERE seller_id = $1 AND status = '%s' AND $2 = ANY(event_types)
+		ORDER BY webhook_id`, strings.Join(fieldName, ", "), e.TableName(), entities.WebhookStatusActive)
+	webhooks := entities.Webhooks{}
+	if err := database.Select(ctx, db, query, sellerID, eventType).ScanAll(&webhooks); err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	return webhooks, nil
+}
+
+// UpdateStatus enables or disables a webhook, enabling it forgets its past failures.
+func (r *WebhookRepo) UpdateStatus(ctx context.Context, db database.Ext, webhook *entities.Webhook) (pgconn.CommandTag, error) {
+	query := fmt.Sprintf(
+		`
+		   UPDATE %s
+		   SET status = $1, consecutive_failures = $2, disabled_at = $3, updated_at = now()
+		   WHERE webhook_id = $4
+	       `,
+		webhook.TableName(),
+	)
+	cmdTag, err := db.Exec(ctx, query, webhook.Status, webhook.ConsecutiveFailures, webhook.DisabledAt, webhook.WebhookID)
+	if err != nil {
+		return cmdTag, fmt.Errorf("db.Exec: %w", err)
+	}
+
+	return cmdTag, nil
+}
+
+func (r *WebhookRepo) RecordSuccess(ctx context.Context, db database.Ext, webhookID pgtype.Int4) (pgconn.CommandTag, error) {
+	cmdTag, err := db.Exec(ctx, `UPDATE webhook SET consecutive_failures = 0, updated_at = now() WHERE webhook_id = $1 AND consecutive_failures > 0`, webhookID)
+	if err != nil {
+		return cmdTag, fmt.Errorf("db.Exec: %w", err)
+	}
+
+	return cmdTag, nil
+}
+
+// RecordFailure counts a failed delivery of a webhook and disables it once it failed
+// disableAfter times in a row, it returns whether the webhook is disabled.
+func (r *WebhookRepo) RecordFailure(ctx context.Context, db database.Ext, webhookID pgtype.Int4, disableAfter int32, now pgtype.Timestamptz) (bool, error) {
+	query := fmt.Sprintf(
+		`
+		   UPDATE webhook
+		   SET consecutive_failures = consecutive_failures + 1,
+		       status = CASE WHEN consecutive_failures + 1 >= $2 THEN '%[1]s' ELSE status END,
+		       disabled_at = CASE WHEN consecutive_failures + 1 >= $2 AND status <> '%[1]s' THEN $3 ELSE disabled_at END,
+		       updated_at = $3
+		   WHERE webhook_id = $1
+		   RETURNING status
+	       `,
+		entities.WebhookStatusDisabled,
+	)
+	var status string
+	if err := db.QueryRow(ctx, query, webhookID, disableAfter, now).Scan(&status); err != nil {
+		return false, fmt.Errorf("db.QueryRow: %w", err)
+	}
+
+	return status == entities.WebhookStatusDisabled, nil
+}
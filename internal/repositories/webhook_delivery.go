@@ -0,0 +1,91 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/libs/database"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgtype"
+)
+
+type WebhookDeliveryRepo struct{}
+
+func (r *WebhookDeliveryRepo) Create(ctx context.Context, db database.Ext, delivery *entities.WebhookDelivery) error {
+	command := `INSERT INTO %s (%s) VALUES (%s) RETURNING delivery_id`
+	fieldNames := database.GetFieldNamesExcepts(delivery, []string{"delivery_id"})
+	placeHolders := database.GeneratePlaceholders(len(fieldNames))
+	ultimateCmd := fmt.Sprintf(command, delivery.TableName(), strings.Join(fieldNames, ","), placeHolders)
+	args := database.GetScanFields(delivery, fieldNames)
+	if err := db.QueryRow(ctx, ultimateCmd, args...).Scan(&delivery.DeliveryID); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ClaimDue leases until lockedUntil the pending deliveries of active webhooks whose
+// next attempt is due and returns them. The deliveries leased or locked by another
+// dispatcher are skipped so dispatchers work side by side.
+func (r *WebhookDeliveryRepo) ClaimDue(ctx context.Context, db database.Ext, now, lockedUntil pgtype.Timestamptz, limit uint32) ([]*entities.WebhookDelivery, error) {
+	e := &entities.WebhookDelivery{}
+	fieldName, _ := e.FieldMap()
+	query := fmt.Sprintf(`UPDATE %[2]s SET locked_until = $2 WHERE delivery_id IN (
+			SELECT d.delivery_id FROM %[2]s d JOIN webhook w ON w.webhook_id = d.webhook_id
+			WHERE d.status = '%[3]s' AND d.next_attempt_at <= $1 AND (d.locked_until IS NULL OR d.locked_until <= $1)
+			  AND w.status = '%[4]s'
+			ORDER BY d.next_attempt_at, d.delivery_id LIMIT $3
+			FOR UPDATE OF d SKIP LOCKED)
+		RETURNING %[1]s`,
+		strings.Join(fieldName, ", "), e.TableName(), entities.WebhookDeliveryStatusPending, entities.WebhookStatusActive)
+	deliveries := entities.WebhookDeliveries{}
+	if err := database.Select(ctx, db, query, now, lockedUntil, limit).ScanAll(&deliveries); err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	return deliveries, nil
+}
+
+// Release ends the lease of a delivery which was not attempted.
+func (r *WebhookDeliveryRepo) Release(ctx context.Context, db database.Ext, deliveryID pgtype.Int8) (pgconn.CommandTag, error) {
+	command := `UPDATE webhook_delivery SET locked_until = NULL WHERE delivery_id = $1`
+	return db.Exec(ctx, command, deliveryID)
+}
+
+// ListByWebhook returns the delivery log of a webhook, latest first, from the delivery
+// before beforeID when it is set.
+func (r *WebhookDeliveryRepo) ListByWebhook(ctx context.Context, db database.Ext, webhookID pgtype.Int4, beforeID pgtype.Int8, limit uint32) ([]*entities.WebhookDelivery, error) {
+	e := &entities.WebhookDelivery{}
+	fieldName, _ := e.FieldMap()
+	query := fmt.Sprintf(`SELECT %s FROM %s
+		WHERE webhook_id = $1 AND ($2::BIGINT IS NULL OR delivery_id < $2)
+		ORDER BY delivery_id DESC LIMIT $3`, strings.Join(fieldName, ", "), e.TableName())
+	deliveries := entities.WebhookDeliveries{}
+	if err := database.Select(ctx, db, query, webhookID, beforeID, limit).ScanAll(&deliveries); err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	return deliveries, nil
+}
+
+// UpdateAttempt records the outcome of an attempt to deliver and ends its lease.
+func (r *WebhookDeliveryRepo) UpdateAttempt(ctx context.Context, db database.Ext, delivery *entities.WebhookDelivery) (pgconn.CommandTag, error) {
+	query := fmt.Sprintf(
+		`
+		   UPDATE %s
+		   SET status = $1, attempts = $2, next_attempt_at = $3, response_status = $4, last_error = $5, delivered_at = $6,
+		       locked_until = NULL
+		   WHERE delivery_id = $7
+	       `,
+		delivery.TableName(),
+	)
+	cmdTag, err := db.Exec(ctx, query, delivery.Status, delivery.Attempts, delivery.NextAttemptAt,
+		delivery.ResponseStatus, delivery.LastError, delivery.DeliveredAt, delivery.DeliveryID)
+	if err != nil {
+		return cmdTag, fmt.Errorf("db.Exec: %w", err)
+	}
+
+	return cmdTag, nil
+}
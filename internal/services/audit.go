@@ -0,0 +1,279 @@
+package services
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/internal/models"
+	"github.com/wager-api/internal/repositories"
+	"github.com/wager-api/libs/database"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/jackc/pgtype"
+)
+
+const (
+	// ActorHeader names who sends a request, the gateway in front of the API sets it
+	ActorHeader = "X-Actor-ID"
+
+	defaultAuditLogLimit = 50
+	maxAuditLogLimit     = 500
+)
+
+// auditInfo is who sent the request a change is made for, it is recorded along the change.
+// The actor is only verified when the request was authenticated with a token, the
+// actor header of an anonymous request is whatever the client claims.
+type auditInfo struct {
+	actor     string
+	verified  bool
+	requestID string
+	clientIP  string
+}
+
+func withAuditInfo(ctx context.Context, info auditInfo) context.Context {
+	return context.WithValue(ctx, auditInfoKey, info)
+}
+
+func auditInfoFromContext(ctx context.Context) auditInfo {
+	info, _ := ctx.Value(auditInfoKey).(auditInfo)
+	return info
+}
+
+// withAuthenticatedActor marks the actor of ctx as verified once the request is
+// authenticated, principal stands for the actor when the request names none.
+func withAuthenticatedActor(ctx context.Context, principal string) context.Context {
+	info := auditInfoFromContext(ctx)
+	info.verified = true
+	if info.actor == "" {
+		info.actor = principal
+	}
+	return withAuditInfo(ctx, info)
+}
+
+// auditMiddleware puts the actor, request id and client ip of the request into its
+// context for the audit log.
+func auditMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := middleware.GetReqID(r.Context())
+		if requestID == "" {
+			requestID = r.Header.Get(middleware.RequestIDHeader)
+		}
+		clientIP, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			clientIP = r.RemoteAddr
+		}
+		ctx := withAuditInfo(r.Context(), auditInfo{
+			actor:     strings.TrimSpace(r.Header.Get(ActorHeader)),
+			requestID: requestID,
+			clientIP:  clientIP,
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// snapshotEntity renders the columns of a record as a JSON object, at once so the
+// snapshot doesn't follow the changes made to the record afterwards.
+func snapshotEntity(e database.Entity) (pgtype.JSONB, error) {
+	fields, values := e.FieldMap()
+	snapshot := make(map[string]interface{}, len(fields))
+	for i, field := range fields {
+		value := values[i]
+		// a column never loaded is left out instead of failing the encoding
+		if v, ok := value.(pgtype.Value); ok && v.Get() == pgtype.Undefined {
+			continue
+		}
+		if valuer, ok := value.(driver.Valuer); ok {
+			v, err := valuer.Value()
+			if err != nil {
+				return pgtype.JSONB{}, err
+			}
+			value = v
+		}
+		snapshot[field] = value
+	}
+	var snapshotJSON pgtype.JSONB
+	if err := snapshotJSON.Set(snapshot); err != nil {
+		return pgtype.JSONB{}, err
+	}
+	return snapshotJSON, nil
+}
+
+// newAuditLog builds the audit entry of a change made for the request of ctx, a null
+// before is a creation.
+func newAuditLog(ctx context.Context, action string, wagerID, purchaseID pgtype.Int4, before, after pgtype.JSONB, now time.Time) *entities.AuditLog {
+	info := auditInfoFromContext(ctx)
+	auditLog := &entities.AuditLog{}
+	database.AllNullEntity(auditLog)
+	_ = auditLog.Action.Set(action)
+	auditLog.WagerID = wagerID
+	auditLog.PurchaseID = purchaseID
+	auditLog.Before = before
+	auditLog.After = after
+	_ = auditLog.CreatedAt.Set(now)
+	_ = auditLog.ActorVerified.Set(info.verified)
+	for _, field := range []struct {
+		value string
+		dst   *pgtype.Text
+	}{
+		{info.actor, &auditLog.Actor},
+		{info.requestID, &auditLog.RequestID},
+		{info.clientIP, &auditLog.ClientIP},
+	} {
+		if field.value != "" {
+			_ = field.dst.Set(field.value)
+		}
+	}
+	return auditLog
+}
+
+// newEntityAuditLog builds the audit entry of a change made to a record which is neither
+// a wager nor an account, the action tells which kind of record entityID is.
+func newEntityAuditLog(ctx context.Context, action string, entityID int32, before, after pgtype.JSONB, now time.Time) *entities.AuditLog {
+	auditLog := newAuditLog(ctx, action, pgtype.Int4{Status: pgtype.Null}, pgtype.Int4{Status: pgtype.Null}, before, after, now)
+	_ = auditLog.EntityID.Set(strconv.Itoa(int(entityID)))
+	return auditLog
+}
+
+// AuditService serves the audit log to the admins, read only.
+type AuditService struct {
+	DB           database.Ext
+	AuditLogRepo interface {
+		List(ctx context.Context, db database.Ext, filter repositories.AuditLogFilter, beforeID pgtype.Int8, limit uint32) ([]*entities.AuditLog, error)
+	}
+}
+
+func convertAuditLogPg2Domain(auditLog *entities.AuditLog) *models.AuditLog {
+	var wagerID, purchaseID *int
+	if auditLog.WagerID.Status == pgtype.Present {
+		id := int(auditLog.WagerID.Int)
+		wagerID = &id
+	}
+	if auditLog.PurchaseID.Status == pgtype.Present {
+		id := int(auditLog.PurchaseID.Int)
+		purchaseID = &id
+	}
+	return &models.AuditLog{
+		ID:            auditLog.AuditID.Int,
+		Actor:         auditLog.Actor.String,
+		ActorVerified: auditLog.ActorVerified.Bool,
+		Action:        auditLog.Action.String,
+		WagerID:       wagerID,
+		PurchaseID:    purchaseID,
+		AccountID:     auditLog.AccountID.String,
+		EntityID:      auditLog.EntityID.String,
+		Before:        auditLog.Before.Bytes,
+		After:         auditLog.After.Bytes,
+		RequestID:     auditLog.RequestID.String,
+		ClientIP:      auditLog.ClientIP.String,
+		CreatedAt:     auditLog.CreatedAt.Time,
+	}
+}
+
+// parseAuditLogQuery reads the filters of the audit log:
+// ?actor=&action=&wager_id=&purchase_id=&account_id=&entity_id=&from=&to=&before_id=&limit=
+func parseAuditLogQuery(req *http.Request) (repositories.AuditLogFilter, pgtype.Int8, int, error) {
+	query := req.URL.Query()
+	filter := repositories.AuditLogFilter{}
+	_ = filter.Actor.Set(nil)
+	_ = filter.Action.Set(nil)
+	_ = filter.WagerID.Set(nil)
+	_ = filter.PurchaseID.Set(nil)
+	_ = filter.AccountID.Set(nil)
+	_ = filter.EntityID.Set(nil)
+	_ = filter.From.Set(nil)
+	_ = filter.To.Set(nil)
+	var beforeID pgtype.Int8
+	_ = beforeID.Set(nil)
+	limit := defaultAuditLogLimit
+
+	if actor := query.Get("actor"); actor != "" {
+		_ = filter.Actor.Set(actor)
+	}
+	if action := query.Get("action"); action != "" {
+		_ = filter.Action.Set(action)
+	}
+	if accountID := query.Get("account_id"); accountID != "" {
+		_ = filter.AccountID.Set(accountID)
+	}
+	if entityID := query.Get("entity_id"); entityID != "" {
+		_ = filter.EntityID.Set(entityID)
+	}
+	for _, param := range []struct {
+		name string
+		dst  *pgtype.Int4
+	}{
+		{"wager_id", &filter.WagerID},
+		{"purchase_id", &filter.PurchaseID},
+	} {
+		if value := query.Get(param.name); value != "" {
+			id, err := strconv.Atoi(value)
+			if err != nil || id <= 0 {
+				return filter, beforeID, 0, fmt.Errorf("the %s must be a positive integer", param.name)
+			}
+			_ = param.dst.Set(id)
+		}
+	}
+	for _, param := range []struct {
+		name string
+		dst  *pgtype.Timestamptz
+	}{
+		{"from", &filter.From},
+		{"to", &filter.To},
+	} {
+		if value := query.Get(param.name); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return filter, beforeID, 0, fmt.Errorf("the %s must be a RFC 3339 date", param.name)
+			}
+			_ = param.dst.Set(t)
+		}
+	}
+	if value := query.Get("before_id"); value != "" {
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || id <= 0 {
+			return filter, beforeID, 0, fmt.Errorf("the before_id must be a positive integer")
+		}
+		_ = beforeID.Set(id)
+	}
+	if value := query.Get("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit <= 0 || limit > maxAuditLogLimit {
+			return filter, beforeID, 0, fmt.Errorf("the limit must be an integer between 1 and %d", maxAuditLogLimit)
+		}
+	}
+	return filter, beforeID, limit, nil
+}
+
+// ListAuditLogs returns the audit entries matching the filters of the query, latest first.
+func (s *AuditService) ListAuditLogs(resp http.ResponseWriter, req *http.Request) {
+	filter, beforeID, limit, err := parseAuditLogQuery(req)
+	if err != nil {
+		resp.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	auditLogs, err := s.AuditLogRepo.List(req.Context(), s.DB, filter, beforeID, uint32(limit))
+	if err != nil {
+		resp.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": "unable to list audit logs",
+		})
+		return
+	}
+	auditLogModels := make([]*models.AuditLog, 0, len(auditLogs))
+	for _, auditLog := range auditLogs {
+		auditLogModels = append(auditLogModels, convertAuditLogPg2Domain(auditLog))
+	}
+	resp.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(resp).Encode(auditLogModels)
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/internal/models"
+	"github.com/wager-api/internal/repositories"
+	"github.com/wager-api/libs/database"
+	mock_database "github.com/wager-api/mocks/libs/database"
+	mock_repositories "github.com/wager-api/mocks/repositories"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgtype"
+)
+
+func Test_snapshotEntity(t *testing.T) {
+	t.Parallel()
+	wager := &entities.Wager{
+		WagerID:             database.Int4(1),
+		TotalWagerValue:     database.Float4(50),
+		CurrentSellingPrice: database.Float4(40.5),
+		SellerID:            pgtype.Text{Status: pgtype.Null},
+	}
+	snapshot, err := snapshotEntity(wager)
+	assert.NoError(t, err)
+	fields := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(snapshot.Bytes, &fields))
+	assert.Equal(t, float64(1), fields["wager_id"])
+	assert.Equal(t, float64(50), fields["total_wager_value"])
+	assert.Equal(t, 40.5, fields["current_selling_price"])
+	assert.Contains(t, fields, "seller_id")
+	assert.Nil(t, fields["seller_id"])
+	assert.NotContains(t, fields, "odds", "the columns never loaded are left out")
+
+	// the snapshot doesn't follow the record
+	wager.CurrentSellingPrice = database.Float4(10)
+	assert.NoError(t, json.Unmarshal(snapshot.Bytes, &fields))
+	assert.Equal(t, 40.5, fields["current_selling_price"])
+}
+
+func Test_auditMiddleware(t *testing.T) {
+	t.Parallel()
+	var info auditInfo
+	handler := auditMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		info = auditInfoFromContext(r.Context())
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/wagers", nil)
+	req.RemoteAddr = "10.0.0.7:51234"
+	req.Header.Set(ActorHeader, " user-42 ")
+	req.Header.Set("X-Request-Id", "req-1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, auditInfo{actor: "user-42", requestID: "req-1", clientIP: "10.0.0.7"}, info)
+
+	auditLog := newAuditLog(withAuditInfo(context.Background(), info), entities.AuditActionWagerPlace, database.Int4(1), pgtype.Int4{Status: pgtype.Null}, pgtype.JSONB{Status: pgtype.Null}, pgtype.JSONB{Bytes: []byte(`{}`), Status: pgtype.Present}, time.Now())
+	assert.Equal(t, database.Text("user-42"), auditLog.Actor)
+	assert.Equal(t, database.Text("req-1"), auditLog.RequestID)
+	assert.Equal(t, database.Text("10.0.0.7"), auditLog.ClientIP)
+	assert.Equal(t, pgtype.Null, auditLog.Before.Status)
+	assert.Equal(t, database.Bool(false), auditLog.ActorVerified, "the header of an anonymous request is only a claim")
+
+	// the admin token verifies the actor, and stands for it when the request names none
+	handler = auditMiddleware(adminAuthMiddleware([]string{"admin-token"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		info = auditInfoFromContext(r.Context())
+	})))
+	req = httptest.NewRequest(http.MethodPost, "/admin/exchange-rates", nil)
+	req.Header.Set("Authorization", "Bearer admin-token")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, "admin", info.actor)
+	assert.True(t, info.verified)
+	req.Header.Set(ActorHeader, "ops-1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, "ops-1", info.actor)
+	assert.True(t, info.verified)
+}
+
+func Test_parseAuditLogQuery(t *testing.T) {
+	t.Parallel()
+	req := httptest.NewRequest(http.MethodGet, "/admin/audit-logs?actor=user-42&wager_id=3&from=2024-01-02T03:04:05Z&before_id=9&limit=10", nil)
+	filter, beforeID, limit, err := parseAuditLogQuery(req)
+	assert.NoError(t, err)
+	assert.Equal(t, database.Text("user-42"), filter.Actor)
+	assert.Equal(t, pgtype.Null, filter.Action.Status)
+	assert.Equal(t, database.Int4(3), filter.WagerID)
+	assert.Equal(t, pgtype.Null, filter.PurchaseID.Status)
+	assert.True(t, filter.From.Time.Equal(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)))
+	assert.Equal(t, pgtype.Null, filter.To.Status)
+	assert.Equal(t, database.Int8(9), beforeID)
+	assert.Equal(t, 10, limit)
+
+	for query, expected := range map[string]string{
+		"purchase_id=x":    "the purchase_id must be a positive integer",
+		"to=yesterday":     "the to must be a RFC 3339 date",
+		"before_id=0":      "the before_id must be a positive integer",
+		"limit=501":        "the limit must be an integer between 1 and 500",
+		"wager_id=-1&to=x": "the wager_id must be a positive integer",
+	} {
+		_, _, _, err := parseAuditLogQuery(httptest.NewRequest(http.MethodGet, "/admin/audit-logs?"+query, nil))
+		assert.EqualError(t, err, expected, query)
+	}
+}
+
+func Test_ListAuditLogs(t *testing.T) {
+	t.Parallel()
+	db := &mock_database.Ext{}
+	auditLogRepo := &mock_repositories.MockAuditLogRepo{}
+	auditLogRepo.On("List", mock.Anything, db, mock.MatchedBy(func(filter repositories.AuditLogFilter) bool {
+		return filter.Action.String == entities.AuditActionWagerBuy
+	}), mock.Anything, uint32(defaultAuditLogLimit)).Once().Return([]*entities.AuditLog{{
+		AuditID:    database.Int8(2),
+		Actor:      database.Text("user-42"),
+		Action:     database.Text(entities.AuditActionWagerBuy),
+		WagerID:    database.Int4(1),
+		PurchaseID: database.Int4(5),
+		Before:     pgtype.JSONB{Bytes: []byte(`{"id":1}`), Status: pgtype.Present},
+		After:      pgtype.JSONB{Bytes: []byte(`{"id":1}`), Status: pgtype.Present},
+		CreatedAt:  database.Timestamptz(time.Now()),
+	}}, nil)
+	mux := chi.NewMux()
+	NewAuditHandler(mux, &AuditService{DB: db, AuditLogRepo: auditLogRepo}, []string{"admin-token"})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/audit-logs?action=wager.buy", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/admin/audit-logs?action=wager.buy", nil)
+	req.Header.Set("Authorization", "Bearer admin-token")
+	mux.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	auditLogs := []*models.AuditLog{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&auditLogs))
+	if assert.Len(t, auditLogs, 1) {
+		assert.Equal(t, "user-42", auditLogs[0].Actor)
+		assert.Equal(t, 5, *auditLogs[0].PurchaseID)
+		assert.JSONEq(t, `{"id":1}`, string(auditLogs[0].Before))
+	}
+	auditLogRepo.AssertExpectations(t)
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// matchToken compares token with every expected token in constant time, no expected
+// token matches nothing.
+func matchToken(token string, tokens []string) bool {
+	if token == "" {
+		return false
+	}
+	for _, expected := range tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+func bearerToken(req *http.Request) string {
+	return strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+}
+
+// adminAuthPrincipal is the audit actor of an admin request which names no actor.
+const adminAuthPrincipal = "admin"
+
+// adminAuthMiddleware only lets through the requests with an `Authorization: Bearer`
+// header carrying one of tokens, their actor is then verified for the audit log.
+func adminAuthMiddleware(tokens []string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !matchToken(bearerToken(r), tokens) {
+				w.WriteHeader(http.StatusUnauthorized)
+				_ = json.NewEncoder(w).Encode(map[string]string{
+					"error": "unable to authenticate the request",
+				})
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(withAuthenticatedActor(r.Context(), adminAuthPrincipal)))
+		})
+	}
+}
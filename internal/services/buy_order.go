@@ -0,0 +1,349 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/internal/models"
+	"github.com/wager-api/internal/repositories"
+	"github.com/wager-api/libs/database"
+	"github.com/wager-api/libs/logs"
+
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+)
+
+// maxBuyOrderLifetime bounds how far in the future a buy order can expire
+const maxBuyOrderLifetime = 90 * 24 * time.Hour
+
+func validatePlaceBuyOrderReq(req *models.PlaceBuyOrderRequest, now time.Time) error {
+	if req.LimitPrice <= 0 {
+		return fmt.Errorf("the limit_price must be a positive decimal")
+	}
+	if req.ExpiresAt == nil || !req.ExpiresAt.After(now) {
+		return fmt.Errorf("the expires_at must be a date in the future")
+	}
+	if req.ExpiresAt.After(now.Add(maxBuyOrderLifetime)) {
+		return fmt.Errorf("the expires_at must be within %d days", int(maxBuyOrderLifetime/(24*time.Hour)))
+	}
+	if len(req.BuyerID) > maxSellerIDLength {
+		return fmt.Errorf("the buyer_id must be at most %d characters long", maxSellerIDLength)
+	}
+	return nil
+}
+
+func convertBuyOrderPg2Domain(buyOrder *entities.BuyOrder) *models.BuyOrder {
+	var purchaseID *int
+	if buyOrder.PurchaseID.Status == pgtype.Present {
+		id := int(buyOrder.PurchaseID.Int)
+		purchaseID = &id
+	}
+	var filledAt *time.Time
+	if buyOrder.FilledAt.Status == pgtype.Present {
+		filledAt = &buyOrder.FilledAt.Time
+	}
+	return &models.BuyOrder{
+		ID:         int(buyOrder.BuyOrderID.Int),
+		WagerID:    int(buyOrder.WagerID.Int),
+		BuyerID:    buyOrder.BuyerID.String,
+		LimitPrice: buyOrder.LimitPrice.Float,
+		Status:     buyOrder.Status.String,
+		PurchaseID: purchaseID,
+		ExpiresAt:  buyOrder.ExpiresAt.Time,
+		FilledAt:   filledAt,
+		CreatedAt:  buyOrder.CreatedAt.Time,
+	}
+}
+
+// matchBuyOrders fills the open orders of a locked wager which its current selling
+// price satisfies, the highest limit first, each buying at that price or at what is
+// left unsold when it is less. It is called in the transaction which lowered the price,
+// the orders are left open once the wager is sold out, while the market of the wager
+// doesn't accept sales, no rate converts to the currency of their buyer or they would
+// go over a risk limit. Each order is filled in a savepoint of tx: one failing is rolled
+// back, logged and left open without failing the change of price.
+func (s *WagerService) matchBuyOrders(ctx context.Context, tx database.Ext, wager *entities.Wager, now time.Time) error {
+	buyOrders, err := s.BuyOrderRepo.ListMatching(ctx, tx, wager.WagerID, wager.CurrentSellingPrice, database.Timestamptz(now))
+	if err != nil {
+		return fmt.Errorf("unable to list buy orders")
+	}
+	if len(buyOrders) == 0 {
+		return nil
+	}
+	if err := s.checkWagerSalesOpen(ctx, tx, wager.MarketID, now); err != nil {
+		if errors.Is(err, errWagerSalesClosed) {
+			return nil
+		}
+		return err
+	}
+	for _, buyOrder := range buyOrders {
+		remaining := roundFloat(wager.SellingPrice.Float - wager.AmountSold.Float)
+		if remaining <= 0 {
+			break
+		}
+		// the anonymous orders placed before the buyer was required are never filled
+		if s.RequireBuyer && buyOrder.BuyerID.Status != pgtype.Present {
+			continue
+		}
+		buyingPrice := wager.CurrentSellingPrice.Float
+		if buyingPrice > remaining {
+			buyingPrice = remaining
+		}
+		// the purchase updates the wager, it is restored when the savepoint is rolled back
+		unfilled := *wager
+		savepoint, err := tx.Begin(ctx)
+		if err != nil {
+			return fmt.Errorf("unable to begin savepoint")
+		}
+		if err := s.fillBuyOrder(ctx, savepoint, wager, buyOrder, buyingPrice, now); err != nil {
+			_ = savepoint.Rollback(ctx)
+			*wager = unfilled
+			logs.Logger.Errorw("unable to fill buy order", "buy_order_id", buyOrder.BuyOrderID.Int, "error", err)
+			continue
+		}
+		if err := savepoint.Commit(ctx); err != nil {
+			return fmt.Errorf("unable to release savepoint")
+		}
+	}
+	return nil
+}
+
+// fillBuyOrder buys the wager at buyingPrice on behalf of the buyer of the order in tx.
+// An order which has to wait is left open and a blocked one is cancelled, neither is an
+// error.
+func (s *WagerService) fillBuyOrder(ctx context.Context, tx database.Ext, wager *entities.Wager, buyOrder *entities.BuyOrder, buyingPrice float32, now time.Time) error {
+	// the purchase is made on behalf of the buyer of the order
+	info := auditInfoFromContext(ctx)
+	info.actor = buyOrder.BuyerID.String
+	purchase, _, err := s.executePurchase(withAuditInfo(ctx, info), tx, wager, buyingPrice, buyOrder.BuyerID, now)
+	if err != nil {
+		// nothing is written before the conversion and the limit checks, the order
+		// waits for a rate, for room under the limits or for the end of a restriction
+		if errors.Is(err, errNoExchangeRate) || errors.Is(err, errRiskLimitExceeded) ||
+			errors.Is(err, errSpendLimitReached) || errors.Is(err, errAccountRestricted) {
+			return nil
+		}
+		// a blocked order is cancelled so it isn't flagged again on every purchase
+		var blockedErr *tradeBlockedError
+		if errors.As(err, &blockedErr) {
+			if err := s.recordTradeAlerts(ctx, tx, blockedErr.alerts, pgtype.Int4{Status: pgtype.Null}); err != nil {
+				return err
+			}
+			if _, err := s.BuyOrderRepo.Cancel(ctx, tx, buyOrder.BuyOrderID, database.Timestamptz(now)); err != nil {
+				return fmt.Errorf("unable to cancel buy order")
+			}
+			return nil
+		}
+		return err
+	}
+	cmdTag, err := s.BuyOrderRepo.Fill(ctx, tx, buyOrder.BuyOrderID, purchase.PurchaseID, database.Timestamptz(now))
+	if err != nil {
+		return fmt.Errorf("unable to fill buy order")
+	}
+	if cmdTag.RowsAffected() != 1 {
+		return fmt.Errorf("unable to fill buy order: no row affected")
+	}
+	return nil
+}
+
+// auditBuyOrder records the change of a buy order made in tx, after is snapshot at once.
+func (s *WagerService) auditBuyOrder(ctx context.Context, tx database.Ext, action string, before pgtype.JSONB, buyOrder *entities.BuyOrder, now time.Time) error {
+	after, err := snapshotEntity(buyOrder)
+	if err != nil {
+		return fmt.Errorf("unable to snapshot buy order record")
+	}
+	auditLog := newEntityAuditLog(ctx, action, buyOrder.BuyOrderID.Int, before, after, now)
+	auditLog.WagerID = buyOrder.WagerID
+	if err := s.AuditLogRepo.Create(ctx, tx, auditLog); err != nil {
+		return fmt.Errorf("unable to create audit log")
+	}
+	return nil
+}
+
+// PlaceBuyOrder places a standing order on a wager, it is filled at once when the
+// current selling price of the wager is at most its limit already.
+func (s *WagerService) PlaceBuyOrder(ctx context.Context, wagerID int, placeBuyOrderRequest *models.PlaceBuyOrderRequest) (*models.BuyOrder, error) {
+	now := time.Now()
+	if err := validatePlaceBuyOrderReq(placeBuyOrderRequest, now); err != nil {
+		return nil, &validationError{err}
+	}
+	if s.RequireBuyer && strings.TrimSpace(placeBuyOrderRequest.BuyerID) == "" {
+		return nil, &validationError{fmt.Errorf("the buyer_id must be given")}
+	}
+	buyOrder := &entities.BuyOrder{}
+	database.AllNullEntity(buyOrder)
+	if buyerID := strings.TrimSpace(placeBuyOrderRequest.BuyerID); buyerID != "" {
+		_ = buyOrder.BuyerID.Set(buyerID)
+	}
+	_ = buyOrder.LimitPrice.Set(placeBuyOrderRequest.LimitPrice)
+	_ = buyOrder.Status.Set(entities.BuyOrderStatusOpen)
+	_ = buyOrder.ExpiresAt.Set(*placeBuyOrderRequest.ExpiresAt)
+	_ = buyOrder.CreatedAt.Set(now)
+	_ = buyOrder.UpdatedAt.Set(now)
+	if err := database.ExecInTx(ctx, s.DB, func(ctx context.Context, tx pgx.Tx) error {
+		wager, err := s.WagerRepo.Get(ctx, tx, database.Int4(int32(wagerID)), repositories.WithUpdateLock())
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return errWagerNotFound
+			}
+			return fmt.Errorf("unable to get wager information")
+		}
+		// an order is only placed on a wager which can still be bought
+		if err := s.checkWagerSalesOpen(ctx, tx, wager.MarketID, now); err != nil {
+			return err
+		}
+		applyPriceDecay(wager, now)
+		buyOrder.WagerID = wager.WagerID
+		if err := s.BuyOrderRepo.Create(ctx, tx, buyOrder); err != nil {
+			return fmt.Errorf("unable to create buy order")
+		}
+		if err := s.auditBuyOrder(ctx, tx, entities.AuditActionBuyOrderPlace, pgtype.JSONB{Status: pgtype.Null}, buyOrder, now); err != nil {
+			return err
+		}
+		if buyOrder.LimitPrice.Float < wager.CurrentSellingPrice.Float {
+			return nil
+		}
+		if err := s.matchBuyOrders(ctx, tx, wager, now); err != nil {
+			return err
+		}
+		if buyOrder, err = s.BuyOrderRepo.Get(ctx, tx, buyOrder.BuyOrderID); err != nil {
+			return fmt.Errorf("unable to get buy order")
+		}
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("unable to place buy order: %w", err)
+	}
+	if s.Webhooks != nil && buyOrder.Status.String == entities.BuyOrderStatusFilled {
+		s.Webhooks.Notify()
+	}
+	return convertBuyOrderPg2Domain(buyOrder), nil
+}
+
+func (s *WagerService) GetBuyOrder(ctx context.Context, buyOrderID int) (*models.BuyOrder, error) {
+	buyOrder, err := s.BuyOrderRepo.Get(ctx, s.DB, database.Int4(int32(buyOrderID)))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errBuyOrderNotFound
+		}
+		return nil, fmt.Errorf("unable to get buy order")
+	}
+	return convertBuyOrderPg2Domain(buyOrder), nil
+}
+
+// CancelBuyOrder cancels an order which is still open for its buyer, buyerID or else the
+// actor of the request. An order placed without a buyer can't be cancelled, it expires.
+func (s *WagerService) CancelBuyOrder(ctx context.Context, buyOrderID int, buyerID string) (*models.BuyOrder, error) {
+	buyerID = strings.TrimSpace(buyerID)
+	if buyerID == "" {
+		buyerID = auditInfoFromContext(ctx).actor
+	}
+	if buyerID == "" {
+		return nil, &validationError{fmt.Errorf("the buyer_id must be given")}
+	}
+	var buyOrder *entities.BuyOrder
+	now := time.Now()
+	if err := database.ExecInTx(ctx, s.DB, func(ctx context.Context, tx pgx.Tx) error {
+		var err error
+		buyOrder, err = s.BuyOrderRepo.Get(ctx, tx, database.Int4(int32(buyOrderID)), repositories.WithUpdateLock())
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return errBuyOrderNotFound
+			}
+			return fmt.Errorf("unable to get buy order")
+		}
+		if buyOrder.BuyerID.Status != pgtype.Present || buyOrder.BuyerID.String != buyerID {
+			return errNotBuyOrderBuyer
+		}
+		before, err := snapshotEntity(buyOrder)
+		if err != nil {
+			return fmt.Errorf("unable to snapshot buy order record")
+		}
+		cmdTag, err := s.BuyOrderRepo.Cancel(ctx, tx, buyOrder.BuyOrderID, database.Timestamptz(now))
+		if err != nil {
+			return fmt.Errorf("unable to cancel buy order")
+		}
+		if cmdTag.RowsAffected() != 1 {
+			return errBuyOrderNotOpen
+		}
+		if buyOrder, err = s.BuyOrderRepo.Get(ctx, tx, buyOrder.BuyOrderID); err != nil {
+			return fmt.Errorf("unable to get buy order")
+		}
+		return s.auditBuyOrder(ctx, tx, entities.AuditActionBuyOrderCancel, before, buyOrder, now)
+	}); err != nil {
+		return nil, fmt.Errorf("unable to cancel buy order: %w", err)
+	}
+	return convertBuyOrderPg2Domain(buyOrder), nil
+}
+
+// matchDecayedBuyOrders fills the open orders of the decaying wagers which their price
+// has reached since they were placed, no purchase lowers the price of these. Each wager
+// is matched in its own transaction, one failing is logged and left for the next run
+// without holding back the others.
+func (s *WagerService) matchDecayedBuyOrders(ctx context.Context, now time.Time) error {
+	wagerIDs, err := s.BuyOrderRepo.ListDecayingWagerIDs(ctx, s.DB, database.Timestamptz(now))
+	if err != nil {
+		return fmt.Errorf("unable to list decaying wagers: %w", err)
+	}
+	matched := 0
+	for _, wagerID := range wagerIDs {
+		if err := database.ExecInTx(ctx, s.DB, func(ctx context.Context, tx pgx.Tx) error {
+			wager, err := s.WagerRepo.Get(ctx, tx, database.Int4(wagerID), repositories.WithUpdateLock())
+			if err != nil {
+				return fmt.Errorf("unable to get wager information")
+			}
+			applyPriceDecay(wager, now)
+			return s.matchBuyOrders(ctx, tx, wager, now)
+		}); err != nil {
+			logs.Logger.Errorw("unable to match buy orders", "wager_id", wagerID, "error", err)
+			continue
+		}
+		matched++
+	}
+	if s.Webhooks != nil && matched > 0 {
+		s.Webhooks.Notify()
+	}
+	return nil
+}
+
+// RunBuyOrderMatcher fills the orders the decay of the price of their wager has
+// reached every interval until ctx is done.
+func (s *WagerService) RunBuyOrderMatcher(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.matchDecayedBuyOrders(ctx, time.Now()); err != nil {
+				logs.Logger.Errorw("unable to match buy orders", "error", err)
+			}
+		}
+	}
+}
+
+// RunBuyOrderExpirer marks the open orders past their expiry expired every interval
+// until ctx is done, the matching ignores them already, it keeps their status right.
+func (s *WagerService) RunBuyOrderExpirer(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			cmdTag, err := s.BuyOrderRepo.ExpireDue(ctx, s.DB, database.Timestamptz(time.Now()))
+			if err != nil {
+				logs.Logger.Errorw("unable to expire buy orders", "error", err)
+				continue
+			}
+			if cmdTag.RowsAffected() > 0 {
+				logs.Logger.Infow("expired buy orders", "expired", cmdTag.RowsAffected())
+			}
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/internal/models"
+	"github.com/wager-api/libs/database"
+	mock_database "github.com/wager-api/mocks/libs/database"
+	mock_repositories "github.com/wager-api/mocks/repositories"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+)
+
+func Test_validatePlaceBuyOrderReq(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	expiresAt := now.Add(time.Hour)
+	assert.NoError(t, validatePlaceBuyOrderReq(&models.PlaceBuyOrderRequest{LimitPrice: 10, ExpiresAt: &expiresAt}, now))
+	assert.EqualError(t, validatePlaceBuyOrderReq(&models.PlaceBuyOrderRequest{LimitPrice: 0, ExpiresAt: &expiresAt}, now), "the limit_price must be a positive decimal")
+	assert.EqualError(t, validatePlaceBuyOrderReq(&models.PlaceBuyOrderRequest{LimitPrice: 10}, now), "the expires_at must be a date in the future")
+	past := now.Add(-time.Second)
+	assert.EqualError(t, validatePlaceBuyOrderReq(&models.PlaceBuyOrderRequest{LimitPrice: 10, ExpiresAt: &past}, now), "the expires_at must be a date in the future")
+	far := now.Add(maxBuyOrderLifetime + time.Hour)
+	assert.EqualError(t, validatePlaceBuyOrderReq(&models.PlaceBuyOrderRequest{LimitPrice: 10, ExpiresAt: &far}, now), "the expires_at must be within 90 days")
+}
+
+func Test_matchBuyOrders(t *testing.T) {
+	t.Parallel()
+	ctx := withAuditInfo(context.Background(), auditInfo{actor: "seller-1", requestID: "req-1"})
+	tx := &mock_database.Tx{}
+	wagerRepo := &mock_repositories.MockWagerRepo{}
+	purchaseRepo := &mock_repositories.MockPurchaseRepo{}
+	priceHistoryRepo := &mock_repositories.MockPriceHistoryRepo{}
+	wagerUpdateRepo := &mock_repositories.MockWagerUpdateRepo{}
+	outboxRepo := &mock_repositories.MockOutboxRepo{}
+	auditLogRepo := &mock_repositories.MockAuditLogRepo{}
+	buyOrderRepo := &mock_repositories.MockBuyOrderRepo{}
+	stakeHoldingRepo := &mock_repositories.MockStakeHoldingRepo{}
+	accountRepo := &mock_repositories.MockAccountRepo{}
+	restrictionRepo := &mock_repositories.MockAccountRestrictionRepo{}
+	spendLimitRepo := &mock_repositories.MockSpendLimitRepo{}
+	wager := &entities.Wager{
+		WagerID:             database.Int4(1),
+		MarketID:            pgtype.Int4{Status: pgtype.Null},
+		SellingPrice:        database.Float4(50),
+		CurrentSellingPrice: database.Float4(30),
+		AmountSold:          database.Float4(0),
+	}
+	now := time.Now()
+	buyOrderRepo.On("ListMatching", mock.Anything, tx, database.Int4(1), database.Float4(30), database.Timestamptz(now)).Once().Return([]*entities.BuyOrder{
+		{BuyOrderID: database.Int4(7), BuyerID: database.Text("buyer-7"), LimitPrice: database.Float4(35)},
+		{BuyOrderID: database.Int4(8), BuyerID: database.Text("buyer-8"), LimitPrice: database.Float4(30)},
+		// the wager is sold out before this one
+		{BuyOrderID: database.Int4(9), BuyerID: database.Text("buyer-9"), LimitPrice: database.Float4(30)},
+	}, nil)
+	// each order is filled in a savepoint of the transaction
+	tx.On("Begin", mock.Anything).Twice().Return(tx, nil)
+	tx.On("Commit", mock.Anything).Twice().Return(nil)
+	accountRepo.On("Get", mock.Anything, tx, mock.Anything).Twice().Return(nil, pgx.ErrNoRows)
+	restrictionRepo.On("ListActive", mock.Anything, tx, mock.Anything, database.Timestamptz(now)).Twice().Return([]*entities.AccountRestriction{}, nil)
+	spendLimitRepo.On("LockAccount", mock.Anything, tx, mock.Anything).Twice().Return(nil)
+	spendLimitRepo.On("ListCurrent", mock.Anything, tx, mock.Anything, database.Timestamptz(now)).Twice().Return([]*entities.SpendLimit{}, nil)
+	purchaseID := int32(100)
+	// the second order only buys the 20 left unsold
+	purchaseRepo.On("Create", mock.Anything, tx, mock.MatchedBy(func(p *entities.Purchase) bool {
+		return (p.BuyerID.String == "buyer-7" && p.BuyingPrice.Float == 30) || (p.BuyerID.String == "buyer-8" && p.BuyingPrice.Float == 20)
+	})).Twice().Run(func(args mock.Arguments) {
+		purchaseID++
+		args.Get(2).(*entities.Purchase).PurchaseID = database.Int4(purchaseID)
+	}).Return(nil)
+	wagerRepo.On("Update", mock.Anything, tx, wager).Twice().Return(pgconn.CommandTag("UPDATE 1"), nil)
+	priceHistoryRepo.On("Create", mock.Anything, tx, mock.Anything).Twice().Return(nil)
+	wagerUpdateRepo.On("Create", mock.Anything, tx, mock.Anything).Times(4).Return(nil)
+	outboxRepo.On("Create", mock.Anything, tx, mock.Anything).Twice().Return(nil)
+	for _, buyer := range []string{"buyer-7", "buyer-8"} {
+		buyer := buyer
+		auditLogRepo.On("Create", mock.Anything, tx, mock.MatchedBy(func(auditLog *entities.AuditLog) bool {
+			return auditLog.Actor.String == buyer && auditLog.RequestID.String == "req-1"
+		})).Once().Return(nil)
+	}
+	stakeHoldingRepo.On("Add", mock.Anything, tx, mock.MatchedBy(func(holding *entities.StakeHolding) bool {
+		return holding.HolderID.String == "buyer-7" && holding.PurchaseID.Int == 101 && holding.Stake.Float == 30
+	})).Once().Return(nil)
+	stakeHoldingRepo.On("Add", mock.Anything, tx, mock.MatchedBy(func(holding *entities.StakeHolding) bool {
+		return holding.HolderID.String == "buyer-8" && holding.PurchaseID.Int == 102 && holding.Stake.Float == 20
+	})).Once().Return(nil)
+	buyOrderRepo.On("Fill", mock.Anything, tx, database.Int4(7), database.Int4(101), database.Timestamptz(now)).Once().Return(pgconn.CommandTag("UPDATE 1"), nil)
+	buyOrderRepo.On("Fill", mock.Anything, tx, database.Int4(8), database.Int4(102), database.Timestamptz(now)).Once().Return(pgconn.CommandTag("UPDATE 1"), nil)
+
+	s := &WagerService{
+		WagerRepo:              wagerRepo,
+		PurchaseRepo:           purchaseRepo,
+		PriceHistoryRepo:       priceHistoryRepo,
+		WagerUpdateRepo:        wagerUpdateRepo,
+		OutboxRepo:             outboxRepo,
+		AuditLogRepo:           auditLogRepo,
+		BuyOrderRepo:           buyOrderRepo,
+		StakeHoldingRepo:       stakeHoldingRepo,
+		AccountRepo:            accountRepo,
+		AccountRestrictionRepo: restrictionRepo,
+		SpendLimitRepo:         spendLimitRepo,
+	}
+	assert.NoError(t, s.matchBuyOrders(ctx, tx, wager, now))
+	assert.Equal(t, float32(50), wager.AmountSold.Float, "the wager is never sold over its selling price")
+	assert.Equal(t, float32(20), wager.CurrentSellingPrice.Float)
+	buyOrderRepo.AssertExpectations(t)
+	buyOrderRepo.AssertNotCalled(t, "Fill", mock.Anything, tx, database.Int4(9), mock.Anything, mock.Anything)
+	purchaseRepo.AssertExpectations(t)
+	auditLogRepo.AssertExpectations(t)
+	stakeHoldingRepo.AssertExpectations(t)
+}
+
+func Test_matchBuyOrders_FailingOrder(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	tx := &mock_database.Tx{}
+	wagerRepo := &mock_repositories.MockWagerRepo{}
+	purchaseRepo := &mock_repositories.MockPurchaseRepo{}
+	priceHistoryRepo := &mock_repositories.MockPriceHistoryRepo{}
+	wagerUpdateRepo := &mock_repositories.MockWagerUpdateRepo{}
+	outboxRepo := &mock_repositories.MockOutboxRepo{}
+	auditLogRepo := &mock_repositories.MockAuditLogRepo{}
+	buyOrderRepo := &mock_repositories.MockBuyOrderRepo{}
+	stakeHoldingRepo := &mock_repositories.MockStakeHoldingRepo{}
+	accountRepo := &mock_repositories.MockAccountRepo{}
+	restrictionRepo := &mock_repositories.MockAccountRestrictionRepo{}
+	spendLimitRepo := &mock_repositories.MockSpendLimitRepo{}
+	wager := &entities.Wager{
+		WagerID:             database.Int4(1),
+		MarketID:            pgtype.Int4{Status: pgtype.Null},
+		SellingPrice:        database.Float4(50),
+		CurrentSellingPrice: database.Float4(30),
+		AmountSold:          database.Float4(0),
+	}
+	now := time.Now()
+	buyOrderRepo.On("ListMatching", ctx, tx, database.Int4(1), database.Float4(30), database.Timestamptz(now)).Once().Return([]*entities.BuyOrder{
+		{BuyOrderID: database.Int4(7), BuyerID: database.Text("buyer-7"), LimitPrice: database.Float4(35)},
+	}, nil)
+	tx.On("Begin", ctx).Once().Return(tx, nil)
+	tx.On("Rollback", ctx).Once().Return(nil)
+	accountRepo.On("Get", mock.Anything, tx, mock.Anything).Once().Return(nil, pgx.ErrNoRows)
+	restrictionRepo.On("ListActive", mock.Anything, tx, mock.Anything, database.Timestamptz(now)).Once().Return([]*entities.AccountRestriction{}, nil)
+	spendLimitRepo.On("LockAccount", mock.Anything, tx, mock.Anything).Once().Return(nil)
+	spendLimitRepo.On("ListCurrent", mock.Anything, tx, mock.Anything, database.Timestamptz(now)).Once().Return([]*entities.SpendLimit{}, nil)
+	purchaseRepo.On("Create", mock.Anything, tx, mock.Anything).Once().Run(func(args mock.Arguments) {
+		args.Get(2).(*entities.Purchase).PurchaseID = database.Int4(101)
+	}).Return(nil)
+	stakeHoldingRepo.On("Add", mock.Anything, tx, mock.Anything).Once().Return(nil)
+	wagerRepo.On("Update", mock.Anything, tx, wager).Once().Return(pgconn.CommandTag("UPDATE 1"), nil)
+	priceHistoryRepo.On("Create", mock.Anything, tx, mock.Anything).Once().Return(nil)
+	wagerUpdateRepo.On("Create", mock.Anything, tx, mock.Anything).Twice().Return(nil)
+	outboxRepo.On("Create", mock.Anything, tx, mock.Anything).Once().Return(nil)
+	auditLogRepo.On("Create", mock.Anything, tx, mock.Anything).Once().Return(nil)
+	buyOrderRepo.On("Fill", ctx, tx, database.Int4(7), database.Int4(101), database.Timestamptz(now)).Once().Return(pgconn.CommandTag(""), pgx.ErrTxClosed)
+
+	s := &WagerService{
+		WagerRepo:              wagerRepo,
+		PurchaseRepo:           purchaseRepo,
+		PriceHistoryRepo:       priceHistoryRepo,
+		WagerUpdateRepo:        wagerUpdateRepo,
+		OutboxRepo:             outboxRepo,
+		AuditLogRepo:           auditLogRepo,
+		BuyOrderRepo:           buyOrderRepo,
+		StakeHoldingRepo:       stakeHoldingRepo,
+		AccountRepo:            accountRepo,
+		AccountRestrictionRepo: restrictionRepo,
+		SpendLimitRepo:         spendLimitRepo,
+	}
+	// the order failing is rolled back to its savepoint, the change of price goes on
+	assert.NoError(t, s.matchBuyOrders(ctx, tx, wager, now))
+	assert.Equal(t, float32(0), wager.AmountSold.Float, "the purchase of the order is rolled back")
+	assert.Equal(t, float32(30), wager.CurrentSellingPrice.Float)
+	tx.AssertExpectations(t)
+	tx.AssertNotCalled(t, "Commit", mock.Anything)
+	buyOrderRepo.AssertExpectations(t)
+}
+
+func Test_PlaceBuyOrder(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	db := &mock_database.Ext{}
+	tx := &mock_database.Tx{}
+	wagerRepo := &mock_repositories.MockWagerRepo{}
+	marketRepo := &mock_repositories.MockMarketRepo{}
+	buyOrderRepo := &mock_repositories.MockBuyOrderRepo{}
+	db.On("Begin", ctx).Return(tx, nil)
+	tx.On("Rollback", mock.Anything).Return(nil)
+	wagerRepo.On("Get", ctx, tx, database.Int4(1)).Once().Return(&entities.Wager{
+		WagerID:             database.Int4(1),
+		MarketID:            database.Int4(3),
+		SellingPrice:        database.Float4(50),
+		CurrentSellingPrice: database.Float4(30),
+		AmountSold:          database.Float4(0),
+	}, nil)
+	marketRepo.On("Get", ctx, tx, database.Int4(3)).Once().Return(&entities.Market{
+		MarketID: database.Int4(3),
+		Status:   database.Text(entities.MarketStatusSettled),
+	}, nil)
+
+	s := &WagerService{DB: db, WagerRepo: wagerRepo, MarketRepo: marketRepo, BuyOrderRepo: buyOrderRepo}
+	expiresAt := time.Now().Add(time.Hour)
+	_, err := s.PlaceBuyOrder(ctx, 1, &models.PlaceBuyOrderRequest{BuyerID: "buyer-7", LimitPrice: 10, ExpiresAt: &expiresAt})
+	assert.EqualError(t, err, "unable to place buy order: wager sales are closed")
+	assert.Equal(t, http.StatusConflict, statusFromError(err))
+	buyOrderRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func Test_CancelBuyOrder(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	t.Run("a filled order can't be cancelled", func(t *testing.T) {
+		db := &mock_database.Ext{}
+		tx := &mock_database.Tx{}
+		buyOrderRepo := &mock_repositories.MockBuyOrderRepo{}
+		db.On("Begin", ctx).Return(tx, nil)
+		tx.On("Rollback", mock.Anything).Return(nil)
+		buyOrderRepo.On("Cancel", ctx, tx, database.Int4(7), mock.Anything).Once().Return(pgconn.CommandTag("UPDATE 0"), nil)
+		buyOrderRepo.On("Get", ctx, tx, database.Int4(7)).Once().Return(&entities.BuyOrder{
+			BuyOrderID: database.Int4(7),
+			BuyerID:    database.Text("buyer-7"),
+			Status:     database.Text(entities.BuyOrderStatusFilled),
+		}, nil)
+
+		s := &WagerService{DB: db, BuyOrderRepo: buyOrderRepo}
+		_, err := s.CancelBuyOrder(ctx, 7, "buyer-7")
+		assert.EqualError(t, err, "unable to cancel buy order: the buy order is not open anymore")
+		assert.Equal(t, http.StatusConflict, statusFromError(err))
+	})
+	t.Run("only the buyer of the order can cancel it", func(t *testing.T) {
+		db := &mock_database.Ext{}
+		tx := &mock_database.Tx{}
+		buyOrderRepo := &mock_repositories.MockBuyOrderRepo{}
+		// without a buyer_id the actor of the request is the buyer
+		ctx := withAuditInfo(ctx, auditInfo{actor: "buyer-8"})
+		db.On("Begin", ctx).Return(tx, nil)
+		tx.On("Rollback", mock.Anything).Return(nil)
+		buyOrderRepo.On("Get", ctx, tx, database.Int4(7)).Once().Return(&entities.BuyOrder{
+			BuyOrderID: database.Int4(7),
+			BuyerID:    database.Text("buyer-7"),
+			Status:     database.Text(entities.BuyOrderStatusOpen),
+		}, nil)
+
+		s := &WagerService{DB: db, BuyOrderRepo: buyOrderRepo}
+		_, err := s.CancelBuyOrder(ctx, 7, "")
+		assert.EqualError(t, err, "unable to cancel buy order: only the buyer of the buy order can cancel it")
+		assert.Equal(t, http.StatusForbidden, statusFromError(err))
+		buyOrderRepo.AssertNotCalled(t, "Cancel", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+		_, err = s.CancelBuyOrder(context.Background(), 7, "")
+		assert.EqualError(t, err, "the buyer_id must be given")
+		assert.Equal(t, http.StatusBadRequest, statusFromError(err))
+	})
+}
+
+func Test_matchDecayedBuyOrders(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	db := &mock_database.Ext{}
+	tx := &mock_database.Tx{}
+	wagerRepo := &mock_repositories.MockWagerRepo{}
+	buyOrderRepo := &mock_repositories.MockBuyOrderRepo{}
+	now := time.Now()
+	db.On("Begin", ctx).Return(tx, nil)
+	tx.On("Rollback", mock.Anything).Return(nil)
+	tx.On("Commit", mock.Anything).Return(nil)
+	buyOrderRepo.On("ListDecayingWagerIDs", ctx, db, database.Timestamptz(now)).Once().Return([]int32{1, 2}, nil)
+	wagerRepo.On("Get", ctx, tx, database.Int4(1), mock.Anything).Once().Return(nil, pgx.ErrTxClosed)
+	wagerRepo.On("Get", ctx, tx, database.Int4(2), mock.Anything).Once().Return(&entities.Wager{
+		WagerID:             database.Int4(2),
+		SellingPrice:        database.Float4(50),
+		CurrentSellingPrice: database.Float4(30),
+		AmountSold:          database.Float4(0),
+	}, nil)
+	buyOrderRepo.On("ListMatching", ctx, tx, database.Int4(2), database.Float4(30), database.Timestamptz(now)).Once().Return([]*entities.BuyOrder{}, nil)
+
+	s := &WagerService{DB: db, WagerRepo: wagerRepo, BuyOrderRepo: buyOrderRepo}
+	// the wager failing is left for the next run, the next one is matched all the same
+	assert.NoError(t, s.matchDecayedBuyOrders(ctx, now))
+	wagerRepo.AssertExpectations(t)
+	buyOrderRepo.AssertExpectations(t)
+}
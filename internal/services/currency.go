@@ -0,0 +1,383 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/internal/models"
+	"github.com/wager-api/internal/repositories"
+	"github.com/wager-api/libs/database"
+
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+	"go.uber.org/multierr"
+)
+
+// defaultCurrency is the base currency when none is configured, the one of the wagers
+// placed before they had a currency
+const defaultCurrency = "USD"
+
+// currencyPattern is the shape of an ISO 4217 code, the list of the codes in use isn't
+// checked
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// ParseCurrency returns code in upper case, it must be three letters long.
+func ParseCurrency(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if !currencyPattern.MatchString(code) {
+		return "", fmt.Errorf("the currency must be an ISO 4217 code of three letters")
+	}
+	return code, nil
+}
+
+func baseCurrency(configured string) string {
+	if configured == "" {
+		return defaultCurrency
+	}
+	return configured
+}
+
+// exchangeRate returns what one unit of from is worth in to at at: the rate of the pair
+// in effect then or, when there is none, the inverse of the one of the reverse pair.
+func exchangeRate(ctx context.Context, db database.Ext, rates interface {
+	GetEffective(ctx context.Context, db database.Ext, baseCurrency, quoteCurrency pgtype.Text, at pgtype.Timestamptz) (*entities.ExchangeRate, error)
+}, from, to string, at time.Time) (float64, error) {
+	rate, err := rates.GetEffective(ctx, db, database.Text(from), database.Text(to), database.Timestamptz(at))
+	if err == nil {
+		return rate.Rate.Float, nil
+	}
+	if err != pgx.ErrNoRows {
+		return 0, fmt.Errorf("unable to get exchange rate")
+	}
+	rate, err = rates.GetEffective(ctx, db, database.Text(to), database.Text(from), database.Timestamptz(at))
+	if err == nil {
+		return 1 / rate.Rate.Float, nil
+	}
+	if err != pgx.ErrNoRows {
+		return 0, fmt.Errorf("unable to get exchange rate")
+	}
+	return 0, fmt.Errorf("%w from %s to %s", errNoExchangeRate, from, to)
+}
+
+// paymentRate returns the currency buyerID pays for the stakes of wager in, the one of
+// their account or the one of the wager when they have none, and the rate from the
+// currency of the wager which is null when they are the same. A payment across
+// currencies is refused unless a rate between them is in effect.
+func (s *WagerService) paymentRate(ctx context.Context, tx database.Ext, wager *entities.Wager, buyerID pgtype.Text, now time.Time) (string, pgtype.Float8, error) {
+	wagerCurrency := wager.Currency.String
+	if wager.Currency.Status != pgtype.Present {
+		wagerCurrency = baseCurrency(s.BaseCurrency)
+	}
+	paidCurrency := wagerCurrency
+	if buyerID.Status == pgtype.Present {
+		account, err := s.AccountRepo.Get(ctx, tx, buyerID)
+		switch {
+		case err == nil:
+			paidCurrency = account.Currency.String
+		case err != pgx.ErrNoRows:
+			return "", pgtype.Float8{}, fmt.Errorf("unable to get account information")
+		}
+	}
+	if paidCurrency == wagerCurrency {
+		return paidCurrency, pgtype.Float8{Status: pgtype.Null}, nil
+	}
+	rate, err := exchangeRate(ctx, tx, s.ExchangeRateRepo, wagerCurrency, paidCurrency, now)
+	if err != nil {
+		return "", pgtype.Float8{}, err
+	}
+	return paidCurrency, database.Float8(rate), nil
+}
+
+// convertAmount converts amount at rate, to the cent, it is left as it is without a rate.
+func convertAmount(amount float32, rate pgtype.Float8) float32 {
+	if rate.Status != pgtype.Present {
+		return amount
+	}
+	return float32(math.Round(float64(amount)*rate.Float*100) / 100)
+}
+
+// convertPurchase sets what the buyer of purchase pays in the currency of their account,
+// the currency of the wager when they have none. A purchase across currencies is
+// refused unless a rate between them is in effect.
+func (s *WagerService) convertPurchase(ctx context.Context, tx database.Ext, wager *entities.Wager, purchase *entities.Purchase, now time.Time) error {
+	paidCurrency, rate, err := s.paymentRate(ctx, tx, wager, purchase.BuyerID, now)
+	if err != nil {
+		return err
+	}
+	return multierr.Combine(
+		purchase.PaidCurrency.Set(paidCurrency),
+		purchase.PaidAmount.Set(convertAmount(purchase.BuyingPrice.Float, rate)),
+		purchase.ExchangeRate.Set(rate))
+}
+
+func convertAccountPg2Domain(account *entities.Account) *models.Account {
+	return &models.Account{
+		AccountID: account.AccountID.String,
+		Currency:  account.Currency.String,
+		CreatedAt: &account.CreatedAt.Time,
+		UpdatedAt: &account.UpdatedAt.Time,
+	}
+}
+
+func validateAccountID(accountID string) (string, error) {
+	accountID = strings.TrimSpace(accountID)
+	if accountID == "" || len(accountID) > maxSellerIDLength {
+		return "", fmt.Errorf("the account_id must be given, at most %d characters long", maxSellerIDLength)
+	}
+	return accountID, nil
+}
+
+// UpsertAccount sets the currency the account pays its purchases in, the purchases made
+// already are left as they are.
+func (s *WagerService) UpsertAccount(ctx context.Context, accountID string, upsertAccountRequest *models.UpsertAccountRequest) (*models.Account, error) {
+	accountID, err := validateAccountID(accountID)
+	if err != nil {
+		return nil, &validationError{err}
+	}
+	currency, err := ParseCurrency(upsertAccountRequest.Currency)
+	if err != nil {
+		return nil, &validationError{err}
+	}
+	now := time.Now()
+	account := &entities.Account{}
+	if err := multierr.Combine(
+		account.AccountID.Set(accountID),
+		account.Currency.Set(currency),
+		account.CreatedAt.Set(now),
+		account.UpdatedAt.Set(now)); err != nil {
+		return nil, &validationError{fmt.Errorf("unable to generate value for account")}
+	}
+	if err := database.ExecInTx(ctx, s.DB, func(ctx context.Context, tx pgx.Tx) error {
+		before := pgtype.JSONB{Status: pgtype.Null}
+		previous, err := s.AccountRepo.Get(ctx, tx, account.AccountID)
+		switch {
+		case err == nil:
+			if before, err = snapshotEntity(previous); err != nil {
+				return fmt.Errorf("unable to snapshot account record")
+			}
+		case err != pgx.ErrNoRows:
+			return fmt.Errorf("unable to get account")
+		}
+		if err := s.AccountRepo.Upsert(ctx, tx, account); err != nil {
+			return fmt.Errorf("unable to save account")
+		}
+		after, err := snapshotEntity(account)
+		if err != nil {
+			return fmt.Errorf("unable to snapshot account record")
+		}
+		if err := s.AuditLogRepo.Create(ctx, tx, newAccountAuditLog(ctx, entities.AuditActionAccountUpdate, account.AccountID, before, after, now)); err != nil {
+			return fmt.Errorf("unable to create audit log")
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return convertAccountPg2Domain(account), nil
+}
+
+func (s *WagerService) GetAccount(ctx context.Context, accountID string) (*models.Account, error) {
+	accountID, err := validateAccountID(accountID)
+	if err != nil {
+		return nil, &validationError{err}
+	}
+	account, err := s.AccountRepo.Get(ctx, s.DB, database.Text(accountID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errAccountNotFound
+		}
+		return nil, fmt.Errorf("unable to get account information")
+	}
+	return convertAccountPg2Domain(account), nil
+}
+
+// CurrencyService lets the admins manage the exchange rates and report the volume
+// bought in the base currency.
+type CurrencyService struct {
+	DB               database.Ext
+	ExchangeRateRepo interface {
+		Create(ctx context.Context, db database.Ext, rate *entities.ExchangeRate) error
+		List(ctx context.Context, db database.Ext, baseCurrency, quoteCurrency pgtype.Text) ([]*entities.ExchangeRate, error)
+	}
+	PurchaseRepo interface {
+		ReportVolume(ctx context.Context, db database.Ext, from, to pgtype.Timestamptz, period string, baseCurrency pgtype.Text) ([]*repositories.VolumeReportRow, error)
+	}
+	AuditLogRepo interface {
+		Create(ctx context.Context, db database.Ext, auditLog *entities.AuditLog) error
+	}
+	// BaseCurrency is the currency the volume is reported in, USD when it is empty
+	BaseCurrency string
+}
+
+func convertExchangeRatePg2Domain(rate *entities.ExchangeRate) *models.ExchangeRate {
+	return &models.ExchangeRate{
+		ID:            int(rate.RateID.Int),
+		BaseCurrency:  rate.BaseCurrency.String,
+		QuoteCurrency: rate.QuoteCurrency.String,
+		Rate:          rate.Rate.Float,
+		EffectiveFrom: rate.EffectiveFrom.Time.UTC(),
+		CreatedAt:     rate.CreatedAt.Time.UTC(),
+	}
+}
+
+// CreateExchangeRate adds a rate of a pair of currencies, it replaces the previous one
+// of the pair from its effective date. The rates are never updated so the purchases
+// can be converted again at the rate in effect when they were made.
+func (s *CurrencyService) CreateExchangeRate(resp http.ResponseWriter, req *http.Request) {
+	createExchangeRateRequest := &models.CreateExchangeRateRequest{}
+	err := json.NewDecoder(req.Body).Decode(createExchangeRateRequest)
+	defer req.Body.Close()
+	if err != nil {
+		resp.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": "unable to parse request",
+		})
+		return
+	}
+	now := time.Now()
+	rate, err := newExchangeRate(createExchangeRateRequest, now)
+	if err != nil {
+		resp.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err := database.ExecInTx(req.Context(), s.DB, func(ctx context.Context, tx pgx.Tx) error {
+		if err := s.ExchangeRateRepo.Create(ctx, tx, rate); err != nil {
+			return err
+		}
+		after, err := snapshotEntity(rate)
+		if err != nil {
+			return err
+		}
+		return s.AuditLogRepo.Create(ctx, tx, newEntityAuditLog(ctx, entities.AuditActionExchangeRateCreate, rate.RateID.Int, pgtype.JSONB{Status: pgtype.Null}, after, now))
+	}); err != nil {
+		resp.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": "unable to create exchange rate",
+		})
+		return
+	}
+	resp.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(resp).Encode(convertExchangeRatePg2Domain(rate))
+}
+
+func newExchangeRate(req *models.CreateExchangeRateRequest, now time.Time) (*entities.ExchangeRate, error) {
+	baseCurrency, err := ParseCurrency(req.BaseCurrency)
+	if err != nil {
+		return nil, fmt.Errorf("base_currency: %w", err)
+	}
+	quoteCurrency, err := ParseCurrency(req.QuoteCurrency)
+	if err != nil {
+		return nil, fmt.Errorf("quote_currency: %w", err)
+	}
+	if baseCurrency == quoteCurrency {
+		return nil, fmt.Errorf("the base_currency and the quote_currency must differ")
+	}
+	if req.Rate <= 0 || math.IsInf(req.Rate, 0) || math.IsNaN(req.Rate) {
+		return nil, fmt.Errorf("the rate must be a positive decimal")
+	}
+	effectiveFrom := now
+	if req.EffectiveFrom != nil {
+		effectiveFrom = *req.EffectiveFrom
+	}
+	rate := &entities.ExchangeRate{}
+	database.AllNullEntity(rate)
+	if err := multierr.Combine(
+		rate.BaseCurrency.Set(baseCurrency),
+		rate.QuoteCurrency.Set(quoteCurrency),
+		rate.Rate.Set(req.Rate),
+		rate.EffectiveFrom.Set(effectiveFrom),
+		rate.CreatedAt.Set(now)); err != nil {
+		return nil, fmt.Errorf("unable to generate value for exchange rate")
+	}
+	return rate, nil
+}
+
+// ListExchangeRates lists the rates, latest effective first:
+// ?base_currency=&quote_currency=, an omitted currency matches every one.
+func (s *CurrencyService) ListExchangeRates(resp http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	var baseCurrency, quoteCurrency pgtype.Text
+	_ = baseCurrency.Set(nil)
+	_ = quoteCurrency.Set(nil)
+	for _, param := range []struct {
+		name string
+		dst  *pgtype.Text
+	}{
+		{"base_currency", &baseCurrency},
+		{"quote_currency", &quoteCurrency},
+	} {
+		if value := query.Get(param.name); value != "" {
+			currency, err := ParseCurrency(value)
+			if err != nil {
+				resp.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(resp).Encode(map[string]string{
+					"error": fmt.Sprintf("%s: %s", param.name, err),
+				})
+				return
+			}
+			_ = param.dst.Set(currency)
+		}
+	}
+	rates, err := s.ExchangeRateRepo.List(req.Context(), s.DB, baseCurrency, quoteCurrency)
+	if err != nil {
+		resp.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": "unable to list exchange rates",
+		})
+		return
+	}
+	rateModels := make([]*models.ExchangeRate, 0, len(rates))
+	for _, rate := range rates {
+		rateModels = append(rateModels, convertExchangeRatePg2Domain(rate))
+	}
+	resp.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(resp).Encode(rateModels)
+}
+
+// ReportVolume sums up the volume bought in [from, to) by period and currency of the
+// wagers, converted to the base currency at the rates in effect at each purchase:
+// ?from=&to=&period=day|week|month, day by default.
+func (s *CurrencyService) ReportVolume(resp http.ResponseWriter, req *http.Request) {
+	from, to, period, err := parseReportQuery(req.URL.Query())
+	if err != nil {
+		resp.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	base := baseCurrency(s.BaseCurrency)
+	rows, err := s.PurchaseRepo.ReportVolume(req.Context(), s.DB, from, to, period, database.Text(base))
+	if err != nil {
+		resp.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": "unable to report volume",
+		})
+		return
+	}
+	report := &models.VolumeReport{
+		BaseCurrency: base,
+		Rows:         make([]*models.VolumeReportRow, 0, len(rows)),
+	}
+	for _, row := range rows {
+		report.Rows = append(report.Rows, &models.VolumeReportRow{
+			PeriodStart:          row.PeriodStart.UTC(),
+			Currency:             row.Currency,
+			Purchases:            row.Purchases,
+			Volume:               row.Volume,
+			BaseVolume:           row.BaseVolume,
+			UnconvertedPurchases: row.UnconvertedPurchases,
+		})
+	}
+	resp.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(resp).Encode(report)
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/internal/models"
+	"github.com/wager-api/internal/repositories"
+	"github.com/wager-api/libs/database"
+	mock_database "github.com/wager-api/mocks/libs/database"
+	mock_repositories "github.com/wager-api/mocks/repositories"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+)
+
+func Test_ParseCurrency(t *testing.T) {
+	t.Parallel()
+	currency, err := ParseCurrency(" eur ")
+	assert.NoError(t, err)
+	assert.Equal(t, "EUR", currency)
+	for _, code := range []string{"", "EURO", "E1R", "€"} {
+		_, err := ParseCurrency(code)
+		assert.EqualError(t, err, "the currency must be an ISO 4217 code of three letters", code)
+	}
+}
+
+func Test_convertPurchase(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	now := time.Now()
+	newPurchase := func() *entities.Purchase {
+		purchase := &entities.Purchase{}
+		database.AllNullEntity(purchase)
+		purchase.BuyingPrice = database.Float4(40)
+		purchase.BuyerID = database.Text("buyer-1")
+		return purchase
+	}
+	wager := &entities.Wager{WagerID: database.Int4(1), Currency: database.Text("USD")}
+
+	t.Run("a buyer without an account pays in the currency of the wager", func(t *testing.T) {
+		tx := &mock_database.Tx{}
+		accountRepo := &mock_repositories.MockAccountRepo{}
+		accountRepo.On("Get", ctx, tx, database.Text("buyer-1")).Once().Return(nil, pgx.ErrNoRows)
+		s := &WagerService{AccountRepo: accountRepo}
+		purchase := newPurchase()
+		assert.NoError(t, s.convertPurchase(ctx, tx, wager, purchase, now))
+		assert.Equal(t, database.Text("USD"), purchase.PaidCurrency)
+		assert.Equal(t, database.Float4(40), purchase.PaidAmount)
+		assert.Equal(t, pgtype.Null, purchase.ExchangeRate.Status)
+	})
+	t.Run("the inverse of the rate of the reverse pair", func(t *testing.T) {
+		tx := &mock_database.Tx{}
+		accountRepo := &mock_repositories.MockAccountRepo{}
+		exchangeRateRepo := &mock_repositories.MockExchangeRateRepo{}
+		accountRepo.On("Get", ctx, tx, database.Text("buyer-1")).Once().Return(&entities.Account{Currency: database.Text("EUR")}, nil)
+		exchangeRateRepo.On("GetEffective", ctx, tx, database.Text("USD"), database.Text("EUR"), database.Timestamptz(now)).Once().Return(nil, pgx.ErrNoRows)
+		exchangeRateRepo.On("GetEffective", ctx, tx, database.Text("EUR"), database.Text("USD"), database.Timestamptz(now)).Once().Return(&entities.ExchangeRate{Rate: database.Float8(1.25)}, nil)
+		s := &WagerService{AccountRepo: accountRepo, ExchangeRateRepo: exchangeRateRepo}
+		purchase := newPurchase()
+		assert.NoError(t, s.convertPurchase(ctx, tx, wager, purchase, now))
+		assert.Equal(t, database.Text("EUR"), purchase.PaidCurrency)
+		assert.Equal(t, database.Float4(32), purchase.PaidAmount)
+		assert.Equal(t, 0.8, purchase.ExchangeRate.Float)
+		exchangeRateRepo.AssertExpectations(t)
+	})
+	t.Run("no rate refuses the purchase", func(t *testing.T) {
+		tx := &mock_database.Tx{}
+		accountRepo := &mock_repositories.MockAccountRepo{}
+		exchangeRateRepo := &mock_repositories.MockExchangeRateRepo{}
+		accountRepo.On("Get", ctx, tx, database.Text("buyer-1")).Once().Return(&entities.Account{Currency: database.Text("GBP")}, nil)
+		exchangeRateRepo.On("GetEffective", ctx, tx, mock.Anything, mock.Anything, database.Timestamptz(now)).Twice().Return(nil, pgx.ErrNoRows)
+		s := &WagerService{AccountRepo: accountRepo, ExchangeRateRepo: exchangeRateRepo}
+		err := s.convertPurchase(ctx, tx, wager, newPurchase(), now)
+		assert.EqualError(t, err, "no exchange rate is in effect from USD to GBP")
+		assert.Equal(t, http.StatusConflict, statusFromError(err))
+	})
+}
+
+func Test_UpsertAccount(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	db := &mock_database.Ext{}
+	tx := &mock_database.Tx{}
+	accountRepo := &mock_repositories.MockAccountRepo{}
+	auditLogRepo := &mock_repositories.MockAuditLogRepo{}
+	db.On("Begin", ctx).Return(tx, nil)
+	tx.On("Commit", mock.Anything).Return(nil)
+	accountRepo.On("Get", ctx, tx, database.Text("buyer-1")).Once().Return(&entities.Account{
+		AccountID: database.Text("buyer-1"),
+		Currency:  database.Text("USD"),
+	}, nil)
+	accountRepo.On("Upsert", ctx, tx, mock.MatchedBy(func(account *entities.Account) bool {
+		return account.AccountID.String == "buyer-1" && account.Currency.String == "EUR"
+	})).Once().Return(nil)
+	auditLogRepo.On("Create", ctx, tx, mock.MatchedBy(func(auditLog *entities.AuditLog) bool {
+		return auditLog.Action.String == entities.AuditActionAccountUpdate && auditLog.AccountID.String == "buyer-1" &&
+			strings.Contains(string(auditLog.Before.Bytes), `"USD"`) && strings.Contains(string(auditLog.After.Bytes), `"EUR"`)
+	})).Once().Return(nil)
+	s := &WagerService{DB: db, AccountRepo: accountRepo, AuditLogRepo: auditLogRepo}
+
+	account, err := s.UpsertAccount(ctx, " buyer-1 ", &models.UpsertAccountRequest{Currency: "eur"})
+	assert.NoError(t, err)
+	assert.Equal(t, "EUR", account.Currency)
+	_, err = s.UpsertAccount(ctx, "buyer-1", &models.UpsertAccountRequest{Currency: "euro"})
+	assert.Equal(t, http.StatusBadRequest, statusFromError(err))
+	accountRepo.AssertExpectations(t)
+	auditLogRepo.AssertExpectations(t)
+}
+
+func Test_CurrencyService(t *testing.T) {
+	t.Parallel()
+	db := &mock_database.Ext{}
+	tx := &mock_database.Tx{}
+	exchangeRateRepo := &mock_repositories.MockExchangeRateRepo{}
+	purchaseRepo := &mock_repositories.MockPurchaseRepo{}
+	auditLogRepo := &mock_repositories.MockAuditLogRepo{}
+	periodStart := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	effectiveFrom := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	db.On("Begin", mock.Anything).Return(tx, nil)
+	tx.On("Commit", mock.Anything).Return(nil)
+	exchangeRateRepo.On("Create", mock.Anything, tx, mock.MatchedBy(func(rate *entities.ExchangeRate) bool {
+		return rate.BaseCurrency.String == "EUR" && rate.QuoteCurrency.String == "USD" && rate.Rate.Float == 1.1 && rate.EffectiveFrom.Time.Equal(effectiveFrom)
+	})).Once().Run(func(args mock.Arguments) {
+		args.Get(2).(*entities.ExchangeRate).RateID = database.Int4(3)
+	}).Return(nil)
+	// the admin token authenticates the request, its actor is verified
+	auditLogRepo.On("Create", mock.Anything, tx, mock.MatchedBy(func(auditLog *entities.AuditLog) bool {
+		return auditLog.Action.String == entities.AuditActionExchangeRateCreate && auditLog.EntityID.String == "3" &&
+			auditLog.Actor.String == "admin" && auditLog.ActorVerified.Bool
+	})).Once().Return(nil)
+	purchaseRepo.On("ReportVolume", mock.Anything, db, pgtype.Timestamptz{Status: pgtype.Null}, pgtype.Timestamptz{Status: pgtype.Null}, "day", database.Text("USD")).Once().Return([]*repositories.VolumeReportRow{
+		{PeriodStart: periodStart, Currency: "EUR", Purchases: 2, Volume: 20, BaseVolume: 22},
+	}, nil)
+	mux := chi.NewMux()
+	NewCurrencyHandler(mux, &CurrencyService{DB: db, ExchangeRateRepo: exchangeRateRepo, PurchaseRepo: purchaseRepo, AuditLogRepo: auditLogRepo}, []string{"admin-token"})
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "Bearer admin-token")
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve(http.MethodPost, "/admin/exchange-rates", `{"base_currency":"EUR","quote_currency":"eur","rate":1.1}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	rec = serve(http.MethodPost, "/admin/exchange-rates", `{"base_currency":"EUR","quote_currency":"USD","rate":0}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	rec = serve(http.MethodPost, "/admin/exchange-rates", `{"base_currency":"eur","quote_currency":"USD","rate":1.1,"effective_from":"2024-01-01T00:00:00Z"}`)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	rec = serve(http.MethodGet, "/admin/reports/volume", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	report := &models.VolumeReport{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(report))
+	assert.Equal(t, &models.VolumeReport{
+		BaseCurrency: "USD",
+		Rows:         []*models.VolumeReportRow{{PeriodStart: periodStart, Currency: "EUR", Purchases: 2, Volume: 20, BaseVolume: 22}},
+	}, report)
+	exchangeRateRepo.AssertExpectations(t)
+	purchaseRepo.AssertExpectations(t)
+	auditLogRepo.AssertExpectations(t)
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+)
+
+var (
+	errEventNotFound           = errors.New("event not found")
+	errMarketNotFound          = errors.New("market not found")
+	errInvalidStatusTransition = errors.New("invalid status transition")
+	errWagerSalesClosed        = errors.New("wager sales are closed")
+	errWagerNotFound           = errors.New("unable to get wager information: not found")
+	errWebhookNotFound         = errors.New("webhook not found")
+	errBuyOrderNotFound        = errors.New("buy order not found")
+	errBuyOrderNotOpen         = errors.New("the buy order is not open anymore")
+	errWagerSettled            = errors.New("the wager is settled")
+	errStakeHoldingNotFound    = errors.New("stake holding not found")
+	errListingNotFound         = errors.New("listing not found")
+	errListingNotOpen          = errors.New("the listing is not open anymore")
+	errAccountNotFound         = errors.New("account not found")
+	errNoExchangeRate          = errors.New("no exchange rate is in effect")
+	errRiskLimitExceeded       = errors.New("risk limit exceeded")
+	errAccountRestricted       = errors.New("the account is restricted")
+	errSpendLimitReached       = errors.New("spend limit reached")
+	errTradeBlocked            = errors.New("the trade is blocked by the surveillance rules")
+	errNotWagerSeller          = errors.New("only the seller of the wager can change it")
+	errNotListingSeller        = errors.New("only the seller of the listing can cancel it")
+	errNotBuyOrderBuyer        = errors.New("only the buyer of the buy order can cancel it")
+)
+
+// validationError is returned when a request is rejected before anything is done,
+// its message is the reason the request is invalid.
+type validationError struct {
+	err error
+}
+
+func (e *validationError) Error() string {
+	return e.err.Error()
+}
+
+func (e *validationError) Unwrap() error {
+	return e.err
+}
+
+// statusFromError maps the errors returned inside a transaction to the http status
+// of the response, unknown errors are considered as internal errors.
+func statusFromError(err error) int {
+	var invalidErr *validationError
+	switch {
+	case errors.As(err, &invalidErr):
+		return http.StatusBadRequest
+	case errors.Is(err, errEventNotFound), errors.Is(err, errMarketNotFound), errors.Is(err, errWagerNotFound),
+		errors.Is(err, errWebhookNotFound), errors.Is(err, errBuyOrderNotFound), errors.Is(err, errStakeHoldingNotFound),
+		errors.Is(err, errListingNotFound), errors.Is(err, errAccountNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errInvalidStatusTransition), errors.Is(err, errWagerSalesClosed), errors.Is(err, errBuyOrderNotOpen),
+		errors.Is(err, errWagerSettled), errors.Is(err, errListingNotOpen), errors.Is(err, errNoExchangeRate),
+		errors.Is(err, errRiskLimitExceeded):
+		return http.StatusConflict
+	case errors.Is(err, errAccountRestricted), errors.Is(err, errSpendLimitReached), errors.Is(err, errTradeBlocked),
+		errors.Is(err, errNotWagerSeller), errors.Is(err, errNotListingSeller),
+		errors.Is(err, errNotBuyOrderBuyer):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,631 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/internal/models"
+	"github.com/wager-api/internal/repositories"
+	"github.com/wager-api/libs/database"
+	"github.com/wager-api/libs/logs"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+	"go.uber.org/multierr"
+)
+
+var marketTypes = map[string]bool{
+	"match_winner":        true,
+	"over_under":          true,
+	"handicap":            true,
+	"correct_score":       true,
+	"both_teams_to_score": true,
+}
+
+// eventTransitions lists the statuses an event is allowed to move to from its current status.
+var eventTransitions = map[string][]string{
+	entities.EventStatusScheduled: {entities.EventStatusInPlay, entities.EventStatusCancelled},
+	entities.EventStatusInPlay:    {entities.EventStatusFinished, entities.EventStatusCancelled},
+}
+
+// marketTransitions lists the statuses a market is allowed to move to from its current status.
+var marketTransitions = map[string][]string{
+	entities.MarketStatusOpen:      {entities.MarketStatusSuspended, entities.MarketStatusClosed},
+	entities.MarketStatusSuspended: {entities.MarketStatusOpen, entities.MarketStatusClosed},
+	entities.MarketStatusClosed:    {entities.MarketStatusSettled},
+}
+
+// marketCloserActor is the actor of the audit entries of the events put in play and the
+// markets closed by RunMarketCloser
+const marketCloserActor = "market-closer"
+
+type EventService struct {
+	DB        database.Ext
+	EventRepo interface {
+		Create(ctx context.Context, db database.Ext, event *entities.Event) error
+		UpdateStatus(ctx context.Context, db database.Ext, event *entities.Event) (pgconn.CommandTag, error)
+		Get(ctx context.Context, db database.Ext, eventID pgtype.Int4, queryEnhancers ...repositories.QueryEnhancer) (*entities.Event, error)
+		List(ctx context.Context, db database.Ext, offset, limit uint32) ([]*entities.Event, error)
+		ListStartDue(ctx context.Context, db database.Ext, now pgtype.Timestamptz) ([]*entities.Event, error)
+	}
+	MarketRepo interface {
+		Create(ctx context.Context, db database.Ext, market *entities.Market) error
+		UpdateStatus(ctx context.Context, db database.Ext, market *entities.Market) (pgconn.CommandTag, error)
+		Get(ctx context.Context, db database.Ext, marketID pgtype.Int4, queryEnhancers ...repositories.QueryEnhancer) (*entities.Market, error)
+		ListByEvent(ctx context.Context, db database.Ext, eventID pgtype.Int4) ([]*entities.Market, error)
+	}
+	WagerRepo interface {
+		ListIDsByMarket(ctx context.Context, db database.Ext, marketID pgtype.Int4) ([]int32, error)
+	}
+	OutboxRepo interface {
+		Create(ctx context.Context, db database.Ext, message *entities.OutboxMessage) error
+	}
+	StakeHoldingRepo interface {
+		ListByWager(ctx context.Context, db database.Ext, wagerID pgtype.Int4) ([]*entities.StakeHolding, error)
+		ListUnheldPurchases(ctx context.Context, db database.Ext, wagerID pgtype.Int4) ([]*entities.Purchase, error)
+	}
+	AuditLogRepo interface {
+		Create(ctx context.Context, db database.Ext, auditLog *entities.AuditLog) error
+	}
+}
+
+func canTransition(transitions map[string][]string, from, to string) bool {
+	for _, status := range transitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+func validateCreateEventReq(req *models.CreateEventRequest, now time.Time) error {
+	if strings.TrimSpace(req.Sport) == "" {
+		return fmt.Errorf("the sport must not be empty")
+	}
+	if strings.TrimSpace(req.HomeTeam) == "" || strings.TrimSpace(req.AwayTeam) == "" {
+		return fmt.Errorf("the home_team and away_team must not be empty")
+	}
+	if strings.EqualFold(strings.TrimSpace(req.HomeTeam), strings.TrimSpace(req.AwayTeam)) {
+		return fmt.Errorf("the home_team and away_team must be different")
+	}
+	if !req.StartAt.After(now) {
+		return fmt.Errorf("the start_at must be in the future")
+	}
+	return nil
+}
+
+func validateCreateMarketReq(req *models.CreateMarketRequest) error {
+	if !marketTypes[req.MarketType] {
+		return fmt.Errorf("the market_type must be one of match_winner, over_under, handicap, correct_score, both_teams_to_score")
+	}
+	return nil
+}
+
+func (s *EventService) CreateEvent(resp http.ResponseWriter, req *http.Request) {
+	createEventRequest := &models.CreateEventRequest{}
+	err := json.NewDecoder(req.Body).Decode(&createEventRequest)
+	defer req.Body.Close()
+	if err != nil {
+		resp.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": "unable to parse request",
+		})
+		return
+	}
+	now := time.Now()
+	if err := validateCreateEventReq(createEventRequest, now); err != nil {
+		resp.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	event := &entities.Event{}
+	database.AllNullEntity(event)
+	if err = multierr.Combine(
+		event.Sport.Set(strings.TrimSpace(createEventRequest.Sport)),
+		event.HomeTeam.Set(strings.TrimSpace(createEventRequest.HomeTeam)),
+		event.AwayTeam.Set(strings.TrimSpace(createEventRequest.AwayTeam)),
+		event.StartAt.Set(createEventRequest.StartAt),
+		event.Status.Set(entities.EventStatusScheduled),
+		event.CreatedAt.Set(now),
+		event.UpdatedAt.Set(now),
+	); err != nil {
+		resp.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": "unable to generate value for event",
+		})
+		return
+	}
+	if err := database.ExecInTx(req.Context(), s.DB, func(ctx context.Context, tx pgx.Tx) error {
+		if err := s.EventRepo.Create(ctx, tx, event); err != nil {
+			return err
+		}
+		after, err := snapshotEntity(event)
+		if err != nil {
+			return err
+		}
+		return s.AuditLogRepo.Create(ctx, tx, newEntityAuditLog(ctx, entities.AuditActionEventCreate, event.EventID.Int, pgtype.JSONB{Status: pgtype.Null}, after, now))
+	}); err != nil {
+		resp.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": "unable to create event",
+		})
+		return
+	}
+	resp.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(resp).Encode(convertEventPg2Domain(event))
+}
+
+func (s *EventService) GetEvent(resp http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	eventID, _ := ctx.Value(eventIDKey).(int)
+	event, err := s.EventRepo.Get(ctx, s.DB, database.Int4(int32(eventID)))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			resp.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(resp).Encode(map[string]string{
+				"error": "unable to get event: not found",
+			})
+			return
+		}
+		resp.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": "unable to get event",
+		})
+		return
+	}
+	resp.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(resp).Encode(convertEventPg2Domain(event))
+}
+
+func (s *EventService) ListEvent(resp http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	if err := validatePaginationParam(req); err != nil {
+		resp.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	page, limit := ctx.Value(pageKey).(int), ctx.Value(limitKey).(int)
+	events, err := s.EventRepo.List(ctx, s.DB, uint32((page-1)*limit), uint32(limit))
+	if err != nil {
+		resp.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": "unable to list event",
+		})
+		return
+	}
+	eventModels := make([]*models.Event, 0, len(events))
+	for _, event := range events {
+		eventModels = append(eventModels, convertEventPg2Domain(event))
+	}
+	resp.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(resp).Encode(eventModels)
+}
+
+// UpdateEventStatus moves an event through its lifecycle, the markets of an event
+// which is cancelled or finished are closed in the same transaction.
+func (s *EventService) UpdateEventStatus(resp http.ResponseWriter, req *http.Request) {
+	updateStatusRequest := &models.UpdateStatusRequest{}
+	err := json.NewDecoder(req.Body).Decode(&updateStatusRequest)
+	defer req.Body.Close()
+	if err != nil {
+		resp.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": "unable to parse request",
+		})
+		return
+	}
+	ctx := req.Context()
+	eventID, _ := ctx.Value(eventIDKey).(int)
+	var event *entities.Event
+	if err := database.ExecInTx(ctx, s.DB, func(ctx context.Context, tx pgx.Tx) error {
+		event, err = s.EventRepo.Get(ctx, tx, database.Int4(int32(eventID)), repositories.WithUpdateLock())
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return errEventNotFound
+			}
+			return fmt.Errorf("unable to get event information")
+		}
+		if !canTransition(eventTransitions, event.Status.String, updateStatusRequest.Status) {
+			return fmt.Errorf("%w: from %s to %s", errInvalidStatusTransition, event.Status.String, updateStatusRequest.Status)
+		}
+		before, err := snapshotEntity(event)
+		if err != nil {
+			return fmt.Errorf("unable to snapshot event record")
+		}
+		if err := event.Status.Set(updateStatusRequest.Status); err != nil {
+			return fmt.Errorf("unable to generate event record")
+		}
+		if _, err := s.EventRepo.UpdateStatus(ctx, tx, event); err != nil {
+			return fmt.Errorf("unable to update event record")
+		}
+		after, err := snapshotEntity(event)
+		if err != nil {
+			return fmt.Errorf("unable to snapshot event record")
+		}
+		if err := s.AuditLogRepo.Create(ctx, tx, newEntityAuditLog(ctx, entities.AuditActionEventStatus, event.EventID.Int, before, after, time.Now())); err != nil {
+			return fmt.Errorf("unable to create audit log")
+		}
+		if updateStatusRequest.Status == entities.EventStatusInPlay ||
+			updateStatusRequest.Status == entities.EventStatusFinished ||
+			updateStatusRequest.Status == entities.EventStatusCancelled {
+			if err := s.closeEventMarkets(ctx, tx, event.EventID); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		resp.WriteHeader(statusFromError(err))
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": fmt.Sprintf("unable to update event: %s", err),
+		})
+		return
+	}
+	resp.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(resp).Encode(convertEventPg2Domain(event))
+}
+
+func (s *EventService) CreateMarket(resp http.ResponseWriter, req *http.Request) {
+	createMarketRequest := &models.CreateMarketRequest{}
+	err := json.NewDecoder(req.Body).Decode(&createMarketRequest)
+	defer req.Body.Close()
+	if err != nil {
+		resp.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": "unable to parse request",
+		})
+		return
+	}
+	if err := validateCreateMarketReq(createMarketRequest); err != nil {
+		resp.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	ctx := req.Context()
+	eventID, _ := ctx.Value(eventIDKey).(int)
+	event, err := s.EventRepo.Get(ctx, s.DB, database.Int4(int32(eventID)))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			resp.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(resp).Encode(map[string]string{
+				"error": "unable to create market: event not found",
+			})
+			return
+		}
+		resp.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": "unable to get event",
+		})
+		return
+	}
+	if event.Status.String != entities.EventStatusScheduled {
+		resp.WriteHeader(http.StatusConflict)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": "unable to create market: the event is not scheduled anymore",
+		})
+		return
+	}
+
+	now := time.Now()
+	market := &entities.Market{}
+	database.AllNullEntity(market)
+	if err = multierr.Combine(
+		market.EventID.Set(eventID),
+		market.MarketType.Set(createMarketRequest.MarketType),
+		market.Status.Set(entities.MarketStatusOpen),
+		market.CreatedAt.Set(now),
+		market.UpdatedAt.Set(now),
+	); err != nil {
+		resp.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": "unable to generate value for market",
+		})
+		return
+	}
+	if err := database.ExecInTx(ctx, s.DB, func(ctx context.Context, tx pgx.Tx) error {
+		if err := s.MarketRepo.Create(ctx, tx, market); err != nil {
+			return err
+		}
+		after, err := snapshotEntity(market)
+		if err != nil {
+			return err
+		}
+		return s.AuditLogRepo.Create(ctx, tx, newEntityAuditLog(ctx, entities.AuditActionMarketCreate, market.MarketID.Int, pgtype.JSONB{Status: pgtype.Null}, after, now))
+	}); err != nil {
+		resp.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": "unable to create market",
+		})
+		return
+	}
+	resp.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(resp).Encode(convertMarketPg2Domain(market))
+}
+
+func (s *EventService) ListMarket(resp http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	eventID, _ := ctx.Value(eventIDKey).(int)
+	markets, err := s.MarketRepo.ListByEvent(ctx, s.DB, database.Int4(int32(eventID)))
+	if err != nil {
+		resp.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": "unable to list market",
+		})
+		return
+	}
+	marketModels := make([]*models.Market, 0, len(markets))
+	for _, market := range markets {
+		marketModels = append(marketModels, convertMarketPg2Domain(market))
+	}
+	resp.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(resp).Encode(marketModels)
+}
+
+func (s *EventService) GetMarket(resp http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	marketID, _ := ctx.Value(marketIDKey).(int)
+	market, err := s.MarketRepo.Get(ctx, s.DB, database.Int4(int32(marketID)))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			resp.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(resp).Encode(map[string]string{
+				"error": "unable to get market: not found",
+			})
+			return
+		}
+		resp.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": "unable to get market",
+		})
+		return
+	}
+	resp.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(resp).Encode(convertMarketPg2Domain(market))
+}
+
+// UpdateMarketStatus suspends, reopens, closes or settles a market. A market can't be
+// reopened once its event has started.
+func (s *EventService) UpdateMarketStatus(resp http.ResponseWriter, req *http.Request) {
+	updateStatusRequest := &models.UpdateStatusRequest{}
+	err := json.NewDecoder(req.Body).Decode(&updateStatusRequest)
+	defer req.Body.Close()
+	if err != nil {
+		resp.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": "unable to parse request",
+		})
+		return
+	}
+	ctx := req.Context()
+	marketID, _ := ctx.Value(marketIDKey).(int)
+	var market *entities.Market
+	if err := database.ExecInTx(ctx, s.DB, func(ctx context.Context, tx pgx.Tx) error {
+		market, err = s.MarketRepo.Get(ctx, tx, database.Int4(int32(marketID)), repositories.WithUpdateLock())
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return errMarketNotFound
+			}
+			return fmt.Errorf("unable to get market information")
+		}
+		if !canTransition(marketTransitions, market.Status.String, updateStatusRequest.Status) {
+			return fmt.Errorf("%w: from %s to %s", errInvalidStatusTransition, market.Status.String, updateStatusRequest.Status)
+		}
+		if updateStatusRequest.Status == entities.MarketStatusOpen {
+			event, err := s.EventRepo.Get(ctx, tx, market.EventID)
+			if err != nil {
+				return fmt.Errorf("unable to get event information")
+			}
+			if event.Status.String != entities.EventStatusScheduled || !event.StartAt.Time.After(time.Now()) {
+				return fmt.Errorf("%w: the event has already started", errInvalidStatusTransition)
+			}
+		}
+		before, err := snapshotEntity(market)
+		if err != nil {
+			return fmt.Errorf("unable to snapshot market record")
+		}
+		if err := market.Status.Set(updateStatusRequest.Status); err != nil {
+			return fmt.Errorf("unable to generate market record")
+		}
+		if _, err := s.MarketRepo.UpdateStatus(ctx, tx, market); err != nil {
+			return fmt.Errorf("unable to update market record")
+		}
+		after, err := snapshotEntity(market)
+		if err != nil {
+			return fmt.Errorf("unable to snapshot market record")
+		}
+		if err := s.AuditLogRepo.Create(ctx, tx, newEntityAuditLog(ctx, entities.AuditActionMarketStatus, market.MarketID.Int, before, after, time.Now())); err != nil {
+			return fmt.Errorf("unable to create audit log")
+		}
+		if market.Status.String == entities.MarketStatusSettled {
+			return s.addWagersSettled(ctx, tx, market.MarketID)
+		}
+		return nil
+	}); err != nil {
+		resp.WriteHeader(statusFromError(err))
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": fmt.Sprintf("unable to update market: %s", err),
+		})
+		return
+	}
+	resp.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(resp).Encode(convertMarketPg2Domain(market))
+}
+
+// addWagersSettled writes a WagerSettled outbox event for every wager of a settled
+// market, in the transaction settling it. The payouts go to the current holders of the
+// stakes bought, resold or not, and to the purchases nobody holds, the ones made without
+// a buyer which are paid out without a holder.
+func (s *EventService) addWagersSettled(ctx context.Context, db database.Ext, marketID pgtype.Int4) error {
+	wagerIDs, err := s.WagerRepo.ListIDsByMarket(ctx, db, marketID)
+	if err != nil {
+		return fmt.Errorf("unable to list the wagers of the market")
+	}
+	now := time.Now()
+	for _, wagerID := range wagerIDs {
+		holdings, err := s.StakeHoldingRepo.ListByWager(ctx, db, database.Int4(wagerID))
+		if err != nil {
+			return fmt.Errorf("unable to list the holders of the wager")
+		}
+		purchases, err := s.StakeHoldingRepo.ListUnheldPurchases(ctx, db, database.Int4(wagerID))
+		if err != nil {
+			return fmt.Errorf("unable to list the purchases of the wager")
+		}
+		payouts := make([]*models.Payout, 0, len(holdings)+len(purchases))
+		for _, holding := range holdings {
+			payouts = append(payouts, &models.Payout{
+				HolderID:   holding.HolderID.String,
+				PurchaseID: int(holding.PurchaseID.Int),
+				Stake:      holding.Stake.Float,
+			})
+		}
+		for _, purchase := range purchases {
+			payouts = append(payouts, &models.Payout{
+				HolderID:   purchase.BuyerID.String,
+				PurchaseID: int(purchase.PurchaseID.Int),
+				Stake:      purchase.BuyingPrice.Float,
+			})
+		}
+		message, err := newOutboxMessage(entities.OutboxWagerSettled, database.Int4(wagerID), &models.WagerSettled{
+			WagerID:   int(wagerID),
+			MarketID:  int(marketID.Int),
+			SettledAt: now,
+			Payouts:   payouts,
+		}, now)
+		if err != nil {
+			return fmt.Errorf("unable to generate outbox record")
+		}
+		if err := s.OutboxRepo.Create(ctx, db, message); err != nil {
+			return fmt.Errorf("unable to create outbox record")
+		}
+	}
+	return nil
+}
+
+// closeEventMarkets closes the open and suspended markets of an event, each along an
+// audit entry, so that no more wager sales can happen on them.
+func (s *EventService) closeEventMarkets(ctx context.Context, tx database.Ext, eventID pgtype.Int4) error {
+	markets, err := s.MarketRepo.ListByEvent(ctx, tx, eventID)
+	if err != nil {
+		return fmt.Errorf("unable to list the markets of the event")
+	}
+	for _, market := range markets {
+		if market.Status.String != entities.MarketStatusOpen && market.Status.String != entities.MarketStatusSuspended {
+			continue
+		}
+		before, err := snapshotEntity(market)
+		if err != nil {
+			return fmt.Errorf("unable to snapshot market record")
+		}
+		if err := market.Status.Set(entities.MarketStatusClosed); err != nil {
+			return fmt.Errorf("unable to generate market record")
+		}
+		if _, err := s.MarketRepo.UpdateStatus(ctx, tx, market); err != nil {
+			return fmt.Errorf("unable to close the markets of the event")
+		}
+		after, err := snapshotEntity(market)
+		if err != nil {
+			return fmt.Errorf("unable to snapshot market record")
+		}
+		if err := s.AuditLogRepo.Create(ctx, tx, newEntityAuditLog(ctx, entities.AuditActionMarketStatus, market.MarketID.Int, before, after, time.Now())); err != nil {
+			return fmt.Errorf("unable to create audit log")
+		}
+	}
+	return nil
+}
+
+// CloseStartedEvents puts every event whose start time has passed in play and closes
+// their markets, so the wagers placed on them can't be bought anymore. The changes are
+// audited with marketCloserActor as their actor.
+func (s *EventService) CloseStartedEvents(ctx context.Context) (int, error) {
+	var startedEvents int
+	ctx = withAuditInfo(ctx, auditInfo{actor: marketCloserActor})
+	err := database.ExecInTx(ctx, s.DB, func(ctx context.Context, tx pgx.Tx) error {
+		events, err := s.EventRepo.ListStartDue(ctx, tx, database.Timestamptz(time.Now()))
+		if err != nil {
+			return fmt.Errorf("unable to list due events: %w", err)
+		}
+		for _, event := range events {
+			before, err := snapshotEntity(event)
+			if err != nil {
+				return fmt.Errorf("unable to snapshot event record")
+			}
+			if err := event.Status.Set(entities.EventStatusInPlay); err != nil {
+				return fmt.Errorf("unable to generate event record")
+			}
+			if _, err := s.EventRepo.UpdateStatus(ctx, tx, event); err != nil {
+				return fmt.Errorf("unable to start due events: %w", err)
+			}
+			after, err := snapshotEntity(event)
+			if err != nil {
+				return fmt.Errorf("unable to snapshot event record")
+			}
+			if err := s.AuditLogRepo.Create(ctx, tx, newEntityAuditLog(ctx, entities.AuditActionEventStatus, event.EventID.Int, before, after, time.Now())); err != nil {
+				return fmt.Errorf("unable to create audit log")
+			}
+			if err := s.closeEventMarkets(ctx, tx, event.EventID); err != nil {
+				return err
+			}
+		}
+		startedEvents = len(events)
+		return nil
+	})
+	return startedEvents, err
+}
+
+// RunMarketCloser calls CloseStartedEvents every interval until ctx is done.
+func (s *EventService) RunMarketCloser(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			startedEvents, err := s.CloseStartedEvents(ctx)
+			if err != nil {
+				logs.Logger.Errorw("unable to close the markets of started events", "error", err)
+				continue
+			}
+			if startedEvents > 0 {
+				logs.Logger.Infow("closed the markets of started events", "events", startedEvents)
+			}
+		}
+	}
+}
+
+func convertEventPg2Domain(event *entities.Event) *models.Event {
+	var startAt *time.Time
+	if event.StartAt.Status == pgtype.Present {
+		startAt = &event.StartAt.Time
+	}
+	return &models.Event{
+		ID:       int(event.EventID.Int),
+		Sport:    event.Sport.String,
+		HomeTeam: event.HomeTeam.String,
+		AwayTeam: event.AwayTeam.String,
+		StartAt:  startAt,
+		Status:   event.Status.String,
+	}
+}
+
+func convertMarketPg2Domain(market *entities.Market) *models.Market {
+	return &models.Market{
+		ID:         int(market.MarketID.Int),
+		EventID:    int(market.EventID.Int),
+		MarketType: market.MarketType.String,
+		Status:     market.Status.String,
+	}
+}
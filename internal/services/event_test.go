@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/internal/models"
+	"github.com/wager-api/libs/database"
+	mock_database "github.com/wager-api/mocks/libs/database"
+	mock_repositories "github.com/wager-api/mocks/repositories"
+
+	"github.com/jackc/pgconn"
+)
+
+func Test_validateCreateEventReq(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	type testcase struct {
+		name           string
+		createEventReq *models.CreateEventRequest
+		expectedErr    error
+	}
+	tests := []testcase{
+		{
+			name:           "empty sport",
+			expectedErr:    fmt.Errorf("the sport must not be empty"),
+			createEventReq: &models.CreateEventRequest{},
+		},
+		{
+			name:        "missing team",
+			expectedErr: fmt.Errorf("the home_team and away_team must not be empty"),
+			createEventReq: &models.CreateEventRequest{
+				Sport:    "football",
+				HomeTeam: "Arsenal",
+			},
+		},
+		{
+			name:        "same teams",
+			expectedErr: fmt.Errorf("the home_team and away_team must be different"),
+			createEventReq: &models.CreateEventRequest{
+				Sport:    "football",
+				HomeTeam: "Arsenal",
+				AwayTeam: "arsenal ",
+			},
+		},
+		{
+			name:        "start_at in the past",
+			expectedErr: fmt.Errorf("the start_at must be in the future"),
+			createEventReq: &models.CreateEventRequest{
+				Sport:    "football",
+				HomeTeam: "Arsenal",
+				AwayTeam: "Chelsea",
+				StartAt:  now.Add(-time.Hour),
+			},
+		},
+		{
+			name: "happy case",
+			createEventReq: &models.CreateEventRequest{
+				Sport:    "football",
+				HomeTeam: "Arsenal",
+				AwayTeam: "Chelsea",
+				StartAt:  now.Add(time.Hour),
+			},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateCreateEventReq(tc.createEventReq, now)
+			assert.Equal(t, tc.expectedErr, err)
+		})
+	}
+}
+
+func Test_validateCreateMarketReq(t *testing.T) {
+	t.Parallel()
+	assert.NoError(t, validateCreateMarketReq(&models.CreateMarketRequest{MarketType: "match_winner"}))
+	assert.Error(t, validateCreateMarketReq(&models.CreateMarketRequest{MarketType: "first_goal_scorer"}))
+}
+
+func Test_canTransition(t *testing.T) {
+	t.Parallel()
+	assert.True(t, canTransition(eventTransitions, entities.EventStatusScheduled, entities.EventStatusInPlay))
+	assert.False(t, canTransition(eventTransitions, entities.EventStatusFinished, entities.EventStatusScheduled))
+	assert.True(t, canTransition(marketTransitions, entities.MarketStatusSuspended, entities.MarketStatusOpen))
+	assert.False(t, canTransition(marketTransitions, entities.MarketStatusSettled, entities.MarketStatusOpen))
+}
+
+func Test_addWagersSettled(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	tx := &mock_database.Tx{}
+	wagerRepo := &mock_repositories.MockWagerRepo{}
+	stakeHoldingRepo := &mock_repositories.MockStakeHoldingRepo{}
+	outboxRepo := &mock_repositories.MockOutboxRepo{}
+	wagerRepo.On("ListIDsByMarket", ctx, tx, database.Int4(3)).Once().Return([]int32{1}, nil)
+	stakeHoldingRepo.On("ListByWager", ctx, tx, database.Int4(1)).Once().Return([]*entities.StakeHolding{
+		{HolderID: database.Text("buyer-1"), PurchaseID: database.Int4(101), Stake: database.Float4(15)},
+	}, nil)
+	// the purchase made without a buyer is paid out too
+	stakeHoldingRepo.On("ListUnheldPurchases", ctx, tx, database.Int4(1)).Once().Return([]*entities.Purchase{
+		{PurchaseID: database.Int4(102), BuyingPrice: database.Float4(10)},
+	}, nil)
+	var settled models.WagerSettled
+	outboxRepo.On("Create", ctx, tx, mock.Anything).Once().Run(func(args mock.Arguments) {
+		_ = args.Get(2).(*entities.OutboxMessage).Payload.AssignTo(&settled)
+	}).Return(nil)
+
+	s := &EventService{WagerRepo: wagerRepo, StakeHoldingRepo: stakeHoldingRepo, OutboxRepo: outboxRepo}
+	assert.NoError(t, s.addWagersSettled(ctx, tx, database.Int4(3)))
+	assert.Equal(t, []*models.Payout{
+		{HolderID: "buyer-1", PurchaseID: 101, Stake: 15},
+		{PurchaseID: 102, Stake: 10},
+	}, settled.Payouts)
+	outboxRepo.AssertExpectations(t)
+}
+
+func Test_CloseStartedEvents(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	mockErr := fmt.Errorf("mock-error")
+
+	t.Run("close the markets of started events", func(t *testing.T) {
+		db := &mock_database.Ext{}
+		tx := &mock_database.Tx{}
+		eventRepo := &mock_repositories.MockEventRepo{}
+		marketRepo := &mock_repositories.MockMarketRepo{}
+		auditLogRepo := &mock_repositories.MockAuditLogRepo{}
+		db.On("Begin", mock.Anything).Return(tx, nil)
+		tx.On("Commit", mock.Anything).Return(nil)
+		eventRepo.On("ListStartDue", mock.Anything, tx, mock.Anything).Once().Return([]*entities.Event{
+			{EventID: database.Int4(1), Status: database.Text(entities.EventStatusScheduled)},
+			{EventID: database.Int4(2), Status: database.Text(entities.EventStatusScheduled)},
+		}, nil)
+		eventRepo.On("UpdateStatus", mock.Anything, tx, mock.MatchedBy(func(event *entities.Event) bool {
+			return event.Status.String == entities.EventStatusInPlay
+		})).Twice().Return(pgconn.CommandTag("UPDATE 1"), nil)
+		marketRepo.On("ListByEvent", mock.Anything, tx, database.Int4(1)).Once().Return([]*entities.Market{
+			{MarketID: database.Int4(3), Status: database.Text(entities.MarketStatusOpen)},
+			{MarketID: database.Int4(4), Status: database.Text(entities.MarketStatusSettled)},
+		}, nil)
+		marketRepo.On("ListByEvent", mock.Anything, tx, database.Int4(2)).Once().Return([]*entities.Market{
+			{MarketID: database.Int4(5), Status: database.Text(entities.MarketStatusSuspended)},
+		}, nil)
+		marketRepo.On("UpdateStatus", mock.Anything, tx, mock.MatchedBy(func(market *entities.Market) bool {
+			return market.MarketID.Int != 4 && market.Status.String == entities.MarketStatusClosed
+		})).Twice().Return(pgconn.CommandTag("UPDATE 1"), nil)
+		// every change is audited with the actor of the job
+		auditLogRepo.On("Create", mock.Anything, tx, mock.MatchedBy(func(auditLog *entities.AuditLog) bool {
+			return auditLog.Actor.String == marketCloserActor
+		})).Times(4).Return(nil)
+
+		s := &EventService{DB: db, EventRepo: eventRepo, MarketRepo: marketRepo, AuditLogRepo: auditLogRepo}
+		startedEvents, err := s.CloseStartedEvents(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, startedEvents)
+		eventRepo.AssertExpectations(t)
+		marketRepo.AssertExpectations(t)
+		auditLogRepo.AssertExpectations(t)
+	})
+	t.Run("no event to start", func(t *testing.T) {
+		db := &mock_database.Ext{}
+		tx := &mock_database.Tx{}
+		eventRepo := &mock_repositories.MockEventRepo{}
+		marketRepo := &mock_repositories.MockMarketRepo{}
+		db.On("Begin", mock.Anything).Return(tx, nil)
+		tx.On("Commit", mock.Anything).Return(nil)
+		eventRepo.On("ListStartDue", mock.Anything, tx, mock.Anything).Once().Return([]*entities.Event{}, nil)
+
+		s := &EventService{DB: db, EventRepo: eventRepo, MarketRepo: marketRepo}
+		startedEvents, err := s.CloseStartedEvents(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, startedEvents)
+		marketRepo.AssertNotCalled(t, "ListByEvent", mock.Anything, mock.Anything, mock.Anything)
+	})
+	t.Run("error when starting due events", func(t *testing.T) {
+		db := &mock_database.Ext{}
+		tx := &mock_database.Tx{}
+		eventRepo := &mock_repositories.MockEventRepo{}
+		marketRepo := &mock_repositories.MockMarketRepo{}
+		db.On("Begin", mock.Anything).Return(tx, nil)
+		tx.On("Rollback", mock.Anything).Return(nil)
+		eventRepo.On("ListStartDue", mock.Anything, tx, mock.Anything).Once().Return(nil, mockErr)
+
+		s := &EventService{DB: db, EventRepo: eventRepo, MarketRepo: marketRepo}
+		_, err := s.CloseStartedEvents(ctx)
+		assert.ErrorIs(t, err, mockErr)
+	})
+}
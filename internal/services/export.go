@@ -0,0 +1,339 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/internal/repositories"
+	"github.com/wager-api/libs/database"
+	"github.com/wager-api/libs/logs"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+)
+
+const (
+	// ExportWagers and ExportPurchases are the kinds of rows which can be exported
+	ExportWagers    = "wagers"
+	ExportPurchases = "purchases"
+	// ExportFormatCSV and ExportFormatNDJSON are the formats of an export, the csv has a
+	// header line naming the columns, the ndjson is a JSON object per line
+	ExportFormatCSV    = "csv"
+	ExportFormatNDJSON = "ndjson"
+
+	defaultExportFetchSize = 1000
+	exportCursor           = "export_cursor"
+)
+
+var exportContentTypes = map[string]string{
+	ExportFormatCSV:    "text/csv",
+	ExportFormatNDJSON: "application/x-ndjson",
+}
+
+// ExportService streams the wagers and the purchases out for the finance, as many rows
+// as there are with a flat memory.
+type ExportService struct {
+	DB         database.Ext
+	ExportRepo interface {
+		DeclareWagerCursor(ctx context.Context, db database.Ext, cursor string, filter repositories.ExportFilter) error
+		DeclarePurchaseCursor(ctx context.Context, db database.Ext, cursor string, filter repositories.ExportFilter) error
+		FetchWagers(ctx context.Context, db database.Ext, cursor string, count uint32) ([]*entities.Wager, error)
+		FetchPurchases(ctx context.Context, db database.Ext, cursor string, count uint32) ([]*entities.Purchase, error)
+	}
+	// FetchSize is the number of rows read from the cursor at a time
+	FetchSize uint32
+}
+
+// NewExportFilter reads the filters of an export, from and to are RFC 3339 dates and
+// status the status of the market of the wagers, empty values don't filter.
+func NewExportFilter(from, to, status string) (repositories.ExportFilter, error) {
+	filter := repositories.ExportFilter{}
+	_ = filter.From.Set(nil)
+	_ = filter.To.Set(nil)
+	_ = filter.MarketStatus.Set(nil)
+	for _, param := range []struct {
+		name  string
+		value string
+		dst   *pgtype.Timestamptz
+	}{
+		{"from", from, &filter.From},
+		{"to", to, &filter.To},
+	} {
+		if param.value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, param.value)
+		if err != nil {
+			return filter, &validationError{fmt.Errorf("the %s must be a RFC 3339 date", param.name)}
+		}
+		_ = param.dst.Set(t)
+	}
+	if filter.From.Status == pgtype.Present && filter.To.Status == pgtype.Present && !filter.From.Time.Before(filter.To.Time) {
+		return filter, &validationError{fmt.Errorf("the from must be before the to")}
+	}
+	switch status {
+	case "":
+	case entities.MarketStatusOpen, entities.MarketStatusSuspended, entities.MarketStatusClosed, entities.MarketStatusSettled:
+		_ = filter.MarketStatus.Set(status)
+	default:
+		return filter, &validationError{fmt.Errorf("the status must be one of %s, %s, %s, %s",
+			entities.MarketStatusOpen, entities.MarketStatusSuspended, entities.MarketStatusClosed, entities.MarketStatusSettled)}
+	}
+	return filter, nil
+}
+
+// exportEncoder writes the rows of an export in one format.
+type exportEncoder interface {
+	Encode(e database.Entity) error
+	Flush() error
+}
+
+type csvExportEncoder struct {
+	out    io.Writer
+	w      *csv.Writer
+	header []string
+}
+
+func (c *csvExportEncoder) writeHeader() error {
+	if c.header == nil {
+		return nil
+	}
+	header := c.header
+	c.header = nil
+	return c.w.Write(header)
+}
+
+func (c *csvExportEncoder) Encode(e database.Entity) error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+	fields, values := e.FieldMap()
+	record := make([]string, len(fields))
+	for i := range fields {
+		record[i] = formatCSVValue(exportValue(values[i]))
+	}
+	return c.w.Write(record)
+}
+
+// Flush writes the header of an export without rows too.
+func (c *csvExportEncoder) Flush() error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+	c.w.Flush()
+	if err := c.w.Error(); err != nil {
+		return err
+	}
+	flushWriter(c.out)
+	return nil
+}
+
+type ndjsonExportEncoder struct {
+	out io.Writer
+	enc *json.Encoder
+}
+
+func (n *ndjsonExportEncoder) Encode(e database.Entity) error {
+	fields, values := e.FieldMap()
+	row := make(map[string]interface{}, len(fields))
+	for i, field := range fields {
+		row[field] = exportValue(values[i])
+	}
+	return n.enc.Encode(row)
+}
+
+func (n *ndjsonExportEncoder) Flush() error {
+	flushWriter(n.out)
+	return nil
+}
+
+// newExportEncoder returns the encoder of format writing to w the rows of the same type
+// as header.
+func newExportEncoder(w io.Writer, format string, header database.Entity) exportEncoder {
+	if format == ExportFormatCSV {
+		return &csvExportEncoder{out: w, w: csv.NewWriter(w), header: database.GetFieldNames(header)}
+	}
+	return &ndjsonExportEncoder{out: w, enc: json.NewEncoder(w)}
+}
+
+// flushWriter sends what is buffered so far to the client when w is a response.
+func flushWriter(w io.Writer) {
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// exportValue is the plain go value of a column, nil when it is null.
+func exportValue(value interface{}) interface{} {
+	if v, ok := value.(pgtype.Value); ok {
+		if got := v.Get(); got != pgtype.Undefined {
+			return got
+		}
+		return nil
+	}
+	return value
+}
+
+func formatCSVValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Export writes the rows of kind matching filter to w in format, reading them from a
+// cursor FetchSize rows at a time. The rows are written as they are read, the ones
+// written before an error are not taken back. It returns the number of rows written.
+func (s *ExportService) Export(ctx context.Context, w io.Writer, kind, format string, filter repositories.ExportFilter) (int, error) {
+	if kind != ExportWagers && kind != ExportPurchases {
+		return 0, &validationError{fmt.Errorf("the export must be one of %s, %s", ExportWagers, ExportPurchases)}
+	}
+	if _, ok := exportContentTypes[format]; !ok {
+		return 0, &validationError{fmt.Errorf("the format must be one of %s, %s", ExportFormatCSV, ExportFormatNDJSON)}
+	}
+	fetchSize := s.FetchSize
+	if fetchSize == 0 {
+		fetchSize = defaultExportFetchSize
+	}
+	var header database.Entity = &entities.Wager{}
+	if kind == ExportPurchases {
+		header = &entities.Purchase{}
+	}
+	encoder := newExportEncoder(w, format, header)
+	written := 0
+	// the cursor only lives in a transaction, which also makes the export a snapshot
+	err := database.ExecInTx(ctx, s.DB, func(ctx context.Context, tx pgx.Tx) error {
+		declare, fetch := s.ExportRepo.DeclareWagerCursor, func() ([]database.Entity, error) {
+			wagers, err := s.ExportRepo.FetchWagers(ctx, tx, exportCursor, fetchSize)
+			rows := make([]database.Entity, 0, len(wagers))
+			for _, wager := range wagers {
+				rows = append(rows, wager)
+			}
+			return rows, err
+		}
+		if kind == ExportPurchases {
+			declare, fetch = s.ExportRepo.DeclarePurchaseCursor, func() ([]database.Entity, error) {
+				purchases, err := s.ExportRepo.FetchPurchases(ctx, tx, exportCursor, fetchSize)
+				rows := make([]database.Entity, 0, len(purchases))
+				for _, purchase := range purchases {
+					rows = append(rows, purchase)
+				}
+				return rows, err
+			}
+		}
+		if err := declare(ctx, tx, exportCursor, filter); err != nil {
+			return fmt.Errorf("unable to declare export cursor: %w", err)
+		}
+		for {
+			rows, err := fetch()
+			if err != nil {
+				return fmt.Errorf("unable to fetch export rows: %w", err)
+			}
+			for _, row := range rows {
+				if err := encoder.Encode(row); err != nil {
+					return fmt.Errorf("unable to write export row: %w", err)
+				}
+				written++
+			}
+			if err := encoder.Flush(); err != nil {
+				return fmt.Errorf("unable to write export row: %w", err)
+			}
+			if len(rows) < int(fetchSize) {
+				return nil
+			}
+		}
+	})
+	return written, err
+}
+
+// exportResponseWriter holds the headers of an export back until its first byte, so a
+// failure before anything is sent can still be answered with an error status.
+type exportResponseWriter struct {
+	http.ResponseWriter
+	contentType string
+	filename    string
+	wrote       bool
+}
+
+func (w *exportResponseWriter) writeHeader() {
+	if w.wrote {
+		return
+	}
+	w.wrote = true
+	w.Header().Set("Content-Type", w.contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, w.filename))
+	w.ResponseWriter.WriteHeader(http.StatusOK)
+}
+
+func (w *exportResponseWriter) Write(b []byte) (int, error) {
+	w.writeHeader()
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *exportResponseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// ExportRows streams the rows of the export named in the path:
+// ?format=csv|ndjson&from=&to=&status=
+func (s *ExportService) ExportRows(resp http.ResponseWriter, req *http.Request) {
+	kind := chi.URLParam(req, "kind")
+	query := req.URL.Query()
+	format := query.Get("format")
+	if format == "" {
+		format = ExportFormatCSV
+	}
+	filter, err := NewExportFilter(query.Get("from"), query.Get("to"), query.Get("status"))
+	if err == nil && exportContentTypes[format] == "" {
+		err = &validationError{fmt.Errorf("the format must be one of %s, %s", ExportFormatCSV, ExportFormatNDJSON)}
+	}
+	if err != nil {
+		resp.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	w := &exportResponseWriter{
+		ResponseWriter: resp,
+		contentType:    exportContentTypes[format],
+		filename:       fmt.Sprintf("%s.%s", kind, format),
+	}
+	if _, err := s.Export(req.Context(), w, kind, format, filter); err != nil {
+		if !w.wrote {
+			status := statusFromError(err)
+			message := err.Error()
+			if status == http.StatusInternalServerError {
+				message = "unable to export rows"
+			}
+			resp.WriteHeader(status)
+			_ = json.NewEncoder(resp).Encode(map[string]string{
+				"error": message,
+			})
+			return
+		}
+		// the status is sent already, cut the connection so the client can't take the
+		// partial export for a complete one
+		logs.Logger.Errorw("unable to export rows", "export", kind, "error", err)
+		panic(http.ErrAbortHandler)
+	}
+	w.writeHeader()
+}
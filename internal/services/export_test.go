@@ -0,0 +1,157 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/internal/repositories"
+	"github.com/wager-api/libs/database"
+	mock_database "github.com/wager-api/mocks/libs/database"
+	mock_repositories "github.com/wager-api/mocks/repositories"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgtype"
+)
+
+func Test_NewExportFilter(t *testing.T) {
+	t.Parallel()
+	filter, err := NewExportFilter("2024-01-01T00:00:00Z", "", entities.MarketStatusSettled)
+	assert.NoError(t, err)
+	assert.True(t, filter.From.Time.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, pgtype.Null, filter.To.Status)
+	assert.Equal(t, database.Text(entities.MarketStatusSettled), filter.MarketStatus)
+
+	_, err = NewExportFilter("yesterday", "", "")
+	assert.EqualError(t, err, "the from must be a RFC 3339 date")
+	_, err = NewExportFilter("2024-02-01T00:00:00Z", "2024-01-01T00:00:00Z", "")
+	assert.EqualError(t, err, "the from must be before the to")
+	_, err = NewExportFilter("", "", "sold")
+	assert.EqualError(t, err, "the status must be one of open, suspended, closed, settled")
+}
+
+func exportWager(id int32) *entities.Wager {
+	wager := &entities.Wager{}
+	database.AllNullEntity(wager)
+	wager.WagerID = database.Int4(id)
+	wager.TotalWagerValue = database.Float4(50)
+	wager.CurrentSellingPrice = database.Float4(40.1)
+	wager.PlaceAt = database.Timestamptz(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	return wager
+}
+
+func Test_ExportService_Export(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	filter, _ := NewExportFilter("", "", "")
+
+	t.Run("csv is fetched a batch at a time", func(t *testing.T) {
+		db := &mock_database.Ext{}
+		tx := &mock_database.Tx{}
+		exportRepo := &mock_repositories.MockExportRepo{}
+		db.On("Begin", ctx).Return(tx, nil)
+		tx.On("Commit", mock.Anything).Return(nil)
+		exportRepo.On("DeclareWagerCursor", ctx, tx, exportCursor, filter).Once().Return(nil)
+		exportRepo.On("FetchWagers", ctx, tx, exportCursor, uint32(2)).Once().Return([]*entities.Wager{exportWager(1), exportWager(2)}, nil)
+		exportRepo.On("FetchWagers", ctx, tx, exportCursor, uint32(2)).Once().Return([]*entities.Wager{exportWager(3)}, nil)
+
+		s := &ExportService{DB: db, ExportRepo: exportRepo, FetchSize: 2}
+		buf := &bytes.Buffer{}
+		written, err := s.Export(ctx, buf, ExportWagers, ExportFormatCSV, filter)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, written)
+		row := ",,,50,,,,40.1,,,2024-01-02T03:04:05Z,,,,,,,,"
+		assert.Equal(t, "wager_id,market_id,seller_id,total_wager_value,odds,selling_percentage,selling_price,current_selling_price,percentage_sold,amount_sold,place_at,created_at,updated_at,deleted_at,decay_type,decay_floor_price,decay_duration_seconds,decay_step_seconds,currency\n"+
+			"1"+row+"\n2"+row+"\n3"+row+"\n", buf.String())
+		exportRepo.AssertExpectations(t)
+	})
+	t.Run("ndjson of purchases", func(t *testing.T) {
+		db := &mock_database.Ext{}
+		tx := &mock_database.Tx{}
+		exportRepo := &mock_repositories.MockExportRepo{}
+		db.On("Begin", ctx).Return(tx, nil)
+		tx.On("Commit", mock.Anything).Return(nil)
+		exportRepo.On("DeclarePurchaseCursor", ctx, tx, exportCursor, filter).Once().Return(nil)
+		exportRepo.On("FetchPurchases", ctx, tx, exportCursor, uint32(defaultExportFetchSize)).Once().Return([]*entities.Purchase{{
+			PurchaseID:  database.Int4(7),
+			WagerID:     database.Int4(1),
+			BuyingPrice: database.Float4(20.3),
+			BoughtAt:    database.Timestamptz(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)),
+			DeletedAt:   pgtype.Timestamptz{Status: pgtype.Null},
+		}}, nil)
+
+		s := &ExportService{DB: db, ExportRepo: exportRepo}
+		buf := &bytes.Buffer{}
+		written, err := s.Export(ctx, buf, ExportPurchases, ExportFormatNDJSON, filter)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, written)
+		assert.JSONEq(t, `{"purchase_id":7,"wager_id":1,"buying_price":20.3,"bought_at":"2024-01-02T03:04:05Z","created_at":null,"updated_at":null,"deleted_at":null,"buyer_id":null,"paid_currency":null,"paid_amount":null,"exchange_rate":null}`, buf.String())
+	})
+	t.Run("csv without rows still has its header", func(t *testing.T) {
+		db := &mock_database.Ext{}
+		tx := &mock_database.Tx{}
+		exportRepo := &mock_repositories.MockExportRepo{}
+		db.On("Begin", ctx).Return(tx, nil)
+		tx.On("Commit", mock.Anything).Return(nil)
+		exportRepo.On("DeclarePurchaseCursor", ctx, tx, exportCursor, filter).Once().Return(nil)
+		exportRepo.On("FetchPurchases", ctx, tx, exportCursor, uint32(defaultExportFetchSize)).Once().Return([]*entities.Purchase{}, nil)
+
+		s := &ExportService{DB: db, ExportRepo: exportRepo}
+		buf := &bytes.Buffer{}
+		_, err := s.Export(ctx, buf, ExportPurchases, ExportFormatCSV, filter)
+		assert.NoError(t, err)
+		assert.Equal(t, "purchase_id,wager_id,buying_price,bought_at,created_at,updated_at,deleted_at,buyer_id,paid_currency,paid_amount,exchange_rate\n", buf.String())
+	})
+	t.Run("unknown format", func(t *testing.T) {
+		_, err := (&ExportService{}).Export(ctx, &bytes.Buffer{}, ExportWagers, "xml", filter)
+		assert.EqualError(t, err, "the format must be one of csv, ndjson")
+	})
+}
+
+func Test_ExportRows(t *testing.T) {
+	t.Parallel()
+	db := &mock_database.Ext{}
+	tx := &mock_database.Tx{}
+	exportRepo := &mock_repositories.MockExportRepo{}
+	db.On("Begin", mock.Anything).Return(tx, nil)
+	tx.On("Commit", mock.Anything).Return(nil)
+	tx.On("Rollback", mock.Anything).Return(nil)
+	exportRepo.On("DeclareWagerCursor", mock.Anything, tx, exportCursor, mock.MatchedBy(func(filter repositories.ExportFilter) bool {
+		return filter.MarketStatus.String == entities.MarketStatusOpen
+	})).Return(nil)
+	exportRepo.On("FetchWagers", mock.Anything, tx, exportCursor, uint32(defaultExportFetchSize)).Once().Return([]*entities.Wager{exportWager(1)}, nil)
+	exportRepo.On("FetchWagers", mock.Anything, tx, exportCursor, uint32(defaultExportFetchSize)).Once().Return(nil, fmt.Errorf("mock-error"))
+	mux := chi.NewMux()
+	NewExportHandler(mux, &ExportService{DB: db, ExportRepo: exportRepo}, []string{"admin-token"})
+	serve := func(url string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		req.Header.Set("Authorization", "Bearer admin-token")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve("/admin/exports/wagers?format=ndjson&status=open")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/x-ndjson", rec.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="wagers.ndjson"`, rec.Header().Get("Content-Disposition"))
+	assert.Contains(t, rec.Body.String(), `"wager_id":1`)
+
+	rec = serve("/admin/exports/wagers?status=open")
+	assert.Equal(t, http.StatusInternalServerError, rec.Code, "nothing is sent before the failure")
+	assert.JSONEq(t, `{"error":"unable to export rows"}`, rec.Body.String())
+
+	rec = serve("/admin/exports/bets")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.JSONEq(t, `{"error":"the export must be one of wagers, purchases"}`, rec.Body.String())
+
+	rec = serve("/admin/exports/wagers?format=xml")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
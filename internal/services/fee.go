@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/internal/models"
+	"github.com/wager-api/internal/repositories"
+	"github.com/wager-api/libs/database"
+
+	"github.com/jackc/pgtype"
+)
+
+// FeeTier replaces the percentage of a schedule for the buyers who spent at least
+// MinVolume over the VolumeWindow of the FeeModel.
+type FeeTier struct {
+	MinVolume  float32
+	Percentage float32
+}
+
+// FeeSchedule is what a purchase is charged on top of its buying price: Percentage of it,
+// or the one of the highest tier its buyer reached, and Flat.
+type FeeSchedule struct {
+	Percentage float32
+	Flat       float32
+	Tiers      []FeeTier
+}
+
+// FeeModel is the schedule of the fees charged on the purchases, the wagers of the markets
+// in Markets follow their own schedule instead of Default.
+type FeeModel struct {
+	Default FeeSchedule
+	Markets map[int32]FeeSchedule
+	// VolumeWindow is how far back the volume of a buyer is summed up for the tiers, 0
+	// sums up every purchase
+	VolumeWindow time.Duration
+}
+
+func (schedule FeeSchedule) validate() error {
+	if schedule.Percentage < 0 || schedule.Percentage > 100 || schedule.Flat < 0 {
+		return fmt.Errorf("the percentage must be between 0 and 100 and the flat fee must not be negative")
+	}
+	for _, tier := range schedule.Tiers {
+		if tier.MinVolume < 0 || tier.Percentage < 0 || tier.Percentage > 100 {
+			return fmt.Errorf("the min_volume of a tier must not be negative and its percentage must be between 0 and 100")
+		}
+	}
+	return nil
+}
+
+// Validate checks every schedule of the model.
+func (m *FeeModel) Validate() error {
+	if err := m.Default.validate(); err != nil {
+		return err
+	}
+	for marketID, schedule := range m.Markets {
+		if err := schedule.validate(); err != nil {
+			return fmt.Errorf("market %d: %w", marketID, err)
+		}
+	}
+	return nil
+}
+
+func (m *FeeModel) schedule(marketID pgtype.Int4) FeeSchedule {
+	if marketID.Status == pgtype.Present {
+		if schedule, ok := m.Markets[marketID.Int]; ok {
+			return schedule
+		}
+	}
+	return m.Default
+}
+
+// percentage is the percentage charged to a buyer who spent volume, the tiers may be in
+// any order.
+func (schedule FeeSchedule) percentage(volume float32) float32 {
+	percentage, reached := schedule.Percentage, float32(-1)
+	for _, tier := range schedule.Tiers {
+		if volume >= tier.MinVolume && tier.MinVolume > reached {
+			percentage, reached = tier.Percentage, tier.MinVolume
+		}
+	}
+	return percentage
+}
+
+// computeFees returns the fees charged on a purchase of wager at buyingPrice by buyerID,
+// without the id of the purchase. The volume of the buyer is only summed up when the
+// schedule has tiers, the purchases without a buyer never reach one.
+func (s *WagerService) computeFees(ctx context.Context, db database.Ext, wager *entities.Wager, buyingPrice float32, buyerID pgtype.Text, now time.Time) ([]*entities.PurchaseFee, error) {
+	if s.Fees == nil {
+		return nil, nil
+	}
+	schedule := s.Fees.schedule(wager.MarketID)
+	var volume float32
+	if len(schedule.Tiers) > 0 && buyerID.Status == pgtype.Present {
+		var since time.Time
+		if s.Fees.VolumeWindow > 0 {
+			since = now.Add(-s.Fees.VolumeWindow)
+		}
+		spent, err := s.PurchaseRepo.SumVolume(ctx, db, buyerID, database.Timestamptz(since))
+		if err != nil {
+			return nil, fmt.Errorf("unable to sum the volume of the buyer")
+		}
+		volume = spent.Float
+	}
+	fees := []*entities.PurchaseFee{}
+	percentage := schedule.percentage(volume)
+	for _, line := range []struct {
+		feeType string
+		rate    float32
+		amount  float32
+	}{
+		{entities.PurchaseFeePercentage, percentage, roundFloat(buyingPrice * percentage / 100)},
+		{entities.PurchaseFeeFlat, schedule.Flat, roundFloat(schedule.Flat)},
+	} {
+		if line.amount <= 0 {
+			continue
+		}
+		fee := &entities.PurchaseFee{}
+		database.AllNullEntity(fee)
+		fee.WagerID = wager.WagerID
+		fee.MarketID = wager.MarketID
+		_ = fee.FeeType.Set(line.feeType)
+		_ = fee.Rate.Set(line.rate)
+		_ = fee.Amount.Set(line.amount)
+		_ = fee.CreatedAt.Set(now)
+		fees = append(fees, fee)
+	}
+	return fees, nil
+}
+
+func convertFeesPg2Domain(fees []*entities.PurchaseFee) ([]*models.Fee, float32) {
+	var total float32
+	feeModels := make([]*models.Fee, 0, len(fees))
+	for _, fee := range fees {
+		total += fee.Amount.Float
+		feeModels = append(feeModels, &models.Fee{
+			Type:   fee.FeeType.String,
+			Rate:   fee.Rate.Float,
+			Amount: fee.Amount.Float,
+		})
+	}
+	return feeModels, roundFloat(total)
+}
+
+// feeReportPeriods are the periods the fees and the volume can be summed up by
+var feeReportPeriods = map[string]bool{"day": true, "week": true, "month": true}
+
+// FeeService reports the fees charged on the purchases to the admins, read only.
+type FeeService struct {
+	DB              database.Ext
+	PurchaseFeeRepo interface {
+		Report(ctx context.Context, db database.Ext, from, to pgtype.Timestamptz, period string) ([]*repositories.FeeReportRow, error)
+	}
+}
+
+// parseReportQuery reads the bounds and the period of a report:
+// ?from=&to=&period=day|week|month, an omitted bound is null and the period is day by
+// default.
+func parseReportQuery(query url.Values) (from, to pgtype.Timestamptz, period string, err error) {
+	_ = from.Set(nil)
+	_ = to.Set(nil)
+	for _, param := range []struct {
+		name string
+		dst  *pgtype.Timestamptz
+	}{
+		{"from", &from},
+		{"to", &to},
+	} {
+		if value := query.Get(param.name); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return from, to, "", fmt.Errorf("the %s must be a RFC 3339 date", param.name)
+			}
+			_ = param.dst.Set(t)
+		}
+	}
+	period = query.Get("period")
+	if period == "" {
+		period = "day"
+	}
+	if !feeReportPeriods[period] {
+		return from, to, "", fmt.Errorf("the period must be one of day, week, month")
+	}
+	return from, to, period, nil
+}
+
+// ReportFees sums up the fees charged in [from, to) by period and kind of fee:
+// ?from=&to=&period=day|week|month, day by default.
+func (s *FeeService) ReportFees(resp http.ResponseWriter, req *http.Request) {
+	from, to, period, err := parseReportQuery(req.URL.Query())
+	if err != nil {
+		resp.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	rows, err := s.PurchaseFeeRepo.Report(req.Context(), s.DB, from, to, period)
+	if err != nil {
+		resp.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": "unable to report fees",
+		})
+		return
+	}
+	rowModels := make([]*models.FeeReportRow, 0, len(rows))
+	for _, row := range rows {
+		rowModels = append(rowModels, &models.FeeReportRow{
+			PeriodStart: row.PeriodStart.UTC(),
+			FeeType:     row.FeeType,
+			Purchases:   row.Purchases,
+			Amount:      row.Amount,
+		})
+	}
+	resp.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(resp).Encode(rowModels)
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/internal/models"
+	"github.com/wager-api/internal/repositories"
+	"github.com/wager-api/libs/database"
+	mock_database "github.com/wager-api/mocks/libs/database"
+	mock_repositories "github.com/wager-api/mocks/repositories"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgtype"
+)
+
+func Test_FeeModel_Validate(t *testing.T) {
+	t.Parallel()
+	assert.NoError(t, (&FeeModel{Default: FeeSchedule{Percentage: 2, Flat: 0.1, Tiers: []FeeTier{{MinVolume: 1000, Percentage: 1}}}}).Validate())
+	assert.EqualError(t, (&FeeModel{Default: FeeSchedule{Percentage: 120}}).Validate(),
+		"the percentage must be between 0 and 100 and the flat fee must not be negative")
+	assert.EqualError(t, (&FeeModel{Markets: map[int32]FeeSchedule{3: {Tiers: []FeeTier{{MinVolume: -1}}}}}).Validate(),
+		"market 3: the min_volume of a tier must not be negative and its percentage must be between 0 and 100")
+}
+
+func Test_FeeSchedule_percentage(t *testing.T) {
+	t.Parallel()
+	schedule := FeeSchedule{Percentage: 2, Tiers: []FeeTier{{MinVolume: 10000, Percentage: 1}, {MinVolume: 1000, Percentage: 1.5}}}
+	assert.Equal(t, float32(2), schedule.percentage(999.99))
+	assert.Equal(t, float32(1.5), schedule.percentage(1000))
+	assert.Equal(t, float32(1), schedule.percentage(25000))
+}
+
+func Test_computeFees(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	now := time.Now()
+	fees := &FeeModel{
+		Default:      FeeSchedule{Percentage: 2, Flat: 0.1, Tiers: []FeeTier{{MinVolume: 1000, Percentage: 1}}},
+		Markets:      map[int32]FeeSchedule{3: {Percentage: 5}},
+		VolumeWindow: 30 * 24 * time.Hour,
+	}
+
+	t.Run("the tier reached by the buyer", func(t *testing.T) {
+		db := &mock_database.Ext{}
+		purchaseRepo := &mock_repositories.MockPurchaseRepo{}
+		purchaseRepo.On("SumVolume", ctx, db, database.Text("buyer-1"), database.Timestamptz(now.Add(-30*24*time.Hour))).Once().Return(database.Float4(1500), nil)
+		s := &WagerService{Fees: fees, PurchaseRepo: purchaseRepo}
+		wager := &entities.Wager{WagerID: database.Int4(1), MarketID: pgtype.Int4{Status: pgtype.Null}}
+		purchaseFees, err := s.computeFees(ctx, db, wager, 40, database.Text("buyer-1"), now)
+		assert.NoError(t, err)
+		feeModels, total := convertFeesPg2Domain(purchaseFees)
+		assert.Equal(t, []*models.Fee{
+			{Type: entities.PurchaseFeePercentage, Rate: 1, Amount: 0.4},
+			{Type: entities.PurchaseFeeFlat, Rate: 0.1, Amount: 0.1},
+		}, feeModels)
+		assert.Equal(t, float32(0.5), total)
+		purchaseRepo.AssertExpectations(t)
+	})
+	t.Run("the schedule of the market without a tier", func(t *testing.T) {
+		s := &WagerService{Fees: fees}
+		wager := &entities.Wager{WagerID: database.Int4(1), MarketID: database.Int4(3)}
+		purchaseFees, err := s.computeFees(ctx, &mock_database.Ext{}, wager, 40, database.Text("buyer-1"), now)
+		assert.NoError(t, err)
+		if assert.Len(t, purchaseFees, 1) {
+			assert.Equal(t, database.Text(entities.PurchaseFeePercentage), purchaseFees[0].FeeType)
+			assert.Equal(t, database.Float4(2), purchaseFees[0].Amount)
+			assert.Equal(t, database.Int4(3), purchaseFees[0].MarketID)
+		}
+	})
+	t.Run("no fee model", func(t *testing.T) {
+		s := &WagerService{}
+		purchaseFees, err := s.computeFees(ctx, &mock_database.Ext{}, &entities.Wager{}, 40, database.Text("buyer-1"), now)
+		assert.NoError(t, err)
+		assert.Empty(t, purchaseFees)
+	})
+}
+
+func Test_ReportFees(t *testing.T) {
+	t.Parallel()
+	db := &mock_database.Ext{}
+	purchaseFeeRepo := &mock_repositories.MockPurchaseFeeRepo{}
+	periodStart := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	purchaseFeeRepo.On("Report", mock.Anything, db, mock.MatchedBy(func(from pgtype.Timestamptz) bool {
+		return from.Status == pgtype.Present && from.Time.Equal(periodStart)
+	}), pgtype.Timestamptz{Status: pgtype.Null}, "month").Once().Return([]*repositories.FeeReportRow{
+		{PeriodStart: periodStart, FeeType: entities.PurchaseFeePercentage, Purchases: 3, Amount: 1.2},
+	}, nil)
+	mux := chi.NewMux()
+	NewFeeHandler(mux, &FeeService{DB: db, PurchaseFeeRepo: purchaseFeeRepo}, []string{"admin-token"})
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/admin/reports/fees?period=year", nil)
+	req.Header.Set("Authorization", "Bearer admin-token")
+	mux.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/admin/reports/fees?period=month&from=2024-01-01T00:00:00Z", nil)
+	req.Header.Set("Authorization", "Bearer admin-token")
+	mux.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	report := []*models.FeeReportRow{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&report))
+	assert.Equal(t, []*models.FeeReportRow{
+		{PeriodStart: periodStart, FeeType: entities.PurchaseFeePercentage, Purchases: 3, Amount: 1.2},
+	}, report)
+	purchaseFeeRepo.AssertExpectations(t)
+}
@@ -0,0 +1,273 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/internal/models"
+	"github.com/wager-api/libs/database"
+	"github.com/wager-api/libs/logs"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+)
+
+const (
+	defaultOutboxBatchSize  = 100
+	defaultOutboxMinBackoff = time.Second
+	defaultOutboxMaxBackoff = 5 * time.Minute
+	defaultOutboxLease      = 5 * time.Minute
+)
+
+// newOutboxMessage builds the outbox row of an event, it must be created in the
+// transaction of the change so the event is relayed if and only if the change is committed.
+func newOutboxMessage(eventType string, wagerID pgtype.Int4, payload interface{}, now time.Time) (*entities.OutboxMessage, error) {
+	message := &entities.OutboxMessage{}
+	database.AllNullEntity(message)
+	if err := message.Payload.Set(payload); err != nil {
+		return nil, err
+	}
+	message.WagerID = wagerID
+	_ = message.EventType.Set(eventType)
+	_ = message.CreatedAt.Set(now)
+	_ = message.Attempts.Set(0)
+	_ = message.NextAttemptAt.Set(now)
+	return message, nil
+}
+
+func convertOutboxMessagePg2Domain(message *entities.OutboxMessage) *models.OutboxEvent {
+	return &models.OutboxEvent{
+		ID:        message.OutboxID.Int,
+		Type:      message.EventType.String,
+		WagerID:   int(message.WagerID.Int),
+		Payload:   message.Payload.Bytes,
+		CreatedAt: message.CreatedAt.Time,
+	}
+}
+
+// Publisher delivers the outbox events to a downstream system, an error means the
+// event must be delivered again.
+type Publisher interface {
+	Publish(ctx context.Context, event *models.OutboxEvent) error
+}
+
+// WriterPublisher writes the events as JSON lines, to the standard output or to a file.
+type WriterPublisher struct {
+	mu     sync.Mutex
+	Writer io.Writer
+}
+
+func NewWriterPublisher(w io.Writer) *WriterPublisher {
+	return &WriterPublisher{Writer: w}
+}
+
+func (p *WriterPublisher) Publish(ctx context.Context, event *models.OutboxEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := json.NewEncoder(p.Writer).Encode(event); err != nil {
+		return fmt.Errorf("unable to write event: %w", err)
+	}
+	return nil
+}
+
+// HTTPPublisher posts every event as JSON to URL, any status but a 2xx is a failure.
+type HTTPPublisher struct {
+	URL    string
+	Client *http.Client
+}
+
+func NewHTTPPublisher(url string, timeout time.Duration) *HTTPPublisher {
+	return &HTTPPublisher{URL: url, Client: &http.Client{Timeout: timeout}}
+}
+
+func (p *HTTPPublisher) Publish(ctx context.Context, event *models.OutboxEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("unable to encode event: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("unable to build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	// receivers drop the events they have already handled with it
+	req.Header.Set("Idempotency-Key", strconv.FormatInt(event.ID, 10))
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to post event: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unable to post event: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// OutboxRelay delivers the outbox events to Publisher in the order they were written.
+// The delivery is at least once: an event is marked sent after it is published, and an
+// event whose lease ends before is published again.
+type OutboxRelay struct {
+	DB         database.Ext
+	OutboxRepo interface {
+		ListPending(ctx context.Context, db database.Ext, limit uint32) ([]*entities.OutboxMessage, error)
+		Claim(ctx context.Context, db database.Ext, outboxIDs []int64, lockedUntil pgtype.Timestamptz) (pgconn.CommandTag, error)
+		Release(ctx context.Context, db database.Ext, outboxIDs []int64) (pgconn.CommandTag, error)
+		MarkSent(ctx context.Context, db database.Ext, outboxID pgtype.Int8, sentAt pgtype.Timestamptz) (pgconn.CommandTag, error)
+		MarkFailed(ctx context.Context, db database.Ext, outboxID pgtype.Int8, nextAttemptAt pgtype.Timestamptz, lastError pgtype.Text) (pgconn.CommandTag, error)
+	}
+	Publisher  Publisher
+	BatchSize  uint32
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// Lease is how long the events claimed by a relay are left to it, it should cover
+	// the publishing of a whole batch
+	Lease time.Duration
+}
+
+func (r *OutboxRelay) batchSize() uint32 {
+	if r.BatchSize == 0 {
+		return defaultOutboxBatchSize
+	}
+	return r.BatchSize
+}
+
+func (r *OutboxRelay) lease() time.Duration {
+	if r.Lease <= 0 {
+		return defaultOutboxLease
+	}
+	return r.Lease
+}
+
+// backoff is the delay before the next attempt to publish an event.
+func (r *OutboxRelay) backoff(attempts int32) time.Duration {
+	minBackoff, maxBackoff := r.MinBackoff, r.MaxBackoff
+	if minBackoff <= 0 {
+		minBackoff = defaultOutboxMinBackoff
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = defaultOutboxMaxBackoff
+	}
+	return exponentialBackoff(attempts, minBackoff, maxBackoff)
+}
+
+// exponentialBackoff doubles the delay from minBackoff with every failed attempt, up
+// to maxBackoff.
+func exponentialBackoff(attempts int32, minBackoff, maxBackoff time.Duration) time.Duration {
+	delay := minBackoff
+	for i := int32(0); i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxBackoff {
+		delay = maxBackoff
+	}
+	return delay
+}
+
+// claim leases the due events at the head of the outbox and commits, the events after
+// the first one waiting for its next attempt or leased by another relay are left. The
+// rows are only locked while they are claimed, not while they are published.
+func (r *OutboxRelay) claim(ctx context.Context, now time.Time) ([]*entities.OutboxMessage, error) {
+	var claimed []*entities.OutboxMessage
+	err := database.ExecInTx(ctx, r.DB, func(ctx context.Context, tx pgx.Tx) error {
+		claimed = nil
+		// the pending rows stay locked until the commit, concurrent relays wait for them
+		messages, err := r.OutboxRepo.ListPending(ctx, tx, r.batchSize())
+		if err != nil {
+			return fmt.Errorf("unable to list pending events: %w", err)
+		}
+		for _, message := range messages {
+			if message.NextAttemptAt.Time.After(now) || message.LockedUntil.Time.After(now) {
+				break
+			}
+			claimed = append(claimed, message)
+		}
+		if len(claimed) == 0 {
+			return nil
+		}
+		lockedUntil := database.Timestamptz(now.Add(r.lease()))
+		if _, err := r.OutboxRepo.Claim(ctx, tx, outboxIDs(claimed), lockedUntil); err != nil {
+			return fmt.Errorf("unable to claim events: %w", err)
+		}
+		return nil
+	})
+	return claimed, err
+}
+
+func outboxIDs(messages []*entities.OutboxMessage) []int64 {
+	ids := make([]int64, 0, len(messages))
+	for _, message := range messages {
+		ids = append(ids, message.OutboxID.Int)
+	}
+	return ids
+}
+
+// RelayOnce publishes a batch of pending events and returns how many were sent. It
+// stops at the first event failing or waiting for its next attempt, so an event is
+// never delivered before the ones written ahead of it. The events are claimed in a
+// transaction and published outside of it, each result is recorded on its own.
+func (r *OutboxRelay) RelayOnce(ctx context.Context) (int, error) {
+	messages, err := r.claim(ctx, time.Now())
+	if err != nil || len(messages) == 0 {
+		return 0, err
+	}
+	var sent int
+	for _, message := range messages {
+		now := time.Now()
+		if err := r.Publisher.Publish(ctx, convertOutboxMessagePg2Domain(message)); err != nil {
+			logs.Logger.Warnw("unable to publish outbox event", "outbox_id", message.OutboxID.Int, "attempts", message.Attempts.Int+1, "error", err)
+			nextAttemptAt := now.Add(r.backoff(message.Attempts.Int))
+			if _, err := r.OutboxRepo.MarkFailed(ctx, r.DB, message.OutboxID, database.Timestamptz(nextAttemptAt), database.Text(err.Error())); err != nil {
+				return sent, fmt.Errorf("unable to mark event failed: %w", err)
+			}
+			break
+		}
+		if _, err := r.OutboxRepo.MarkSent(ctx, r.DB, message.OutboxID, database.Timestamptz(now)); err != nil {
+			return sent, fmt.Errorf("unable to mark event sent: %w", err)
+		}
+		sent++
+	}
+	if sent < len(messages) {
+		// the events after a failure wait for it, the next relay claims them again
+		if _, err := r.OutboxRepo.Release(ctx, r.DB, outboxIDs(messages[sent:])); err != nil {
+			return sent, fmt.Errorf("unable to release events: %w", err)
+		}
+	}
+	return sent, nil
+}
+
+// Run relays the pending events every interval until ctx is done, full batches are
+// followed by the next one straight away.
+func (r *OutboxRelay) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for {
+				sent, err := r.RelayOnce(ctx)
+				if err != nil {
+					logs.Logger.Errorw("unable to relay the outbox events", "error", err)
+					break
+				}
+				if sent > 0 {
+					logs.Logger.Debugw("relayed outbox events", "sent", sent)
+				}
+				if uint32(sent) < r.batchSize() {
+					break
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/internal/models"
+	"github.com/wager-api/libs/database"
+	mock_database "github.com/wager-api/mocks/libs/database"
+	mock_repositories "github.com/wager-api/mocks/repositories"
+
+	"github.com/jackc/pgconn"
+)
+
+type fakePublisher struct {
+	published []int64
+	failOn    int64
+}
+
+func (p *fakePublisher) Publish(ctx context.Context, event *models.OutboxEvent) error {
+	if event.ID == p.failOn {
+		return fmt.Errorf("mock-error")
+	}
+	p.published = append(p.published, event.ID)
+	return nil
+}
+
+func outboxMessage(id int64, attempts int32, nextAttemptAt time.Time) *entities.OutboxMessage {
+	return &entities.OutboxMessage{
+		OutboxID:      database.Int8(id),
+		EventType:     database.Text(entities.OutboxWagerPlaced),
+		WagerID:       database.Int4(1),
+		Attempts:      database.Int4(attempts),
+		NextAttemptAt: database.Timestamptz(nextAttemptAt),
+	}
+}
+
+func Test_OutboxRelay_RelayOnce(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	past := time.Now().Add(-time.Minute)
+
+	t.Run("stop at the first failure to keep the order", func(t *testing.T) {
+		db := &mock_database.Ext{}
+		tx := &mock_database.Tx{}
+		outboxRepo := &mock_repositories.MockOutboxRepo{}
+		publisher := &fakePublisher{failOn: 2}
+		db.On("Begin", ctx).Return(tx, nil)
+		tx.On("Commit", mock.Anything).Return(nil)
+		outboxRepo.On("ListPending", ctx, tx, uint32(10)).Once().Return([]*entities.OutboxMessage{
+			outboxMessage(1, 0, past),
+			outboxMessage(2, 2, past),
+			outboxMessage(3, 0, past),
+		}, nil)
+		outboxRepo.On("Claim", ctx, tx, []int64{1, 2, 3}, mock.Anything).Once().Return(pgconn.CommandTag("UPDATE 3"), nil)
+		// the results are recorded once the claim is committed, outside of its transaction
+		outboxRepo.On("MarkSent", ctx, db, database.Int8(1), mock.Anything).Once().Return(pgconn.CommandTag("UPDATE 1"), nil)
+		outboxRepo.On("MarkFailed", ctx, db, database.Int8(2), mock.Anything, database.Text("mock-error")).Once().Return(pgconn.CommandTag("UPDATE 1"), nil)
+		outboxRepo.On("Release", ctx, db, []int64{2, 3}).Once().Return(pgconn.CommandTag("UPDATE 1"), nil)
+
+		relay := &OutboxRelay{DB: db, OutboxRepo: outboxRepo, Publisher: publisher, BatchSize: 10}
+		sent, err := relay.RelayOnce(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, sent)
+		assert.Equal(t, []int64{1}, publisher.published)
+		outboxRepo.AssertExpectations(t)
+		outboxRepo.AssertNotCalled(t, "MarkSent", ctx, db, database.Int8(3), mock.Anything)
+	})
+	t.Run("wait for the next attempt of the oldest event", func(t *testing.T) {
+		db := &mock_database.Ext{}
+		tx := &mock_database.Tx{}
+		outboxRepo := &mock_repositories.MockOutboxRepo{}
+		publisher := &fakePublisher{}
+		db.On("Begin", ctx).Return(tx, nil)
+		tx.On("Commit", mock.Anything).Return(nil)
+		outboxRepo.On("ListPending", ctx, tx, uint32(defaultOutboxBatchSize)).Once().Return([]*entities.OutboxMessage{
+			outboxMessage(1, 1, time.Now().Add(time.Minute)),
+			outboxMessage(2, 0, past),
+		}, nil)
+
+		relay := &OutboxRelay{DB: db, OutboxRepo: outboxRepo, Publisher: publisher}
+		sent, err := relay.RelayOnce(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, sent)
+		assert.Empty(t, publisher.published)
+		outboxRepo.AssertNotCalled(t, "Claim", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+	t.Run("leave the events leased by another relay", func(t *testing.T) {
+		db := &mock_database.Ext{}
+		tx := &mock_database.Tx{}
+		outboxRepo := &mock_repositories.MockOutboxRepo{}
+		publisher := &fakePublisher{}
+		db.On("Begin", ctx).Return(tx, nil)
+		tx.On("Commit", mock.Anything).Return(nil)
+		leased := outboxMessage(1, 0, past)
+		leased.LockedUntil = database.Timestamptz(time.Now().Add(time.Minute))
+		outboxRepo.On("ListPending", ctx, tx, uint32(defaultOutboxBatchSize)).Once().Return([]*entities.OutboxMessage{
+			leased,
+			outboxMessage(2, 0, past),
+		}, nil)
+
+		relay := &OutboxRelay{DB: db, OutboxRepo: outboxRepo, Publisher: publisher}
+		sent, err := relay.RelayOnce(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, sent)
+		assert.Empty(t, publisher.published)
+	})
+}
+
+func Test_OutboxRelay_backoff(t *testing.T) {
+	t.Parallel()
+	relay := &OutboxRelay{MinBackoff: time.Second, MaxBackoff: time.Minute}
+	assert.Equal(t, time.Second, relay.backoff(0))
+	assert.Equal(t, 2*time.Second, relay.backoff(1))
+	assert.Equal(t, 32*time.Second, relay.backoff(5))
+	assert.Equal(t, time.Minute, relay.backoff(6))
+	assert.Equal(t, time.Minute, relay.backoff(100))
+}
+
+func Test_WriterPublisher(t *testing.T) {
+	t.Parallel()
+	buf := &bytes.Buffer{}
+	publisher := NewWriterPublisher(buf)
+	assert.NoError(t, publisher.Publish(context.Background(), &models.OutboxEvent{ID: 1, Type: entities.OutboxWagerPlaced, WagerID: 2, Payload: []byte(`{"id":2}`)}))
+	assert.NoError(t, publisher.Publish(context.Background(), &models.OutboxEvent{ID: 2, Type: entities.OutboxWagerPurchased, WagerID: 2, Payload: []byte(`{"purchase_id":3}`)}))
+	assert.Equal(t, `{"id":1,"type":"WagerPlaced","wager_id":2,"payload":{"id":2},"created_at":"0001-01-01T00:00:00Z"}
+{"id":2,"type":"WagerPurchased","wager_id":2,"payload":{"purchase_id":3},"created_at":"0001-01-01T00:00:00Z"}
+`, buf.String())
+}
+
+func Test_HTTPPublisher(t *testing.T) {
+	t.Parallel()
+	var received *models.OutboxEvent
+	status := http.StatusAccepted
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "7", r.Header.Get("Idempotency-Key"))
+		received = &models.OutboxEvent{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(received))
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+	publisher := NewHTTPPublisher(server.URL, time.Second)
+	event := &models.OutboxEvent{ID: 7, Type: entities.OutboxWagerSettled, WagerID: 1, Payload: []byte(`{"wager_id":1}`)}
+
+	assert.NoError(t, publisher.Publish(context.Background(), event))
+	if assert.NotNil(t, received) {
+		assert.Equal(t, event.Type, received.Type)
+		assert.JSONEq(t, `{"wager_id":1}`, string(received.Payload))
+	}
+
+	status = http.StatusServiceUnavailable
+	assert.EqualError(t, publisher.Publish(context.Background(), event), "unable to post event: status 503")
+}
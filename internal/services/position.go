@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/internal/models"
+	"github.com/wager-api/internal/repositories"
+	"github.com/wager-api/libs/database"
+)
+
+// convertPositionPg2Domain values a position at now. The stakes are bought and resold at
+// different prices, the resold ones are taken off at the average cost of what was
+// acquired; what is still held is worth its share of the wager at the decayed price.
+func convertPositionPg2Domain(position *repositories.Position, now time.Time) *models.Position {
+	wager := position.Wager
+	held := position.AcquiredStake - position.SoldStake
+	var averageCost float32
+	if position.AcquiredStake > 0 {
+		averageCost = position.Cost / position.AcquiredStake
+	}
+	heldCost := averageCost * held
+	currentSellingPrice := decayedPrice(wager, now)
+	var share float32
+	if wager.SellingPrice.Float > 0 {
+		share = held / wager.SellingPrice.Float
+	}
+	marketValue := share * currentSellingPrice
+	domain := &models.Position{
+		WagerID:             int(wager.WagerID.Int),
+		MarketID:            int(wager.MarketID.Int),
+		Status:              models.PositionStatusOpen,
+		Stake:               roundFloat(held),
+		TotalCost:           roundFloat(position.Cost),
+		ImpliedStake:        roundFloat(share * wager.TotalWagerValue.Float * float32(wager.SellingPercentage.Int) / 100),
+		CurrentSellingPrice: currentSellingPrice,
+		MarketValue:         roundFloat(marketValue),
+		RealizedPnL:         roundFloat(position.Proceeds - averageCost*position.SoldStake),
+		Currency:            wager.Currency.String,
+	}
+	switch {
+	case position.MarketStatus.String == entities.MarketStatusSettled:
+		domain.Status = models.PositionStatusSettled
+		domain.RealizedPnL = roundFloat(position.Proceeds - averageCost*position.SoldStake + marketValue - heldCost)
+		domain.MarketValue = 0
+	case roundFloat(held) <= 0:
+		domain.Status = models.PositionStatusClosed
+	default:
+		domain.UnrealizedPnL = roundFloat(marketValue - heldCost)
+	}
+	return domain
+}
+
+// ListPositions returns a page of the positions of an account in wager id order, along
+// the summary of all of them summed up by the database, currency by currency.
+func (s *WagerService) ListPositions(ctx context.Context, accountID string, page, limit int) (*models.AccountPositions, error) {
+	accountID = strings.TrimSpace(accountID)
+	if accountID == "" || len(accountID) > maxSellerIDLength {
+		return nil, &validationError{fmt.Errorf("the account id must be given, at most %d characters long", maxSellerIDLength)}
+	}
+	if page <= 0 || limit <= 0 {
+		return nil, &validationError{fmt.Errorf("`page` must be positive number and `limit` should be greater than 0")}
+	}
+	now := time.Now()
+	positions, err := s.PositionRepo.ListByHolder(ctx, s.DB, database.Text(accountID), uint32((page-1)*limit), uint32(limit))
+	if err != nil {
+		return nil, fmt.Errorf("unable to list positions")
+	}
+	totals, err := s.PositionRepo.SumByHolder(ctx, s.DB, database.Text(accountID), database.Timestamptz(now))
+	if err != nil {
+		return nil, fmt.Errorf("unable to sum up positions")
+	}
+	positionModels := make([]*models.Position, 0, len(positions))
+	for _, position := range positions {
+		positionModels = append(positionModels, convertPositionPg2Domain(position, now))
+	}
+	summary := make([]*models.PositionSummary, 0, len(totals))
+	for _, total := range totals {
+		summary = append(summary, &models.PositionSummary{
+			Currency:      total.Currency,
+			Positions:     int(total.Positions),
+			OpenPositions: int(total.OpenPositions),
+			TotalCost:     roundFloat(total.TotalCost),
+			MarketValue:   roundFloat(total.MarketValue),
+			UnrealizedPnL: roundFloat(total.UnrealizedPnL),
+			RealizedPnL:   roundFloat(total.RealizedPnL),
+		})
+	}
+	return &models.AccountPositions{
+		AccountID: accountID,
+		Page:      page,
+		Limit:     limit,
+		Positions: positionModels,
+		Summary:   summary,
+	}, nil
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/internal/models"
+	"github.com/wager-api/internal/repositories"
+	"github.com/wager-api/libs/database"
+	mock_database "github.com/wager-api/mocks/libs/database"
+	mock_repositories "github.com/wager-api/mocks/repositories"
+
+	"github.com/jackc/pgtype"
+)
+
+func position(wagerID int32, marketStatus string, acquiredStake, cost, soldStake, proceeds float32) *repositories.Position {
+	wager := &entities.Wager{}
+	database.AllNullEntity(wager)
+	wager.WagerID = database.Int4(wagerID)
+	wager.TotalWagerValue = database.Float4(100)
+	wager.SellingPercentage = database.Int4(30)
+	wager.SellingPrice = database.Float4(50)
+	wager.CurrentSellingPrice = database.Float4(40)
+	wager.Currency = database.Text("USD")
+	marketStatusPg := pgtype.Text{Status: pgtype.Null}
+	if marketStatus != "" {
+		wager.MarketID = database.Int4(3)
+		marketStatusPg = database.Text(marketStatus)
+	}
+	return &repositories.Position{
+		Wager:         wager,
+		MarketStatus:  marketStatusPg,
+		AcquiredStake: acquiredStake,
+		Cost:          cost,
+		SoldStake:     soldStake,
+		Proceeds:      proceeds,
+	}
+}
+
+func Test_convertPositionPg2Domain(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	tests := []struct {
+		name     string
+		position *repositories.Position
+		expected *models.Position
+	}{
+		{
+			name:     "partly resold",
+			position: position(1, entities.MarketStatusOpen, 20, 20, 5, 8),
+			expected: &models.Position{
+				WagerID: 1, MarketID: 3, Status: models.PositionStatusOpen, Stake: 15, TotalCost: 20, ImpliedStake: 9,
+				CurrentSellingPrice: 40, MarketValue: 12, UnrealizedPnL: -3, RealizedPnL: 3, Currency: "USD",
+			},
+		},
+		{
+			name:     "wholly resold",
+			position: position(2, "", 10, 12, 10, 15),
+			expected: &models.Position{
+				WagerID: 2, Status: models.PositionStatusClosed, TotalCost: 12, CurrentSellingPrice: 40, RealizedPnL: 3, Currency: "USD",
+			},
+		},
+		{
+			name:     "settled at the last value",
+			position: position(3, entities.MarketStatusSettled, 20, 20, 0, 0),
+			expected: &models.Position{
+				WagerID: 3, MarketID: 3, Status: models.PositionStatusSettled, Stake: 20, TotalCost: 20, ImpliedStake: 12,
+				CurrentSellingPrice: 40, RealizedPnL: -4, Currency: "USD",
+			},
+		},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, convertPositionPg2Domain(tt.position, now), tt.name)
+	}
+}
+
+func Test_ListPositions(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	t.Run("the summary covers every position", func(t *testing.T) {
+		db := &mock_database.Ext{}
+		positionRepo := &mock_repositories.MockPositionRepo{}
+		// the second page of 2 is read from the third position on
+		positionRepo.On("ListByHolder", ctx, db, database.Text("buyer-1"), uint32(2), uint32(2)).Once().Return([]*repositories.Position{
+			position(3, entities.MarketStatusSettled, 20, 20, 0, 0),
+		}, nil)
+		// the amounts of each currency are summed up apart
+		positionRepo.On("SumByHolder", ctx, db, database.Text("buyer-1"), mock.Anything).Once().Return([]*repositories.PositionTotals{
+			{Currency: "EUR", Positions: 1, OpenPositions: 1, TotalCost: 10, MarketValue: 8, UnrealizedPnL: -2},
+			{Currency: "USD", Positions: 3, OpenPositions: 1, TotalCost: 52, MarketValue: 12.004, UnrealizedPnL: -3, RealizedPnL: 2},
+		}, nil)
+
+		s := &WagerService{DB: db, PositionRepo: positionRepo}
+		positions, err := s.ListPositions(ctx, " buyer-1 ", 2, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, "buyer-1", positions.AccountID)
+		assert.Len(t, positions.Positions, 1)
+		assert.Equal(t, 3, positions.Positions[0].WagerID)
+		assert.Equal(t, []*models.PositionSummary{
+			{Currency: "EUR", Positions: 1, OpenPositions: 1, TotalCost: 10, MarketValue: 8, UnrealizedPnL: -2},
+			{Currency: "USD", Positions: 3, OpenPositions: 1, TotalCost: 52, MarketValue: 12, UnrealizedPnL: -3, RealizedPnL: 2},
+		}, positions.Summary)
+		positionRepo.AssertExpectations(t)
+	})
+	t.Run("an account id is needed", func(t *testing.T) {
+		s := &WagerService{}
+		_, err := s.ListPositions(ctx, " ", 1, 10)
+		assert.EqualError(t, err, "the account id must be given, at most 64 characters long")
+		assert.Equal(t, http.StatusBadRequest, statusFromError(err))
+	})
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/internal/models"
+
+	"github.com/jackc/pgtype"
+	"go.uber.org/multierr"
+)
+
+// maxPriceDecayDuration bounds how long the price of a wager can take to reach its floor
+const maxPriceDecayDuration = 365 * 24 * time.Hour
+
+func validatePriceDecay(decay *models.PriceDecay, sellingPrice float32) error {
+	if decay.Type != entities.WagerDecayLinear && decay.Type != entities.WagerDecayStep {
+		return fmt.Errorf("the price_decay.type must be one of %s, %s", entities.WagerDecayLinear, entities.WagerDecayStep)
+	}
+	tempFloorPriceNumber := decay.FloorPrice * 100
+	if decay.FloorPrice <= 0 || tempFloorPriceNumber-float32(int(tempFloorPriceNumber)) > 0 {
+		return fmt.Errorf("the price_decay.floor_price must be a positive decimal value to two decimal places")
+	}
+	if decay.FloorPrice >= sellingPrice {
+		return fmt.Errorf("the price_decay.floor_price must be lesser than selling_price")
+	}
+	duration, err := time.ParseDuration(decay.Duration)
+	if err != nil || duration < time.Second || duration > maxPriceDecayDuration {
+		return fmt.Errorf("the price_decay.duration must be a duration between 1s and %dh", int(maxPriceDecayDuration.Hours()))
+	}
+	if decay.Type == entities.WagerDecayLinear {
+		if decay.Step != "" {
+			return fmt.Errorf("the price_decay.step is only for a step decay")
+		}
+		return nil
+	}
+	step, err := time.ParseDuration(decay.Step)
+	if err != nil || step < time.Second || step > duration {
+		return fmt.Errorf("the price_decay.step must be a duration between 1s and the price_decay.duration")
+	}
+	return nil
+}
+
+// setPriceDecay stores a decay which has been checked by validatePriceDecay on wager.
+func setPriceDecay(wager *entities.Wager, decay *models.PriceDecay) error {
+	duration, _ := time.ParseDuration(decay.Duration)
+	var step time.Duration
+	if decay.Type == entities.WagerDecayStep {
+		step, _ = time.ParseDuration(decay.Step)
+	}
+	err := multierr.Combine(
+		wager.DecayType.Set(decay.Type),
+		wager.DecayFloorPrice.Set(decay.FloorPrice),
+		wager.DecayDurationSeconds.Set(int32(duration/time.Second)),
+	)
+	if step > 0 {
+		err = multierr.Append(err, wager.DecayStepSeconds.Set(int32(step/time.Second)))
+	}
+	return err
+}
+
+// decayedPrice is the price of a wager at now: the scheduled price of its decay, or its
+// stored current_selling_price when a purchase has brought it lower already.
+func decayedPrice(wager *entities.Wager, now time.Time) float32 {
+	current := wager.CurrentSellingPrice.Float
+	if wager.DecayType.Status != pgtype.Present || wager.PlaceAt.Status != pgtype.Present {
+		return current
+	}
+	duration := time.Duration(wager.DecayDurationSeconds.Int) * time.Second
+	elapsed := now.Sub(wager.PlaceAt.Time)
+	if elapsed <= 0 || duration <= 0 {
+		return current
+	}
+	if wager.DecayType.String == entities.WagerDecayStep {
+		step := time.Duration(wager.DecayStepSeconds.Int) * time.Second
+		if step > 0 {
+			elapsed = elapsed / step * step
+		}
+	}
+	ratio := math.Min(float64(elapsed)/float64(duration), 1)
+	start, floor := wager.SellingPrice.Float, wager.DecayFloorPrice.Float
+	scheduled := roundFloat(start - float32(float64(start-floor)*ratio))
+	if scheduled < current {
+		return scheduled
+	}
+	return current
+}
+
+// applyPriceDecay sets the current_selling_price of wager to its price at now, it is
+// only stored by a purchase at that price.
+func applyPriceDecay(wager *entities.Wager, now time.Time) {
+	if price := decayedPrice(wager, now); price != wager.CurrentSellingPrice.Float {
+		_ = wager.CurrentSellingPrice.Set(price)
+	}
+}
+
+func convertPriceDecayPg2Domain(wager *entities.Wager) *models.PriceDecay {
+	if wager.DecayType.Status != pgtype.Present {
+		return nil
+	}
+	decay := &models.PriceDecay{
+		Type:       wager.DecayType.String,
+		FloorPrice: wager.DecayFloorPrice.Float,
+		Duration:   (time.Duration(wager.DecayDurationSeconds.Int) * time.Second).String(),
+	}
+	if wager.DecayStepSeconds.Status == pgtype.Present {
+		decay.Step = (time.Duration(wager.DecayStepSeconds.Int) * time.Second).String()
+	}
+	return decay
+}
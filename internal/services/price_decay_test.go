@@ -0,0 +1,75 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/internal/models"
+	"github.com/wager-api/libs/database"
+)
+
+func Test_validatePriceDecay(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		decay       models.PriceDecay
+		expectedErr string
+	}{
+		{decay: models.PriceDecay{Type: "linear", FloorPrice: 20, Duration: "48h"}},
+		{decay: models.PriceDecay{Type: "step", FloorPrice: 20.5, Duration: "48h", Step: "6h"}},
+		{decay: models.PriceDecay{Type: "exponential", FloorPrice: 20, Duration: "48h"}, expectedErr: "the price_decay.type must be one of linear, step"},
+		{decay: models.PriceDecay{Type: "linear", FloorPrice: 20.555, Duration: "48h"}, expectedErr: "the price_decay.floor_price must be a positive decimal value to two decimal places"},
+		{decay: models.PriceDecay{Type: "linear", FloorPrice: 50, Duration: "48h"}, expectedErr: "the price_decay.floor_price must be lesser than selling_price"},
+		{decay: models.PriceDecay{Type: "linear", FloorPrice: 20, Duration: "two days"}, expectedErr: "the price_decay.duration must be a duration between 1s and 8760h"},
+		{decay: models.PriceDecay{Type: "linear", FloorPrice: 20, Duration: "48h", Step: "1h"}, expectedErr: "the price_decay.step is only for a step decay"},
+		{decay: models.PriceDecay{Type: "step", FloorPrice: 20, Duration: "48h", Step: "72h"}, expectedErr: "the price_decay.step must be a duration between 1s and the price_decay.duration"},
+	}
+	for _, tt := range tests {
+		err := validatePriceDecay(&tt.decay, 50)
+		if tt.expectedErr == "" {
+			assert.NoError(t, err)
+			continue
+		}
+		assert.EqualError(t, err, tt.expectedErr)
+	}
+}
+
+func Test_decayedPrice(t *testing.T) {
+	t.Parallel()
+	placeAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newWager := func(decay *models.PriceDecay, current float32) *entities.Wager {
+		wager := &entities.Wager{}
+		database.AllNullEntity(wager)
+		wager.SellingPrice = database.Float4(50)
+		wager.CurrentSellingPrice = database.Float4(current)
+		wager.PlaceAt = database.Timestamptz(placeAt)
+		if decay != nil {
+			assert.NoError(t, setPriceDecay(wager, decay))
+		}
+		return wager
+	}
+	linear := &models.PriceDecay{Type: entities.WagerDecayLinear, FloorPrice: 10, Duration: "40h"}
+	step := &models.PriceDecay{Type: entities.WagerDecayStep, FloorPrice: 10, Duration: "40h", Step: "10h"}
+	tests := []struct {
+		description string
+		wager       *entities.Wager
+		at          time.Duration
+		expected    float32
+	}{
+		{"no decay", newWager(nil, 50), 20 * time.Hour, 50},
+		{"linear before placement", newWager(linear, 50), -time.Hour, 50},
+		{"linear halfway", newWager(linear, 50), 20 * time.Hour, 30},
+		{"linear past its duration", newWager(linear, 50), 100 * time.Hour, 10},
+		{"step within a step", newWager(step, 50), 19 * time.Hour, 40},
+		{"step on a step", newWager(step, 50), 20 * time.Hour, 30},
+		{"a purchase below the schedule", newWager(linear, 25), 20 * time.Hour, 25},
+		{"the schedule below a purchase", newWager(linear, 40), 30 * time.Hour, 20},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, decayedPrice(tt.wager, placeAt.Add(tt.at)), tt.description)
+	}
+	wager := newWager(step, 50)
+	assert.Equal(t, &models.PriceDecay{Type: "step", FloorPrice: 10, Duration: "40h0m0s", Step: "10h0m0s"}, convertPriceDecayPg2Domain(wager))
+	assert.Nil(t, convertPriceDecayPg2Domain(newWager(nil, 50)))
+}
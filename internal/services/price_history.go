@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/internal/models"
+	"github.com/wager-api/libs/database"
+	"github.com/wager-api/libs/logs"
+
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+)
+
+// recordPrice appends the current selling price of a wager to its price history,
+// it must run in the transaction which changes the price.
+func (s *WagerService) recordPrice(ctx context.Context, db database.Ext, wager *entities.Wager, now time.Time) error {
+	priceHistory := &entities.WagerPriceHistory{}
+	database.AllNullEntity(priceHistory)
+	if err := priceHistory.WagerID.Set(wager.WagerID); err != nil {
+		return err
+	}
+	priceHistory.Price = wager.CurrentSellingPrice
+	_ = priceHistory.RecordedAt.Set(now)
+	_ = priceHistory.CreatedAt.Set(now)
+	return s.PriceHistoryRepo.Create(ctx, db, priceHistory)
+}
+
+// PriceHistory returns the time series of the current_selling_price of a wager,
+// bucketed into OHLC candles when the query has an interval.
+func (s *WagerService) PriceHistory(ctx context.Context, wagerID int, query *models.PriceHistoryQuery) (*models.PriceHistoryResponse, error) {
+	if _, err := s.WagerRepo.Get(ctx, s.DB, database.Int4(int32(wagerID))); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errWagerNotFound
+		}
+		return nil, fmt.Errorf("unable to get wager information")
+	}
+	var from, to pgtype.Timestamptz
+	_ = from.Set(nil)
+	_ = to.Set(nil)
+	if query.From != nil {
+		_ = from.Set(*query.From)
+	}
+	if query.To != nil {
+		_ = to.Set(*query.To)
+	}
+
+	priceHistoryResponse := &models.PriceHistoryResponse{WagerID: wagerID}
+	if query.Interval > 0 {
+		candles, err := s.PriceHistoryRepo.ListCandles(ctx, s.DB, database.Int4(int32(wagerID)), from, to, query.Interval)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get price history")
+		}
+		priceHistoryResponse.Interval = query.Interval.String()
+		priceHistoryResponse.Candles = make([]*models.PriceCandle, 0, len(candles))
+		for _, candle := range candles {
+			bucketStart := candle.BucketStart
+			priceHistoryResponse.Candles = append(priceHistoryResponse.Candles, &models.PriceCandle{
+				BucketStart: &bucketStart,
+				Open:        candle.Open,
+				High:        candle.High,
+				Low:         candle.Low,
+				Close:       candle.Close,
+				Count:       candle.Count,
+			})
+		}
+		return priceHistoryResponse, nil
+	}
+	priceHistories, err := s.PriceHistoryRepo.List(ctx, s.DB, database.Int4(int32(wagerID)), from, to)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get price history")
+	}
+	priceHistoryResponse.Points = make([]*models.PricePoint, 0, len(priceHistories))
+	for _, priceHistory := range priceHistories {
+		recordedAt := priceHistory.RecordedAt.Time
+		priceHistoryResponse.Points = append(priceHistoryResponse.Points, &models.PricePoint{
+			Price:      priceHistory.Price.Float,
+			RecordedAt: &recordedAt,
+		})
+	}
+	return priceHistoryResponse, nil
+}
+
+// RunPriceHistoryCleaner removes the prices older than retention every interval until
+// ctx is done. A retention which is not positive keeps the history forever.
+func (s *WagerService) RunPriceHistoryCleaner(ctx context.Context, retention, interval time.Duration) {
+	if retention <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			cmdTag, err := s.PriceHistoryRepo.DeleteBefore(ctx, s.DB, database.Timestamptz(time.Now().Add(-retention)))
+			if err != nil {
+				logs.Logger.Errorw("unable to clean the price history", "error", err)
+				continue
+			}
+			if cmdTag.RowsAffected() > 0 {
+				logs.Logger.Infow("cleaned the price history", "deleted", cmdTag.RowsAffected())
+			}
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/internal/repositories"
+	"github.com/wager-api/libs/database"
+	mock_database "github.com/wager-api/mocks/libs/database"
+	mock_repositories "github.com/wager-api/mocks/repositories"
+)
+
+func Test_parsePriceHistoryQuery(t *testing.T) {
+	t.Parallel()
+	type testcase struct {
+		name        string
+		url         string
+		expectedErr error
+	}
+	tests := []testcase{
+		{
+			name: "no params",
+			url:  "/wagers/1/prices",
+		},
+		{
+			name: "range and interval",
+			url:  "/wagers/1/prices?from=2022-01-01T00:00:00Z&to=2022-01-02T00:00:00Z&interval=1h",
+		},
+		{
+			name:        "from is not RFC3339",
+			url:         "/wagers/1/prices?from=yesterday",
+			expectedErr: fmt.Errorf("the from must be a RFC3339 timestamp"),
+		},
+		{
+			name:        "to before from",
+			url:         "/wagers/1/prices?from=2022-01-02T00:00:00Z&to=2022-01-01T00:00:00Z",
+			expectedErr: fmt.Errorf("the to must be after the from"),
+		},
+		{
+			name:        "interval too small",
+			url:         "/wagers/1/prices?interval=10s",
+			expectedErr: fmt.Errorf("the interval must be a duration of at least 1m0s"),
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := parsePriceHistoryQuery(httptest.NewRequest(http.MethodGet, tc.url, nil))
+			assert.Equal(t, tc.expectedErr, err)
+		})
+	}
+}
+
+func Test_GetPriceHistory(t *testing.T) {
+	t.Parallel()
+	db := &mock_database.Ext{}
+	wagerRepo := &mock_repositories.MockWagerRepo{}
+	priceHistoryRepo := &mock_repositories.MockPriceHistoryRepo{}
+	wagerID := 1
+	ctx := context.WithValue(context.Background(), wagerIDKey, wagerID)
+	bucketStart := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	wagerRepo.On("Get", ctx, db, database.Int4(int32(wagerID))).Return(&entities.Wager{WagerID: database.Int4(int32(wagerID))}, nil)
+	priceHistoryRepo.On("List", ctx, db, database.Int4(int32(wagerID)), mock.Anything, mock.Anything).Once().Return([]*entities.WagerPriceHistory{
+		{Price: database.Float4(40), RecordedAt: database.Timestamptz(bucketStart)},
+	}, nil)
+	priceHistoryRepo.On("ListCandles", ctx, db, database.Int4(int32(wagerID)), mock.Anything, mock.Anything, time.Hour).Once().Return([]*repositories.PriceCandle{
+		{BucketStart: bucketStart, Open: 40, High: 40, Low: 30, Close: 30, Count: 2},
+	}, nil)
+	wagerService := &WagerService{
+		DB:               db,
+		WagerRepo:        wagerRepo,
+		PriceHistoryRepo: priceHistoryRepo,
+	}
+
+	testcases := []TestCase{
+		{
+			name:           "raw points",
+			url:            "/wagers/1/prices",
+			expectedStatus: http.StatusOK,
+			expectedResp:   []byte(`{"wager_id":1,"points":[{"price":40,"recorded_at":"2022-01-01T00:00:00Z"}]}`),
+		},
+		{
+			name:           "OHLC candles",
+			url:            "/wagers/1/prices?interval=1h",
+			expectedStatus: http.StatusOK,
+			expectedResp:   []byte(`{"wager_id":1,"interval":"1h0m0s","candles":[{"bucket_start":"2022-01-01T00:00:00Z","open":40,"high":40,"low":30,"close":30,"count":2}]}`),
+		},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.url, nil).WithContext(ctx)
+			rec := httptest.NewRecorder()
+			http.HandlerFunc((&WagerHandler{WagerService: wagerService}).GetPriceHistory).ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			data, err := ioutil.ReadAll(rec.Body)
+			assert.NoError(t, err)
+			// :len(data)-1 remove the `\n`
+			assert.Equal(t, tc.expectedResp, data[:len(data)-1])
+		})
+	}
+}
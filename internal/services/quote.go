@@ -0,0 +1,110 @@
+package services
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/internal/models"
+)
+
+// defaultFairPriceSpread is how far above the expected value, in percent, a price is
+// still considered fair unless configured otherwise
+const defaultFairPriceSpread = 10
+
+func (s *WagerService) fairPriceSpread() float64 {
+	if s.FairPriceSpread <= 0 {
+		return defaultFairPriceSpread
+	}
+	return s.FairPriceSpread
+}
+
+// quoteWager prices the share on sale of a wager of totalWagerValue at decimalOdds. At
+// the probability the odds imply the share is worth its part of the stake, which the
+// selling price must be above: the fair range goes from the lowest selling price allowed
+// up to spread percent above the expected value, never above the payout.
+func quoteWager(decimalOdds float64, totalWagerValue float32, sellingPercentage int, spread float64) *models.WagerQuote {
+	share := float64(sellingPercentage) / 100
+	payout := float64(totalWagerValue) * decimalOdds * share
+	probability := 1 / decimalOdds
+	expectedValue := payout * probability
+	// the selling price must be greater than total_wager_value * (selling_percentage / 100)
+	minSellingPrice := (math.Floor(float64(totalWagerValue)*float64(sellingPercentage)+1e-6) + 1) / 100
+	fairPriceHigh := math.Max(math.Min(expectedValue*(1+spread/100), payout), minSellingPrice)
+	return &models.WagerQuote{
+		DecimalOdds:        math.Round(decimalOdds*10000) / 10000,
+		ImpliedProbability: math.Round(probability*10000) / 10000,
+		PotentialPayout:    roundFloat(float32(payout)),
+		ExpectedValue:      roundFloat(float32(expectedValue)),
+		MinSellingPrice:    roundFloat(float32(minSellingPrice)),
+		FairPriceLow:       roundFloat(float32(minSellingPrice)),
+		FairPriceHigh:      roundFloat(float32(fairPriceHigh)),
+	}
+}
+
+// assessPrice compares price to the fair range of quote.
+func assessPrice(quote *models.WagerQuote, price float32) {
+	quote.Price = price
+	if quote.ExpectedValue > 0 {
+		premium := roundFloat((price/quote.ExpectedValue - 1) * 100)
+		quote.Premium = &premium
+	}
+	switch {
+	case price < quote.FairPriceLow:
+		quote.Assessment = models.QuoteAssessmentUnderpriced
+	case price > quote.FairPriceHigh:
+		quote.Assessment = models.QuoteAssessmentOverpriced
+	default:
+		quote.Assessment = models.QuoteAssessmentFair
+	}
+}
+
+// quoteWagerEntity prices a wager placed already at its current selling price, nil when
+// its odds can't be priced.
+func (s *WagerService) quoteWagerEntity(wager *entities.Wager) *models.WagerQuote {
+	if wager.Odds.Float <= 1 || wager.TotalWagerValue.Float <= 0 || wager.SellingPercentage.Int <= 0 {
+		return nil
+	}
+	quote := quoteWager(wager.Odds.Float, wager.TotalWagerValue.Float, int(wager.SellingPercentage.Int), s.fairPriceSpread())
+	assessPrice(quote, wager.CurrentSellingPrice.Float)
+	return quote
+}
+
+func validateQuoteWagerReq(req *models.QuoteWagerRequest) (float64, error) {
+	if req.TotalWagerValue <= 0 {
+		return 0, fmt.Errorf("the total_wager_value must be a positive integer above 0")
+	}
+	oddsFormat, err := models.ParseOddsFormat(req.OddsFormat)
+	if err != nil {
+		return 0, err
+	}
+	decimalOdds, err := req.Odds.ToDecimal(oddsFormat)
+	if err != nil {
+		return 0, err
+	}
+	if req.SellingPercentage < 1 || req.SellingPercentage > 100 {
+		return 0, fmt.Errorf("the selling_percentage must be specified as an integer between 1 and 100")
+	}
+	if req.SellingPrice != nil {
+		tempSellingPriceNumber := *req.SellingPrice * 100
+		if *req.SellingPrice <= 0 || tempSellingPriceNumber-float32(int(tempSellingPriceNumber)) > 0 {
+			return 0, fmt.Errorf("the selling_price must be a positive decimal value to two decimal places")
+		}
+	}
+	return decimalOdds, nil
+}
+
+// Quote prices the share on sale of a wager before it is placed, the selling price of
+// the request is assessed against the fair range when it is given. A selling price too
+// low to be placed is assessed as underpriced rather than refused.
+func (s *WagerService) Quote(quoteWagerRequest *models.QuoteWagerRequest) (*models.WagerQuote, error) {
+	decimalOdds, err := validateQuoteWagerReq(quoteWagerRequest)
+	if err != nil {
+		return nil, &validationError{err}
+	}
+	quote := quoteWager(decimalOdds, quoteWagerRequest.TotalWagerValue, quoteWagerRequest.SellingPercentage, s.fairPriceSpread())
+	if quoteWagerRequest.SellingPrice != nil {
+		assessPrice(quote, *quoteWagerRequest.SellingPrice)
+	}
+	return quote, nil
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/internal/models"
+	"github.com/wager-api/internal/repositories"
+	"github.com/wager-api/libs/database"
+	mock_database "github.com/wager-api/mocks/libs/database"
+	mock_repositories "github.com/wager-api/mocks/repositories"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgtype"
+)
+
+func Test_quoteWager(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		name              string
+		decimalOdds       float64
+		totalWagerValue   float32
+		sellingPercentage int
+		expected          *models.WagerQuote
+	}{
+		{
+			name: "the share is worth its part of the stake", decimalOdds: 30, totalWagerValue: 50, sellingPercentage: 30,
+			expected: &models.WagerQuote{
+				DecimalOdds: 30, ImpliedProbability: 0.0333, PotentialPayout: 450, ExpectedValue: 15,
+				MinSellingPrice: 15.01, FairPriceLow: 15.01, FairPriceHigh: 16.5,
+			},
+		},
+		{
+			name: "the fair range stops at the payout", decimalOdds: 1.05, totalWagerValue: 10, sellingPercentage: 100,
+			expected: &models.WagerQuote{
+				DecimalOdds: 1.05, ImpliedProbability: 0.9524, PotentialPayout: 10.5, ExpectedValue: 10,
+				MinSellingPrice: 10.01, FairPriceLow: 10.01, FairPriceHigh: 10.5,
+			},
+		},
+		{
+			name: "a stake share with fractions of a cent", decimalOdds: 2.5, totalWagerValue: 33.33, sellingPercentage: 33,
+			expected: &models.WagerQuote{
+				DecimalOdds: 2.5, ImpliedProbability: 0.4, PotentialPayout: 27.5, ExpectedValue: 11,
+				MinSellingPrice: 11, FairPriceLow: 11, FairPriceHigh: 12.1,
+			},
+		},
+	} {
+		assert.Equal(t, tc.expected, quoteWager(tc.decimalOdds, tc.totalWagerValue, tc.sellingPercentage, defaultFairPriceSpread), tc.name)
+	}
+}
+
+func Test_assessPrice(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		price      float32
+		premium    float32
+		assessment string
+	}{
+		{price: 12, premium: -20, assessment: models.QuoteAssessmentUnderpriced},
+		{price: 16, premium: 6.67, assessment: models.QuoteAssessmentFair},
+		{price: 30, premium: 100, assessment: models.QuoteAssessmentOverpriced},
+	} {
+		quote := quoteWager(30, 50, 30, defaultFairPriceSpread)
+		assessPrice(quote, tc.price)
+		assert.Equal(t, tc.price, quote.Price)
+		if assert.NotNil(t, quote.Premium) {
+			assert.Equal(t, tc.premium, *quote.Premium)
+		}
+		assert.Equal(t, tc.assessment, quote.Assessment)
+	}
+}
+
+func Test_QuoteWager(t *testing.T) {
+	t.Parallel()
+	mux := chi.NewMux()
+	NewWagerHandler(mux, &WagerService{FairPriceSpread: 20})
+
+	for _, body := range []string{
+		`{"total_wager_value": 0, "odds": 2, "selling_percentage": 30}`,
+		`{"total_wager_value": 50, "odds": "5/2", "selling_percentage": 30}`,
+		`{"total_wager_value": 50, "odds": 2, "selling_percentage": 101}`,
+		`{"total_wager_value": 50, "odds": 2, "selling_percentage": 30, "selling_price": 15.001}`,
+	} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/wagers/quote", bytes.NewBufferString(body)))
+		assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/wagers/quote", bytes.NewBufferString(
+		`{"total_wager_value": 50, "odds": "+200", "odds_format": "american", "selling_percentage": 30, "selling_price": 15}`)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	quote := &models.WagerQuote{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(quote))
+	premium := float32(0)
+	// a selling price which can't be placed is assessed rather than refused
+	assert.Equal(t, &models.WagerQuote{
+		DecimalOdds: 3, ImpliedProbability: 0.3333, PotentialPayout: 45, ExpectedValue: 15,
+		MinSellingPrice: 15.01, FairPriceLow: 15.01, FairPriceHigh: 18,
+		Price: 15, Premium: &premium, Assessment: models.QuoteAssessmentUnderpriced,
+	}, quote)
+}
+
+func Test_WagerService_List_Quote(t *testing.T) {
+	t.Parallel()
+	db := &mock_database.Ext{}
+	wagerRepo := &mock_repositories.MockWagerRepo{}
+	wagerService := &WagerService{DB: db, WagerRepo: wagerRepo}
+	ctx := context.Background()
+
+	filter := repositories.WagerFilter{}
+	_ = filter.EventID.Set(nil)
+	_ = filter.MarketID.Set(nil)
+	wagerRepo.On("List", ctx, db, filter, pgtype.Int4{Status: pgtype.Null}, uint32(0), uint32(5)).Return([]*entities.Wager{
+		{
+			WagerID: database.Int4(1), TotalWagerValue: database.Float4(50), Odds: database.Float8(30), SellingPercentage: database.Int4(30),
+			SellingPrice: database.Float4(40), CurrentSellingPrice: database.Float4(40),
+		},
+		// a wager whose odds can't be priced has no quote
+		{WagerID: database.Int4(2), TotalWagerValue: database.Float4(50), Odds: database.Float8(1), SellingPercentage: database.Int4(30)},
+	}, nil)
+
+	wagers, err := wagerService.List(ctx, &models.ListWagersQuery{Limit: 5, Quote: true})
+	if assert.NoError(t, err) && assert.Len(t, wagers, 2) {
+		if assert.NotNil(t, wagers[0].Quote) {
+			assert.Equal(t, float32(15), wagers[0].Quote.ExpectedValue)
+			assert.Equal(t, float32(40), wagers[0].Quote.Price)
+			assert.Equal(t, models.QuoteAssessmentOverpriced, wagers[0].Quote.Assessment)
+		}
+		assert.Nil(t, wagers[1].Quote)
+	}
+	wagers, err = wagerService.List(ctx, &models.ListWagersQuery{Limit: 5})
+	if assert.NoError(t, err) && assert.Len(t, wagers, 2) {
+		assert.Nil(t, wagers[0].Quote, "the quotes are only added when asked for")
+	}
+}
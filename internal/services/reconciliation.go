@@ -0,0 +1,200 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/internal/models"
+	"github.com/wager-api/internal/repositories"
+	"github.com/wager-api/libs/database"
+	"github.com/wager-api/libs/logs"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+	"go.uber.org/multierr"
+)
+
+const (
+	defaultReconciliationBatchSize = 500
+	// reconciliationTolerance is the drift ignored, below half a cent the aggregates round
+	// to their expected value
+	reconciliationTolerance = 0.005
+	// reconciliationActor is the actor of the audit entries of the repairs
+	reconciliationActor = "reconciliation"
+)
+
+// ReconciliationService checks the amount_sold and the percentage_sold of the wagers,
+// running totals kept by the purchases, against what their purchases sum up to.
+type ReconciliationService struct {
+	DB                 database.Ext
+	ReconciliationRepo interface {
+		ListWagerAggregates(ctx context.Context, db database.Ext, afterID pgtype.Int4, limit uint32) ([]*repositories.WagerAggregate, error)
+		SumPurchases(ctx context.Context, db database.Ext, wagerID pgtype.Int4) (pgtype.Float4, error)
+	}
+	WagerRepo interface {
+		Get(ctx context.Context, db database.Ext, wagerID pgtype.Int4, queryEnhancers ...repositories.QueryEnhancer) (*entities.Wager, error)
+		Update(ctx context.Context, db database.Ext, wager *entities.Wager) (pgconn.CommandTag, error)
+	}
+	AuditLogRepo interface {
+		Create(ctx context.Context, db database.Ext, auditLog *entities.AuditLog) error
+	}
+	// BatchSize is the number of wagers checked at a time, 500 when it is 0
+	BatchSize uint32
+}
+
+// expectedAggregates are the amount_sold and the percentage_sold of a wager of
+// sellingPrice whose purchases sum up to purchased.
+func expectedAggregates(sellingPrice, purchased float32) (amountSold, percentageSold float32) {
+	amountSold = roundFloat(purchased)
+	if sellingPrice <= 0 {
+		return amountSold, 0
+	}
+	return amountSold, roundFloat((amountSold / sellingPrice) * 100)
+}
+
+func drifted(value, expected float32) bool {
+	return math.Abs(float64(value-expected)) >= reconciliationTolerance
+}
+
+// checkAggregate returns the discrepancy of aggregate, nil when it holds none.
+func checkAggregate(aggregate *repositories.WagerAggregate) *models.WagerDiscrepancy {
+	amountSold, percentageSold := expectedAggregates(aggregate.SellingPrice, aggregate.PurchasedAmount)
+	if !drifted(aggregate.AmountSold, amountSold) && !drifted(aggregate.PercentageSold, percentageSold) {
+		return nil
+	}
+	return &models.WagerDiscrepancy{
+		WagerID:                int(aggregate.WagerID),
+		Purchases:              aggregate.Purchases,
+		AmountSold:             aggregate.AmountSold,
+		ExpectedAmountSold:     amountSold,
+		AmountSoldDiff:         roundFloat(aggregate.AmountSold - amountSold),
+		PercentageSold:         aggregate.PercentageSold,
+		ExpectedPercentageSold: percentageSold,
+		PercentageSoldDiff:     roundFloat(aggregate.PercentageSold - percentageSold),
+	}
+}
+
+// Reconcile checks every wager in id order and reports the ones which drifted, repair
+// sets them back to what their purchases sum up to.
+func (s *ReconciliationService) Reconcile(ctx context.Context, repair bool) (*models.ReconciliationReport, error) {
+	batchSize := s.BatchSize
+	if batchSize == 0 {
+		batchSize = defaultReconciliationBatchSize
+	}
+	report := &models.ReconciliationReport{StartedAt: time.Now(), Discrepancies: []*models.WagerDiscrepancy{}}
+	afterID := database.Int4(0)
+	for {
+		aggregates, err := s.ReconciliationRepo.ListWagerAggregates(ctx, s.DB, afterID, batchSize)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list wager aggregates")
+		}
+		for _, aggregate := range aggregates {
+			report.Checked++
+			discrepancy := checkAggregate(aggregate)
+			if discrepancy == nil {
+				continue
+			}
+			report.Discrepancies = append(report.Discrepancies, discrepancy)
+			if !repair {
+				continue
+			}
+			if discrepancy.Repaired, err = s.repair(ctx, database.Int4(aggregate.WagerID)); err != nil {
+				return nil, fmt.Errorf("unable to repair wager %d: %w", aggregate.WagerID, err)
+			}
+			if discrepancy.Repaired {
+				report.Repaired++
+			}
+		}
+		if len(aggregates) < int(batchSize) {
+			break
+		}
+		afterID = database.Int4(aggregates[len(aggregates)-1].WagerID)
+	}
+	report.FinishedAt = time.Now()
+	return report, nil
+}
+
+// repair recomputes the aggregates of a wager with the wager locked, so no purchase of
+// it runs meanwhile, and stores them along an audit entry. Nothing is written when the
+// wager doesn't drift anymore.
+func (s *ReconciliationService) repair(ctx context.Context, wagerID pgtype.Int4) (bool, error) {
+	repaired := false
+	ctx = withAuditInfo(ctx, auditInfo{actor: reconciliationActor})
+	err := database.ExecInTx(ctx, s.DB, func(ctx context.Context, tx pgx.Tx) error {
+		wager, err := s.WagerRepo.Get(ctx, tx, wagerID, repositories.WithUpdateLock())
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return nil
+			}
+			return fmt.Errorf("unable to get wager information")
+		}
+		purchased, err := s.ReconciliationRepo.SumPurchases(ctx, tx, wagerID)
+		if err != nil {
+			return fmt.Errorf("unable to sum the purchases of the wager")
+		}
+		amountSold, percentageSold := expectedAggregates(wager.SellingPrice.Float, purchased.Float)
+		if !drifted(wager.AmountSold.Float, amountSold) && !drifted(wager.PercentageSold.Float, percentageSold) {
+			return nil
+		}
+		before, err := snapshotEntity(wager)
+		if err != nil {
+			return fmt.Errorf("unable to snapshot wager record")
+		}
+		if err := multierr.Combine(
+			wager.AmountSold.Set(amountSold),
+			wager.PercentageSold.Set(percentageSold),
+			wager.UpdatedAt.Set(time.Now())); err != nil {
+			return fmt.Errorf("unable to generate wager record")
+		}
+		cmdTag, err := s.WagerRepo.Update(ctx, tx, wager)
+		if err != nil {
+			return fmt.Errorf("unable to update wager record")
+		}
+		if cmdTag.RowsAffected() != 1 {
+			return fmt.Errorf("unable to update wager record: no row affected")
+		}
+		after, err := snapshotEntity(wager)
+		if err != nil {
+			return fmt.Errorf("unable to snapshot wager record")
+		}
+		if err := s.AuditLogRepo.Create(ctx, tx, newAuditLog(ctx, entities.AuditActionWagerReconcile, wagerID, pgtype.Int4{Status: pgtype.Null}, before, after, time.Now())); err != nil {
+			return fmt.Errorf("unable to create audit log")
+		}
+		repaired = true
+		return nil
+	})
+	return repaired && err == nil, err
+}
+
+// Run reconciles the wager aggregates every interval until ctx is done, repair sets the
+// drifted ones back. An interval which is not positive disables it.
+func (s *ReconciliationService) Run(ctx context.Context, interval time.Duration, repair bool) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report, err := s.Reconcile(ctx, repair)
+			if err != nil {
+				logs.Logger.Errorw("unable to reconcile the wager aggregates", "error", err)
+				continue
+			}
+			for _, discrepancy := range report.Discrepancies {
+				logs.Logger.Warnw("wager aggregates drifted from the purchases", "wager_id", discrepancy.WagerID,
+					"amount_sold_diff", discrepancy.AmountSoldDiff, "percentage_sold_diff", discrepancy.PercentageSoldDiff,
+					"repaired", discrepancy.Repaired)
+			}
+			logs.Logger.Infow("reconciled the wager aggregates", "checked", report.Checked,
+				"discrepancies", len(report.Discrepancies), "repaired", report.Repaired)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/internal/models"
+	"github.com/wager-api/internal/repositories"
+	"github.com/wager-api/libs/database"
+	mock_database "github.com/wager-api/mocks/libs/database"
+	mock_repositories "github.com/wager-api/mocks/repositories"
+
+	"github.com/jackc/pgconn"
+)
+
+func Test_checkAggregate(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		name      string
+		aggregate *repositories.WagerAggregate
+		expected  *models.WagerDiscrepancy
+	}{
+		{
+			name:      "float error below a cent is no drift",
+			aggregate: &repositories.WagerAggregate{WagerID: 1, SellingPrice: 30, AmountSold: 20.100002, PercentageSold: 67, Purchases: 3, PurchasedAmount: 20.1},
+		},
+		{
+			name:      "a drifted amount_sold",
+			aggregate: &repositories.WagerAggregate{WagerID: 2, SellingPrice: 50, AmountSold: 30.5, PercentageSold: 61, Purchases: 2, PurchasedAmount: 30},
+			expected: &models.WagerDiscrepancy{
+				WagerID: 2, Purchases: 2,
+				AmountSold: 30.5, ExpectedAmountSold: 30, AmountSoldDiff: 0.5,
+				PercentageSold: 61, ExpectedPercentageSold: 60, PercentageSoldDiff: 1,
+			},
+		},
+		{
+			name:      "a drifted percentage_sold only",
+			aggregate: &repositories.WagerAggregate{WagerID: 3, SellingPrice: 50, AmountSold: 10, PercentageSold: 25, Purchases: 1, PurchasedAmount: 10},
+			expected: &models.WagerDiscrepancy{
+				WagerID: 3, Purchases: 1,
+				AmountSold: 10, ExpectedAmountSold: 10,
+				PercentageSold: 25, ExpectedPercentageSold: 20, PercentageSoldDiff: 5,
+			},
+		},
+	} {
+		assert.Equal(t, tc.expected, checkAggregate(tc.aggregate), tc.name)
+	}
+}
+
+func Test_Reconcile(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	db := &mock_database.Ext{}
+	reconciliationRepo := &mock_repositories.MockReconciliationRepo{}
+	reconciliationRepo.On("ListWagerAggregates", ctx, db, database.Int4(0), uint32(2)).Once().Return([]*repositories.WagerAggregate{
+		{WagerID: 1, SellingPrice: 50, AmountSold: 20, PercentageSold: 40, PurchasedAmount: 20},
+		{WagerID: 4, SellingPrice: 50, AmountSold: 25, PercentageSold: 50, PurchasedAmount: 20},
+	}, nil)
+	reconciliationRepo.On("ListWagerAggregates", ctx, db, database.Int4(4), uint32(2)).Once().Return([]*repositories.WagerAggregate{
+		{WagerID: 7, SellingPrice: 50, AmountSold: 0, PercentageSold: 0, PurchasedAmount: 0},
+	}, nil)
+
+	s := &ReconciliationService{DB: db, ReconciliationRepo: reconciliationRepo, BatchSize: 2}
+	report, err := s.Reconcile(ctx, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, report.Checked)
+	assert.Equal(t, 0, report.Repaired)
+	if assert.Len(t, report.Discrepancies, 1) {
+		assert.Equal(t, 4, report.Discrepancies[0].WagerID)
+		assert.Equal(t, float32(5), report.Discrepancies[0].AmountSoldDiff)
+		assert.False(t, report.Discrepancies[0].Repaired)
+	}
+	reconciliationRepo.AssertExpectations(t)
+}
+
+func Test_Reconcile_Repair(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	db := &mock_database.Ext{}
+	tx := &mock_database.Tx{}
+	reconciliationRepo := &mock_repositories.MockReconciliationRepo{}
+	wagerRepo := &mock_repositories.MockWagerRepo{}
+	auditLogRepo := &mock_repositories.MockAuditLogRepo{}
+	db.On("Begin", mock.Anything).Return(tx, nil)
+	tx.On("Commit", mock.Anything).Return(nil)
+	reconciliationRepo.On("ListWagerAggregates", ctx, db, database.Int4(0), uint32(defaultReconciliationBatchSize)).Once().Return([]*repositories.WagerAggregate{
+		{WagerID: 4, SellingPrice: 50, AmountSold: 25, PercentageSold: 50, PurchasedAmount: 20},
+	}, nil)
+	wagerRepo.On("Get", mock.Anything, tx, database.Int4(4), mock.Anything).Once().Return(&entities.Wager{
+		WagerID:             database.Int4(4),
+		SellingPrice:        database.Float4(50),
+		CurrentSellingPrice: database.Float4(20),
+		AmountSold:          database.Float4(25),
+		PercentageSold:      database.Float4(50),
+	}, nil)
+	// a purchase committed since the listing is taken into account
+	reconciliationRepo.On("SumPurchases", mock.Anything, tx, database.Int4(4)).Once().Return(database.Float4(22.5), nil)
+	wagerRepo.On("Update", mock.Anything, tx, mock.MatchedBy(func(wager *entities.Wager) bool {
+		return wager.AmountSold.Float == 22.5 && wager.PercentageSold.Float == 45 && wager.CurrentSellingPrice.Float == 20
+	})).Once().Return(pgconn.CommandTag("UPDATE 1"), nil)
+	auditLogRepo.On("Create", mock.Anything, tx, mock.MatchedBy(func(auditLog *entities.AuditLog) bool {
+		return auditLog.Action.String == entities.AuditActionWagerReconcile && auditLog.Actor.String == reconciliationActor &&
+			auditLog.WagerID.Int == 4
+	})).Once().Return(nil)
+
+	s := &ReconciliationService{DB: db, ReconciliationRepo: reconciliationRepo, WagerRepo: wagerRepo, AuditLogRepo: auditLogRepo}
+	report, err := s.Reconcile(ctx, true)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Repaired)
+	if assert.Len(t, report.Discrepancies, 1) {
+		assert.True(t, report.Discrepancies[0].Repaired)
+	}
+	wagerRepo.AssertExpectations(t)
+	auditLogRepo.AssertExpectations(t)
+}
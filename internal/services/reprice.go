@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/internal/models"
+	"github.com/wager-api/internal/repositories"
+	"github.com/wager-api/libs/database"
+
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+	"go.uber.org/multierr"
+)
+
+// validateRepriceWagerReq checks the new prices of a wager with the rules of a placement,
+// the selling price can't drop below what is sold already.
+func validateRepriceWagerReq(req *models.RepriceWagerRequest, wager *entities.Wager) error {
+	if req.SellingPrice == nil && req.CurrentSellingPrice == nil {
+		return fmt.Errorf("the selling_price or the current_selling_price must be given")
+	}
+	sellingPrice := wager.SellingPrice.Float
+	if req.SellingPrice != nil {
+		sellingPrice = *req.SellingPrice
+		if err := validateSellingPrice(sellingPrice, wager.TotalWagerValue.Float, int(wager.SellingPercentage.Int)); err != nil {
+			return err
+		}
+		if sellingPrice < wager.AmountSold.Float {
+			return fmt.Errorf("the selling_price must be at least the amount_sold")
+		}
+	}
+	if req.CurrentSellingPrice != nil {
+		tempCurrentSellingPrice := *req.CurrentSellingPrice * 100
+		if *req.CurrentSellingPrice <= 0 || tempCurrentSellingPrice-float32(int(tempCurrentSellingPrice)) > 0 {
+			return fmt.Errorf("the current_selling_price must be a positive decimal value to two decimal places")
+		}
+		if *req.CurrentSellingPrice > sellingPrice {
+			return fmt.Errorf("the current_selling_price must be lesser or equal to selling_price")
+		}
+	}
+	return nil
+}
+
+// checkWagerNotSettled makes sure the market of a wager is not settled yet.
+func (s *WagerService) checkWagerNotSettled(ctx context.Context, db database.Ext, marketID pgtype.Int4) error {
+	if marketID.Status != pgtype.Present {
+		return nil
+	}
+	market, err := s.MarketRepo.Get(ctx, db, marketID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return errMarketNotFound
+		}
+		return fmt.Errorf("unable to get market information")
+	}
+	if market.Status.String == entities.MarketStatusSettled {
+		return errWagerSettled
+	}
+	return nil
+}
+
+// Reprice sets the asking prices of a wager for its seller, the seller_id of the request
+// or else its actor. Without a current_selling_price, the current price follows a new
+// selling_price until something is sold and is only capped by it after. A new price
+// ends the decay of the wager, the open buy orders it satisfies are filled.
+func (s *WagerService) Reprice(ctx context.Context, wagerID int, repriceWagerRequest *models.RepriceWagerRequest) (*models.Wager, error) {
+	sellerID := strings.TrimSpace(repriceWagerRequest.SellerID)
+	if sellerID == "" {
+		sellerID = auditInfoFromContext(ctx).actor
+	}
+	if sellerID == "" {
+		return nil, &validationError{fmt.Errorf("the seller_id must be given")}
+	}
+	var wager *entities.Wager
+	if err := database.ExecInTx(ctx, s.DB, func(ctx context.Context, tx pgx.Tx) error {
+		var err error
+		wager, err = s.WagerRepo.Get(ctx, tx, database.Int4(int32(wagerID)), repositories.WithUpdateLock())
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return errWagerNotFound
+			}
+			return fmt.Errorf("unable to get wager information")
+		}
+		if wager.SellerID.Status != pgtype.Present || wager.SellerID.String != sellerID {
+			return errNotWagerSeller
+		}
+		if err := s.checkWagerNotSettled(ctx, tx, wager.MarketID); err != nil {
+			return err
+		}
+		if err := validateRepriceWagerReq(repriceWagerRequest, wager); err != nil {
+			return &validationError{err}
+		}
+		before, err := snapshotEntity(wager)
+		if err != nil {
+			return fmt.Errorf("unable to snapshot wager record")
+		}
+		now := time.Now()
+		applyPriceDecay(wager, now)
+		previousPrice := wager.CurrentSellingPrice.Float
+		sellingPrice, currentSellingPrice := wager.SellingPrice.Float, previousPrice
+		if repriceWagerRequest.SellingPrice != nil {
+			sellingPrice = *repriceWagerRequest.SellingPrice
+			if wager.AmountSold.Float == 0 || currentSellingPrice > sellingPrice {
+				currentSellingPrice = sellingPrice
+			}
+		}
+		if repriceWagerRequest.CurrentSellingPrice != nil {
+			currentSellingPrice = *repriceWagerRequest.CurrentSellingPrice
+		}
+		if err := s.checkRepriceExposure(ctx, tx, wager, sellingPrice, now); err != nil {
+			return err
+		}
+		if err := multierr.Combine(
+			wager.SellingPrice.Set(sellingPrice),
+			wager.CurrentSellingPrice.Set(currentSellingPrice),
+			wager.PercentageSold.Set(roundFloat((wager.AmountSold.Float/sellingPrice)*100)),
+			wager.DecayType.Set(nil),
+			wager.DecayFloorPrice.Set(nil),
+			wager.DecayDurationSeconds.Set(nil),
+			wager.DecayStepSeconds.Set(nil),
+			wager.UpdatedAt.Set(now),
+		); err != nil {
+			return fmt.Errorf("unable to generate wager record")
+		}
+		cmdTag, err := s.WagerRepo.Reprice(ctx, tx, wager)
+		if err != nil {
+			return fmt.Errorf("unable to update wager record")
+		}
+		if cmdTag.RowsAffected() != 1 {
+			return fmt.Errorf("unable to update wager record: no row affected")
+		}
+		if err := s.recordPrice(ctx, tx, wager, now); err != nil {
+			return fmt.Errorf("unable to record price history")
+		}
+		if err := s.addWagerUpdate(ctx, tx, entities.WagerUpdatePriceChanged, wager.WagerID, &models.PriceChanged{
+			WagerID:             int(wager.WagerID.Int),
+			PreviousPrice:       previousPrice,
+			CurrentSellingPrice: wager.CurrentSellingPrice.Float,
+		}); err != nil {
+			return fmt.Errorf("unable to publish wager updates")
+		}
+		after, err := snapshotEntity(wager)
+		if err != nil {
+			return fmt.Errorf("unable to snapshot wager record")
+		}
+		if err := s.AuditLogRepo.Create(ctx, tx, newAuditLog(ctx, entities.AuditActionWagerReprice, wager.WagerID, pgtype.Int4{Status: pgtype.Null}, before, after, now)); err != nil {
+			return fmt.Errorf("unable to create audit log")
+		}
+		return s.matchBuyOrders(ctx, tx, wager, now)
+	}); err != nil {
+		return nil, fmt.Errorf("unable to reprice wager: %w", err)
+	}
+	if s.Webhooks != nil {
+		s.Webhooks.Notify()
+	}
+	return convertWagerPg2Domain(wager, models.OddsFormatDecimal), nil
+}
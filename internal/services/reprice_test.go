@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/internal/models"
+	"github.com/wager-api/libs/database"
+	mock_database "github.com/wager-api/mocks/libs/database"
+	mock_repositories "github.com/wager-api/mocks/repositories"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgtype"
+)
+
+func repriceWager(amountSold float32) *entities.Wager {
+	wager := &entities.Wager{}
+	database.AllNullEntity(wager)
+	wager.WagerID = database.Int4(1)
+	wager.TotalWagerValue = database.Float4(50)
+	wager.SellingPercentage = database.Int4(30)
+	wager.SellingPrice = database.Float4(50)
+	wager.CurrentSellingPrice = database.Float4(40)
+	wager.AmountSold = database.Float4(amountSold)
+	wager.PlaceAt = database.Timestamptz(time.Now())
+	return wager
+}
+
+func Test_validateRepriceWagerReq(t *testing.T) {
+	t.Parallel()
+	price := func(p float32) *float32 { return &p }
+	tests := []struct {
+		name        string
+		req         *models.RepriceWagerRequest
+		expectedErr string
+	}{
+		{name: "new selling_price", req: &models.RepriceWagerRequest{SellingPrice: price(60)}},
+		{name: "new current_selling_price", req: &models.RepriceWagerRequest{CurrentSellingPrice: price(45)}},
+		{name: "nothing to change", req: &models.RepriceWagerRequest{}, expectedErr: "the selling_price or the current_selling_price must be given"},
+		{name: "selling_price of the placement rules", req: &models.RepriceWagerRequest{SellingPrice: price(15)}, expectedErr: "selling_price must be greater than total_wager_value * (selling_percentage / 100)"},
+		{name: "selling_price below the amount sold", req: &models.RepriceWagerRequest{SellingPrice: price(30)}, expectedErr: "the selling_price must be at least the amount_sold"},
+		{name: "current_selling_price above the selling_price", req: &models.RepriceWagerRequest{CurrentSellingPrice: price(55)}, expectedErr: "the current_selling_price must be lesser or equal to selling_price"},
+		{name: "current_selling_price to three decimal places", req: &models.RepriceWagerRequest{CurrentSellingPrice: price(10.125)}, expectedErr: "the current_selling_price must be a positive decimal value to two decimal places"},
+	}
+	for _, tt := range tests {
+		err := validateRepriceWagerReq(tt.req, repriceWager(35))
+		if tt.expectedErr == "" {
+			assert.NoError(t, err, tt.name)
+			continue
+		}
+		assert.EqualError(t, err, tt.expectedErr, tt.name)
+	}
+}
+
+func Test_Reprice(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	price := func(p float32) *float32 { return &p }
+
+	t.Run("lowering the price fills the buy orders it satisfies", func(t *testing.T) {
+		db := &mock_database.Ext{}
+		tx := &mock_database.Tx{}
+		wagerRepo := &mock_repositories.MockWagerRepo{}
+		priceHistoryRepo := &mock_repositories.MockPriceHistoryRepo{}
+		wagerUpdateRepo := &mock_repositories.MockWagerUpdateRepo{}
+		auditLogRepo := &mock_repositories.MockAuditLogRepo{}
+		buyOrderRepo := &mock_repositories.MockBuyOrderRepo{}
+		db.On("Begin", ctx).Return(tx, nil)
+		tx.On("Commit", mock.Anything).Return(nil)
+		wager := repriceWager(0)
+		wager.DecayType = database.Text(entities.WagerDecayLinear)
+		wager.DecayFloorPrice = database.Float4(20)
+		wager.DecayDurationSeconds = database.Int4(3600)
+		wager.SellerID = database.Text("seller-1")
+		wagerRepo.On("Get", ctx, tx, database.Int4(1)).Once().Return(wager, nil)
+		wagerRepo.On("Reprice", ctx, tx, mock.MatchedBy(func(wager *entities.Wager) bool {
+			return wager.SellingPrice.Float == 45 && wager.CurrentSellingPrice.Float == 45 && wager.DecayType.Status == pgtype.Null
+		})).Once().Return(pgconn.CommandTag("UPDATE 1"), nil)
+		priceHistoryRepo.On("Create", ctx, tx, mock.Anything).Once().Return(nil)
+		wagerUpdateRepo.On("Create", ctx, tx, mock.Anything).Once().Return(nil)
+		auditLogRepo.On("Create", ctx, tx, mock.MatchedBy(func(auditLog *entities.AuditLog) bool {
+			return auditLog.Action.String == entities.AuditActionWagerReprice
+		})).Once().Return(nil)
+		buyOrderRepo.On("ListMatching", ctx, tx, database.Int4(1), database.Float4(45), mock.Anything).Once().Return([]*entities.BuyOrder{}, nil)
+
+		s := &WagerService{
+			DB:               db,
+			WagerRepo:        wagerRepo,
+			PriceHistoryRepo: priceHistoryRepo,
+			WagerUpdateRepo:  wagerUpdateRepo,
+			AuditLogRepo:     auditLogRepo,
+			BuyOrderRepo:     buyOrderRepo,
+		}
+		repriced, err := s.Reprice(ctx, 1, &models.RepriceWagerRequest{SellerID: "seller-1", SellingPrice: price(45)})
+		assert.NoError(t, err)
+		assert.Equal(t, float32(45), repriced.CurrentSellingPrice)
+		assert.Nil(t, repriced.PriceDecay)
+		wagerRepo.AssertExpectations(t)
+		auditLogRepo.AssertExpectations(t)
+	})
+	t.Run("a settled wager can't be repriced", func(t *testing.T) {
+		db := &mock_database.Ext{}
+		tx := &mock_database.Tx{}
+		wagerRepo := &mock_repositories.MockWagerRepo{}
+		marketRepo := &mock_repositories.MockMarketRepo{}
+		db.On("Begin", ctx).Return(tx, nil)
+		tx.On("Rollback", mock.Anything).Return(nil)
+		wager := repriceWager(0)
+		wager.MarketID = database.Int4(3)
+		wager.SellerID = database.Text("seller-1")
+		wagerRepo.On("Get", ctx, tx, database.Int4(1)).Once().Return(wager, nil)
+		marketRepo.On("Get", ctx, tx, database.Int4(3)).Once().Return(&entities.Market{
+			MarketID: database.Int4(3),
+			Status:   database.Text(entities.MarketStatusSettled),
+		}, nil)
+
+		s := &WagerService{DB: db, WagerRepo: wagerRepo, MarketRepo: marketRepo}
+		_, err := s.Reprice(ctx, 1, &models.RepriceWagerRequest{SellerID: "seller-1", SellingPrice: price(45)})
+		assert.EqualError(t, err, "unable to reprice wager: the wager is settled")
+		assert.Equal(t, http.StatusConflict, statusFromError(err))
+	})
+	t.Run("only the seller of the wager can reprice it", func(t *testing.T) {
+		db := &mock_database.Ext{}
+		tx := &mock_database.Tx{}
+		wagerRepo := &mock_repositories.MockWagerRepo{}
+		// without a seller_id the actor of the request is the seller
+		ctx := withAuditInfo(ctx, auditInfo{actor: "seller-2"})
+		db.On("Begin", ctx).Return(tx, nil)
+		tx.On("Rollback", mock.Anything).Return(nil)
+		wager := repriceWager(0)
+		wager.SellerID = database.Text("seller-1")
+		wagerRepo.On("Get", ctx, tx, database.Int4(1)).Once().Return(wager, nil)
+
+		s := &WagerService{DB: db, WagerRepo: wagerRepo}
+		_, err := s.Reprice(ctx, 1, &models.RepriceWagerRequest{SellingPrice: price(45)})
+		assert.EqualError(t, err, "unable to reprice wager: only the seller of the wager can change it")
+		assert.Equal(t, http.StatusForbidden, statusFromError(err))
+
+		_, err = s.Reprice(context.Background(), 1, &models.RepriceWagerRequest{SellingPrice: price(45)})
+		assert.EqualError(t, err, "the seller_id must be given")
+		assert.Equal(t, http.StatusBadRequest, statusFromError(err))
+		wagerRepo.AssertExpectations(t)
+	})
+}
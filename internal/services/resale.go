@@ -0,0 +1,354 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/internal/models"
+	"github.com/wager-api/internal/repositories"
+	"github.com/wager-api/libs/database"
+
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+	"go.uber.org/multierr"
+)
+
+// isTwoDecimalPlaces tells whether a price or a stake has at most two decimal places
+func isTwoDecimalPlaces(value float32) bool {
+	tempNumber := value * 100
+	return tempNumber-float32(int(tempNumber)) <= 0
+}
+
+func validateCreateListingReq(req *models.CreateListingRequest) error {
+	if strings.TrimSpace(req.SellerID) == "" || len(req.SellerID) > maxSellerIDLength {
+		return fmt.Errorf("the seller_id must be given, at most %d characters long", maxSellerIDLength)
+	}
+	if req.Stake <= 0 || !isTwoDecimalPlaces(req.Stake) {
+		return fmt.Errorf("the stake must be a positive decimal value to two decimal places")
+	}
+	if req.AskingPrice <= 0 || !isTwoDecimalPlaces(req.AskingPrice) {
+		return fmt.Errorf("the asking_price must be a positive decimal value to two decimal places")
+	}
+	return nil
+}
+
+func convertListingPg2Domain(listing *entities.ResaleListing) *models.Listing {
+	var soldAt *time.Time
+	if listing.SoldAt.Status == pgtype.Present {
+		soldAt = &listing.SoldAt.Time
+	}
+	return &models.Listing{
+		ID:          int(listing.ListingID.Int),
+		PurchaseID:  int(listing.PurchaseID.Int),
+		WagerID:     int(listing.WagerID.Int),
+		SellerID:    listing.SellerID.String,
+		Stake:       listing.Stake.Float,
+		AskingPrice: listing.AskingPrice.Float,
+		Status:      listing.Status.String,
+		BuyerID:     listing.BuyerID.String,
+		SoldAt:      soldAt,
+		CreatedAt:   listing.CreatedAt.Time,
+	}
+}
+
+func convertStakeTransferPg2Domain(transfer *entities.StakeTransfer) *models.StakeTransfer {
+	response := &models.StakeTransfer{
+		ID:            int(transfer.TransferID.Int),
+		ListingID:     int(transfer.ListingID.Int),
+		PurchaseID:    int(transfer.PurchaseID.Int),
+		WagerID:       int(transfer.WagerID.Int),
+		FromHolderID:  transfer.FromHolderID.String,
+		ToHolderID:    transfer.ToHolderID.String,
+		Stake:         transfer.Stake.Float,
+		Price:         transfer.Price.Float,
+		TransferredAt: transfer.TransferredAt.Time,
+	}
+	if transfer.PaidCurrency.Status == pgtype.Present {
+		response.PaidCurrency = transfer.PaidCurrency.String
+		response.PaidAmount = transfer.PaidAmount.Float
+		response.ExchangeRate = transfer.ExchangeRate.Float
+	}
+	return response
+}
+
+// checkResaleOpen makes sure the stakes of a wager can still change hands, which they
+// can while the wager itself can be bought, it returns the wager.
+func (s *WagerService) checkResaleOpen(ctx context.Context, db database.Ext, wagerID pgtype.Int4, now time.Time) (*entities.Wager, error) {
+	wager, err := s.WagerRepo.Get(ctx, db, wagerID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errWagerNotFound
+		}
+		return nil, fmt.Errorf("unable to get wager information")
+	}
+	if err := s.checkWagerSalesOpen(ctx, db, wager.MarketID, now); err != nil {
+		return nil, err
+	}
+	return wager, nil
+}
+
+// CreateListing puts a part of the stake the seller holds in a purchase up for resale,
+// the stakes listed by a seller can't add up to more than it holds.
+func (s *WagerService) CreateListing(ctx context.Context, purchaseID int, createListingRequest *models.CreateListingRequest) (*models.Listing, error) {
+	if err := validateCreateListingReq(createListingRequest); err != nil {
+		return nil, &validationError{err}
+	}
+	now := time.Now()
+	listing := &entities.ResaleListing{}
+	database.AllNullEntity(listing)
+	if err := database.ExecInTx(ctx, s.DB, func(ctx context.Context, tx pgx.Tx) error {
+		sellerID := database.Text(strings.TrimSpace(createListingRequest.SellerID))
+		// the holding is locked so concurrent listings of the same stake are checked
+		// one after another
+		holding, err := s.StakeHoldingRepo.Get(ctx, tx, database.Int4(int32(purchaseID)), sellerID, repositories.WithUpdateLock())
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return errStakeHoldingNotFound
+			}
+			return fmt.Errorf("unable to get stake holding")
+		}
+		if _, err := s.checkResaleOpen(ctx, tx, holding.WagerID, now); err != nil {
+			return err
+		}
+		listed, err := s.ResaleListingRepo.SumOpenStake(ctx, tx, holding.PurchaseID, sellerID)
+		if err != nil {
+			return fmt.Errorf("unable to sum listed stakes")
+		}
+		if unlisted := holding.Stake.Float - listed.Float; createListingRequest.Stake > unlisted {
+			return &validationError{fmt.Errorf("the stake must be at most the %.2f held and not listed yet", unlisted)}
+		}
+		if err := multierr.Combine(
+			listing.PurchaseID.Set(holding.PurchaseID),
+			listing.WagerID.Set(holding.WagerID),
+			listing.SellerID.Set(sellerID),
+			listing.Stake.Set(createListingRequest.Stake),
+			listing.AskingPrice.Set(createListingRequest.AskingPrice),
+			listing.Status.Set(entities.ResaleListingStatusOpen),
+			listing.CreatedAt.Set(now),
+			listing.UpdatedAt.Set(now),
+		); err != nil {
+			return fmt.Errorf("unable to generate listing record")
+		}
+		if err := s.ResaleListingRepo.Create(ctx, tx, listing); err != nil {
+			return fmt.Errorf("unable to create listing")
+		}
+		return s.auditListing(ctx, tx, entities.AuditActionListingCreate, pgtype.JSONB{Status: pgtype.Null}, listing, now)
+	}); err != nil {
+		return nil, fmt.Errorf("unable to create listing: %w", err)
+	}
+	return convertListingPg2Domain(listing), nil
+}
+
+func (s *WagerService) GetListing(ctx context.Context, listingID int) (*models.Listing, error) {
+	listing, err := s.ResaleListingRepo.Get(ctx, s.DB, database.Int4(int32(listingID)))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errListingNotFound
+		}
+		return nil, fmt.Errorf("unable to get listing")
+	}
+	return convertListingPg2Domain(listing), nil
+}
+
+// auditListing records the change of a listing made in tx, after is snapshot at once.
+func (s *WagerService) auditListing(ctx context.Context, tx database.Ext, action string, before pgtype.JSONB, listing *entities.ResaleListing, now time.Time) error {
+	after, err := snapshotEntity(listing)
+	if err != nil {
+		return fmt.Errorf("unable to snapshot listing record")
+	}
+	auditLog := newEntityAuditLog(ctx, action, listing.ListingID.Int, before, after, now)
+	auditLog.WagerID = listing.WagerID
+	auditLog.PurchaseID = listing.PurchaseID
+	if err := s.AuditLogRepo.Create(ctx, tx, auditLog); err != nil {
+		return fmt.Errorf("unable to create audit log")
+	}
+	return nil
+}
+
+// CancelListing takes a listing which is still open off the market for its seller, sellerID
+// or else the actor of the request.
+func (s *WagerService) CancelListing(ctx context.Context, listingID int, sellerID string) (*models.Listing, error) {
+	sellerID = strings.TrimSpace(sellerID)
+	if sellerID == "" {
+		sellerID = auditInfoFromContext(ctx).actor
+	}
+	if sellerID == "" {
+		return nil, &validationError{fmt.Errorf("the seller_id must be given")}
+	}
+	var listing *entities.ResaleListing
+	now := time.Now()
+	if err := database.ExecInTx(ctx, s.DB, func(ctx context.Context, tx pgx.Tx) error {
+		var err error
+		listing, err = s.ResaleListingRepo.Get(ctx, tx, database.Int4(int32(listingID)), repositories.WithUpdateLock())
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return errListingNotFound
+			}
+			return fmt.Errorf("unable to get listing")
+		}
+		if listing.SellerID.String != sellerID {
+			return errNotListingSeller
+		}
+		before, err := snapshotEntity(listing)
+		if err != nil {
+			return fmt.Errorf("unable to snapshot listing record")
+		}
+		cmdTag, err := s.ResaleListingRepo.Cancel(ctx, tx, listing.ListingID, database.Timestamptz(now))
+		if err != nil {
+			return fmt.Errorf("unable to cancel listing")
+		}
+		if cmdTag.RowsAffected() != 1 {
+			return errListingNotOpen
+		}
+		if listing, err = s.ResaleListingRepo.Get(ctx, tx, listing.ListingID); err != nil {
+			return fmt.Errorf("unable to get listing")
+		}
+		return s.auditListing(ctx, tx, entities.AuditActionListingCancel, before, listing, now)
+	}); err != nil {
+		return nil, fmt.Errorf("unable to cancel listing: %w", err)
+	}
+	return convertListingPg2Domain(listing), nil
+}
+
+// BuyListing sells the whole stake of an open listing to the buyer at its asking price:
+// the stake moves from the holding of the seller to the one of the buyer, which is paid
+// for it when the wager is settled.
+func (s *WagerService) BuyListing(ctx context.Context, listingID int, buyListingRequest *models.BuyListingRequest) (*models.StakeTransfer, error) {
+	buyerID := strings.TrimSpace(buyListingRequest.BuyerID)
+	if buyerID == "" || len(buyerID) > maxSellerIDLength {
+		return nil, &validationError{fmt.Errorf("the buyer_id must be given, at most %d characters long", maxSellerIDLength)}
+	}
+	now := time.Now()
+	transfer := &entities.StakeTransfer{}
+	database.AllNullEntity(transfer)
+	var fees []*entities.PurchaseFee
+	if err := database.ExecInTx(ctx, s.DB, func(ctx context.Context, tx pgx.Tx) error {
+		listing, err := s.ResaleListingRepo.Get(ctx, tx, database.Int4(int32(listingID)), repositories.WithUpdateLock())
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return errListingNotFound
+			}
+			return fmt.Errorf("unable to get listing")
+		}
+		if listing.Status.String != entities.ResaleListingStatusOpen {
+			return errListingNotOpen
+		}
+		if listing.SellerID.String == buyerID {
+			return &validationError{fmt.Errorf("the buyer_id must not be the seller_id of the listing")}
+		}
+		wager, err := s.checkResaleOpen(ctx, tx, listing.WagerID, now)
+		if err != nil {
+			return err
+		}
+		if err := s.checkPurchaseCap(ctx, tx, wager, listing.AskingPrice.Float, now); err != nil {
+			return err
+		}
+		// the resale is charged the fees of a purchase of the wager at the asking price
+		if fees, err = s.computeFees(ctx, tx, wager, listing.AskingPrice.Float, database.Text(buyerID), now); err != nil {
+			return err
+		}
+		// the buyer pays the asking price in the currency of their account
+		paidCurrency, rate, err := s.paymentRate(ctx, tx, wager, database.Text(buyerID), now)
+		if err != nil {
+			return err
+		}
+		if err := multierr.Combine(
+			transfer.PaidCurrency.Set(paidCurrency),
+			transfer.PaidAmount.Set(convertAmount(listing.AskingPrice.Float, rate)),
+			transfer.ExchangeRate.Set(rate),
+		); err != nil {
+			return fmt.Errorf("unable to generate stake transfer record")
+		}
+		if err := s.checkBuyerLimits(ctx, tx, database.Text(buyerID), paidCurrency, paidSpend(transfer.PaidAmount.Float, rate, fees), now); err != nil {
+			return err
+		}
+		alerts, err := s.evaluateResale(ctx, tx, wager, listing, database.Text(buyerID), now)
+		if err != nil {
+			return err
+		}
+		before, err := snapshotEntity(listing)
+		if err != nil {
+			return fmt.Errorf("unable to snapshot listing record")
+		}
+		holding, err := s.StakeHoldingRepo.Get(ctx, tx, listing.PurchaseID, listing.SellerID, repositories.WithUpdateLock())
+		if err != nil {
+			return fmt.Errorf("unable to get stake holding")
+		}
+		cmdTag, err := s.StakeHoldingRepo.Reduce(ctx, tx, holding.HoldingID, listing.Stake, database.Timestamptz(now))
+		if err != nil {
+			return fmt.Errorf("unable to update stake holding")
+		}
+		if cmdTag.RowsAffected() != 1 {
+			return fmt.Errorf("unable to update stake holding: the seller doesn't hold the stake anymore")
+		}
+		buyerHolding := &entities.StakeHolding{}
+		if err := multierr.Combine(
+			buyerHolding.PurchaseID.Set(listing.PurchaseID),
+			buyerHolding.WagerID.Set(listing.WagerID),
+			buyerHolding.HolderID.Set(buyerID),
+			buyerHolding.Stake.Set(listing.Stake),
+			buyerHolding.CreatedAt.Set(now),
+			buyerHolding.UpdatedAt.Set(now),
+		); err != nil {
+			return fmt.Errorf("unable to generate stake holding record")
+		}
+		if err := s.StakeHoldingRepo.Add(ctx, tx, buyerHolding); err != nil {
+			return fmt.Errorf("unable to create stake holding")
+		}
+		if cmdTag, err = s.ResaleListingRepo.Sell(ctx, tx, listing.ListingID, database.Text(buyerID), database.Timestamptz(now)); err != nil {
+			return fmt.Errorf("unable to update listing")
+		}
+		if cmdTag.RowsAffected() != 1 {
+			return fmt.Errorf("unable to update listing: no row affected")
+		}
+		if err := multierr.Combine(
+			transfer.ListingID.Set(listing.ListingID),
+			transfer.PurchaseID.Set(listing.PurchaseID),
+			transfer.WagerID.Set(listing.WagerID),
+			transfer.FromHolderID.Set(listing.SellerID),
+			transfer.ToHolderID.Set(buyerID),
+			transfer.Stake.Set(listing.Stake),
+			transfer.Price.Set(listing.AskingPrice),
+			transfer.TransferredAt.Set(now),
+			transfer.CreatedAt.Set(now),
+		); err != nil {
+			return fmt.Errorf("unable to generate stake transfer record")
+		}
+		if err := s.StakeTransferRepo.Create(ctx, tx, transfer); err != nil {
+			return fmt.Errorf("unable to create stake transfer")
+		}
+		if err := s.recordTradeAlerts(ctx, tx, alerts, pgtype.Int4{Status: pgtype.Null}); err != nil {
+			return err
+		}
+		for _, fee := range fees {
+			fee.PurchaseID = listing.PurchaseID
+			fee.TransferID = transfer.TransferID
+			if err := s.PurchaseFeeRepo.Create(ctx, tx, fee); err != nil {
+				return fmt.Errorf("unable to create purchase fee record")
+			}
+		}
+		_ = listing.Status.Set(entities.ResaleListingStatusSold)
+		_ = listing.BuyerID.Set(buyerID)
+		_ = listing.SoldAt.Set(now)
+		_ = listing.UpdatedAt.Set(now)
+		after, err := snapshotEntity(listing)
+		if err != nil {
+			return fmt.Errorf("unable to snapshot listing record")
+		}
+		if err := s.AuditLogRepo.Create(ctx, tx, newAuditLog(ctx, entities.AuditActionStakeResale, listing.WagerID, listing.PurchaseID, before, after, now)); err != nil {
+			return fmt.Errorf("unable to create audit log")
+		}
+		return nil
+	}); err != nil {
+		s.recordBlockedTrade(ctx, err)
+		return nil, fmt.Errorf("unable to buy listing: %w", err)
+	}
+	response := convertStakeTransferPg2Domain(transfer)
+	if len(fees) > 0 {
+		response.Fees, response.TotalFee = convertFeesPg2Domain(fees)
+	}
+	return response, nil
+}
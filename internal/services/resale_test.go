@@ -0,0 +1,311 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/internal/models"
+	"github.com/wager-api/internal/repositories"
+	"github.com/wager-api/libs/database"
+	mock_database "github.com/wager-api/mocks/libs/database"
+	mock_repositories "github.com/wager-api/mocks/repositories"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+)
+
+func resaleListing(status string) *entities.ResaleListing {
+	listing := &entities.ResaleListing{}
+	database.AllNullEntity(listing)
+	listing.ListingID = database.Int4(5)
+	listing.PurchaseID = database.Int4(101)
+	listing.WagerID = database.Int4(1)
+	listing.SellerID = database.Text("seller-1")
+	listing.Stake = database.Float4(10)
+	listing.AskingPrice = database.Float4(12.5)
+	listing.Status = database.Text(status)
+	listing.CreatedAt = database.Timestamptz(time.Now())
+	return listing
+}
+
+func Test_validateCreateListingReq(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name        string
+		req         *models.CreateListingRequest
+		expectedErr string
+	}{
+		{name: "valid listing", req: &models.CreateListingRequest{SellerID: "seller-1", Stake: 10, AskingPrice: 12.5}},
+		{name: "no seller", req: &models.CreateListingRequest{SellerID: " ", Stake: 10, AskingPrice: 12.5}, expectedErr: "the seller_id must be given, at most 64 characters long"},
+		{name: "no stake", req: &models.CreateListingRequest{SellerID: "seller-1", AskingPrice: 12.5}, expectedErr: "the stake must be a positive decimal value to two decimal places"},
+		{name: "asking_price to three decimal places", req: &models.CreateListingRequest{SellerID: "seller-1", Stake: 10, AskingPrice: 12.125}, expectedErr: "the asking_price must be a positive decimal value to two decimal places"},
+	}
+	for _, tt := range tests {
+		err := validateCreateListingReq(tt.req)
+		if tt.expectedErr == "" {
+			assert.NoError(t, err, tt.name)
+			continue
+		}
+		assert.EqualError(t, err, tt.expectedErr, tt.name)
+	}
+}
+
+func Test_CreateListing(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	t.Run("the stake can't exceed what is held and not listed yet", func(t *testing.T) {
+		db := &mock_database.Ext{}
+		tx := &mock_database.Tx{}
+		wagerRepo := &mock_repositories.MockWagerRepo{}
+		stakeHoldingRepo := &mock_repositories.MockStakeHoldingRepo{}
+		resaleListingRepo := &mock_repositories.MockResaleListingRepo{}
+		db.On("Begin", ctx).Return(tx, nil)
+		tx.On("Rollback", mock.Anything).Return(nil)
+		stakeHoldingRepo.On("Get", ctx, tx, database.Int4(101), database.Text("seller-1")).Once().Return(&entities.StakeHolding{
+			HoldingID:  database.Int4(7),
+			PurchaseID: database.Int4(101),
+			WagerID:    database.Int4(1),
+			HolderID:   database.Text("seller-1"),
+			Stake:      database.Float4(20),
+		}, nil)
+		wagerRepo.On("Get", ctx, tx, database.Int4(1)).Once().Return(repriceWager(20), nil)
+		resaleListingRepo.On("SumOpenStake", ctx, tx, database.Int4(101), database.Text("seller-1")).Once().Return(database.Float4(15), nil)
+
+		s := &WagerService{DB: db, WagerRepo: wagerRepo, StakeHoldingRepo: stakeHoldingRepo, ResaleListingRepo: resaleListingRepo}
+		_, err := s.CreateListing(ctx, 101, &models.CreateListingRequest{SellerID: "seller-1", Stake: 10, AskingPrice: 12.5})
+		assert.EqualError(t, err, "unable to create listing: the stake must be at most the 5.00 held and not listed yet")
+		assert.Equal(t, http.StatusBadRequest, statusFromError(err))
+		resaleListingRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func Test_CancelListing(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	t.Run("only the seller of the listing can cancel it", func(t *testing.T) {
+		db := &mock_database.Ext{}
+		tx := &mock_database.Tx{}
+		resaleListingRepo := &mock_repositories.MockResaleListingRepo{}
+		// without a seller_id the actor of the request is the seller
+		ctx := withAuditInfo(ctx, auditInfo{actor: "seller-2"})
+		db.On("Begin", ctx).Return(tx, nil)
+		tx.On("Rollback", mock.Anything).Return(nil)
+		resaleListingRepo.On("Get", ctx, tx, database.Int4(5)).Once().Return(resaleListing(entities.ResaleListingStatusOpen), nil)
+
+		s := &WagerService{DB: db, ResaleListingRepo: resaleListingRepo}
+		_, err := s.CancelListing(ctx, 5, "")
+		assert.EqualError(t, err, "unable to cancel listing: only the seller of the listing can cancel it")
+		assert.Equal(t, http.StatusForbidden, statusFromError(err))
+		resaleListingRepo.AssertNotCalled(t, "Cancel", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+		_, err = s.CancelListing(context.Background(), 5, " ")
+		assert.EqualError(t, err, "the seller_id must be given")
+		assert.Equal(t, http.StatusBadRequest, statusFromError(err))
+		resaleListingRepo.AssertExpectations(t)
+	})
+}
+
+func Test_BuyListing(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	t.Run("the stake moves from the seller to the buyer", func(t *testing.T) {
+		db := &mock_database.Ext{}
+		tx := &mock_database.Tx{}
+		wagerRepo := &mock_repositories.MockWagerRepo{}
+		stakeHoldingRepo := &mock_repositories.MockStakeHoldingRepo{}
+		resaleListingRepo := &mock_repositories.MockResaleListingRepo{}
+		stakeTransferRepo := &mock_repositories.MockStakeTransferRepo{}
+		purchaseFeeRepo := &mock_repositories.MockPurchaseFeeRepo{}
+		restrictionRepo := &mock_repositories.MockAccountRestrictionRepo{}
+		spendLimitRepo := &mock_repositories.MockSpendLimitRepo{}
+		purchaseRepo := &mock_repositories.MockPurchaseRepo{}
+		auditLogRepo := &mock_repositories.MockAuditLogRepo{}
+		accountRepo := &mock_repositories.MockAccountRepo{}
+		exchangeRateRepo := &mock_repositories.MockExchangeRateRepo{}
+		db.On("Begin", ctx).Return(tx, nil)
+		tx.On("Commit", mock.Anything).Return(nil)
+		resaleListingRepo.On("Get", ctx, tx, database.Int4(5)).Once().Return(resaleListing(entities.ResaleListingStatusOpen), nil)
+		wagerRepo.On("Get", ctx, tx, database.Int4(1)).Once().Return(repriceWager(20), nil)
+		// the buyer pays in the currency of their account
+		accountRepo.On("Get", ctx, tx, database.Text("buyer-2")).Once().Return(&entities.Account{Currency: database.Text("EUR")}, nil)
+		exchangeRateRepo.On("GetEffective", ctx, tx, database.Text("USD"), database.Text("EUR"), mock.Anything).Once().Return(&entities.ExchangeRate{Rate: database.Float8(0.8)}, nil)
+		// the converted asking price and its fee count against the spend limit of the buyer
+		restrictionRepo.On("ListActive", ctx, tx, []string{"buyer-2"}, mock.Anything).Once().Return([]*entities.AccountRestriction{}, nil)
+		spendLimitRepo.On("LockAccount", ctx, tx, database.Text("buyer-2")).Once().Return(nil)
+		spendLimitRepo.On("ListCurrent", ctx, tx, database.Text("buyer-2"), mock.Anything).Once().Return([]*entities.SpendLimit{
+			{Period: database.Text(entities.SpendLimitPeriodDay), Amount: database.Float4(20), EffectiveFrom: database.Timestamptz(time.Now().Add(-time.Hour))},
+		}, nil)
+		purchaseRepo.On("SumSpend", ctx, tx, database.Text("buyer-2"), mock.Anything).Once().Return([]*repositories.CurrencySpend{
+			{Currency: database.Text("EUR"), Amount: database.Float4(7.25)},
+		}, nil)
+		stakeHoldingRepo.On("Get", ctx, tx, database.Int4(101), database.Text("seller-1")).Once().Return(&entities.StakeHolding{
+			HoldingID: database.Int4(7),
+			Stake:     database.Float4(20),
+		}, nil)
+		stakeHoldingRepo.On("Reduce", ctx, tx, database.Int4(7), database.Float4(10), mock.Anything).Once().Return(pgconn.CommandTag("UPDATE 1"), nil)
+		stakeHoldingRepo.On("Add", ctx, tx, mock.MatchedBy(func(holding *entities.StakeHolding) bool {
+			return holding.HolderID.String == "buyer-2" && holding.PurchaseID.Int == 101 && holding.Stake.Float == 10
+		})).Once().Return(nil)
+		resaleListingRepo.On("Sell", ctx, tx, database.Int4(5), database.Text("buyer-2"), mock.Anything).Once().Return(pgconn.CommandTag("UPDATE 1"), nil)
+		stakeTransferRepo.On("Create", ctx, tx, mock.MatchedBy(func(transfer *entities.StakeTransfer) bool {
+			return transfer.PaidCurrency.String == "EUR" && transfer.PaidAmount.Float == 10 && transfer.ExchangeRate.Float == 0.8
+		})).Once().Run(func(args mock.Arguments) {
+			args.Get(2).(*entities.StakeTransfer).TransferID = database.Int4(8)
+		}).Return(nil)
+		purchaseFeeRepo.On("Create", ctx, tx, mock.MatchedBy(func(fee *entities.PurchaseFee) bool {
+			return fee.PurchaseID.Int == 101 && fee.TransferID.Int == 8 && fee.FeeType.String == entities.PurchaseFeePercentage && fee.Amount.Float == 0.25
+		})).Once().Return(nil)
+		auditLogRepo.On("Create", ctx, tx, mock.MatchedBy(func(auditLog *entities.AuditLog) bool {
+			return auditLog.Action.String == entities.AuditActionStakeResale && auditLog.PurchaseID.Int == 101
+		})).Once().Return(nil)
+
+		s := &WagerService{
+			DB:                     db,
+			WagerRepo:              wagerRepo,
+			StakeHoldingRepo:       stakeHoldingRepo,
+			ResaleListingRepo:      resaleListingRepo,
+			StakeTransferRepo:      stakeTransferRepo,
+			PurchaseFeeRepo:        purchaseFeeRepo,
+			PurchaseRepo:           purchaseRepo,
+			AccountRestrictionRepo: restrictionRepo,
+			SpendLimitRepo:         spendLimitRepo,
+			AuditLogRepo:           auditLogRepo,
+			AccountRepo:            accountRepo,
+			ExchangeRateRepo:       exchangeRateRepo,
+			Fees:                   &FeeModel{Default: FeeSchedule{Percentage: 2}},
+		}
+		transfer, err := s.BuyListing(ctx, 5, &models.BuyListingRequest{BuyerID: " buyer-2 "})
+		assert.NoError(t, err)
+		assert.Equal(t, "seller-1", transfer.FromHolderID)
+		assert.Equal(t, "buyer-2", transfer.ToHolderID)
+		assert.Equal(t, float32(10), transfer.Stake)
+		assert.Equal(t, float32(12.5), transfer.Price)
+		assert.Equal(t, float32(0.25), transfer.TotalFee)
+		assert.Equal(t, "EUR", transfer.PaidCurrency)
+		assert.Equal(t, float32(10), transfer.PaidAmount)
+		stakeHoldingRepo.AssertExpectations(t)
+		resaleListingRepo.AssertExpectations(t)
+		purchaseFeeRepo.AssertExpectations(t)
+		spendLimitRepo.AssertExpectations(t)
+		auditLogRepo.AssertExpectations(t)
+	})
+	t.Run("a listing which is sold already can't be bought", func(t *testing.T) {
+		db := &mock_database.Ext{}
+		tx := &mock_database.Tx{}
+		resaleListingRepo := &mock_repositories.MockResaleListingRepo{}
+		db.On("Begin", ctx).Return(tx, nil)
+		tx.On("Rollback", mock.Anything).Return(nil)
+		resaleListingRepo.On("Get", ctx, tx, database.Int4(5)).Once().Return(resaleListing(entities.ResaleListingStatusSold), nil)
+
+		s := &WagerService{DB: db, ResaleListingRepo: resaleListingRepo}
+		_, err := s.BuyListing(ctx, 5, &models.BuyListingRequest{BuyerID: "buyer-2"})
+		assert.EqualError(t, err, "unable to buy listing: the listing is not open anymore")
+		assert.Equal(t, http.StatusConflict, statusFromError(err))
+	})
+	t.Run("a restricted buyer can't buy a listing", func(t *testing.T) {
+		db := &mock_database.Ext{}
+		tx := &mock_database.Tx{}
+		wagerRepo := &mock_repositories.MockWagerRepo{}
+		resaleListingRepo := &mock_repositories.MockResaleListingRepo{}
+		restrictionRepo := &mock_repositories.MockAccountRestrictionRepo{}
+		accountRepo := &mock_repositories.MockAccountRepo{}
+		db.On("Begin", ctx).Return(tx, nil)
+		tx.On("Rollback", mock.Anything).Return(nil)
+		resaleListingRepo.On("Get", ctx, tx, database.Int4(5)).Once().Return(resaleListing(entities.ResaleListingStatusOpen), nil)
+		wagerRepo.On("Get", ctx, tx, database.Int4(1)).Once().Return(repriceWager(20), nil)
+		accountRepo.On("Get", ctx, tx, database.Text("buyer-2")).Once().Return(nil, pgx.ErrNoRows)
+		restrictionRepo.On("ListActive", ctx, tx, []string{"buyer-2"}, mock.Anything).Once().Return([]*entities.AccountRestriction{
+			{AccountID: database.Text("buyer-2"), Kind: database.Text(entities.AccountRestrictionSelfExclusion), EndsAt: pgtype.Timestamptz{Status: pgtype.Null}},
+		}, nil)
+
+		s := &WagerService{DB: db, WagerRepo: wagerRepo, ResaleListingRepo: resaleListingRepo, AccountRestrictionRepo: restrictionRepo, AccountRepo: accountRepo}
+		_, err := s.BuyListing(ctx, 5, &models.BuyListingRequest{BuyerID: "buyer-2"})
+		assert.EqualError(t, err, "unable to buy listing: the account is restricted: self_exclusion of buyer-2 for ever")
+		assert.Equal(t, http.StatusForbidden, statusFromError(err))
+	})
+	t.Run("a listing can't be bought across currencies without a rate", func(t *testing.T) {
+		db := &mock_database.Ext{}
+		tx := &mock_database.Tx{}
+		wagerRepo := &mock_repositories.MockWagerRepo{}
+		resaleListingRepo := &mock_repositories.MockResaleListingRepo{}
+		accountRepo := &mock_repositories.MockAccountRepo{}
+		exchangeRateRepo := &mock_repositories.MockExchangeRateRepo{}
+		stakeTransferRepo := &mock_repositories.MockStakeTransferRepo{}
+		db.On("Begin", ctx).Return(tx, nil)
+		tx.On("Rollback", mock.Anything).Return(nil)
+		resaleListingRepo.On("Get", ctx, tx, database.Int4(5)).Once().Return(resaleListing(entities.ResaleListingStatusOpen), nil)
+		wagerRepo.On("Get", ctx, tx, database.Int4(1)).Once().Return(repriceWager(20), nil)
+		accountRepo.On("Get", ctx, tx, database.Text("buyer-2")).Once().Return(&entities.Account{Currency: database.Text("GBP")}, nil)
+		exchangeRateRepo.On("GetEffective", ctx, tx, mock.Anything, mock.Anything, mock.Anything).Twice().Return(nil, pgx.ErrNoRows)
+
+		s := &WagerService{DB: db, WagerRepo: wagerRepo, ResaleListingRepo: resaleListingRepo, StakeTransferRepo: stakeTransferRepo, AccountRepo: accountRepo, ExchangeRateRepo: exchangeRateRepo}
+		_, err := s.BuyListing(ctx, 5, &models.BuyListingRequest{BuyerID: "buyer-2"})
+		assert.EqualError(t, err, "unable to buy listing: no exchange rate is in effect from USD to GBP")
+		assert.Equal(t, http.StatusConflict, statusFromError(err))
+		stakeTransferRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+	})
+	t.Run("a purchase of a listing from a linked account is blocked", func(t *testing.T) {
+		db := &mock_database.Ext{}
+		tx := &mock_database.Tx{}
+		wagerRepo := &mock_repositories.MockWagerRepo{}
+		resaleListingRepo := &mock_repositories.MockResaleListingRepo{}
+		restrictionRepo := &mock_repositories.MockAccountRestrictionRepo{}
+		spendLimitRepo := &mock_repositories.MockSpendLimitRepo{}
+		surveillanceRepo := &mock_repositories.MockSurveillanceRepo{}
+		tradeAlertRepo := &mock_repositories.MockTradeAlertRepo{}
+		accountRepo := &mock_repositories.MockAccountRepo{}
+		db.On("Begin", ctx).Return(tx, nil)
+		tx.On("Rollback", mock.Anything).Return(nil)
+		resaleListingRepo.On("Get", ctx, tx, database.Int4(5)).Once().Return(resaleListing(entities.ResaleListingStatusOpen), nil)
+		wagerRepo.On("Get", ctx, tx, database.Int4(1)).Once().Return(repriceWager(20), nil)
+		accountRepo.On("Get", ctx, tx, database.Text("buyer-2")).Once().Return(nil, pgx.ErrNoRows)
+		restrictionRepo.On("ListActive", ctx, tx, []string{"buyer-2"}, mock.Anything).Once().Return([]*entities.AccountRestriction{}, nil)
+		spendLimitRepo.On("LockAccount", ctx, tx, database.Text("buyer-2")).Once().Return(nil)
+		spendLimitRepo.On("ListCurrent", ctx, tx, database.Text("buyer-2"), mock.Anything).Once().Return([]*entities.SpendLimit{}, nil)
+		surveillanceRepo.On("AreLinked", ctx, tx, database.Text("buyer-2"), database.Text("seller-1")).Once().Return(true, nil)
+		// the alert is recorded once the transaction is rolled back
+		tradeAlertRepo.On("Create", ctx, db, mock.MatchedBy(func(alert *entities.TradeAlert) bool {
+			return alert.ListingID.Int == 5 && alert.SellerID.String == "seller-1" && alert.Blocked.Bool
+		})).Once().Return(nil)
+
+		s := &WagerService{
+			DB:                     db,
+			WagerRepo:              wagerRepo,
+			ResaleListingRepo:      resaleListingRepo,
+			AccountRestrictionRepo: restrictionRepo,
+			SpendLimitRepo:         spendLimitRepo,
+			Surveillance:           &SurveillanceRules{SelfTrade: SurveillanceRule{Enabled: true, Block: true}},
+			SurveillanceRepo:       surveillanceRepo,
+			TradeAlertRepo:         tradeAlertRepo,
+			AccountRepo:            accountRepo,
+		}
+		_, err := s.BuyListing(ctx, 5, &models.BuyListingRequest{BuyerID: "buyer-2"})
+		assert.EqualError(t, err, "unable to buy listing: the trade is blocked by the surveillance rules: flagged as self_trade")
+		assert.Equal(t, http.StatusForbidden, statusFromError(err))
+		tradeAlertRepo.AssertExpectations(t)
+	})
+	t.Run("a listing above the max purchase can't be bought", func(t *testing.T) {
+		db := &mock_database.Ext{}
+		tx := &mock_database.Tx{}
+		wagerRepo := &mock_repositories.MockWagerRepo{}
+		resaleListingRepo := &mock_repositories.MockResaleListingRepo{}
+		db.On("Begin", ctx).Return(tx, nil)
+		tx.On("Rollback", mock.Anything).Return(nil)
+		resaleListingRepo.On("Get", ctx, tx, database.Int4(5)).Once().Return(resaleListing(entities.ResaleListingStatusOpen), nil)
+		wagerRepo.On("Get", ctx, tx, database.Int4(1)).Once().Return(repriceWager(20), nil)
+
+		s := &WagerService{DB: db, WagerRepo: wagerRepo, ResaleListingRepo: resaleListingRepo, Risk: &RiskLimits{MaxPurchase: 10}}
+		_, err := s.BuyListing(ctx, 5, &models.BuyListingRequest{BuyerID: "buyer-2"})
+		assert.EqualError(t, err, "unable to buy listing: risk limit exceeded: the buying_price must be at most 10.00")
+		assert.Equal(t, http.StatusConflict, statusFromError(err))
+	})
+}
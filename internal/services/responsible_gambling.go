@@ -0,0 +1,408 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/internal/models"
+	"github.com/wager-api/libs/database"
+
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+	"go.uber.org/multierr"
+)
+
+const (
+	// defaultSpendLimitIncreaseDelay is how long an account waits for a looser spend
+	// limit to take effect unless configured otherwise
+	defaultSpendLimitIncreaseDelay = 24 * time.Hour
+
+	minCoolOffDuration       = 24 * time.Hour
+	maxCoolOffDuration       = 42 * 24 * time.Hour
+	minSelfExclusionDuration = 4380 * time.Hour
+)
+
+// spendLimitPeriods are the periods a spend limit can be set on, in the order they are
+// listed, along the rolling window each one sums the spend over.
+var spendLimitPeriods = []struct {
+	period string
+	window time.Duration
+}{
+	{entities.SpendLimitPeriodDay, 24 * time.Hour},
+	{entities.SpendLimitPeriodWeek, 7 * 24 * time.Hour},
+	{entities.SpendLimitPeriodMonth, 30 * 24 * time.Hour},
+}
+
+func spendLimitWindow(period string) (time.Duration, bool) {
+	for _, p := range spendLimitPeriods {
+		if p.period == period {
+			return p.window, true
+		}
+	}
+	return 0, false
+}
+
+func (s *WagerService) spendLimitIncreaseDelay() time.Duration {
+	if s.SpendLimitIncreaseDelay <= 0 {
+		return defaultSpendLimitIncreaseDelay
+	}
+	return s.SpendLimitIncreaseDelay
+}
+
+func restrictionError(restriction *entities.AccountRestriction) error {
+	if restriction.EndsAt.Status != pgtype.Present {
+		return fmt.Errorf("%w: %s of %s for ever", errAccountRestricted, restriction.Kind.String, restriction.AccountID.String)
+	}
+	return fmt.Errorf("%w: %s of %s until %s", errAccountRestricted, restriction.Kind.String, restriction.AccountID.String,
+		restriction.EndsAt.Time.UTC().Format(time.RFC3339))
+}
+
+// checkSellerRestrictions rejects the wagers about to be placed in tx whose seller is in
+// a cool-off period or excluded, the reasons are returned at the index of the wagers.
+func (s *WagerService) checkSellerRestrictions(ctx context.Context, tx database.Ext, wagers []*entities.Wager, now time.Time) ([]error, error) {
+	rejections := make([]error, len(wagers))
+	sellerIDs := make([]string, 0, len(wagers))
+	for _, wager := range wagers {
+		if wager.SellerID.Status == pgtype.Present {
+			sellerIDs = append(sellerIDs, wager.SellerID.String)
+		}
+	}
+	if len(sellerIDs) == 0 {
+		return rejections, nil
+	}
+	restrictions, err := s.AccountRestrictionRepo.ListActive(ctx, tx, sellerIDs, database.Timestamptz(now))
+	if err != nil {
+		return nil, fmt.Errorf("unable to list account restrictions")
+	}
+	restricted := make(map[string]*entities.AccountRestriction, len(restrictions))
+	for _, restriction := range restrictions {
+		// the longest restriction comes first
+		if _, ok := restricted[restriction.AccountID.String]; !ok {
+			restricted[restriction.AccountID.String] = restriction
+		}
+	}
+	for i, wager := range wagers {
+		if restriction, ok := restricted[wager.SellerID.String]; ok && wager.SellerID.Status == pgtype.Present {
+			rejections[i] = restrictionError(restriction)
+		}
+	}
+	return rejections, nil
+}
+
+// checkBuyerLimits refuses a purchase of buyerID spending spend, in the currency they
+// pay in, when they are restricted or it would take them over one of their spend limits
+// in force, what they spent before is converted into that currency. The account is locked until the end of tx so its concurrent purchases are
+// checked one after another. A purchase without a buyer, only made when RequireBuyer is
+// off, isn't limited.
+func (s *WagerService) checkBuyerLimits(ctx context.Context, tx database.Ext, buyerID pgtype.Text, currency string, spend float32, now time.Time) error {
+	if buyerID.Status != pgtype.Present {
+		return nil
+	}
+	restrictions, err := s.AccountRestrictionRepo.ListActive(ctx, tx, []string{buyerID.String}, database.Timestamptz(now))
+	if err != nil {
+		return fmt.Errorf("unable to list account restrictions")
+	}
+	if len(restrictions) > 0 {
+		return restrictionError(restrictions[0])
+	}
+	if err := s.SpendLimitRepo.LockAccount(ctx, tx, buyerID); err != nil {
+		return fmt.Errorf("unable to lock account")
+	}
+	limits, err := s.SpendLimitRepo.ListCurrent(ctx, tx, buyerID, database.Timestamptz(now))
+	if err != nil {
+		return fmt.Errorf("unable to list spend limits")
+	}
+	for _, limit := range limits {
+		if limit.EffectiveFrom.Time.After(now) || limit.Amount.Status != pgtype.Present {
+			continue
+		}
+		window, _ := spendLimitWindow(limit.Period.String)
+		spent, err := s.sumSpend(ctx, tx, buyerID, currency, database.Timestamptz(now.Add(-window)), now)
+		if err != nil {
+			return err
+		}
+		if spent+spend > limit.Amount.Float {
+			return fmt.Errorf("%w: the %s limit of %.2f would be exceeded, %.2f spent already",
+				errSpendLimitReached, limit.Period.String, limit.Amount.Float, spent)
+		}
+	}
+	return nil
+}
+
+// sumSpend is what buyerID spent since since converted into currency at the rates in
+// effect at now, a currency without a rate to it is refused.
+func (s *WagerService) sumSpend(ctx context.Context, db database.Ext, buyerID pgtype.Text, currency string, since pgtype.Timestamptz, now time.Time) (float32, error) {
+	spends, err := s.PurchaseRepo.SumSpend(ctx, db, buyerID, since)
+	if err != nil {
+		return 0, fmt.Errorf("unable to sum the spend of the account")
+	}
+	var spent float32
+	for _, spend := range spends {
+		if spend.Currency.String == currency {
+			spent += spend.Amount.Float
+			continue
+		}
+		rate, err := exchangeRate(ctx, db, s.ExchangeRateRepo, spend.Currency.String, currency, now)
+		if err != nil {
+			return 0, err
+		}
+		spent += float32(float64(spend.Amount.Float) * rate)
+	}
+	return spent, nil
+}
+
+// purchaseSpend is what the buyer of purchase spends on it in the currency they pay in,
+// the fees included.
+func purchaseSpend(purchase *entities.Purchase, fees []*entities.PurchaseFee) float32 {
+	return paidSpend(purchase.PaidAmount.Float, purchase.ExchangeRate, fees)
+}
+
+// paidSpend is paidAmount along the fees, charged in the currency of the wager, converted
+// at rate which is null when the buyer pays in that currency.
+func paidSpend(paidAmount float32, rate pgtype.Float8, fees []*entities.PurchaseFee) float32 {
+	conversion := 1.0
+	if rate.Status == pgtype.Present {
+		conversion = rate.Float
+	}
+	spend := paidAmount
+	for _, fee := range fees {
+		spend += float32(float64(fee.Amount.Float) * conversion)
+	}
+	return spend
+}
+
+// splitSpendLimits returns the limit in force and the pending one of each period.
+func splitSpendLimits(limits []*entities.SpendLimit, now time.Time) (current, pending map[string]*entities.SpendLimit) {
+	current, pending = map[string]*entities.SpendLimit{}, map[string]*entities.SpendLimit{}
+	for _, limit := range limits {
+		if limit.EffectiveFrom.Time.After(now) {
+			pending[limit.Period.String] = limit
+			continue
+		}
+		current[limit.Period.String] = limit
+	}
+	return current, pending
+}
+
+func newAccountAuditLog(ctx context.Context, action string, accountID pgtype.Text, before, after pgtype.JSONB, now time.Time) *entities.AuditLog {
+	auditLog := newAuditLog(ctx, action, pgtype.Int4{Status: pgtype.Null}, pgtype.Int4{Status: pgtype.Null}, before, after, now)
+	auditLog.AccountID = accountID
+	return auditLog
+}
+
+// SetSpendLimit sets the limit on what the account spends over period, a nil amount
+// removes it. A tighter limit takes effect at once while an increase or a removal only
+// takes effect after the delay, replacing the change pending before it either way.
+func (s *WagerService) SetSpendLimit(ctx context.Context, accountID, period string, setSpendLimitRequest *models.SetSpendLimitRequest) (*models.AccountLimits, error) {
+	accountID, err := validateAccountID(accountID)
+	if err != nil {
+		return nil, &validationError{err}
+	}
+	if _, ok := spendLimitWindow(period); !ok {
+		return nil, &validationError{fmt.Errorf("the period must be one of %s, %s, %s",
+			entities.SpendLimitPeriodDay, entities.SpendLimitPeriodWeek, entities.SpendLimitPeriodMonth)}
+	}
+	amount := setSpendLimitRequest.Amount
+	if amount != nil && (*amount <= 0 || roundFloat(*amount) != *amount) {
+		return nil, &validationError{fmt.Errorf("the amount must be a positive decimal value to two decimal places")}
+	}
+	now := time.Now()
+	if err := database.ExecInTx(ctx, s.DB, func(ctx context.Context, tx pgx.Tx) error {
+		if err := s.SpendLimitRepo.LockAccount(ctx, tx, database.Text(accountID)); err != nil {
+			return fmt.Errorf("unable to lock account")
+		}
+		limits, err := s.SpendLimitRepo.ListCurrent(ctx, tx, database.Text(accountID), database.Timestamptz(now))
+		if err != nil {
+			return fmt.Errorf("unable to list spend limits")
+		}
+		current, _ := splitSpendLimits(limits, now)
+		before := pgtype.JSONB{Status: pgtype.Null}
+		currentAmount := pgtype.Float4{Status: pgtype.Null}
+		if limit, ok := current[period]; ok {
+			if before, err = snapshotEntity(limit); err != nil {
+				return fmt.Errorf("unable to snapshot spend limit record")
+			}
+			currentAmount = limit.Amount
+		}
+		effectiveFrom := now
+		loosened := currentAmount.Status == pgtype.Present && (amount == nil || *amount > currentAmount.Float)
+		if loosened {
+			effectiveFrom = now.Add(s.spendLimitIncreaseDelay())
+		}
+		limit := &entities.SpendLimit{}
+		database.AllNullEntity(limit)
+		if err := multierr.Combine(
+			limit.AccountID.Set(accountID),
+			limit.Period.Set(period),
+			limit.EffectiveFrom.Set(effectiveFrom),
+			limit.CreatedAt.Set(now)); err != nil {
+			return fmt.Errorf("unable to generate spend limit record")
+		}
+		if amount != nil {
+			_ = limit.Amount.Set(*amount)
+		}
+		if _, err := s.SpendLimitRepo.DeletePending(ctx, tx, limit.AccountID, limit.Period, database.Timestamptz(now)); err != nil {
+			return fmt.Errorf("unable to delete pending spend limits")
+		}
+		if err := s.SpendLimitRepo.Create(ctx, tx, limit); err != nil {
+			return fmt.Errorf("unable to create spend limit record")
+		}
+		after, err := snapshotEntity(limit)
+		if err != nil {
+			return fmt.Errorf("unable to snapshot spend limit record")
+		}
+		if err := s.AuditLogRepo.Create(ctx, tx, newAccountAuditLog(ctx, entities.AuditActionAccountSpendLimit, limit.AccountID, before, after, now)); err != nil {
+			return fmt.Errorf("unable to create audit log")
+		}
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("unable to set spend limit: %w", err)
+	}
+	return s.GetLimits(ctx, accountID)
+}
+
+// restrictAccount starts a restriction of kind on the account from now until endsAt, for
+// ever when endsAt is nil. A restriction can't be lifted once started.
+func (s *WagerService) restrictAccount(ctx context.Context, accountID, kind, action string, endsAt *time.Time) (*models.AccountRestriction, error) {
+	now := time.Now()
+	restriction := &entities.AccountRestriction{}
+	database.AllNullEntity(restriction)
+	if err := multierr.Combine(
+		restriction.AccountID.Set(accountID),
+		restriction.Kind.Set(kind),
+		restriction.StartsAt.Set(now),
+		restriction.CreatedAt.Set(now)); err != nil {
+		return nil, &validationError{fmt.Errorf("unable to generate value for account restriction")}
+	}
+	if endsAt != nil {
+		_ = restriction.EndsAt.Set(*endsAt)
+	}
+	if err := database.ExecInTx(ctx, s.DB, func(ctx context.Context, tx pgx.Tx) error {
+		if err := s.AccountRestrictionRepo.Create(ctx, tx, restriction); err != nil {
+			return fmt.Errorf("unable to create account restriction record")
+		}
+		after, err := snapshotEntity(restriction)
+		if err != nil {
+			return fmt.Errorf("unable to snapshot account restriction record")
+		}
+		if err := s.AuditLogRepo.Create(ctx, tx, newAccountAuditLog(ctx, action, restriction.AccountID, pgtype.JSONB{Status: pgtype.Null}, after, now)); err != nil {
+			return fmt.Errorf("unable to create audit log")
+		}
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("unable to restrict account: %w", err)
+	}
+	return convertAccountRestrictionPg2Domain(restriction), nil
+}
+
+// StartCoolOff keeps the account from buying and placing wagers for the duration of the
+// request, between a day and six weeks.
+func (s *WagerService) StartCoolOff(ctx context.Context, accountID string, coolOffRequest *models.CoolOffRequest) (*models.AccountRestriction, error) {
+	accountID, err := validateAccountID(accountID)
+	if err != nil {
+		return nil, &validationError{err}
+	}
+	duration, err := time.ParseDuration(coolOffRequest.Duration)
+	if err != nil || duration < minCoolOffDuration || duration > maxCoolOffDuration {
+		return nil, &validationError{fmt.Errorf("the duration must be between %s and %s", minCoolOffDuration, maxCoolOffDuration)}
+	}
+	endsAt := time.Now().Add(duration)
+	return s.restrictAccount(ctx, accountID, entities.AccountRestrictionCoolOff, entities.AuditActionAccountCoolOff, &endsAt)
+}
+
+// SelfExclude keeps the account from buying and placing wagers for at least six months,
+// or for ever.
+func (s *WagerService) SelfExclude(ctx context.Context, accountID string, selfExclusionRequest *models.SelfExclusionRequest) (*models.AccountRestriction, error) {
+	accountID, err := validateAccountID(accountID)
+	if err != nil {
+		return nil, &validationError{err}
+	}
+	if selfExclusionRequest.Permanent {
+		if selfExclusionRequest.Duration != "" {
+			return nil, &validationError{fmt.Errorf("a permanent self-exclusion has no duration")}
+		}
+		return s.restrictAccount(ctx, accountID, entities.AccountRestrictionSelfExclusion, entities.AuditActionAccountSelfExclusion, nil)
+	}
+	duration, err := time.ParseDuration(selfExclusionRequest.Duration)
+	if err != nil || duration < minSelfExclusionDuration {
+		return nil, &validationError{fmt.Errorf("the duration must be at least %s unless the self-exclusion is permanent", minSelfExclusionDuration)}
+	}
+	endsAt := time.Now().Add(duration)
+	return s.restrictAccount(ctx, accountID, entities.AccountRestrictionSelfExclusion, entities.AuditActionAccountSelfExclusion, &endsAt)
+}
+
+// GetLimits returns the spend limits of the account, with what it spent over their
+// period and their pending changes, along its restrictions in force.
+func (s *WagerService) GetLimits(ctx context.Context, accountID string) (*models.AccountLimits, error) {
+	accountID, err := validateAccountID(accountID)
+	if err != nil {
+		return nil, &validationError{err}
+	}
+	now := time.Now()
+	limits, err := s.SpendLimitRepo.ListCurrent(ctx, s.DB, database.Text(accountID), database.Timestamptz(now))
+	if err != nil {
+		return nil, fmt.Errorf("unable to list spend limits")
+	}
+	restrictions, err := s.AccountRestrictionRepo.ListActive(ctx, s.DB, []string{accountID}, database.Timestamptz(now))
+	if err != nil {
+		return nil, fmt.Errorf("unable to list account restrictions")
+	}
+	// the spend is shown in the currency of the account, the base one when it has none
+	currency := baseCurrency(s.BaseCurrency)
+	account, err := s.AccountRepo.Get(ctx, s.DB, database.Text(accountID))
+	switch {
+	case err == nil:
+		currency = account.Currency.String
+	case err != pgx.ErrNoRows:
+		return nil, fmt.Errorf("unable to get account information")
+	}
+	current, pending := splitSpendLimits(limits, now)
+	accountLimits := &models.AccountLimits{
+		AccountID:    accountID,
+		Currency:     currency,
+		Limits:       make([]*models.SpendLimit, 0, len(spendLimitPeriods)),
+		Restrictions: make([]*models.AccountRestriction, 0, len(restrictions)),
+	}
+	for _, p := range spendLimitPeriods {
+		spent, err := s.sumSpend(ctx, s.DB, database.Text(accountID), currency, database.Timestamptz(now.Add(-p.window)), now)
+		if err != nil {
+			return nil, err
+		}
+		limit := &models.SpendLimit{Period: p.period, Spent: spent}
+		if c, ok := current[p.period]; ok {
+			limit.Amount = float4Ptr(c.Amount)
+			limit.EffectiveFrom = &c.EffectiveFrom.Time
+		}
+		if pg, ok := pending[p.period]; ok {
+			limit.Pending = &models.PendingSpendLimit{Amount: float4Ptr(pg.Amount), EffectiveFrom: pg.EffectiveFrom.Time}
+		}
+		accountLimits.Limits = append(accountLimits.Limits, limit)
+	}
+	for _, restriction := range restrictions {
+		accountLimits.Restrictions = append(accountLimits.Restrictions, convertAccountRestrictionPg2Domain(restriction))
+	}
+	return accountLimits, nil
+}
+
+func float4Ptr(value pgtype.Float4) *float32 {
+	if value.Status != pgtype.Present {
+		return nil
+	}
+	v := value.Float
+	return &v
+}
+
+func convertAccountRestrictionPg2Domain(restriction *entities.AccountRestriction) *models.AccountRestriction {
+	var endsAt *time.Time
+	if restriction.EndsAt.Status == pgtype.Present {
+		endsAt = &restriction.EndsAt.Time
+	}
+	return &models.AccountRestriction{
+		ID:       int(restriction.RestrictionID.Int),
+		Kind:     restriction.Kind.String,
+		StartsAt: restriction.StartsAt.Time,
+		EndsAt:   endsAt,
+	}
+}
@@ -0,0 +1,236 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/internal/models"
+	"github.com/wager-api/internal/repositories"
+	"github.com/wager-api/libs/database"
+	mock_database "github.com/wager-api/mocks/libs/database"
+	mock_repositories "github.com/wager-api/mocks/repositories"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+	"google.golang.org/grpc/codes"
+)
+
+func Test_checkBuyerLimits(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	now := time.Now()
+	buyerID := database.Text("buyer-1")
+
+	t.Run("an anonymous buyer has no limit", func(t *testing.T) {
+		s := &WagerService{}
+		assert.NoError(t, s.checkBuyerLimits(ctx, &mock_database.Tx{}, pgtype.Text{Status: pgtype.Null}, "USD", 100, now))
+	})
+	t.Run("a restricted buyer can't buy", func(t *testing.T) {
+		tx := &mock_database.Tx{}
+		restrictionRepo := &mock_repositories.MockAccountRestrictionRepo{}
+		spendLimitRepo := &mock_repositories.MockSpendLimitRepo{}
+		restrictionRepo.On("ListActive", ctx, tx, []string{"buyer-1"}, database.Timestamptz(now)).Once().Return([]*entities.AccountRestriction{
+			{AccountID: buyerID, Kind: database.Text(entities.AccountRestrictionSelfExclusion), EndsAt: pgtype.Timestamptz{Status: pgtype.Null}},
+		}, nil)
+		s := &WagerService{AccountRestrictionRepo: restrictionRepo, SpendLimitRepo: spendLimitRepo}
+		err := s.checkBuyerLimits(ctx, tx, buyerID, "USD", 10, now)
+		assert.EqualError(t, err, "the account is restricted: self_exclusion of buyer-1 for ever")
+		assert.Equal(t, http.StatusForbidden, statusFromError(err))
+		assert.Equal(t, codes.PermissionDenied, codeFromError(err))
+		spendLimitRepo.AssertNotCalled(t, "LockAccount", mock.Anything, mock.Anything, mock.Anything)
+	})
+	t.Run("the limits in force bound the spend over their period", func(t *testing.T) {
+		tx := &mock_database.Tx{}
+		restrictionRepo := &mock_repositories.MockAccountRestrictionRepo{}
+		spendLimitRepo := &mock_repositories.MockSpendLimitRepo{}
+		purchaseRepo := &mock_repositories.MockPurchaseRepo{}
+		exchangeRateRepo := &mock_repositories.MockExchangeRateRepo{}
+		restrictionRepo.On("ListActive", ctx, tx, []string{"buyer-1"}, database.Timestamptz(now)).Return([]*entities.AccountRestriction{}, nil)
+		spendLimitRepo.On("LockAccount", ctx, tx, buyerID).Return(nil)
+		spendLimitRepo.On("ListCurrent", ctx, tx, buyerID, database.Timestamptz(now)).Return([]*entities.SpendLimit{
+			{Period: database.Text(entities.SpendLimitPeriodDay), Amount: database.Float4(100), EffectiveFrom: database.Timestamptz(now.Add(-time.Hour))},
+			// the increase isn't in force yet
+			{Period: database.Text(entities.SpendLimitPeriodDay), Amount: database.Float4(500), EffectiveFrom: database.Timestamptz(now.Add(time.Hour))},
+			{Period: database.Text(entities.SpendLimitPeriodMonth), Amount: pgtype.Float4{Status: pgtype.Null}, EffectiveFrom: database.Timestamptz(now.Add(-time.Hour))},
+		}, nil)
+		// what was paid in another currency is converted into the one of the spend
+		purchaseRepo.On("SumSpend", ctx, tx, buyerID, database.Timestamptz(now.Add(-24*time.Hour))).Return([]*repositories.CurrencySpend{
+			{Currency: database.Text("EUR"), Amount: database.Float4(16)},
+			{Currency: database.Text("USD"), Amount: database.Float4(60)},
+		}, nil)
+		exchangeRateRepo.On("GetEffective", ctx, tx, database.Text("EUR"), database.Text("USD"), database.Timestamptz(now)).Return(&entities.ExchangeRate{Rate: database.Float8(1.25)}, nil)
+		s := &WagerService{AccountRestrictionRepo: restrictionRepo, SpendLimitRepo: spendLimitRepo, PurchaseRepo: purchaseRepo, ExchangeRateRepo: exchangeRateRepo}
+
+		assert.NoError(t, s.checkBuyerLimits(ctx, tx, buyerID, "USD", 20, now))
+		err := s.checkBuyerLimits(ctx, tx, buyerID, "USD", 20.5, now)
+		assert.EqualError(t, err, "spend limit reached: the day limit of 100.00 would be exceeded, 80.00 spent already")
+		assert.Equal(t, http.StatusForbidden, statusFromError(err))
+		purchaseRepo.AssertNumberOfCalls(t, "SumSpend", 2)
+	})
+}
+
+func Test_RequireBuyer(t *testing.T) {
+	t.Parallel()
+	s := &WagerService{RequireBuyer: true}
+	// the buyer is checked before the wager is read, on HTTP, gRPC and websocket alike,
+	// and on the buy orders which are filled on behalf of their buyer
+	_, err := s.Buy(context.Background(), &models.BuyWagerCommand{WagerID: 1, BuyingPrice: 10, BuyerID: " "})
+	assert.EqualError(t, err, "the buyer_id must be given")
+	assert.Equal(t, http.StatusBadRequest, statusFromError(err))
+	assert.Equal(t, codes.InvalidArgument, codeFromError(err))
+
+	expiresAt := time.Now().Add(time.Hour)
+	_, err = s.PlaceBuyOrder(context.Background(), 1, &models.PlaceBuyOrderRequest{LimitPrice: 10, ExpiresAt: &expiresAt})
+	assert.EqualError(t, err, "the buyer_id must be given")
+	assert.Equal(t, http.StatusBadRequest, statusFromError(err))
+}
+
+func Test_purchaseSpend(t *testing.T) {
+	t.Parallel()
+	purchase := &entities.Purchase{PaidAmount: database.Float4(32), ExchangeRate: database.Float8(0.8)}
+	fees := []*entities.PurchaseFee{{Amount: database.Float4(0.8)}, {Amount: database.Float4(0.2)}}
+	assert.InDelta(t, 32.8, purchaseSpend(purchase, fees), 0.001)
+	purchase = &entities.Purchase{PaidAmount: database.Float4(40), ExchangeRate: pgtype.Float8{Status: pgtype.Null}}
+	assert.InDelta(t, 41, purchaseSpend(purchase, fees), 0.001)
+}
+
+func Test_SetSpendLimit(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	amount := func(v float32) *float32 { return &v }
+
+	for _, tc := range []struct {
+		name      string
+		current   []*entities.SpendLimit
+		amount    *float32
+		delayed   bool
+		hasBefore bool
+	}{
+		{name: "a first limit takes effect at once", amount: amount(50)},
+		{
+			name:      "a tighter limit takes effect at once",
+			current:   []*entities.SpendLimit{{Period: database.Text("week"), Amount: database.Float4(100), EffectiveFrom: database.Timestamptz(time.Now().Add(-time.Hour))}},
+			amount:    amount(50),
+			hasBefore: true,
+		},
+		{
+			name:      "an increase waits for the delay",
+			current:   []*entities.SpendLimit{{Period: database.Text("week"), Amount: database.Float4(100), EffectiveFrom: database.Timestamptz(time.Now().Add(-time.Hour))}},
+			amount:    amount(150),
+			delayed:   true,
+			hasBefore: true,
+		},
+		{
+			name:      "a removal waits for the delay",
+			current:   []*entities.SpendLimit{{Period: database.Text("week"), Amount: database.Float4(100), EffectiveFrom: database.Timestamptz(time.Now().Add(-time.Hour))}},
+			delayed:   true,
+			hasBefore: true,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			db := &mock_database.Ext{}
+			tx := &mock_database.Tx{}
+			spendLimitRepo := &mock_repositories.MockSpendLimitRepo{}
+			auditLogRepo := &mock_repositories.MockAuditLogRepo{}
+			restrictionRepo := &mock_repositories.MockAccountRestrictionRepo{}
+			purchaseRepo := &mock_repositories.MockPurchaseRepo{}
+			accountRepo := &mock_repositories.MockAccountRepo{}
+			db.On("Begin", ctx).Return(tx, nil)
+			tx.On("Commit", ctx).Return(nil)
+			spendLimitRepo.On("LockAccount", ctx, tx, database.Text("buyer-1")).Once().Return(nil)
+			spendLimitRepo.On("ListCurrent", ctx, tx, database.Text("buyer-1"), mock.Anything).Once().Return(tc.current, nil)
+			spendLimitRepo.On("DeletePending", ctx, tx, database.Text("buyer-1"), database.Text("week"), mock.Anything).Once().Return(pgconn.CommandTag("DELETE 0"), nil)
+			var created *entities.SpendLimit
+			spendLimitRepo.On("Create", ctx, tx, mock.Anything).Once().Run(func(args mock.Arguments) {
+				created = args.Get(2).(*entities.SpendLimit)
+			}).Return(nil)
+			auditLogRepo.On("Create", ctx, tx, mock.MatchedBy(func(auditLog *entities.AuditLog) bool {
+				return auditLog.Action.String == entities.AuditActionAccountSpendLimit && auditLog.AccountID.String == "buyer-1" &&
+					(auditLog.Before.Status == pgtype.Present) == tc.hasBefore
+			})).Once().Return(nil)
+			// the limits are read back once set
+			spendLimitRepo.On("ListCurrent", ctx, db, database.Text("buyer-1"), mock.Anything).Once().Return([]*entities.SpendLimit{}, nil)
+			restrictionRepo.On("ListActive", ctx, db, []string{"buyer-1"}, mock.Anything).Once().Return([]*entities.AccountRestriction{}, nil)
+			purchaseRepo.On("SumSpend", ctx, db, database.Text("buyer-1"), mock.Anything).Times(3).Return([]*repositories.CurrencySpend{}, nil)
+			accountRepo.On("Get", ctx, db, database.Text("buyer-1")).Once().Return(nil, pgx.ErrNoRows)
+			s := &WagerService{
+				DB:                      db,
+				AccountRepo:             accountRepo,
+				PurchaseRepo:            purchaseRepo,
+				AuditLogRepo:            auditLogRepo,
+				SpendLimitIncreaseDelay: 48 * time.Hour,
+				SpendLimitRepo:          spendLimitRepo,
+				AccountRestrictionRepo:  restrictionRepo,
+			}
+
+			limits, err := s.SetSpendLimit(ctx, "buyer-1", "week", &models.SetSpendLimitRequest{Amount: tc.amount})
+			assert.NoError(t, err)
+			assert.Len(t, limits.Limits, 3)
+			if tc.delayed {
+				assert.WithinDuration(t, time.Now().Add(48*time.Hour), created.EffectiveFrom.Time, time.Minute)
+			} else {
+				assert.WithinDuration(t, time.Now(), created.EffectiveFrom.Time, time.Minute)
+			}
+			assert.Equal(t, tc.amount == nil, created.Amount.Status == pgtype.Null)
+			auditLogRepo.AssertExpectations(t)
+		})
+	}
+
+	s := &WagerService{}
+	for _, tc := range []struct {
+		accountID, period string
+		amount            *float32
+		expectedErr       string
+	}{
+		{" ", "week", amount(10), "the account_id must be given, at most 64 characters long"},
+		{"buyer-1", "year", amount(10), "the period must be one of day, week, month"},
+		{"buyer-1", "day", amount(0), "the amount must be a positive decimal value to two decimal places"},
+		{"buyer-1", "day", amount(10.125), "the amount must be a positive decimal value to two decimal places"},
+	} {
+		_, err := s.SetSpendLimit(ctx, tc.accountID, tc.period, &models.SetSpendLimitRequest{Amount: tc.amount})
+		assert.EqualError(t, err, tc.expectedErr)
+		assert.Equal(t, http.StatusBadRequest, statusFromError(err))
+	}
+}
+
+func Test_restrictions_validation(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	s := &WagerService{}
+	for _, duration := range []string{"", "12h", "1009h", "a week"} {
+		_, err := s.StartCoolOff(ctx, "buyer-1", &models.CoolOffRequest{Duration: duration})
+		assert.EqualError(t, err, "the duration must be between 24h0m0s and 1008h0m0s", duration)
+	}
+	_, err := s.SelfExclude(ctx, "buyer-1", &models.SelfExclusionRequest{Duration: "720h"})
+	assert.EqualError(t, err, "the duration must be at least 4380h0m0s unless the self-exclusion is permanent")
+	_, err = s.SelfExclude(ctx, "buyer-1", &models.SelfExclusionRequest{Duration: "8760h", Permanent: true})
+	assert.EqualError(t, err, "a permanent self-exclusion has no duration")
+}
+
+func Test_Place_RestrictedSeller(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	db := &mock_database.Ext{}
+	tx := &mock_database.Tx{}
+	wagerRepo := &mock_repositories.MockWagerRepo{}
+	restrictionRepo := &mock_repositories.MockAccountRestrictionRepo{}
+	db.On("Begin", ctx).Return(tx, nil)
+	tx.On("Rollback", mock.Anything).Return(nil)
+	endsAt := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	restrictionRepo.On("ListActive", ctx, tx, []string{"seller-1"}, mock.Anything).Once().Return([]*entities.AccountRestriction{
+		{AccountID: database.Text("seller-1"), Kind: database.Text(entities.AccountRestrictionCoolOff), EndsAt: database.Timestamptz(endsAt)},
+	}, nil)
+
+	s := &WagerService{DB: db, WagerRepo: wagerRepo, AccountRestrictionRepo: restrictionRepo}
+	_, err := s.Place(ctx, &models.PlaceWagerRequest{SellerID: "seller-1", TotalWagerValue: 50, Odds: "30", SellingPercentage: 30, SellingPrice: 50})
+	assert.EqualError(t, err, "unable to place wager: the account is restricted: cool_off of seller-1 until 2030-01-02T03:04:05Z")
+	assert.Equal(t, http.StatusForbidden, statusFromError(err))
+	wagerRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,332 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/internal/models"
+	"github.com/wager-api/internal/repositories"
+	"github.com/wager-api/libs/database"
+
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+)
+
+// RiskLimits bound the exposure of the sellers and the markets, a zero limit doesn't
+// bound anything. The limits are in the base currency, the amounts are converted to it
+// at the rates in effect and an amount no rate converts is refused.
+type RiskLimits struct {
+	// MaxSellerExposure bounds the open selling value of the wagers of a seller, what is
+	// left to sell of them while their market isn't settled
+	MaxSellerExposure float32
+	// MaxMarketVolume bounds what is bought of the wagers of a market
+	MaxMarketVolume float32
+	// MaxPurchase bounds the buying price of a purchase
+	MaxPurchase float32
+}
+
+// Validate checks that no limit is negative.
+func (l *RiskLimits) Validate() error {
+	if l.MaxSellerExposure < 0 || l.MaxMarketVolume < 0 || l.MaxPurchase < 0 {
+		return fmt.Errorf("the risk limits must not be negative")
+	}
+	return nil
+}
+
+// inBaseCurrency sums amounts up in the base currency at the rates in effect at now.
+func (s *WagerService) inBaseCurrency(ctx context.Context, db database.Ext, amounts []*repositories.CurrencyAmount, now time.Time) (float32, error) {
+	base := baseCurrency(s.BaseCurrency)
+	var sum float32
+	for _, amount := range amounts {
+		if amount.Currency == base {
+			sum += amount.Amount
+			continue
+		}
+		rate, err := exchangeRate(ctx, db, s.ExchangeRateRepo, amount.Currency, base, now)
+		if err != nil {
+			return 0, err
+		}
+		sum += float32(float64(amount.Amount) * rate)
+	}
+	return sum, nil
+}
+
+// wagerInBaseCurrency converts amount, in the currency of wager, to the base currency.
+func (s *WagerService) wagerInBaseCurrency(ctx context.Context, db database.Ext, wager *entities.Wager, amount float32, now time.Time) (float32, error) {
+	currency := wager.Currency.String
+	if wager.Currency.Status != pgtype.Present {
+		currency = baseCurrency(s.BaseCurrency)
+	}
+	return s.inBaseCurrency(ctx, db, []*repositories.CurrencyAmount{{Currency: currency, Amount: amount}}, now)
+}
+
+// checkSellerExposures checks the wagers about to be placed in tx against the exposure
+// limit of their sellers, in order: a wager is rejected when its seller would go over
+// the limit along the wagers accepted before it, when it has no seller or when no rate
+// converts its currency. The sellers are locked until the end of tx, in a stable order,
+// so their concurrent placements are checked one after another. The reasons of the
+// rejected wagers are returned at their index.
+func (s *WagerService) checkSellerExposures(ctx context.Context, tx database.Ext, wagers []*entities.Wager, now time.Time) ([]error, error) {
+	rejections := make([]error, len(wagers))
+	if s.Risk == nil || s.Risk.MaxSellerExposure <= 0 {
+		return rejections, nil
+	}
+	exposures := map[string]float32{}
+	for _, wager := range wagers {
+		if wager.SellerID.Status == pgtype.Present {
+			exposures[wager.SellerID.String] = 0
+		}
+	}
+	sellerIDs := make([]string, 0, len(exposures))
+	for sellerID := range exposures {
+		sellerIDs = append(sellerIDs, sellerID)
+	}
+	sort.Strings(sellerIDs)
+	for _, sellerID := range sellerIDs {
+		if err := s.RiskRepo.LockSeller(ctx, tx, database.Text(sellerID)); err != nil {
+			return nil, fmt.Errorf("unable to lock seller")
+		}
+		amounts, err := s.RiskRepo.SumSellerExposure(ctx, tx, database.Text(sellerID))
+		if err != nil {
+			return nil, fmt.Errorf("unable to sum the exposure of the seller")
+		}
+		if exposures[sellerID], err = s.inBaseCurrency(ctx, tx, amounts, now); err != nil {
+			return nil, err
+		}
+	}
+	for i, wager := range wagers {
+		if wager.SellerID.Status != pgtype.Present {
+			rejections[i] = &validationError{fmt.Errorf("the seller_id must be given while the exposure of the sellers is limited")}
+			continue
+		}
+		sellingPrice, err := s.wagerInBaseCurrency(ctx, tx, wager, wager.SellingPrice.Float, now)
+		if err != nil {
+			if !errors.Is(err, errNoExchangeRate) {
+				return nil, err
+			}
+			rejections[i] = err
+			continue
+		}
+		exposure := exposures[wager.SellerID.String] + sellingPrice
+		if exposure > s.Risk.MaxSellerExposure {
+			rejections[i] = fmt.Errorf("%w: the open selling value of the seller would reach %.2f, above its limit of %.2f",
+				errRiskLimitExceeded, exposure, s.Risk.MaxSellerExposure)
+			continue
+		}
+		exposures[wager.SellerID.String] = exposure
+	}
+	return rejections, nil
+}
+
+// checkRepriceExposure checks a raise of the selling price of wager to sellingPrice
+// against the exposure limit of its seller, the open value of the wager is counted at
+// the new price instead of the current one. The seller is locked until the end of tx
+// like on a placement.
+func (s *WagerService) checkRepriceExposure(ctx context.Context, tx database.Ext, wager *entities.Wager, sellingPrice float32, now time.Time) error {
+	if s.Risk == nil || s.Risk.MaxSellerExposure <= 0 || sellingPrice <= wager.SellingPrice.Float {
+		return nil
+	}
+	if err := s.RiskRepo.LockSeller(ctx, tx, wager.SellerID); err != nil {
+		return fmt.Errorf("unable to lock seller")
+	}
+	amounts, err := s.RiskRepo.SumSellerExposure(ctx, tx, wager.SellerID)
+	if err != nil {
+		return fmt.Errorf("unable to sum the exposure of the seller")
+	}
+	exposure, err := s.inBaseCurrency(ctx, tx, amounts, now)
+	if err != nil {
+		return err
+	}
+	openValue := func(price float32) float32 {
+		if price < wager.AmountSold.Float {
+			return 0
+		}
+		return price - wager.AmountSold.Float
+	}
+	raise, err := s.wagerInBaseCurrency(ctx, tx, wager, openValue(sellingPrice)-openValue(wager.SellingPrice.Float), now)
+	if err != nil {
+		return err
+	}
+	if total := exposure + raise; total > s.Risk.MaxSellerExposure {
+		return fmt.Errorf("%w: the open selling value of the seller would reach %.2f, above its limit of %.2f",
+			errRiskLimitExceeded, total, s.Risk.MaxSellerExposure)
+	}
+	return nil
+}
+
+// checkPurchaseCap checks a purchase of wager, or the purchase of a listing, at
+// buyingPrice against the limit of a single purchase.
+func (s *WagerService) checkPurchaseCap(ctx context.Context, tx database.Ext, wager *entities.Wager, buyingPrice float32, now time.Time) error {
+	if s.Risk == nil || s.Risk.MaxPurchase <= 0 {
+		return nil
+	}
+	buyingPrice, err := s.wagerInBaseCurrency(ctx, tx, wager, buyingPrice, now)
+	if err != nil {
+		return err
+	}
+	if buyingPrice > s.Risk.MaxPurchase {
+		return fmt.Errorf("%w: the buying_price must be at most %.2f", errRiskLimitExceeded, s.Risk.MaxPurchase)
+	}
+	return nil
+}
+
+// checkPurchaseRisk checks a purchase of wager at buyingPrice against the limits, the
+// market of the wager is locked until the end of tx so the concurrent purchases of its
+// wagers are checked one after another.
+func (s *WagerService) checkPurchaseRisk(ctx context.Context, tx database.Ext, wager *entities.Wager, buyingPrice float32, now time.Time) error {
+	if s.Risk == nil {
+		return nil
+	}
+	if err := s.checkPurchaseCap(ctx, tx, wager, buyingPrice, now); err != nil {
+		return err
+	}
+	if s.Risk.MaxMarketVolume <= 0 || wager.MarketID.Status != pgtype.Present {
+		return nil
+	}
+	if _, err := s.MarketRepo.Get(ctx, tx, wager.MarketID, repositories.WithUpdateLock()); err != nil {
+		if err == pgx.ErrNoRows {
+			return errMarketNotFound
+		}
+		return fmt.Errorf("unable to get market information")
+	}
+	amounts, err := s.RiskRepo.SumMarketVolume(ctx, tx, wager.MarketID)
+	if err != nil {
+		return fmt.Errorf("unable to sum the volume of the market")
+	}
+	volume, err := s.inBaseCurrency(ctx, tx, amounts, now)
+	if err != nil {
+		return err
+	}
+	if buyingPrice, err = s.wagerInBaseCurrency(ctx, tx, wager, buyingPrice, now); err != nil {
+		return err
+	}
+	if volume+buyingPrice > s.Risk.MaxMarketVolume {
+		return fmt.Errorf("%w: the volume of the market would reach %.2f, above its limit of %.2f",
+			errRiskLimitExceeded, volume+buyingPrice, s.Risk.MaxMarketVolume)
+	}
+	return nil
+}
+
+const (
+	defaultRiskSummaryLimit = 20
+	maxRiskSummaryLimit     = 100
+)
+
+// RiskService shows the admins the exposure of the sellers and the markets against the
+// limits, read only.
+type RiskService struct {
+	DB       database.Ext
+	RiskRepo interface {
+		ListSellerExposures(ctx context.Context, db database.Ext, sellerID pgtype.Text, baseCurrency pgtype.Text, limit uint32) ([]*repositories.SellerExposure, error)
+		ListMarketVolumes(ctx context.Context, db database.Ext, marketID pgtype.Int4, baseCurrency pgtype.Text, limit uint32) ([]*repositories.MarketVolume, error)
+	}
+	// Limits are the limits enforced by the WagerService, nil when there are none
+	Limits *RiskLimits
+	// BaseCurrency is the currency of the limits, USD when it is empty
+	BaseCurrency string
+}
+
+// utilization is the percentage of limit that amount reaches, nil without a limit.
+func utilization(amount, limit float32) *float32 {
+	if limit <= 0 {
+		return nil
+	}
+	percentage := roundFloat(amount / limit * 100)
+	return &percentage
+}
+
+// GetRiskSummary lists the sellers with the highest exposure and the markets with the
+// highest volume along the limits: ?seller_id=&market_id=&limit=, limit is 20 by
+// default and at most 100 of each.
+func (s *RiskService) GetRiskSummary(resp http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	var sellerID pgtype.Text
+	var marketID pgtype.Int4
+	_ = sellerID.Set(nil)
+	_ = marketID.Set(nil)
+	if value := strings.TrimSpace(query.Get("seller_id")); value != "" {
+		_ = sellerID.Set(value)
+	}
+	limit := defaultRiskSummaryLimit
+	for _, param := range []struct {
+		name string
+		set  func(int)
+	}{
+		{"market_id", func(v int) { _ = marketID.Set(v) }},
+		{"limit", func(v int) { limit = v }},
+	} {
+		if value := query.Get(param.name); value != "" {
+			v, err := strconv.Atoi(value)
+			if err != nil || v <= 0 {
+				resp.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(resp).Encode(map[string]string{
+					"error": fmt.Sprintf("the %s must be a positive integer", param.name),
+				})
+				return
+			}
+			param.set(v)
+		}
+	}
+	if limit > maxRiskSummaryLimit {
+		limit = maxRiskSummaryLimit
+	}
+	limits := RiskLimits{}
+	if s.Limits != nil {
+		limits = *s.Limits
+	}
+	base := database.Text(baseCurrency(s.BaseCurrency))
+	sellers, err := s.RiskRepo.ListSellerExposures(req.Context(), s.DB, sellerID, base, uint32(limit))
+	if err != nil {
+		resp.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": "unable to list seller exposures",
+		})
+		return
+	}
+	markets, err := s.RiskRepo.ListMarketVolumes(req.Context(), s.DB, marketID, base, uint32(limit))
+	if err != nil {
+		resp.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": "unable to list market volumes",
+		})
+		return
+	}
+	summary := &models.RiskSummary{
+		Currency: base.String,
+		Limits: models.RiskLimits{
+			MaxSellerExposure: limits.MaxSellerExposure,
+			MaxMarketVolume:   limits.MaxMarketVolume,
+			MaxPurchase:       limits.MaxPurchase,
+		},
+		Sellers: make([]*models.SellerExposure, 0, len(sellers)),
+		Markets: make([]*models.MarketExposure, 0, len(markets)),
+	}
+	for _, seller := range sellers {
+		summary.Sellers = append(summary.Sellers, &models.SellerExposure{
+			SellerID:          seller.SellerID,
+			OpenWagers:        seller.OpenWagers,
+			OpenSellingValue:  roundFloat(seller.OpenSellingValue),
+			Utilization:       utilization(seller.OpenSellingValue, limits.MaxSellerExposure),
+			UnconvertedWagers: seller.UnconvertedWagers,
+		})
+	}
+	for _, market := range markets {
+		summary.Markets = append(summary.Markets, &models.MarketExposure{
+			MarketID:          int(market.MarketID),
+			Wagers:            market.Wagers,
+			Volume:            roundFloat(market.Volume),
+			Utilization:       utilization(market.Volume, limits.MaxMarketVolume),
+			UnconvertedWagers: market.UnconvertedWagers,
+		})
+	}
+	resp.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(resp).Encode(summary)
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/internal/models"
+	"github.com/wager-api/internal/repositories"
+	"github.com/wager-api/libs/database"
+	mock_database "github.com/wager-api/mocks/libs/database"
+	mock_repositories "github.com/wager-api/mocks/repositories"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+)
+
+func Test_RiskLimits_Validate(t *testing.T) {
+	t.Parallel()
+	assert.NoError(t, (&RiskLimits{MaxSellerExposure: 1000}).Validate())
+	assert.EqualError(t, (&RiskLimits{MaxPurchase: -1}).Validate(), "the risk limits must not be negative")
+}
+
+func Test_checkSellerExposures(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	now := time.Now()
+	tx := &mock_database.Tx{}
+	riskRepo := &mock_repositories.MockRiskRepo{}
+	exchangeRateRepo := &mock_repositories.MockExchangeRateRepo{}
+	riskRepo.On("LockSeller", ctx, tx, database.Text("seller-1")).Once().Return(nil)
+	// the exposure in EUR is converted to the base currency
+	riskRepo.On("SumSellerExposure", ctx, tx, database.Text("seller-1")).Once().Return([]*repositories.CurrencyAmount{
+		{Currency: "EUR", Amount: 80},
+		{Currency: "USD", Amount: 300},
+	}, nil)
+	exchangeRateRepo.On("GetEffective", ctx, tx, database.Text("EUR"), database.Text("USD"), database.Timestamptz(now)).Return(&entities.ExchangeRate{Rate: database.Float8(1.25)}, nil)
+	exchangeRateRepo.On("GetEffective", ctx, tx, mock.Anything, mock.Anything, database.Timestamptz(now)).Return(nil, pgx.ErrNoRows)
+	newWager := func(sellerID pgtype.Text, sellingPrice float32, currency string) *entities.Wager {
+		return &entities.Wager{SellerID: sellerID, SellingPrice: database.Float4(sellingPrice), Currency: database.Text(currency)}
+	}
+
+	s := &WagerService{Risk: &RiskLimits{MaxSellerExposure: 500}, RiskRepo: riskRepo, ExchangeRateRepo: exchangeRateRepo}
+	rejections, err := s.checkSellerExposures(ctx, tx, []*entities.Wager{
+		newWager(database.Text("seller-1"), 64, "EUR"),
+		newWager(database.Text("seller-1"), 50, "USD"),
+		newWager(pgtype.Text{Status: pgtype.Null}, 10, "USD"),
+		newWager(database.Text("seller-1"), 10, "GBP"),
+		newWager(database.Text("seller-1"), 20, "USD"),
+	}, now)
+	assert.NoError(t, err)
+	assert.NoError(t, rejections[0])
+	assert.EqualError(t, rejections[1], "risk limit exceeded: the open selling value of the seller would reach 530.00, above its limit of 500.00")
+	assert.Equal(t, http.StatusConflict, statusFromError(rejections[1]))
+	assert.EqualError(t, rejections[2], "the seller_id must be given while the exposure of the sellers is limited")
+	assert.Equal(t, http.StatusBadRequest, statusFromError(rejections[2]))
+	assert.EqualError(t, rejections[3], "no exchange rate is in effect from GBP to USD")
+	assert.Equal(t, http.StatusConflict, statusFromError(rejections[3]))
+	assert.NoError(t, rejections[4], "the rejected wagers leave room for the next ones")
+	riskRepo.AssertExpectations(t)
+}
+
+func Test_PlaceBatch_SellerExposure(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	db := &mock_database.Ext{}
+	tx := &mock_database.Tx{}
+	wagerRepo := &mock_repositories.MockWagerRepo{}
+	riskRepo := &mock_repositories.MockRiskRepo{}
+	restrictionRepo := &mock_repositories.MockAccountRestrictionRepo{}
+	db.On("Begin", ctx).Return(tx, nil)
+	tx.On("Rollback", mock.Anything).Return(nil)
+	restrictionRepo.On("ListActive", ctx, tx, []string{"seller-1", "seller-1"}, mock.Anything).Once().Return([]*entities.AccountRestriction{}, nil)
+	riskRepo.On("LockSeller", ctx, tx, database.Text("seller-1")).Once().Return(nil)
+	riskRepo.On("SumSellerExposure", ctx, tx, database.Text("seller-1")).Once().Return([]*repositories.CurrencyAmount{}, nil)
+	placeWagerRequest := func() *models.PlaceWagerRequest {
+		return &models.PlaceWagerRequest{SellerID: "seller-1", TotalWagerValue: 50, Odds: "30", SellingPercentage: 30, SellingPrice: 50}
+	}
+
+	s := &WagerService{DB: db, WagerRepo: wagerRepo, Risk: &RiskLimits{MaxSellerExposure: 80}, RiskRepo: riskRepo, AccountRestrictionRepo: restrictionRepo}
+	batch, err := s.PlaceBatch(ctx, []*models.PlaceWagerRequest{placeWagerRequest(), placeWagerRequest()}, PlaceBatchAtomic)
+	assert.EqualError(t, err, "wager 1: risk limit exceeded: the open selling value of the seller would reach 100.00, above its limit of 80.00")
+	assert.Equal(t, http.StatusConflict, statusFromError(err))
+	assert.Equal(t, placeBatchStatusAborted, batch.Results[0].Status)
+	assert.Equal(t, placeBatchStatusFailed, batch.Results[1].Status)
+	assert.Equal(t, 0, batch.Placed)
+	wagerRepo.AssertNotCalled(t, "CreateBatch", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func Test_checkRepriceExposure(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	tx := &mock_database.Tx{}
+	riskRepo := &mock_repositories.MockRiskRepo{}
+	// the exposure of the seller counts the 40 left unsold of the wager
+	riskRepo.On("LockSeller", ctx, tx, database.Text("seller-1")).Twice().Return(nil)
+	riskRepo.On("SumSellerExposure", ctx, tx, database.Text("seller-1")).Twice().Return([]*repositories.CurrencyAmount{{Currency: "USD", Amount: 900}}, nil)
+	wager := &entities.Wager{SellerID: database.Text("seller-1"), SellingPrice: database.Float4(50), AmountSold: database.Float4(10)}
+	s := &WagerService{Risk: &RiskLimits{MaxSellerExposure: 1000}, RiskRepo: riskRepo}
+	now := time.Now()
+
+	assert.NoError(t, s.checkRepriceExposure(ctx, tx, wager, 150, now))
+	err := s.checkRepriceExposure(ctx, tx, wager, 160, now)
+	assert.EqualError(t, err, "risk limit exceeded: the open selling value of the seller would reach 1010.00, above its limit of 1000.00")
+	assert.NoError(t, s.checkRepriceExposure(ctx, tx, wager, 40, now), "lowering the price is not limited")
+	riskRepo.AssertExpectations(t)
+}
+
+func Test_checkPurchaseRisk(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	now := time.Now()
+	limits := &RiskLimits{MaxMarketVolume: 1000, MaxPurchase: 100}
+	wager := &entities.Wager{WagerID: database.Int4(1), MarketID: database.Int4(3)}
+
+	t.Run("above the max purchase", func(t *testing.T) {
+		s := &WagerService{Risk: limits}
+		err := s.checkPurchaseRisk(ctx, &mock_database.Tx{}, wager, 150, now)
+		assert.EqualError(t, err, "risk limit exceeded: the buying_price must be at most 100.00")
+	})
+	t.Run("above the volume of the market", func(t *testing.T) {
+		tx := &mock_database.Tx{}
+		marketRepo := &mock_repositories.MockMarketRepo{}
+		riskRepo := &mock_repositories.MockRiskRepo{}
+		marketRepo.On("Get", ctx, tx, database.Int4(3)).Once().Return(&entities.Market{MarketID: database.Int4(3)}, nil)
+		riskRepo.On("SumMarketVolume", ctx, tx, database.Int4(3)).Once().Return([]*repositories.CurrencyAmount{{Currency: "USD", Amount: 950}}, nil)
+		s := &WagerService{Risk: limits, MarketRepo: marketRepo, RiskRepo: riskRepo}
+		err := s.checkPurchaseRisk(ctx, tx, wager, 60, now)
+		assert.EqualError(t, err, "risk limit exceeded: the volume of the market would reach 1010.00, above its limit of 1000.00")
+		assert.NoError(t, s.checkPurchaseRisk(ctx, tx, &entities.Wager{MarketID: pgtype.Int4{Status: pgtype.Null}}, 60, now), "a wager without a market is not limited")
+		marketRepo.AssertExpectations(t)
+	})
+	t.Run("no limits", func(t *testing.T) {
+		s := &WagerService{}
+		assert.NoError(t, s.checkPurchaseRisk(ctx, &mock_database.Tx{}, wager, 1e6, now))
+	})
+}
+
+func Test_GetRiskSummary(t *testing.T) {
+	t.Parallel()
+	db := &mock_database.Ext{}
+	riskRepo := &mock_repositories.MockRiskRepo{}
+	riskRepo.On("ListSellerExposures", mock.Anything, db, pgtype.Text{Status: pgtype.Null}, database.Text("USD"), uint32(defaultRiskSummaryLimit)).Once().Return([]*repositories.SellerExposure{
+		{SellerID: "seller-1", OpenWagers: 2, OpenSellingValue: 250},
+	}, nil)
+	riskRepo.On("ListMarketVolumes", mock.Anything, db, pgtype.Int4{Status: pgtype.Null}, database.Text("USD"), uint32(defaultRiskSummaryLimit)).Once().Return([]*repositories.MarketVolume{
+		{MarketID: 3, Wagers: 4, Volume: 120, UnconvertedWagers: 1},
+	}, nil)
+	mux := chi.NewMux()
+	NewRiskHandler(mux, &RiskService{DB: db, RiskRepo: riskRepo, Limits: &RiskLimits{MaxSellerExposure: 1000}}, []string{"admin-token"})
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/admin/risk?market_id=abc", nil)
+	req.Header.Set("Authorization", "Bearer admin-token")
+	mux.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/admin/risk", nil)
+	req.Header.Set("Authorization", "Bearer admin-token")
+	mux.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	summary := &models.RiskSummary{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(summary))
+	utilization := float32(25)
+	assert.Equal(t, &models.RiskSummary{
+		Currency: "USD",
+		Limits:   models.RiskLimits{MaxSellerExposure: 1000},
+		Sellers:  []*models.SellerExposure{{SellerID: "seller-1", OpenWagers: 2, OpenSellingValue: 250, Utilization: &utilization}},
+		Markets:  []*models.MarketExposure{{MarketID: 3, Wagers: 4, Volume: 120, UnconvertedWagers: 1}},
+	}, summary)
+	riskRepo.AssertExpectations(t)
+}
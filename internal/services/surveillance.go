@@ -0,0 +1,477 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/internal/models"
+	"github.com/wager-api/internal/repositories"
+	"github.com/wager-api/libs/database"
+	"github.com/wager-api/libs/logs"
+
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+	"go.uber.org/multierr"
+)
+
+// SurveillanceRule is a rule run on the purchases of the wagers, Block refuses the
+// purchases it flags on top of raising an alert.
+type SurveillanceRule struct {
+	Enabled bool
+	Block   bool
+	// Threshold is the count of trades in the window the rule flags from, the self
+	// trades have none
+	Threshold int
+}
+
+// SurveillanceRules are the rules looking for wash trading in the purchases made by an
+// account, the anonymous purchases aren't looked at.
+type SurveillanceRules struct {
+	// Window is how far back the rules look into the trading history
+	Window time.Duration
+	// SelfTrade flags the purchases of a wager by its seller or an account linked to it
+	SelfTrade SurveillanceRule
+	// ResellLoop flags the purchases of a wager by a buyer who resold Threshold stakes
+	// of it in the window
+	ResellLoop SurveillanceRule
+	// PriceWalking flags the buyer making the last Threshold purchases of a wager in the
+	// window, each one below the one before it
+	PriceWalking SurveillanceRule
+}
+
+// Validate checks that the rules looking into the history have a window and a
+// threshold they can flag from.
+func (r *SurveillanceRules) Validate() error {
+	if (r.ResellLoop.Enabled || r.PriceWalking.Enabled) && r.Window <= 0 {
+		return fmt.Errorf("the surveillance window must be positive")
+	}
+	if r.ResellLoop.Enabled && r.ResellLoop.Threshold < 1 {
+		return fmt.Errorf("the resell loop threshold must be at least 1")
+	}
+	if r.PriceWalking.Enabled && r.PriceWalking.Threshold < 2 {
+		return fmt.Errorf("the price walking threshold must be at least 2")
+	}
+	return nil
+}
+
+// tradeBlockedError is returned when a rule blocks a purchase, it carries the alerts
+// raised on it so they are recorded once the transaction of the purchase is rolled back.
+type tradeBlockedError struct {
+	alerts []*entities.TradeAlert
+}
+
+func (e *tradeBlockedError) Error() string {
+	rules := make([]string, 0, len(e.alerts))
+	for _, alert := range e.alerts {
+		rules = append(rules, alert.Rule.String)
+	}
+	return fmt.Sprintf("%s: flagged as %s", errTradeBlocked, strings.Join(rules, ", "))
+}
+
+func (e *tradeBlockedError) Unwrap() error {
+	return errTradeBlocked
+}
+
+// trade is a purchase checked by the rules before it is written: of the wager from its
+// seller, or of the stake of a listing from the seller of the listing.
+type trade struct {
+	wager *entities.Wager
+	// listingID is null on a purchase of the wager
+	listingID pgtype.Int4
+	sellerID  pgtype.Text
+	buyerID   pgtype.Text
+	price     float32
+}
+
+func newTradeAlert(rule string, trade *trade, details map[string]interface{}, now time.Time) (*entities.TradeAlert, error) {
+	alert := &entities.TradeAlert{}
+	database.AllNullEntity(alert)
+	if err := multierr.Combine(
+		alert.Rule.Set(rule),
+		alert.WagerID.Set(trade.wager.WagerID),
+		alert.ListingID.Set(trade.listingID),
+		alert.BuyerID.Set(trade.buyerID),
+		alert.SellerID.Set(trade.sellerID),
+		alert.BuyingPrice.Set(trade.price),
+		alert.Details.Set(details),
+		alert.Blocked.Set(false),
+		alert.CreatedAt.Set(now)); err != nil {
+		return nil, fmt.Errorf("unable to generate trade alert record")
+	}
+	return alert, nil
+}
+
+// evaluateTrade runs the rules on purchase of wager before it is written in tx and
+// returns the alerts raised on it. A *tradeBlockedError carrying every alert is
+// returned instead when one of them comes from a blocking rule.
+func (s *WagerService) evaluateTrade(ctx context.Context, tx database.Ext, wager *entities.Wager, purchase *entities.Purchase, now time.Time) ([]*entities.TradeAlert, error) {
+	return s.evaluate(ctx, tx, &trade{
+		wager:     wager,
+		listingID: pgtype.Int4{Status: pgtype.Null},
+		sellerID:  wager.SellerID,
+		buyerID:   purchase.BuyerID,
+		price:     purchase.BuyingPrice.Float,
+	}, now)
+}
+
+// evaluateResale runs the rules on the purchase of listing of a stake of wager by
+// buyerID like evaluateTrade, the seller is the one of the listing. The price walking
+// is left out, the asking prices are of parts of the stake.
+func (s *WagerService) evaluateResale(ctx context.Context, tx database.Ext, wager *entities.Wager, listing *entities.ResaleListing, buyerID pgtype.Text, now time.Time) ([]*entities.TradeAlert, error) {
+	return s.evaluate(ctx, tx, &trade{
+		wager:     wager,
+		listingID: listing.ListingID,
+		sellerID:  listing.SellerID,
+		buyerID:   buyerID,
+		price:     listing.AskingPrice.Float,
+	}, now)
+}
+
+func (s *WagerService) evaluate(ctx context.Context, tx database.Ext, trade *trade, now time.Time) ([]*entities.TradeAlert, error) {
+	rules := s.Surveillance
+	if rules == nil || trade.buyerID.Status != pgtype.Present {
+		return nil, nil
+	}
+	wager := trade.wager
+	type hit struct {
+		rule    string
+		block   bool
+		details map[string]interface{}
+	}
+	hits := []hit{}
+	since := database.Timestamptz(now.Add(-rules.Window))
+	if rules.SelfTrade.Enabled && trade.sellerID.Status == pgtype.Present {
+		if trade.sellerID.String == trade.buyerID.String {
+			hits = append(hits, hit{entities.TradeAlertRuleSelfTrade, rules.SelfTrade.Block, map[string]interface{}{
+				"reason": "the buyer is the seller",
+			}})
+		} else {
+			linked, err := s.SurveillanceRepo.AreLinked(ctx, tx, trade.buyerID, trade.sellerID)
+			if err != nil {
+				return nil, fmt.Errorf("unable to look up account links")
+			}
+			if linked {
+				hits = append(hits, hit{entities.TradeAlertRuleSelfTrade, rules.SelfTrade.Block, map[string]interface{}{
+					"reason": "the buyer is linked to the seller",
+				}})
+			}
+		}
+	}
+	if rules.ResellLoop.Enabled {
+		resales, err := s.SurveillanceRepo.CountResales(ctx, tx, wager.WagerID, trade.buyerID, since)
+		if err != nil {
+			return nil, fmt.Errorf("unable to count the resales of the buyer")
+		}
+		if resales >= int64(rules.ResellLoop.Threshold) {
+			hits = append(hits, hit{entities.TradeAlertRuleResellLoop, rules.ResellLoop.Block, map[string]interface{}{
+				"resales": resales,
+				"window":  rules.Window.String(),
+			}})
+		}
+	}
+	if rules.PriceWalking.Enabled && trade.listingID.Status != pgtype.Present {
+		purchases, err := s.SurveillanceRepo.ListRecentPurchases(ctx, tx, wager.WagerID, since, uint32(rules.PriceWalking.Threshold-1))
+		if err != nil {
+			return nil, fmt.Errorf("unable to list the recent purchases of the wager")
+		}
+		// the purchases are the latest first, each one must be above the one after it
+		walked, fromPrice := 1, trade.price
+		for _, previous := range purchases {
+			if previous.BuyerID.String != trade.buyerID.String || previous.BuyingPrice <= fromPrice {
+				break
+			}
+			walked, fromPrice = walked+1, previous.BuyingPrice
+		}
+		if walked >= rules.PriceWalking.Threshold {
+			hits = append(hits, hit{entities.TradeAlertRulePriceWalking, rules.PriceWalking.Block, map[string]interface{}{
+				"purchases":  walked,
+				"from_price": fromPrice,
+				"to_price":   trade.price,
+				"window":     rules.Window.String(),
+			}})
+		}
+	}
+	alerts := make([]*entities.TradeAlert, 0, len(hits))
+	blocked := false
+	for _, hit := range hits {
+		alert, err := newTradeAlert(hit.rule, trade, hit.details, now)
+		if err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, alert)
+		blocked = blocked || hit.block
+	}
+	if blocked {
+		for _, alert := range alerts {
+			_ = alert.Blocked.Set(true)
+		}
+		return nil, &tradeBlockedError{alerts: alerts}
+	}
+	return alerts, nil
+}
+
+// recordTradeAlerts writes the alerts raised on a purchase, purchaseID is null when the
+// purchase was blocked or is one of a listing.
+func (s *WagerService) recordTradeAlerts(ctx context.Context, db database.Ext, alerts []*entities.TradeAlert, purchaseID pgtype.Int4) error {
+	for _, alert := range alerts {
+		alert.PurchaseID = purchaseID
+		if err := s.TradeAlertRepo.Create(ctx, db, alert); err != nil {
+			return fmt.Errorf("unable to create trade alert record")
+		}
+	}
+	return nil
+}
+
+// recordBlockedTrade writes the alerts of a purchase blocked by err, if it is one, out of
+// the transaction of the purchase which is rolled back.
+func (s *WagerService) recordBlockedTrade(ctx context.Context, err error) {
+	var blockedErr *tradeBlockedError
+	if !errors.As(err, &blockedErr) {
+		return
+	}
+	if err := s.recordTradeAlerts(ctx, s.DB, blockedErr.alerts, pgtype.Int4{Status: pgtype.Null}); err != nil {
+		logs.Logger.Errorw("unable to record the alerts of a blocked trade", "error", err)
+	}
+}
+
+const (
+	defaultTradeAlertLimit = 50
+	maxTradeAlertLimit     = 500
+)
+
+// SurveillanceService shows the admins the alerts raised by the surveillance rules and
+// manages the links between the accounts held by the same party.
+type SurveillanceService struct {
+	DB             database.Ext
+	TradeAlertRepo interface {
+		List(ctx context.Context, db database.Ext, filter repositories.TradeAlertFilter, beforeID pgtype.Int4, limit uint32) ([]*entities.TradeAlert, error)
+	}
+	AccountLinkRepo interface {
+		Upsert(ctx context.Context, db database.Ext, link *entities.AccountLink) error
+		List(ctx context.Context, db database.Ext, accountID pgtype.Text) ([]*entities.AccountLink, error)
+	}
+	AuditLogRepo interface {
+		Create(ctx context.Context, db database.Ext, auditLog *entities.AuditLog) error
+	}
+}
+
+func convertTradeAlertPg2Domain(alert *entities.TradeAlert) *models.TradeAlert {
+	var purchaseID *int
+	if alert.PurchaseID.Status == pgtype.Present {
+		id := int(alert.PurchaseID.Int)
+		purchaseID = &id
+	}
+	var listingID *int
+	if alert.ListingID.Status == pgtype.Present {
+		id := int(alert.ListingID.Int)
+		listingID = &id
+	}
+	return &models.TradeAlert{
+		ID:          int(alert.AlertID.Int),
+		Rule:        alert.Rule.String,
+		WagerID:     int(alert.WagerID.Int),
+		PurchaseID:  purchaseID,
+		ListingID:   listingID,
+		BuyerID:     alert.BuyerID.String,
+		SellerID:    alert.SellerID.String,
+		BuyingPrice: alert.BuyingPrice.Float,
+		Details:     alert.Details.Bytes,
+		Blocked:     alert.Blocked.Bool,
+		CreatedAt:   alert.CreatedAt.Time,
+	}
+}
+
+func convertAccountLinkPg2Domain(link *entities.AccountLink) *models.AccountLink {
+	return &models.AccountLink{
+		ID:              int(link.LinkID.Int),
+		AccountID:       link.AccountID.String,
+		LinkedAccountID: link.LinkedAccountID.String,
+		Reason:          link.Reason.String,
+		CreatedAt:       link.CreatedAt.Time,
+	}
+}
+
+// parseTradeAlertQuery reads the filters of the trade alerts:
+// ?rule=&wager_id=&account_id=&blocked=&before_id=&limit=
+func parseTradeAlertQuery(req *http.Request) (repositories.TradeAlertFilter, pgtype.Int4, int, error) {
+	query := req.URL.Query()
+	filter := repositories.TradeAlertFilter{}
+	_ = filter.Rule.Set(nil)
+	_ = filter.WagerID.Set(nil)
+	_ = filter.AccountID.Set(nil)
+	_ = filter.Blocked.Set(nil)
+	var beforeID pgtype.Int4
+	_ = beforeID.Set(nil)
+	limit := defaultTradeAlertLimit
+
+	if rule := query.Get("rule"); rule != "" {
+		switch rule {
+		case entities.TradeAlertRuleSelfTrade, entities.TradeAlertRuleResellLoop, entities.TradeAlertRulePriceWalking:
+			_ = filter.Rule.Set(rule)
+		default:
+			return filter, beforeID, 0, fmt.Errorf("the rule must be one of %s, %s, %s",
+				entities.TradeAlertRuleSelfTrade, entities.TradeAlertRuleResellLoop, entities.TradeAlertRulePriceWalking)
+		}
+	}
+	if accountID := query.Get("account_id"); accountID != "" {
+		_ = filter.AccountID.Set(accountID)
+	}
+	if value := query.Get("blocked"); value != "" {
+		blocked, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, beforeID, 0, fmt.Errorf("the blocked must be true or false")
+		}
+		_ = filter.Blocked.Set(blocked)
+	}
+	for _, param := range []struct {
+		name string
+		dst  *pgtype.Int4
+	}{
+		{"wager_id", &filter.WagerID},
+		{"before_id", &beforeID},
+	} {
+		if value := query.Get(param.name); value != "" {
+			id, err := strconv.Atoi(value)
+			if err != nil || id <= 0 {
+				return filter, beforeID, 0, fmt.Errorf("the %s must be a positive integer", param.name)
+			}
+			_ = param.dst.Set(id)
+		}
+	}
+	if value := query.Get("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit <= 0 || limit > maxTradeAlertLimit {
+			return filter, beforeID, 0, fmt.Errorf("the limit must be an integer between 1 and %d", maxTradeAlertLimit)
+		}
+	}
+	return filter, beforeID, limit, nil
+}
+
+// ListTradeAlerts returns the alerts matching the filters of the query, latest first.
+func (s *SurveillanceService) ListTradeAlerts(resp http.ResponseWriter, req *http.Request) {
+	filter, beforeID, limit, err := parseTradeAlertQuery(req)
+	if err != nil {
+		resp.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	alerts, err := s.TradeAlertRepo.List(req.Context(), s.DB, filter, beforeID, uint32(limit))
+	if err != nil {
+		resp.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": "unable to list trade alerts",
+		})
+		return
+	}
+	alertModels := make([]*models.TradeAlert, 0, len(alerts))
+	for _, alert := range alerts {
+		alertModels = append(alertModels, convertTradeAlertPg2Domain(alert))
+	}
+	resp.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(resp).Encode(alertModels)
+}
+
+func newAccountLink(req *models.LinkAccountsRequest, now time.Time) (*entities.AccountLink, error) {
+	accountID, err := validateAccountID(req.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	linkedAccountID, err := validateAccountID(req.LinkedAccountID)
+	if err != nil {
+		return nil, fmt.Errorf("the linked_account_id must be given, at most %d characters long", maxSellerIDLength)
+	}
+	if accountID == linkedAccountID {
+		return nil, fmt.Errorf("an account can't be linked to itself")
+	}
+	// a pair is stored once, the lowest account first
+	if linkedAccountID < accountID {
+		accountID, linkedAccountID = linkedAccountID, accountID
+	}
+	link := &entities.AccountLink{}
+	database.AllNullEntity(link)
+	if reason := strings.TrimSpace(req.Reason); reason != "" {
+		_ = link.Reason.Set(reason)
+	}
+	if err := multierr.Combine(
+		link.AccountID.Set(accountID),
+		link.LinkedAccountID.Set(linkedAccountID),
+		link.CreatedAt.Set(now)); err != nil {
+		return nil, fmt.Errorf("unable to generate value for account link")
+	}
+	return link, nil
+}
+
+// LinkAccounts records that two accounts are held by the same party, their trades with
+// each other are self trades from then on. Linking a pair again updates its reason.
+func (s *SurveillanceService) LinkAccounts(resp http.ResponseWriter, req *http.Request) {
+	linkAccountsRequest := &models.LinkAccountsRequest{}
+	err := json.NewDecoder(req.Body).Decode(linkAccountsRequest)
+	defer req.Body.Close()
+	if err != nil {
+		resp.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": "unable to parse request",
+		})
+		return
+	}
+	now := time.Now()
+	link, err := newAccountLink(linkAccountsRequest, now)
+	if err != nil {
+		resp.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err := database.ExecInTx(req.Context(), s.DB, func(ctx context.Context, tx pgx.Tx) error {
+		if err := s.AccountLinkRepo.Upsert(ctx, tx, link); err != nil {
+			return err
+		}
+		after, err := snapshotEntity(link)
+		if err != nil {
+			return err
+		}
+		return s.AuditLogRepo.Create(ctx, tx, newEntityAuditLog(ctx, entities.AuditActionAccountLinkCreate, link.LinkID.Int, pgtype.JSONB{Status: pgtype.Null}, after, now))
+	}); err != nil {
+		resp.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": "unable to link accounts",
+		})
+		return
+	}
+	resp.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(resp).Encode(convertAccountLinkPg2Domain(link))
+}
+
+// ListAccountLinks lists the links, latest first: ?account_id=, an omitted account
+// matches every one.
+func (s *SurveillanceService) ListAccountLinks(resp http.ResponseWriter, req *http.Request) {
+	var accountID pgtype.Text
+	_ = accountID.Set(nil)
+	if value := strings.TrimSpace(req.URL.Query().Get("account_id")); value != "" {
+		_ = accountID.Set(value)
+	}
+	links, err := s.AccountLinkRepo.List(req.Context(), s.DB, accountID)
+	if err != nil {
+		resp.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": "unable to list account links",
+		})
+		return
+	}
+	linkModels := make([]*models.AccountLink, 0, len(links))
+	for _, link := range links {
+		linkModels = append(linkModels, convertAccountLinkPg2Domain(link))
+	}
+	resp.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(resp).Encode(linkModels)
+}
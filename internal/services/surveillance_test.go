@@ -0,0 +1,233 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/internal/models"
+	"github.com/wager-api/internal/repositories"
+	"github.com/wager-api/libs/database"
+	mock_database "github.com/wager-api/mocks/libs/database"
+	mock_repositories "github.com/wager-api/mocks/repositories"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+	"google.golang.org/grpc/codes"
+)
+
+func Test_SurveillanceRules_Validate(t *testing.T) {
+	t.Parallel()
+	assert.NoError(t, (&SurveillanceRules{SelfTrade: SurveillanceRule{Enabled: true, Block: true}}).Validate())
+	assert.Error(t, (&SurveillanceRules{ResellLoop: SurveillanceRule{Enabled: true, Threshold: 2}}).Validate())
+	assert.Error(t, (&SurveillanceRules{Window: time.Hour, ResellLoop: SurveillanceRule{Enabled: true}}).Validate())
+	assert.Error(t, (&SurveillanceRules{Window: time.Hour, PriceWalking: SurveillanceRule{Enabled: true, Threshold: 1}}).Validate())
+	assert.NoError(t, (&SurveillanceRules{Window: time.Hour, PriceWalking: SurveillanceRule{Enabled: true, Threshold: 2}}).Validate())
+}
+
+func Test_evaluateTrade(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	now := time.Now()
+	since := database.Timestamptz(now.Add(-time.Hour))
+	wager := &entities.Wager{WagerID: database.Int4(1), SellerID: database.Text("seller-1")}
+	purchase := &entities.Purchase{BuyerID: database.Text("buyer-1"), BuyingPrice: database.Float4(18)}
+	rules := &SurveillanceRules{
+		Window:       time.Hour,
+		SelfTrade:    SurveillanceRule{Enabled: true, Block: true},
+		ResellLoop:   SurveillanceRule{Enabled: true, Threshold: 2},
+		PriceWalking: SurveillanceRule{Enabled: true, Threshold: 3},
+	}
+
+	t.Run("no rule runs on the anonymous purchases", func(t *testing.T) {
+		s := &WagerService{Surveillance: rules}
+		alerts, err := s.evaluateTrade(ctx, &mock_database.Tx{}, wager, &entities.Purchase{BuyerID: pgtype.Text{Status: pgtype.Null}}, now)
+		assert.NoError(t, err)
+		assert.Empty(t, alerts)
+	})
+	t.Run("a trade with a linked account is blocked", func(t *testing.T) {
+		tx := &mock_database.Tx{}
+		surveillanceRepo := &mock_repositories.MockSurveillanceRepo{}
+		surveillanceRepo.On("AreLinked", ctx, tx, database.Text("buyer-1"), database.Text("seller-1")).Once().Return(true, nil)
+		surveillanceRepo.On("CountResales", ctx, tx, database.Int4(1), database.Text("buyer-1"), since).Once().Return(int64(0), nil)
+		surveillanceRepo.On("ListRecentPurchases", ctx, tx, database.Int4(1), since, uint32(2)).Once().Return([]*repositories.RecentPurchase{}, nil)
+		s := &WagerService{Surveillance: rules, SurveillanceRepo: surveillanceRepo}
+		alerts, err := s.evaluateTrade(ctx, tx, wager, purchase, now)
+		assert.Nil(t, alerts)
+		assert.EqualError(t, err, "the trade is blocked by the surveillance rules: flagged as self_trade")
+		assert.Equal(t, http.StatusForbidden, statusFromError(err))
+		assert.Equal(t, codes.PermissionDenied, codeFromError(err))
+		var blockedErr *tradeBlockedError
+		if assert.True(t, errors.As(err, &blockedErr)) && assert.Len(t, blockedErr.alerts, 1) {
+			assert.True(t, blockedErr.alerts[0].Blocked.Bool)
+			assert.Equal(t, "seller-1", blockedErr.alerts[0].SellerID.String)
+		}
+	})
+	t.Run("the resell loops and the price walking raise alerts", func(t *testing.T) {
+		tx := &mock_database.Tx{}
+		surveillanceRepo := &mock_repositories.MockSurveillanceRepo{}
+		surveillanceRepo.On("AreLinked", ctx, tx, database.Text("buyer-1"), database.Text("seller-1")).Once().Return(false, nil)
+		surveillanceRepo.On("CountResales", ctx, tx, database.Int4(1), database.Text("buyer-1"), since).Once().Return(int64(2), nil)
+		surveillanceRepo.On("ListRecentPurchases", ctx, tx, database.Int4(1), since, uint32(2)).Once().Return([]*repositories.RecentPurchase{
+			{BuyerID: database.Text("buyer-1"), BuyingPrice: 19},
+			{BuyerID: database.Text("buyer-1"), BuyingPrice: 20},
+		}, nil)
+		s := &WagerService{Surveillance: rules, SurveillanceRepo: surveillanceRepo}
+		alerts, err := s.evaluateTrade(ctx, tx, wager, purchase, now)
+		assert.NoError(t, err)
+		if assert.Len(t, alerts, 2) {
+			assert.Equal(t, entities.TradeAlertRuleResellLoop, alerts[0].Rule.String)
+			assert.Equal(t, entities.TradeAlertRulePriceWalking, alerts[1].Rule.String)
+			assert.False(t, alerts[1].Blocked.Bool)
+			assert.JSONEq(t, `{"purchases": 3, "from_price": 20, "to_price": 18, "window": "1h0m0s"}`, string(alerts[1].Details.Bytes))
+		}
+	})
+	t.Run("the purchase of a listing is checked against the seller of the listing", func(t *testing.T) {
+		tx := &mock_database.Tx{}
+		surveillanceRepo := &mock_repositories.MockSurveillanceRepo{}
+		surveillanceRepo.On("AreLinked", ctx, tx, database.Text("buyer-1"), database.Text("holder-1")).Once().Return(true, nil)
+		surveillanceRepo.On("CountResales", ctx, tx, database.Int4(1), database.Text("buyer-1"), since).Once().Return(int64(2), nil)
+		listing := &entities.ResaleListing{ListingID: database.Int4(5), SellerID: database.Text("holder-1"), AskingPrice: database.Float4(12)}
+		s := &WagerService{Surveillance: rules, SurveillanceRepo: surveillanceRepo}
+		alerts, err := s.evaluateResale(ctx, tx, wager, listing, database.Text("buyer-1"), now)
+		assert.Nil(t, alerts)
+		assert.EqualError(t, err, "the trade is blocked by the surveillance rules: flagged as self_trade, resell_loop")
+		var blockedErr *tradeBlockedError
+		if assert.True(t, errors.As(err, &blockedErr)) && assert.Len(t, blockedErr.alerts, 2) {
+			assert.Equal(t, database.Int4(5), blockedErr.alerts[0].ListingID)
+			assert.Equal(t, "holder-1", blockedErr.alerts[0].SellerID.String)
+			assert.Equal(t, float32(12), blockedErr.alerts[0].BuyingPrice.Float)
+		}
+		surveillanceRepo.AssertNotCalled(t, "ListRecentPurchases", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+	t.Run("another buyer in between breaks the price walking", func(t *testing.T) {
+		tx := &mock_database.Tx{}
+		surveillanceRepo := &mock_repositories.MockSurveillanceRepo{}
+		surveillanceRepo.On("ListRecentPurchases", ctx, tx, database.Int4(1), since, uint32(2)).Once().Return([]*repositories.RecentPurchase{
+			{BuyerID: database.Text("buyer-1"), BuyingPrice: 19},
+			{BuyerID: database.Text("buyer-2"), BuyingPrice: 20},
+		}, nil)
+		s := &WagerService{Surveillance: &SurveillanceRules{Window: time.Hour, PriceWalking: rules.PriceWalking}, SurveillanceRepo: surveillanceRepo}
+		alerts, err := s.evaluateTrade(ctx, tx, wager, purchase, now)
+		assert.NoError(t, err)
+		assert.Empty(t, alerts)
+	})
+}
+
+func Test_matchBuyOrders_BlockedTrade(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	tx := &mock_database.Tx{}
+	purchaseRepo := &mock_repositories.MockPurchaseRepo{}
+	buyOrderRepo := &mock_repositories.MockBuyOrderRepo{}
+	accountRepo := &mock_repositories.MockAccountRepo{}
+	restrictionRepo := &mock_repositories.MockAccountRestrictionRepo{}
+	spendLimitRepo := &mock_repositories.MockSpendLimitRepo{}
+	tradeAlertRepo := &mock_repositories.MockTradeAlertRepo{}
+	wager := &entities.Wager{
+		WagerID:             database.Int4(1),
+		SellerID:            database.Text("seller-1"),
+		MarketID:            pgtype.Int4{Status: pgtype.Null},
+		SellingPrice:        database.Float4(50),
+		CurrentSellingPrice: database.Float4(30),
+		AmountSold:          database.Float4(0),
+	}
+	now := time.Now()
+	buyOrderRepo.On("ListMatching", mock.Anything, tx, database.Int4(1), database.Float4(30), database.Timestamptz(now)).Once().Return([]*entities.BuyOrder{
+		{BuyOrderID: database.Int4(7), BuyerID: database.Text("seller-1"), LimitPrice: database.Float4(35)},
+	}, nil)
+	// the cancel of the blocked order is kept with its savepoint
+	tx.On("Begin", mock.Anything).Once().Return(tx, nil)
+	tx.On("Commit", mock.Anything).Once().Return(nil)
+	accountRepo.On("Get", mock.Anything, tx, mock.Anything).Once().Return(nil, pgx.ErrNoRows)
+	restrictionRepo.On("ListActive", mock.Anything, tx, mock.Anything, database.Timestamptz(now)).Once().Return([]*entities.AccountRestriction{}, nil)
+	spendLimitRepo.On("LockAccount", mock.Anything, tx, mock.Anything).Once().Return(nil)
+	spendLimitRepo.On("ListCurrent", mock.Anything, tx, mock.Anything, database.Timestamptz(now)).Once().Return([]*entities.SpendLimit{}, nil)
+	tradeAlertRepo.On("Create", mock.Anything, tx, mock.MatchedBy(func(alert *entities.TradeAlert) bool {
+		return alert.Rule.String == entities.TradeAlertRuleSelfTrade && alert.Blocked.Bool && alert.PurchaseID.Status == pgtype.Null
+	})).Once().Return(nil)
+	buyOrderRepo.On("Cancel", mock.Anything, tx, database.Int4(7), database.Timestamptz(now)).Once().Return(pgconn.CommandTag("UPDATE 1"), nil)
+
+	s := &WagerService{
+		PurchaseRepo:           purchaseRepo,
+		BuyOrderRepo:           buyOrderRepo,
+		AccountRepo:            accountRepo,
+		AccountRestrictionRepo: restrictionRepo,
+		SpendLimitRepo:         spendLimitRepo,
+		Surveillance:           &SurveillanceRules{SelfTrade: SurveillanceRule{Enabled: true, Block: true}},
+		TradeAlertRepo:         tradeAlertRepo,
+	}
+	assert.NoError(t, s.matchBuyOrders(ctx, tx, wager, now))
+	assert.Equal(t, float32(0), wager.AmountSold.Float)
+	purchaseRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+	buyOrderRepo.AssertExpectations(t)
+	tradeAlertRepo.AssertExpectations(t)
+}
+
+func Test_newAccountLink(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	link, err := newAccountLink(&models.LinkAccountsRequest{AccountID: "b", LinkedAccountID: " a ", Reason: "same card"}, now)
+	assert.NoError(t, err)
+	assert.Equal(t, "a", link.AccountID.String)
+	assert.Equal(t, "b", link.LinkedAccountID.String)
+	assert.Equal(t, "same card", link.Reason.String)
+	_, err = newAccountLink(&models.LinkAccountsRequest{AccountID: "a", LinkedAccountID: "a"}, now)
+	assert.EqualError(t, err, "an account can't be linked to itself")
+	_, err = newAccountLink(&models.LinkAccountsRequest{AccountID: "a"}, now)
+	assert.Error(t, err)
+}
+
+func Test_ListTradeAlerts(t *testing.T) {
+	t.Parallel()
+	db := &mock_database.Ext{}
+	tradeAlertRepo := &mock_repositories.MockTradeAlertRepo{}
+	filter := repositories.TradeAlertFilter{
+		Rule:      database.Text(entities.TradeAlertRuleSelfTrade),
+		WagerID:   pgtype.Int4{Status: pgtype.Null},
+		AccountID: database.Text("buyer-1"),
+		Blocked:   pgtype.Bool{Bool: true, Status: pgtype.Present},
+	}
+	tradeAlertRepo.On("List", mock.Anything, db, filter, database.Int4(40), uint32(10)).Once().Return([]*entities.TradeAlert{
+		{
+			AlertID: database.Int4(3), Rule: database.Text(entities.TradeAlertRuleSelfTrade), WagerID: database.Int4(1),
+			PurchaseID: pgtype.Int4{Status: pgtype.Null}, BuyerID: database.Text("buyer-1"), SellerID: database.Text("seller-1"),
+			BuyingPrice: database.Float4(20), Details: pgtype.JSONB{Bytes: []byte(`{"reason":"the buyer is the seller"}`), Status: pgtype.Present},
+			Blocked: pgtype.Bool{Bool: true, Status: pgtype.Present}, CreatedAt: database.Timestamptz(time.Now()),
+		},
+	}, nil)
+	mux := chi.NewMux()
+	NewSurveillanceHandler(mux, &SurveillanceService{DB: db, TradeAlertRepo: tradeAlertRepo}, []string{"admin-token"})
+
+	for _, url := range []string{"/admin/trade-alerts?rule=spoofing", "/admin/trade-alerts?blocked=maybe", "/admin/trade-alerts?limit=1000"} {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		req.Header.Set("Authorization", "Bearer admin-token")
+		mux.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, url)
+	}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/admin/trade-alerts?rule=self_trade&account_id=buyer-1&blocked=true&before_id=40&limit=10", nil)
+	req.Header.Set("Authorization", "Bearer admin-token")
+	mux.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	alerts := []*models.TradeAlert{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&alerts))
+	if assert.Len(t, alerts, 1) {
+		assert.Equal(t, 3, alerts[0].ID)
+		assert.Nil(t, alerts[0].PurchaseID)
+		assert.True(t, alerts[0].Blocked)
+		assert.JSONEq(t, `{"reason":"the buyer is the seller"}`, string(alerts[0].Details))
+	}
+	tradeAlertRepo.AssertExpectations(t)
+}
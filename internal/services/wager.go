@@ -2,10 +2,10 @@ package services
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
-	"net/http"
+	"strings"
 	"time"
 
 	"github.com/wager-api/internal/entities"
@@ -19,231 +19,707 @@ import (
 	"go.uber.org/multierr"
 )
 
+// maxSellerIDLength bounds the seller_id of a wager, it is an opaque id of the seller
+// in the systems upstream
+const maxSellerIDLength = 64
+
+const (
+	// PlaceBatchAtomic and PlaceBatchBestEffort are the modes of PlaceBatch
+	PlaceBatchAtomic     = "atomic"
+	PlaceBatchBestEffort = "best_effort"
+
+	maxWagerBatchSize       = 1000
+	placeBatchStatusPlaced  = "placed"
+	placeBatchStatusFailed  = "failed"
+	placeBatchStatusAborted = "aborted"
+)
+
 type WagerService struct {
 	DB        database.Ext
 	WagerRepo interface {
 		Create(ctx context.Context, db database.Ext, wager *entities.Wager) error
+		CreateBatch(ctx context.Context, db database.Ext, wagers []*entities.Wager) error
 		Update(ctx context.Context, db database.Ext, wager *entities.Wager) (pgconn.CommandTag, error)
+		Reprice(ctx context.Context, db database.Ext, wager *entities.Wager) (pgconn.CommandTag, error)
 		Get(ctx context.Context, db database.Ext, wagerID pgtype.Int4, queryEnhancers ...repositories.QueryEnhancer) (*entities.Wager, error)
-		List(ctx context.Context, db database.Ext, lastID pgtype.Int4, limit uint32) ([]*entities.Wager, error)
+		List(ctx context.Context, db database.Ext, filter repositories.WagerFilter, lastID pgtype.Int4, offset, limit uint32) ([]*entities.Wager, error)
 	}
 	PurchaseRepo interface {
 		Create(ctx context.Context, db database.Ext, purchase *entities.Purchase) error
+		SumVolume(ctx context.Context, db database.Ext, buyerID pgtype.Text, since pgtype.Timestamptz) (pgtype.Float4, error)
+		SumSpend(ctx context.Context, db database.Ext, buyerID pgtype.Text, since pgtype.Timestamptz) ([]*repositories.CurrencySpend, error)
+	}
+	MarketRepo interface {
+		Get(ctx context.Context, db database.Ext, marketID pgtype.Int4, queryEnhancers ...repositories.QueryEnhancer) (*entities.Market, error)
+	}
+	EventRepo interface {
+		Get(ctx context.Context, db database.Ext, eventID pgtype.Int4, queryEnhancers ...repositories.QueryEnhancer) (*entities.Event, error)
+	}
+	PriceHistoryRepo interface {
+		Create(ctx context.Context, db database.Ext, priceHistory *entities.WagerPriceHistory) error
+		List(ctx context.Context, db database.Ext, wagerID pgtype.Int4, from, to pgtype.Timestamptz) ([]*entities.WagerPriceHistory, error)
+		ListCandles(ctx context.Context, db database.Ext, wagerID pgtype.Int4, from, to pgtype.Timestamptz, interval time.Duration) ([]*repositories.PriceCandle, error)
+		DeleteBefore(ctx context.Context, db database.Ext, before pgtype.Timestamptz) (pgconn.CommandTag, error)
+	}
+	WagerUpdateRepo interface {
+		Create(ctx context.Context, db database.Ext, update *entities.WagerUpdate) error
+		ListAfter(ctx context.Context, db database.Ext, lastID pgtype.Int8, wagerID pgtype.Int4, limit uint32) ([]*entities.WagerUpdate, error)
+	}
+	OutboxRepo interface {
+		Create(ctx context.Context, db database.Ext, message *entities.OutboxMessage) error
+	}
+	Stream       *WagerStream
+	AuditLogRepo interface {
+		Create(ctx context.Context, db database.Ext, auditLog *entities.AuditLog) error
+	}
+	// Webhooks notifies the sellers when their wagers are bought, nil notifies nobody
+	Webhooks     *WebhookService
+	BuyOrderRepo interface {
+		Create(ctx context.Context, db database.Ext, buyOrder *entities.BuyOrder) error
+		Get(ctx context.Context, db database.Ext, buyOrderID pgtype.Int4, queryEnhancers ...repositories.QueryEnhancer) (*entities.BuyOrder, error)
+		ListMatching(ctx context.Context, db database.Ext, wagerID pgtype.Int4, price pgtype.Float4, now pgtype.Timestamptz) ([]*entities.BuyOrder, error)
+		Fill(ctx context.Context, db database.Ext, buyOrderID, purchaseID pgtype.Int4, filledAt pgtype.Timestamptz) (pgconn.CommandTag, error)
+		Cancel(ctx context.Context, db database.Ext, buyOrderID pgtype.Int4, now pgtype.Timestamptz) (pgconn.CommandTag, error)
+		ExpireDue(ctx context.Context, db database.Ext, now pgtype.Timestamptz) (pgconn.CommandTag, error)
+		ListDecayingWagerIDs(ctx context.Context, db database.Ext, now pgtype.Timestamptz) ([]int32, error)
+	}
+	StakeHoldingRepo interface {
+		Add(ctx context.Context, db database.Ext, holding *entities.StakeHolding) error
+		Get(ctx context.Context, db database.Ext, purchaseID pgtype.Int4, holderID pgtype.Text, queryEnhancers ...repositories.QueryEnhancer) (*entities.StakeHolding, error)
+		Reduce(ctx context.Context, db database.Ext, holdingID pgtype.Int4, stake pgtype.Float4, now pgtype.Timestamptz) (pgconn.CommandTag, error)
+	}
+	ResaleListingRepo interface {
+		Create(ctx context.Context, db database.Ext, listing *entities.ResaleListing) error
+		Get(ctx context.Context, db database.Ext, listingID pgtype.Int4, queryEnhancers ...repositories.QueryEnhancer) (*entities.ResaleListing, error)
+		SumOpenStake(ctx context.Context, db database.Ext, purchaseID pgtype.Int4, sellerID pgtype.Text) (pgtype.Float4, error)
+		Sell(ctx context.Context, db database.Ext, listingID pgtype.Int4, buyerID pgtype.Text, soldAt pgtype.Timestamptz) (pgconn.CommandTag, error)
+		Cancel(ctx context.Context, db database.Ext, listingID pgtype.Int4, now pgtype.Timestamptz) (pgconn.CommandTag, error)
+	}
+	StakeTransferRepo interface {
+		Create(ctx context.Context, db database.Ext, transfer *entities.StakeTransfer) error
+	}
+	PositionRepo interface {
+		ListByHolder(ctx context.Context, db database.Ext, holderID pgtype.Text, offset, limit uint32) ([]*repositories.Position, error)
+		SumByHolder(ctx context.Context, db database.Ext, holderID pgtype.Text, now pgtype.Timestamptz) ([]*repositories.PositionTotals, error)
 	}
+	// Fees is the fee model of the purchases, nil charges nothing
+	Fees            *FeeModel
+	PurchaseFeeRepo interface {
+		Create(ctx context.Context, db database.Ext, fee *entities.PurchaseFee) error
+	}
+	AccountRepo interface {
+		Upsert(ctx context.Context, db database.Ext, account *entities.Account) error
+		Get(ctx context.Context, db database.Ext, accountID pgtype.Text) (*entities.Account, error)
+	}
+	ExchangeRateRepo interface {
+		GetEffective(ctx context.Context, db database.Ext, baseCurrency, quoteCurrency pgtype.Text, at pgtype.Timestamptz) (*entities.ExchangeRate, error)
+	}
+	// BaseCurrency is the currency of the wagers placed without one, USD when it is empty
+	BaseCurrency string
+	// Risk are the exposure limits of the sellers and the markets, nil enforces none
+	Risk     *RiskLimits
+	RiskRepo interface {
+		LockSeller(ctx context.Context, db database.Ext, sellerID pgtype.Text) error
+		SumSellerExposure(ctx context.Context, db database.Ext, sellerID pgtype.Text) ([]*repositories.CurrencyAmount, error)
+		SumMarketVolume(ctx context.Context, db database.Ext, marketID pgtype.Int4) ([]*repositories.CurrencyAmount, error)
+	}
+	// SpendLimitIncreaseDelay delays the increases and the removals of the spend limits,
+	// 24h when it isn't positive
+	SpendLimitIncreaseDelay time.Duration
+	// RequireBuyer refuses the purchases without a buyer_id, which no restriction and no
+	// spend limit applies to
+	RequireBuyer   bool
+	SpendLimitRepo interface {
+		LockAccount(ctx context.Context, db database.Ext, accountID pgtype.Text) error
+		Create(ctx context.Context, db database.Ext, limit *entities.SpendLimit) error
+		DeletePending(ctx context.Context, db database.Ext, accountID, period pgtype.Text, now pgtype.Timestamptz) (pgconn.CommandTag, error)
+		ListCurrent(ctx context.Context, db database.Ext, accountID pgtype.Text, now pgtype.Timestamptz) ([]*entities.SpendLimit, error)
+	}
+	AccountRestrictionRepo interface {
+		Create(ctx context.Context, db database.Ext, restriction *entities.AccountRestriction) error
+		ListActive(ctx context.Context, db database.Ext, accountIDs []string, now pgtype.Timestamptz) ([]*entities.AccountRestriction, error)
+	}
+	// Surveillance are the rules run on the purchases, nil runs none
+	Surveillance     *SurveillanceRules
+	SurveillanceRepo interface {
+		AreLinked(ctx context.Context, db database.Ext, accountID, otherAccountID pgtype.Text) (bool, error)
+		CountResales(ctx context.Context, db database.Ext, wagerID pgtype.Int4, holderID pgtype.Text, since pgtype.Timestamptz) (int64, error)
+		ListRecentPurchases(ctx context.Context, db database.Ext, wagerID pgtype.Int4, since pgtype.Timestamptz, limit uint32) ([]*repositories.RecentPurchase, error)
+	}
+	TradeAlertRepo interface {
+		Create(ctx context.Context, db database.Ext, alert *entities.TradeAlert) error
+	}
+	// FairPriceSpread is how far above the expected value of a wager, in percent, its
+	// price is still quoted as fair, 10 when it isn't positive
+	FairPriceSpread float64
+}
+
+// checkWagerSalesOpen makes sure the market of a wager still accepts sales: the market
+// must be open and its event must not have started yet.
+func (s *WagerService) checkWagerSalesOpen(ctx context.Context, db database.Ext, marketID pgtype.Int4, now time.Time) error {
+	if marketID.Status != pgtype.Present {
+		return nil
+	}
+	market, err := s.MarketRepo.Get(ctx, db, marketID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return errMarketNotFound
+		}
+		return fmt.Errorf("unable to get market information")
+	}
+	if market.Status.String != entities.MarketStatusOpen {
+		return errWagerSalesClosed
+	}
+	event, err := s.EventRepo.Get(ctx, db, market.EventID)
+	if err != nil {
+		return fmt.Errorf("unable to get event information")
+	}
+	if event.Status.String != entities.EventStatusScheduled || !event.StartAt.Time.After(now) {
+		return errWagerSalesClosed
+	}
+	return nil
+}
+
+// validateSellingPrice checks the selling price of a wager of totalWagerValue selling
+// sellingPercentage of it.
+func validateSellingPrice(sellingPrice, totalWagerValue float32, sellingPercentage int) error {
+	tempSellingPriceNumber := sellingPrice * 100
+	if sellingPrice <= 0 || tempSellingPriceNumber-float32(int(tempSellingPriceNumber)) > 0 {
+		return fmt.Errorf("the selling_price must be a positive decimal value to two decimal places")
+	}
+	if sellingPrice <= totalWagerValue*float32(sellingPercentage)/100 {
+		return fmt.Errorf("selling_price must be greater than total_wager_value * (selling_percentage / 100)")
+	}
+	return nil
 }
 
 func validatePlaceWagerReq(req *models.PlaceWagerRequest) error {
 	if req.TotalWagerValue <= 0 {
 		return fmt.Errorf("the total_wager_value must be a positive integer above 0")
 	}
-	if req.Odds <= 0 {
-		return fmt.Errorf("the odds must be a positive integer above 0")
+	oddsFormat, err := models.ParseOddsFormat(req.OddsFormat)
+	if err != nil {
+		return err
+	}
+	if _, err := req.Odds.ToDecimal(oddsFormat); err != nil {
+		return err
 	}
 	if req.SellingPercentage < 1 || req.SellingPercentage > 100 {
 		return fmt.Errorf("the selling_percentage must be specified as an integer between 1 and 100")
 	}
-	tempSellingPriceNumber := req.SellingPrice * 100
-	if req.SellingPrice <= 0 || tempSellingPriceNumber-float32(int(tempSellingPriceNumber)) > 0 {
-		return fmt.Errorf("the selling_price must be a positive decimal value to two decimal places")
+	if err := validateSellingPrice(req.SellingPrice, req.TotalWagerValue, req.SellingPercentage); err != nil {
+		return err
 	}
-	if req.SellingPrice <= req.TotalWagerValue*float32(req.SellingPercentage)/100 {
-		return fmt.Errorf("selling_price must be greater than total_wager_value * (selling_percentage / 100)")
+	if req.MarketID != nil && *req.MarketID <= 0 {
+		return fmt.Errorf("the market_id must be a positive integer")
+	}
+	if len(req.SellerID) > maxSellerIDLength {
+		return fmt.Errorf("the seller_id must be at most %d characters long", maxSellerIDLength)
+	}
+	if req.PriceDecay != nil {
+		if err := validatePriceDecay(req.PriceDecay, req.SellingPrice); err != nil {
+			return err
+		}
+	}
+	if req.Currency != "" {
+		if _, err := ParseCurrency(req.Currency); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
-func (s *WagerService) PlaceWager(resp http.ResponseWriter, req *http.Request) {
-	placeWagerRequest := &models.PlaceWagerRequest{}
-	err := json.NewDecoder(req.Body).Decode(&placeWagerRequest)
-	defer req.Body.Close()
-	if err != nil {
-		resp.WriteHeader(http.StatusInternalServerError)
-		_ = json.NewEncoder(resp).Encode(map[string]string{
-			"error": "unable to parse request",
-		})
-		return
-	}
+
+// prepareWager validates a request and builds its wager, the market of the wager must
+// accept sales. The outcome of the market checks is kept in salesChecks when it is not
+// nil, for the wagers of a batch placed on the same market.
+func (s *WagerService) prepareWager(ctx context.Context, placeWagerRequest *models.PlaceWagerRequest, now time.Time, salesChecks map[int32]error) (*entities.Wager, models.OddsFormat, error) {
 	if err := validatePlaceWagerReq(placeWagerRequest); err != nil {
-		resp.WriteHeader(http.StatusBadRequest)
-		_ = json.NewEncoder(resp).Encode(map[string]string{
-			"error": err.Error(),
-		})
-		return
+		return nil, "", &validationError{err}
 	}
-	ctx := req.Context()
+	// both have been checked by validatePlaceWagerReq
+	oddsFormat, _ := models.ParseOddsFormat(placeWagerRequest.OddsFormat)
+	oddsDecimal, _ := placeWagerRequest.Odds.ToDecimal(oddsFormat)
 
 	wager := &entities.Wager{}
-	now := time.Now()
 	database.AllNullEntity(wager)
-	if err = multierr.Combine(
+	if placeWagerRequest.MarketID != nil {
+		_ = wager.MarketID.Set(*placeWagerRequest.MarketID)
+	}
+	if sellerID := strings.TrimSpace(placeWagerRequest.SellerID); sellerID != "" {
+		_ = wager.SellerID.Set(sellerID)
+	}
+	err, checked := salesChecks[wager.MarketID.Int]
+	if !checked || wager.MarketID.Status != pgtype.Present {
+		err = s.checkWagerSalesOpen(ctx, s.DB, wager.MarketID, now)
+		if salesChecks != nil && wager.MarketID.Status == pgtype.Present {
+			salesChecks[wager.MarketID.Int] = err
+		}
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to place wager: %w", err)
+	}
+	currency := baseCurrency(s.BaseCurrency)
+	if placeWagerRequest.Currency != "" {
+		// checked by validatePlaceWagerReq
+		currency, _ = ParseCurrency(placeWagerRequest.Currency)
+	}
+	if err := multierr.Combine(
 		wager.TotalWagerValue.Set(placeWagerRequest.TotalWagerValue),
-		wager.Odds.Set(placeWagerRequest.Odds),
+		wager.Odds.Set(oddsDecimal),
 		wager.SellingPercentage.Set(placeWagerRequest.SellingPercentage),
 		wager.SellingPrice.Set(placeWagerRequest.SellingPrice),
 		wager.CurrentSellingPrice.Set(placeWagerRequest.SellingPrice),
 		wager.PlaceAt.Set(now),
 		wager.CreatedAt.Set(now),
 		wager.UpdatedAt.Set(now),
+		wager.Currency.Set(currency),
 	); err != nil {
-		resp.WriteHeader(http.StatusBadRequest)
-		_ = json.NewEncoder(resp).Encode(map[string]string{
-			"error": "unable to generate value for wager",
-		})
-		return
-	}
-	if err := s.WagerRepo.Create(ctx, s.DB, wager); err != nil {
-		resp.WriteHeader(http.StatusInternalServerError)
-		_ = json.NewEncoder(resp).Encode(map[string]string{
-			"error": "unable to create wager",
-		})
-		return
-	}
-	resp.WriteHeader(http.StatusCreated)
-	_ = json.NewEncoder(resp).Encode(convertWagerPg2placeWagerResponse(wager))
+		return nil, "", &validationError{fmt.Errorf("unable to generate value for wager")}
+	}
+	if placeWagerRequest.PriceDecay != nil {
+		if err := setPriceDecay(wager, placeWagerRequest.PriceDecay); err != nil {
+			return nil, "", &validationError{fmt.Errorf("unable to generate value for wager")}
+		}
+	}
+	return wager, oddsFormat, nil
 }
-func convertWagerPg2placeWagerResponse(wager *entities.Wager) *models.PlaceWagerResponse {
-	var placedAt *time.Time
-	if wager.PlaceAt.Status == pgtype.Present {
-		placedAt = &wager.PlaceAt.Time
+
+// recordWagerPlaced writes in tx what follows the creation of a wager: the first point of
+// its price history, the update for its subscribers, the outbox event and the audit entry.
+func (s *WagerService) recordWagerPlaced(ctx context.Context, tx database.Ext, wager *entities.Wager, now time.Time) error {
+	if err := s.recordPrice(ctx, tx, wager, now); err != nil {
+		return err
 	}
-	return &models.PlaceWagerResponse{
-		ID:                  int(wager.WagerID.Int),
-		TotalWagerValue:     wager.TotalWagerValue.Float,
-		Odds:                int(wager.Odds.Int),
-		SellingPercentage:   int(wager.SellingPercentage.Int),
-		SellingPrice:        wager.SellingPrice.Float,
-		CurrentSellingPrice: wager.CurrentSellingPrice.Float,
-		PercentageSold:      wager.PercentageSold.Float,
-		AmountSold:          wager.AmountSold.Float,
-		PlacedAt:            placedAt,
+	placedWager := convertWagerPg2Domain(wager, models.OddsFormatDecimal)
+	if err := s.addWagerUpdate(ctx, tx, entities.WagerUpdateWagerPlaced, wager.WagerID, placedWager); err != nil {
+		return err
+	}
+	message, err := newOutboxMessage(entities.OutboxWagerPlaced, wager.WagerID, placedWager, now)
+	if err != nil {
+		return err
+	}
+	if err := s.OutboxRepo.Create(ctx, tx, message); err != nil {
+		return err
+	}
+	after, err := snapshotEntity(wager)
+	if err != nil {
+		return err
+	}
+	return s.AuditLogRepo.Create(ctx, tx, newAuditLog(ctx, entities.AuditActionWagerPlace, wager.WagerID, pgtype.Int4{Status: pgtype.Null}, pgtype.JSONB{Status: pgtype.Null}, after, now))
+}
+
+// checkPlacements checks the wagers about to be placed in tx: their seller must not be
+// restricted and must stay within the exposure limit along the wagers accepted before.
+// The reasons of the rejected wagers are returned at their index.
+func (s *WagerService) checkPlacements(ctx context.Context, tx database.Ext, wagers []*entities.Wager, now time.Time) ([]error, error) {
+	rejections, err := s.checkSellerRestrictions(ctx, tx, wagers, now)
+	if err != nil {
+		return nil, err
+	}
+	indexes, allowed := make([]int, 0, len(wagers)), make([]*entities.Wager, 0, len(wagers))
+	for i, wager := range wagers {
+		if rejections[i] == nil {
+			indexes, allowed = append(indexes, i), append(allowed, wager)
+		}
+	}
+	exposureRejections, err := s.checkSellerExposures(ctx, tx, allowed, now)
+	if err != nil {
+		return nil, err
+	}
+	for i, rejection := range exposureRejections {
+		rejections[indexes[i]] = rejection
+	}
+	return rejections, nil
+}
+
+// Place validates and creates a wager, the wager is rendered in the odds format of
+// the command. The seller of the wager must not be restricted and must stay within the
+// exposure limit.
+func (s *WagerService) Place(ctx context.Context, placeWagerRequest *models.PlaceWagerRequest) (*models.Wager, error) {
+	now := time.Now()
+	wager, oddsFormat, err := s.prepareWager(ctx, placeWagerRequest, now, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := database.ExecInTx(ctx, s.DB, func(ctx context.Context, tx pgx.Tx) error {
+		rejections, err := s.checkPlacements(ctx, tx, []*entities.Wager{wager}, now)
+		if err != nil {
+			return err
+		}
+		if rejections[0] != nil {
+			return rejections[0]
+		}
+		if err := s.WagerRepo.Create(ctx, tx, wager); err != nil {
+			return err
+		}
+		return s.recordWagerPlaced(ctx, tx, wager, now)
+	}); err != nil {
+		if errors.Is(err, errRiskLimitExceeded) || errors.Is(err, errAccountRestricted) {
+			return nil, fmt.Errorf("unable to place wager: %w", err)
+		}
+		return nil, fmt.Errorf("unable to create wager")
+	}
+	return convertWagerPg2Domain(wager, oddsFormat), nil
+}
+
+// PlaceBatch places many wagers with a single insert. In the atomic mode nothing is
+// placed unless every wager is valid, the error is then the one of the first invalid
+// wager; in the best effort mode the valid wagers are placed and the others reported.
+// The results are in the order of the requests either way. The wagers which would take
+// their seller over the exposure limit or whose seller is restricted are invalid too,
+// checked in the transaction.
+func (s *WagerService) PlaceBatch(ctx context.Context, placeWagerRequests []*models.PlaceWagerRequest, mode string) (*models.PlaceWagerBatchResponse, error) {
+	if mode == "" {
+		mode = PlaceBatchAtomic
+	}
+	if mode != PlaceBatchAtomic && mode != PlaceBatchBestEffort {
+		return nil, &validationError{fmt.Errorf("the mode must be one of %s, %s", PlaceBatchAtomic, PlaceBatchBestEffort)}
+	}
+	if len(placeWagerRequests) == 0 || len(placeWagerRequests) > maxWagerBatchSize {
+		return nil, &validationError{fmt.Errorf("the batch must hold between 1 and %d wagers", maxWagerBatchSize)}
+	}
+
+	type pendingWager struct {
+		result     *models.PlaceWagerBatchResult
+		wager      *entities.Wager
+		oddsFormat models.OddsFormat
+	}
+	now := time.Now()
+	response := &models.PlaceWagerBatchResponse{
+		Mode:    mode,
+		Results: make([]*models.PlaceWagerBatchResult, 0, len(placeWagerRequests)),
+	}
+	pendings := make([]*pendingWager, 0, len(placeWagerRequests))
+	salesChecks := map[int32]error{}
+	var firstErr error
+	for i, placeWagerRequest := range placeWagerRequests {
+		result := &models.PlaceWagerBatchResult{Index: i}
+		response.Results = append(response.Results, result)
+		if placeWagerRequest == nil {
+			placeWagerRequest = &models.PlaceWagerRequest{}
+		}
+		wager, oddsFormat, err := s.prepareWager(ctx, placeWagerRequest, now, salesChecks)
+		if err != nil {
+			result.Status = placeBatchStatusFailed
+			result.Error = err.Error()
+			response.Failed++
+			if firstErr == nil {
+				firstErr = fmt.Errorf("wager %d: %w", i, err)
+			}
+			continue
+		}
+		pendings = append(pendings, &pendingWager{result: result, wager: wager, oddsFormat: oddsFormat})
+	}
+	if mode == PlaceBatchAtomic && firstErr != nil {
+		for _, pending := range pendings {
+			pending.result.Status = placeBatchStatusAborted
+		}
+		return response, firstErr
+	}
+	if len(pendings) == 0 {
+		return response, nil
+	}
+
+	var placed []*pendingWager
+	if err := database.ExecInTx(ctx, s.DB, func(ctx context.Context, tx pgx.Tx) error {
+		wagers := make([]*entities.Wager, 0, len(pendings))
+		for _, pending := range pendings {
+			wagers = append(wagers, pending.wager)
+		}
+		rejections, err := s.checkPlacements(ctx, tx, wagers, now)
+		if err != nil {
+			return err
+		}
+		placed, wagers = make([]*pendingWager, 0, len(pendings)), wagers[:0]
+		for i, pending := range pendings {
+			if rejections[i] == nil {
+				placed, wagers = append(placed, pending), append(wagers, pending.wager)
+				continue
+			}
+			pending.result.Status = placeBatchStatusFailed
+			pending.result.Error = rejections[i].Error()
+			response.Failed++
+			if firstErr == nil {
+				firstErr = fmt.Errorf("wager %d: %w", pending.result.Index, rejections[i])
+			}
+		}
+		if mode == PlaceBatchAtomic && firstErr != nil {
+			return firstErr
+		}
+		if len(wagers) == 0 {
+			return nil
+		}
+		if err := s.WagerRepo.CreateBatch(ctx, tx, wagers); err != nil {
+			return err
+		}
+		for _, wager := range wagers {
+			if err := s.recordWagerPlaced(ctx, tx, wager, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		if errors.Is(err, errRiskLimitExceeded) || errors.Is(err, errAccountRestricted) {
+			for _, pending := range placed {
+				pending.result.Status = placeBatchStatusAborted
+			}
+			return response, err
+		}
+		for _, pending := range pendings {
+			if pending.result.Status == "" {
+				pending.result.Status = placeBatchStatusFailed
+				pending.result.Error = "unable to create wager"
+				response.Failed++
+			}
+		}
+		return response, fmt.Errorf("unable to create wagers")
 	}
+	for _, pending := range placed {
+		pending.result.Status = placeBatchStatusPlaced
+		pending.result.Wager = (*models.PlaceWagerResponse)(convertWagerPg2Domain(pending.wager, pending.oddsFormat))
+		response.Placed++
+	}
+	return response, nil
 }
 
-func convertWagerPg2Domain(wager *entities.Wager) *models.Wager {
+func convertWagerPg2Domain(wager *entities.Wager, oddsFormat models.OddsFormat) *models.Wager {
 	var placedAt *time.Time
 	if wager.PlaceAt.Status == pgtype.Present {
 		placedAt = &wager.PlaceAt.Time
 	}
+	var marketID *int
+	if wager.MarketID.Status == pgtype.Present {
+		id := int(wager.MarketID.Int)
+		marketID = &id
+	}
 	return &models.Wager{
 		ID:                  int(wager.WagerID.Int),
+		MarketID:            marketID,
+		SellerID:            wager.SellerID.String,
 		TotalWagerValue:     wager.TotalWagerValue.Float,
-		Odds:                int(wager.Odds.Int),
+		Odds:                models.FormatOdds(wager.Odds.Float, oddsFormat),
+		OddsFormat:          oddsFormat,
 		SellingPercentage:   int(wager.SellingPercentage.Int),
 		SellingPrice:        wager.SellingPrice.Float,
 		CurrentSellingPrice: wager.CurrentSellingPrice.Float,
 		PercentageSold:      wager.PercentageSold.Float,
 		AmountSold:          wager.AmountSold.Float,
 		PlacedAt:            placedAt,
+		PriceDecay:          convertPriceDecayPg2Domain(wager),
+		Currency:            wager.Currency.String,
 	}
 }
 
-func validateBuyWagerReq(req *models.BuyWagerRequest) error {
+func validateBuyWagerReq(req *models.BuyWagerCommand) error {
+	if req.WagerID <= 0 {
+		return fmt.Errorf("the wager_id must be a positive integer")
+	}
 	if req.BuyingPrice <= 0 {
 		return fmt.Errorf("the buying_price must be a positive decimal")
 	}
+	if len(req.BuyerID) > maxSellerIDLength {
+		return fmt.Errorf("the buyer_id must be at most %d characters long", maxSellerIDLength)
+	}
 	return nil
 }
-func (s *WagerService) BuyWager(resp http.ResponseWriter, req *http.Request) {
-	buyWagerRequest := &models.BuyWagerRequest{}
-	err := json.NewDecoder(req.Body).Decode(&buyWagerRequest)
-	defer req.Body.Close()
-	if err != nil {
-		resp.WriteHeader(http.StatusInternalServerError)
-		_ = json.NewEncoder(resp).Encode(map[string]string{
-			"error": "unable to parse request",
-		})
-		return
-	}
-	if err := validateBuyWagerReq(buyWagerRequest); err != nil {
-		resp.WriteHeader(http.StatusBadRequest)
-		_ = json.NewEncoder(resp).Encode(map[string]string{
-			"error": err.Error(),
-		})
-		return
-	}
 
-	ctx := req.Context()
-	var wagerID int
-	if wagerIDRaw, ok := ctx.Value("wager_id").(int); ok {
-		wagerID = wagerIDRaw
-	} else {
-		resp.WriteHeader(http.StatusBadRequest)
-		_ = json.NewEncoder(resp).Encode(map[string]string{
-			"error": "wager_id wrong format",
-		})
-		return
+// Buy buys a wager at buying price, the wager is locked for the whole transaction
+// so concurrent purchases are applied one after another. The buying price is checked
+// against the decayed price of the wager, the purchase stores it as the new price.
+func (s *WagerService) Buy(ctx context.Context, buyWagerCommand *models.BuyWagerCommand) (*models.BuyWagerResponse, error) {
+	if err := validateBuyWagerReq(buyWagerCommand); err != nil {
+		return nil, &validationError{err}
 	}
-	purchaseRecord := &entities.Purchase{}
-	database.AllNullEntity(purchaseRecord)
-	//TODO: validate req
+	if s.RequireBuyer && strings.TrimSpace(buyWagerCommand.BuyerID) == "" {
+		return nil, &validationError{fmt.Errorf("the buyer_id must be given")}
+	}
+	wagerID := buyWagerCommand.WagerID
+	var purchaseRecord *entities.Purchase
+	var fees []*entities.PurchaseFee
 	if err := database.ExecInTx(ctx, s.DB, func(ctx context.Context, tx pgx.Tx) error {
 		wager, err := s.WagerRepo.Get(ctx, tx, database.Int4(int32((wagerID))), repositories.WithUpdateLock())
 		if err != nil {
 			if err == pgx.ErrNoRows {
-				return fmt.Errorf("unable to get wager information: not found")
+				return errWagerNotFound
 			}
 			return fmt.Errorf("unable to get wager information")
 		}
-		if buyWagerRequest.BuyingPrice > wager.CurrentSellingPrice.Float {
+		now := time.Now()
+		applyPriceDecay(wager, now)
+		if buyWagerCommand.BuyingPrice > wager.CurrentSellingPrice.Float {
 			return fmt.Errorf("unable to execute: buying_price must be lesser or equal to current_selling_price")
 		}
-		now := time.Now()
+		if err := s.checkWagerSalesOpen(ctx, tx, wager.MarketID, now); err != nil {
+			return err
+		}
+		var buyerID pgtype.Text
+		_ = buyerID.Set(nil)
+		if trimmed := strings.TrimSpace(buyWagerCommand.BuyerID); trimmed != "" {
+			_ = buyerID.Set(trimmed)
+		}
+		if purchaseRecord, fees, err = s.executePurchase(ctx, tx, wager, buyWagerCommand.BuyingPrice, buyerID, now); err != nil {
+			return err
+		}
+		return s.matchBuyOrders(ctx, tx, wager, now)
+	}); err != nil {
+		s.recordBlockedTrade(ctx, err)
+		return nil, fmt.Errorf("unable to buy wager: %w", err)
+	}
+	if s.Webhooks != nil {
+		s.Webhooks.Notify()
+	}
+	return convert2BuyWagerResponse(purchaseRecord, fees), nil
+}
 
-		if err = multierr.Combine(
-			purchaseRecord.WagerID.Set(wagerID),
-			purchaseRecord.BuyingPrice.Set(buyWagerRequest.BuyingPrice),
-			purchaseRecord.BoughtAt.Set(now),
-			purchaseRecord.CreatedAt.Set(now),
-			purchaseRecord.UpdatedAt.Set(now)); err != nil {
-			return fmt.Errorf("unable to generate new purchase record")
-		}
-		err = s.PurchaseRepo.Create(ctx, tx, purchaseRecord)
-		if err != nil {
-			return fmt.Errorf("unable to create new purchase record")
+// executePurchase buys a wager at buyingPrice for buyerID in tx, the wager must be
+// locked and its sales checked. Along the purchase it records the holding of the buyer,
+// the new price of the wager, the updates of its subscribers, the outbox event, the
+// audit entry, the alerts of the surveillance rules and the deliveries of the webhooks
+// of its seller. The fees charged on the purchase are returned along it. The buyer pays
+// in the currency of their account, nothing is written when no rate converts to it, the
+// purchase goes over a risk limit or a spend limit of the buyer, the buyer is restricted
+// or a surveillance rule blocks the purchase.
+func (s *WagerService) executePurchase(ctx context.Context, tx database.Ext, wager *entities.Wager, buyingPrice float32, buyerID pgtype.Text, now time.Time) (*entities.Purchase, []*entities.PurchaseFee, error) {
+	before, err := snapshotEntity(wager)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to snapshot wager record")
+	}
+	if err := s.checkPurchaseRisk(ctx, tx, wager, buyingPrice, now); err != nil {
+		return nil, nil, err
+	}
+	// the fees are computed first so the volume of the buyer leaves this purchase out
+	fees, err := s.computeFees(ctx, tx, wager, buyingPrice, buyerID, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	purchaseRecord := &entities.Purchase{}
+	database.AllNullEntity(purchaseRecord)
+	if err = multierr.Combine(
+		purchaseRecord.WagerID.Set(wager.WagerID),
+		purchaseRecord.BuyingPrice.Set(buyingPrice),
+		purchaseRecord.BuyerID.Set(buyerID),
+		purchaseRecord.BoughtAt.Set(now),
+		purchaseRecord.CreatedAt.Set(now),
+		purchaseRecord.UpdatedAt.Set(now)); err != nil {
+		return nil, nil, fmt.Errorf("unable to generate new purchase record")
+	}
+	if err = s.convertPurchase(ctx, tx, wager, purchaseRecord, now); err != nil {
+		return nil, nil, err
+	}
+	if err = s.checkBuyerLimits(ctx, tx, buyerID, purchaseRecord.PaidCurrency.String, purchaseSpend(purchaseRecord, fees), now); err != nil {
+		return nil, nil, err
+	}
+	alerts, err := s.evaluateTrade(ctx, tx, wager, purchaseRecord, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = s.PurchaseRepo.Create(ctx, tx, purchaseRecord)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to create new purchase record")
+	}
+	if err = s.recordTradeAlerts(ctx, tx, alerts, purchaseRecord.PurchaseID); err != nil {
+		return nil, nil, err
+	}
+	for _, fee := range fees {
+		fee.PurchaseID = purchaseRecord.PurchaseID
+		if err = s.PurchaseFeeRepo.Create(ctx, tx, fee); err != nil {
+			return nil, nil, fmt.Errorf("unable to create purchase fee record")
 		}
+	}
+	if buyerID.Status == pgtype.Present {
+		holding := &entities.StakeHolding{}
 		if err = multierr.Combine(
-			wager.CurrentSellingPrice.Set(buyWagerRequest.BuyingPrice),
-			wager.AmountSold.Set(wager.AmountSold.Float+buyWagerRequest.BuyingPrice),
-			wager.PercentageSold.Set(roundFloat((wager.AmountSold.Float/wager.SellingPrice.Float)*100)),
-
-			wager.UpdatedAt.Set(now)); err != nil {
-			return fmt.Errorf("unable to generate wager record")
+			holding.PurchaseID.Set(purchaseRecord.PurchaseID),
+			holding.WagerID.Set(wager.WagerID),
+			holding.HolderID.Set(buyerID),
+			holding.Stake.Set(buyingPrice),
+			holding.CreatedAt.Set(now),
+			holding.UpdatedAt.Set(now)); err != nil {
+			return nil, nil, fmt.Errorf("unable to generate stake holding record")
+		}
+		if err = s.StakeHoldingRepo.Add(ctx, tx, holding); err != nil {
+			return nil, nil, fmt.Errorf("unable to create stake holding record")
 		}
+	}
+	previousPrice := wager.CurrentSellingPrice.Float
+	if err = multierr.Combine(
+		wager.CurrentSellingPrice.Set(buyingPrice),
+		wager.AmountSold.Set(wager.AmountSold.Float+buyingPrice),
+		wager.PercentageSold.Set(roundFloat((wager.AmountSold.Float/wager.SellingPrice.Float)*100)),
 
-		cmdTag, err := s.WagerRepo.Update(ctx, tx, wager)
-		if err != nil {
+		wager.UpdatedAt.Set(now)); err != nil {
+		return nil, nil, fmt.Errorf("unable to generate wager record")
+	}
 
-			return fmt.Errorf("unable to update wager record")
-		}
-		if cmdTag.RowsAffected() != 1 {
-			return fmt.Errorf("unable to update wager record: no row affected")
-		}
-		return nil
+	cmdTag, err := s.WagerRepo.Update(ctx, tx, wager)
+	if err != nil {
+
+		return nil, nil, fmt.Errorf("unable to update wager record")
+	}
+	if cmdTag.RowsAffected() != 1 {
+		return nil, nil, fmt.Errorf("unable to update wager record: no row affected")
+	}
+	if err := s.recordPrice(ctx, tx, wager, now); err != nil {
+		return nil, nil, fmt.Errorf("unable to record price history")
+	}
+	if err := s.addWagerUpdate(ctx, tx, entities.WagerUpdatePurchaseMade, wager.WagerID, convert2BuyWagerResponse(purchaseRecord, fees)); err != nil {
+		return nil, nil, fmt.Errorf("unable to publish wager updates")
+	}
+	if err := s.addWagerUpdate(ctx, tx, entities.WagerUpdatePriceChanged, wager.WagerID, &models.PriceChanged{
+		WagerID:             int(wager.WagerID.Int),
+		PreviousPrice:       previousPrice,
+		CurrentSellingPrice: wager.CurrentSellingPrice.Float,
 	}); err != nil {
-		if err.Error() == "unable to get wager information: not found" {
-			resp.WriteHeader(http.StatusNotFound)
-			_ = json.NewEncoder(resp).Encode(map[string]string{
-				"error": fmt.Sprintf("unable to buy wager: %s", err),
-			})
-			return
-		}
-		resp.WriteHeader(http.StatusInternalServerError)
-		_ = json.NewEncoder(resp).Encode(map[string]string{
-			"error": fmt.Sprintf("unable to buy wager: %s", err.Error()),
-		})
-		return
-	}
-	resp.WriteHeader(http.StatusCreated)
-	_ = json.NewEncoder(resp).Encode(convert2BuyWagerResponse(purchaseRecord))
+		return nil, nil, fmt.Errorf("unable to publish wager updates")
+	}
+	message, err := newOutboxMessage(entities.OutboxWagerPurchased, wager.WagerID, convert2BuyWagerResponse(purchaseRecord, fees), now)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to generate outbox record")
+	}
+	if err := s.OutboxRepo.Create(ctx, tx, message); err != nil {
+		return nil, nil, fmt.Errorf("unable to create outbox record")
+	}
+	after, err := snapshotEntity(wager)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to snapshot wager record")
+	}
+	if err := s.AuditLogRepo.Create(ctx, tx, newAuditLog(ctx, entities.AuditActionWagerBuy, wager.WagerID, purchaseRecord.PurchaseID, before, after, now)); err != nil {
+		return nil, nil, fmt.Errorf("unable to create audit log")
+	}
+	if s.Webhooks != nil {
+		if err := s.Webhooks.Enqueue(ctx, tx, wager.SellerID, entities.WebhookEventWagerBought, &models.WagerBought{
+			WagerID:             int(wager.WagerID.Int),
+			PurchaseID:          int(purchaseRecord.PurchaseID.Int),
+			BuyingPrice:         purchaseRecord.BuyingPrice.Float,
+			BoughtAt:            &purchaseRecord.BoughtAt.Time,
+			CurrentSellingPrice: wager.CurrentSellingPrice.Float,
+			PercentageSold:      wager.PercentageSold.Float,
+			AmountSold:          wager.AmountSold.Float,
+		}); err != nil {
+			return nil, nil, fmt.Errorf("unable to queue webhook deliveries")
+		}
+	}
+	return purchaseRecord, fees, nil
 }
 
-func convert2BuyWagerResponse(purchase *entities.Purchase) *models.BuyWagerResponse {
-	return &models.BuyWagerResponse{
+func convert2BuyWagerResponse(purchase *entities.Purchase, fees []*entities.PurchaseFee) *models.BuyWagerResponse {
+	response := &models.BuyWagerResponse{
 		PurchaseID:  int(purchase.PurchaseID.Int),
 		WagerID:     int(purchase.WagerID.Int),
 		BuyingPrice: purchase.BuyingPrice.Float,
 		BoughtAt:    &purchase.BoughtAt.Time,
+		BuyerID:     purchase.BuyerID.String,
+	}
+	if purchase.PaidCurrency.Status == pgtype.Present {
+		response.PaidCurrency = purchase.PaidCurrency.String
+		response.PaidAmount = purchase.PaidAmount.Float
+		response.ExchangeRate = purchase.ExchangeRate.Float
 	}
+	if len(fees) > 0 {
+		response.Fees, response.TotalFee = convertFeesPg2Domain(fees)
+	}
+	return response
 }
 
 // roundFloat ensure round to two decimal places
@@ -251,55 +727,59 @@ func roundFloat(number float32) float32 {
 	return float32(math.Round((float64(number) * 100)) / 100)
 }
 
-func validateListWagerParam(req *http.Request) error {
-
-	ctx := req.Context()
-	var page, limit int
-
-	if pageRaw, ok := ctx.Value("page").(int); ok {
-		page = pageRaw
-	} else {
-		return fmt.Errorf("page wrong format")
-	}
-	if limitRaw, ok := ctx.Value("limit").(int); ok {
-		limit = limitRaw
-	} else {
-		return fmt.Errorf("limit wrong format")
-	}
-	if page <= 0 || limit <= 0 {
-		return fmt.Errorf("`page` must be positive number and `limit` should be greater than 0")
-	}
-	return nil
-}
-
-func (s *WagerService) ListWager(resp http.ResponseWriter, req *http.Request) {
-	ctx := req.Context()
-	if err := validateListWagerParam(req); err != nil {
-		resp.WriteHeader(http.StatusBadRequest)
-		_ = json.NewEncoder(resp).Encode(map[string]string{
-			"error": err.Error(),
-		})
-		return
+// List returns the wagers matching query in id order, at their decayed prices, along
+// their quotes when query asks for them.
+func (s *WagerService) List(ctx context.Context, query *models.ListWagersQuery) ([]*models.Wager, error) {
+	if query.Limit <= 0 || query.AfterID < 0 || query.Offset < 0 || query.EventID < 0 || query.MarketID < 0 {
+		return nil, &validationError{fmt.Errorf("the limit must be positive and the after_id, offset, event_id and market_id must not be negative")}
 	}
-
-	page, limit := ctx.Value("page").(int), ctx.Value("limit").(int)
 	var lastID pgtype.Int4
 	_ = lastID.Set(nil)
-	if page > 1 {
-		_ = lastID.Set((page - 1) * limit)
+	if query.AfterID > 0 {
+		_ = lastID.Set(query.AfterID)
+	}
+	var filter repositories.WagerFilter
+	_ = filter.EventID.Set(nil)
+	_ = filter.MarketID.Set(nil)
+	if query.EventID > 0 {
+		_ = filter.EventID.Set(query.EventID)
 	}
-	wagers, err := s.WagerRepo.List(ctx, s.DB, lastID, uint32(limit))
+	if query.MarketID > 0 {
+		_ = filter.MarketID.Set(query.MarketID)
+	}
+	wagers, err := s.WagerRepo.List(ctx, s.DB, filter, lastID, uint32(query.Offset), uint32(query.Limit))
 	if err != nil {
-		resp.WriteHeader(http.StatusInternalServerError)
-		_ = json.NewEncoder(resp).Encode(map[string]string{
-			"error": "unable to list wager",
-		})
-		return
+		return nil, fmt.Errorf("unable to list wager")
+	}
+	oddsFormat := query.OddsFormat
+	if oddsFormat == "" {
+		oddsFormat = models.OddsFormatDecimal
 	}
+	now := time.Now()
 	wagermodels := make([]*models.Wager, 0, len(wagers))
 	for _, wager := range wagers {
-		wagermodels = append(wagermodels, convertWagerPg2Domain(wager))
+		applyPriceDecay(wager, now)
+		wagermodel := convertWagerPg2Domain(wager, oddsFormat)
+		if query.Quote {
+			wagermodel.Quote = s.quoteWagerEntity(wager)
+		}
+		wagermodels = append(wagermodels, wagermodel)
+	}
+	return wagermodels, nil
+}
+
+// Get returns one wager rendered in oddsFormat, at its decayed price.
+func (s *WagerService) Get(ctx context.Context, wagerID int, oddsFormat models.OddsFormat) (*models.Wager, error) {
+	wager, err := s.WagerRepo.Get(ctx, s.DB, database.Int4(int32(wagerID)))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errWagerNotFound
+		}
+		return nil, fmt.Errorf("unable to get wager information")
+	}
+	if oddsFormat == "" {
+		oddsFormat = models.OddsFormatDecimal
 	}
-	resp.WriteHeader(http.StatusOK)
-	_ = json.NewEncoder(resp).Encode(wagermodels)
+	applyPriceDecay(wager, time.Now())
+	return convertWagerPg2Domain(wager, oddsFormat), nil
 }
@@ -0,0 +1,235 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/wager-api/internal/models"
+	"github.com/wager-api/internal/pb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// grpcListBatchSize is the number of wagers read at once while streaming ListWagers.
+const (
+	grpcListBatchSize = 100
+	// grpcPrincipal is the audit actor of a call which names no actor
+	grpcPrincipal = "grpc"
+)
+
+// WagerGRPCServer serves the wager operations over gRPC with the same logic as the
+// HTTP handlers.
+type WagerGRPCServer struct {
+	pb.UnimplementedWagerServiceServer
+	WagerService *WagerService
+}
+
+// NewWagerGRPCServer returns a gRPC server with the wager service registered, only the
+// calls carrying one of tokens are served.
+func NewWagerGRPCServer(wagerService *WagerService, tokens []string, opts ...grpc.ServerOption) *grpc.Server {
+	opts = append([]grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(authUnaryInterceptor(tokens), auditUnaryInterceptor),
+		grpc.ChainStreamInterceptor(authStreamInterceptor(tokens)),
+	}, opts...)
+	server := grpc.NewServer(opts...)
+	pb.RegisterWagerServiceServer(server, &WagerGRPCServer{WagerService: wagerService})
+	return server
+}
+
+// authenticateGRPC is the gRPC counterpart of adminAuthMiddleware, the token is read
+// from the `authorization: Bearer` metadata.
+func authenticateGRPC(ctx context.Context, tokens []string) error {
+	var token string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("authorization"); len(values) > 0 {
+			token = strings.TrimPrefix(values[0], "Bearer ")
+		}
+	}
+	if !matchToken(token, tokens) {
+		return status.Error(codes.Unauthenticated, "unable to authenticate the request")
+	}
+	return nil
+}
+
+func authUnaryInterceptor(tokens []string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := authenticateGRPC(ctx, tokens); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func authStreamInterceptor(tokens []string) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := authenticateGRPC(stream.Context(), tokens); err != nil {
+			return err
+		}
+		return handler(srv, stream)
+	}
+}
+
+// auditUnaryInterceptor is the gRPC counterpart of auditMiddleware, the actor and the
+// request id are read from the metadata named after the http headers. It runs after
+// authUnaryInterceptor so the actor is verified.
+func auditUnaryInterceptor(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	info := auditInfo{actor: grpcPrincipal, verified: true}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(ActorHeader); len(values) > 0 && strings.TrimSpace(values[0]) != "" {
+			info.actor = strings.TrimSpace(values[0])
+		}
+		if values := md.Get("X-Request-Id"); len(values) > 0 {
+			info.requestID = values[0]
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		clientIP, _, err := net.SplitHostPort(p.Addr.String())
+		if err != nil {
+			clientIP = p.Addr.String()
+		}
+		info.clientIP = clientIP
+	}
+	return handler(withAuditInfo(ctx, info), req)
+}
+
+// codeFromError is the gRPC counterpart of statusFromError.
+func codeFromError(err error) codes.Code {
+	var invalidErr *validationError
+	switch {
+	case errors.As(err, &invalidErr):
+		return codes.InvalidArgument
+	case errors.Is(err, errEventNotFound), errors.Is(err, errMarketNotFound), errors.Is(err, errWagerNotFound):
+		return codes.NotFound
+	case errors.Is(err, errInvalidStatusTransition), errors.Is(err, errWagerSalesClosed), errors.Is(err, errNoExchangeRate),
+		errors.Is(err, errRiskLimitExceeded):
+		return codes.FailedPrecondition
+	case errors.Is(err, errAccountRestricted), errors.Is(err, errSpendLimitReached), errors.Is(err, errTradeBlocked):
+		return codes.PermissionDenied
+	default:
+		return codes.Internal
+	}
+}
+
+func grpcError(err error) error {
+	return status.Error(codeFromError(err), err.Error())
+}
+
+func (g *WagerGRPCServer) PlaceWager(ctx context.Context, req *pb.PlaceWagerRequest) (*pb.Wager, error) {
+	placeWagerRequest := &models.PlaceWagerRequest{
+		TotalWagerValue:   req.GetTotalWagerValue(),
+		Odds:              models.OddsValue(req.GetOdds()),
+		OddsFormat:        req.GetOddsFormat(),
+		SellingPercentage: int(req.GetSellingPercentage()),
+		SellingPrice:      req.GetSellingPrice(),
+		SellerID:          req.GetSellerId(),
+	}
+	if req.GetMarketId() != 0 {
+		marketID := int(req.GetMarketId())
+		placeWagerRequest.MarketID = &marketID
+	}
+	wager, err := g.WagerService.Place(ctx, placeWagerRequest)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return convertWager2Proto(wager), nil
+}
+
+func (g *WagerGRPCServer) BuyWager(ctx context.Context, req *pb.BuyWagerRequest) (*pb.Purchase, error) {
+	purchase, err := g.WagerService.Buy(ctx, &models.BuyWagerCommand{
+		WagerID:     int(req.GetWagerId()),
+		BuyingPrice: req.GetBuyingPrice(),
+		BuyerID:     req.GetBuyerId(),
+	})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &pb.Purchase{
+		PurchaseId:  int32(purchase.PurchaseID),
+		WagerId:     int32(purchase.WagerID),
+		BuyingPrice: purchase.BuyingPrice,
+		BoughtAt:    timestamppb.New(*purchase.BoughtAt),
+	}, nil
+}
+
+func (g *WagerGRPCServer) GetWager(ctx context.Context, req *pb.GetWagerRequest) (*pb.Wager, error) {
+	oddsFormat, err := models.ParseOddsFormat(req.GetOddsFormat())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	wager, err := g.WagerService.Get(ctx, int(req.GetWagerId()), oddsFormat)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return convertWager2Proto(wager), nil
+}
+
+// ListWagers streams the wagers after after_id in batches, until limit wagers have been
+// sent or there is no wager left.
+func (g *WagerGRPCServer) ListWagers(req *pb.ListWagersRequest, stream pb.WagerService_ListWagersServer) error {
+	oddsFormat, err := models.ParseOddsFormat(req.GetOddsFormat())
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	query := &models.ListWagersQuery{
+		AfterID:    int(req.GetAfterId()),
+		EventID:    int(req.GetEventId()),
+		MarketID:   int(req.GetMarketId()),
+		OddsFormat: oddsFormat,
+	}
+	remaining := int(req.GetLimit())
+	for {
+		query.Limit = grpcListBatchSize
+		if req.GetLimit() > 0 && remaining < query.Limit {
+			query.Limit = remaining
+		}
+		if query.Limit == 0 {
+			return nil
+		}
+		wagers, err := g.WagerService.List(stream.Context(), query)
+		if err != nil {
+			return grpcError(err)
+		}
+		for _, wager := range wagers {
+			if err := stream.Send(convertWager2Proto(wager)); err != nil {
+				return err
+			}
+			query.AfterID = wager.ID
+		}
+		remaining -= len(wagers)
+		if len(wagers) < query.Limit {
+			return nil
+		}
+	}
+}
+
+func convertWager2Proto(wager *models.Wager) *pb.Wager {
+	var placedAt *timestamppb.Timestamp
+	if wager.PlacedAt != nil {
+		placedAt = timestamppb.New(*wager.PlacedAt)
+	}
+	var marketID int32
+	if wager.MarketID != nil {
+		marketID = int32(*wager.MarketID)
+	}
+	return &pb.Wager{
+		Id:                  int32(wager.ID),
+		MarketId:            marketID,
+		TotalWagerValue:     wager.TotalWagerValue,
+		Odds:                string(wager.Odds),
+		OddsFormat:          string(wager.OddsFormat),
+		SellingPercentage:   int32(wager.SellingPercentage),
+		SellingPrice:        wager.SellingPrice,
+		CurrentSellingPrice: wager.CurrentSellingPrice,
+		PercentageSold:      wager.PercentageSold,
+		AmountSold:          wager.AmountSold,
+		PlacedAt:            placedAt,
+		SellerId:            wager.SellerID,
+	}
+}
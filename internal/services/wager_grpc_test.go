@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/internal/pb"
+	"github.com/wager-api/internal/repositories"
+	"github.com/wager-api/libs/database"
+	mock_database "github.com/wager-api/mocks/libs/database"
+	mock_repositories "github.com/wager-api/mocks/repositories"
+
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const grpcTestToken = "grpc-test-token"
+
+// grpcToken sends its token along every call, like the clients of the gRPC API do.
+type grpcToken string
+
+func (c grpcToken) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(c)}, nil
+}
+
+func (grpcToken) RequireTransportSecurity() bool {
+	return false
+}
+
+func newWagerGRPCClient(t *testing.T, wagerService *WagerService) pb.WagerServiceClient {
+	t.Helper()
+	return newWagerGRPCClientWithToken(t, wagerService, grpcTestToken)
+}
+
+func newWagerGRPCClientWithToken(t *testing.T, wagerService *WagerService, token string) pb.WagerServiceClient {
+	t.Helper()
+	listener := bufconn.Listen(1024 * 1024)
+	server := NewWagerGRPCServer(wagerService, []string{grpcTestToken})
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithPerRPCCredentials(grpcToken(token)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return pb.NewWagerServiceClient(conn)
+}
+
+func Test_WagerGRPCServer_Unauthenticated(t *testing.T) {
+	t.Parallel()
+	for _, token := range []string{"", "wrong-token"} {
+		client := newWagerGRPCClientWithToken(t, &WagerService{DB: &mock_database.Ext{}}, token)
+
+		_, err := client.GetWager(context.Background(), &pb.GetWagerRequest{WagerId: 1})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err), token)
+		stream, err := client.ListWagers(context.Background(), &pb.ListWagersRequest{})
+		if assert.NoError(t, err) {
+			_, err = stream.Recv()
+			assert.Equal(t, codes.Unauthenticated, status.Code(err), "the streams are authenticated too")
+		}
+	}
+}
+
+func Test_WagerGRPCServer_PlaceWager_Invalid(t *testing.T) {
+	t.Parallel()
+	client := newWagerGRPCClient(t, &WagerService{DB: &mock_database.Ext{}})
+
+	_, err := client.PlaceWager(context.Background(), &pb.PlaceWagerRequest{TotalWagerValue: 0, Odds: "2"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Equal(t, "the total_wager_value must be a positive integer above 0", status.Convert(err).Message())
+}
+
+func Test_WagerGRPCServer_GetWager(t *testing.T) {
+	t.Parallel()
+	db := &mock_database.Ext{}
+	wagerRepo := &mock_repositories.MockWagerRepo{}
+	client := newWagerGRPCClient(t, &WagerService{DB: db, WagerRepo: wagerRepo})
+
+	wagerRepo.On("Get", mock.Anything, db, database.Int4(1)).Once().Return(&entities.Wager{
+		WagerID:         database.Int4(1),
+		TotalWagerValue: database.Float4(100),
+		Odds:            database.Float8(2.5),
+	}, nil)
+	wagerRepo.On("Get", mock.Anything, db, database.Int4(2)).Once().Return(nil, pgx.ErrNoRows)
+
+	wager, err := client.GetWager(context.Background(), &pb.GetWagerRequest{WagerId: 1, OddsFormat: "fractional"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, int32(1), wager.Id)
+		assert.Equal(t, "3/2", wager.Odds)
+		assert.Equal(t, "fractional", wager.OddsFormat)
+	}
+
+	_, err = client.GetWager(context.Background(), &pb.GetWagerRequest{WagerId: 2})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func Test_WagerGRPCServer_ListWagers(t *testing.T) {
+	t.Parallel()
+	db := &mock_database.Ext{}
+	wagerRepo := &mock_repositories.MockWagerRepo{}
+	client := newWagerGRPCClient(t, &WagerService{DB: db, WagerRepo: wagerRepo})
+
+	filter := repositories.WagerFilter{}
+	_ = filter.EventID.Set(nil)
+	_ = filter.MarketID.Set(3)
+	afterID := pgtype.Int4{}
+	_ = afterID.Set(5)
+	wagerRepo.On("List", mock.Anything, db, filter, afterID, uint32(0), uint32(2)).Once().Return([]*entities.Wager{
+		{WagerID: database.Int4(6)},
+		{WagerID: database.Int4(8)},
+	}, nil)
+
+	stream, err := client.ListWagers(context.Background(), &pb.ListWagersRequest{AfterId: 5, Limit: 2, MarketId: 3})
+	if !assert.NoError(t, err) {
+		return
+	}
+	var ids []int32
+	for {
+		wager, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if !assert.NoError(t, err) {
+			return
+		}
+		ids = append(ids, wager.Id)
+	}
+	assert.Equal(t, []int32{6, 8}, ids, "the stream stops once limit wagers have been sent")
+	wagerRepo.AssertExpectations(t)
+}
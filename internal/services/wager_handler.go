@@ -3,13 +3,38 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wager-api/internal/models"
+	"github.com/wager-api/libs/logs"
 
 	"github.com/go-chi/chi/middleware"
 	"github.com/go-chi/chi/v5"
 )
 
+// contextKey is the type of the keys the middlewares store the request params under,
+// it can't collide with the keys of other packages.
+type contextKey string
+
+const (
+	pageKey       contextKey = "page"
+	limitKey      contextKey = "limit"
+	wagerIDKey    contextKey = "wager_id"
+	eventIDKey    contextKey = "event_id"
+	marketIDKey   contextKey = "market_id"
+	oddsFormatKey contextKey = "odds_format"
+	webhookIDKey  contextKey = "webhook_id"
+	auditInfoKey  contextKey = "audit_info"
+	buyOrderIDKey contextKey = "buy_order_id"
+	purchaseIDKey contextKey = "purchase_id"
+	listingIDKey  contextKey = "listing_id"
+)
+
+// WagerHandler adapts the http requests to the WagerService.
 type WagerHandler struct {
 	WagerService *WagerService
 }
@@ -50,8 +75,8 @@ func paginateMiddleware(next http.Handler) http.Handler {
 				return
 			}
 		}
-		ctx := context.WithValue(r.Context(), "page", intPage)
-		ctx = context.WithValue(ctx, "limit", intLimit)
+		ctx := context.WithValue(r.Context(), pageKey, intPage)
+		ctx = context.WithValue(ctx, limitKey, intLimit)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -71,10 +96,73 @@ func extractWagerIDMiddleware(next http.Handler) http.Handler {
 
 			}
 		}
-		ctx := context.WithValue(r.Context(), "wager_id", wagerIDInt)
+		ctx := context.WithValue(r.Context(), wagerIDKey, wagerIDInt)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// extractIntURLParamMiddleware puts the integer url param `param` into the request context under `key`.
+func extractIntURLParamMiddleware(param string, key contextKey) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			value, err := strconv.Atoi(chi.URLParam(r, param))
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(map[string]string{
+					"error": fmt.Sprintf("unable to extract %s value", key),
+				})
+				return
+			}
+			ctx := context.WithValue(r.Context(), key, value)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// to handle the optional filters of the wager list: /wagers?event_id=:event_id&market_id=:market_id
+func wagerFilterMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		for _, key := range []contextKey{eventIDKey, marketIDKey} {
+			value := strings.TrimPrefix(r.URL.Query().Get(string(key)), ":")
+			if value == "" {
+				continue
+			}
+			intValue, err := strconv.Atoi(value)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(map[string]string{
+					"error": fmt.Sprintf("unable to extract %s value", key),
+				})
+				return
+			}
+			ctx = context.WithValue(ctx, key, intValue)
+		}
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// to pick the format odds are rendered in, from the `odds_format` query param or the
+// `X-Odds-Format` header, the query param wins when both are set.
+func oddsFormatMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw := strings.TrimPrefix(r.URL.Query().Get("odds_format"), ":")
+		if raw == "" {
+			raw = r.Header.Get("X-Odds-Format")
+		}
+		oddsFormat, err := models.ParseOddsFormat(raw)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+		ctx := context.WithValue(r.Context(), oddsFormatKey, oddsFormat)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
 func setContentTypeMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Add("Content-Type", "application/json")
@@ -86,13 +174,809 @@ func NewWagerHandler(mux *chi.Mux, wagerService *WagerService) {
 	handler := &WagerHandler{
 		WagerService: wagerService,
 	}
+	extractBuyOrderID := extractIntURLParamMiddleware("buyOrderID", buyOrderIDKey)
+	extractPurchaseID := extractIntURLParamMiddleware("purchaseID", purchaseIDKey)
+	extractListingID := extractIntURLParamMiddleware("listingID", listingIDKey)
 	// StripSlashes remove redundant slash in endpoint, example /login/ -> /login
 	mux.Use(middleware.StripSlashes)
 	mux.Use(setContentTypeMiddleware)
+	mux.Use(auditMiddleware)
 
 	mux.Group(func(r chi.Router) {
-		r.Post("/wagers", handler.WagerService.PlaceWager)
-		r.With(extractWagerIDMiddleware).Post("/buy/{wagerID}", handler.WagerService.BuyWager)
-		r.With(paginateMiddleware).Get("/wagers", handler.WagerService.ListWager)
+		r.Post("/wagers", handler.PlaceWager)
+		r.Post("/wagers/batch", handler.PlaceWagerBatch)
+		r.Post("/wagers/quote", handler.QuoteWager)
+		r.With(extractWagerIDMiddleware).Post("/buy/{wagerID}", handler.BuyWager)
+		r.With(paginateMiddleware, wagerFilterMiddleware, oddsFormatMiddleware).Get("/wagers", handler.ListWager)
+		r.With(extractWagerIDMiddleware, oddsFormatMiddleware).Get("/wagers/{wagerID}", handler.GetWager)
+		r.With(extractWagerIDMiddleware).Patch("/wagers/{wagerID}", handler.RepriceWager)
+		r.With(extractWagerIDMiddleware).Get("/wagers/{wagerID}/prices", handler.GetPriceHistory)
+		r.Get("/wagers/stream", handler.StreamWager)
+		r.With(extractWagerIDMiddleware).Post("/wagers/{wagerID}/buy-orders", handler.PlaceBuyOrder)
+		r.With(extractBuyOrderID).Get("/buy-orders/{buyOrderID}", handler.GetBuyOrder)
+		r.With(extractBuyOrderID).Delete("/buy-orders/{buyOrderID}", handler.CancelBuyOrder)
+		r.With(extractPurchaseID).Post("/purchases/{purchaseID}/listings", handler.CreateListing)
+		r.With(extractListingID).Get("/listings/{listingID}", handler.GetListing)
+		r.With(extractListingID).Delete("/listings/{listingID}", handler.CancelListing)
+		r.With(extractListingID).Post("/listings/{listingID}/buy", handler.BuyListing)
+		r.With(paginateMiddleware).Get("/accounts/{accountID}/positions", handler.ListPositions)
+		r.Put("/accounts/{accountID}", handler.UpsertAccount)
+		r.Get("/accounts/{accountID}", handler.GetAccount)
+		r.Get("/accounts/{accountID}/limits", handler.GetLimits)
+		r.Put("/accounts/{accountID}/limits/{period}", handler.SetSpendLimit)
+		r.Post("/accounts/{accountID}/cool-off", handler.StartCoolOff)
+		r.Post("/accounts/{accountID}/self-exclusion", handler.SelfExclude)
+	})
+}
+
+func (h *WagerHandler) PlaceWager(resp http.ResponseWriter, req *http.Request) {
+	placeWagerRequest := &models.PlaceWagerRequest{}
+	err := json.NewDecoder(req.Body).Decode(&placeWagerRequest)
+	defer req.Body.Close()
+	if err != nil {
+		resp.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": "unable to parse request",
+		})
+		return
+	}
+	wager, err := h.WagerService.Place(req.Context(), placeWagerRequest)
+	if err != nil {
+		resp.WriteHeader(statusFromError(err))
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	resp.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(resp).Encode((*models.PlaceWagerResponse)(wager))
+}
+
+// QuoteWager prices the wager of the body before it is placed, nothing is written.
+func (h *WagerHandler) QuoteWager(resp http.ResponseWriter, req *http.Request) {
+	quoteWagerRequest := &models.QuoteWagerRequest{}
+	err := json.NewDecoder(req.Body).Decode(&quoteWagerRequest)
+	defer req.Body.Close()
+	if err != nil {
+		resp.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": "unable to parse request",
+		})
+		return
+	}
+	quote, err := h.WagerService.Quote(quoteWagerRequest)
+	if err != nil {
+		resp.WriteHeader(statusFromError(err))
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	resp.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(resp).Encode(quote)
+}
+
+// PlaceWagerBatch places the array of wagers of the body: ?mode=atomic|best_effort
+// It answers 201 when every wager is placed and 207 when some of a best effort batch
+// are not, the results of an atomic batch which failed come with the error status.
+func (h *WagerHandler) PlaceWagerBatch(resp http.ResponseWriter, req *http.Request) {
+	placeWagerRequests := []*models.PlaceWagerRequest{}
+	err := json.NewDecoder(req.Body).Decode(&placeWagerRequests)
+	defer req.Body.Close()
+	if err != nil {
+		resp.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": "unable to parse request: the body must be an array of wagers",
+		})
+		return
+	}
+	batch, err := h.WagerService.PlaceBatch(req.Context(), placeWagerRequests, req.URL.Query().Get("mode"))
+	if err != nil && batch == nil {
+		resp.WriteHeader(statusFromError(err))
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	switch {
+	case err != nil:
+		batch.Error = err.Error()
+		resp.WriteHeader(statusFromError(err))
+	case batch.Failed > 0:
+		resp.WriteHeader(http.StatusMultiStatus)
+	default:
+		resp.WriteHeader(http.StatusCreated)
+	}
+	_ = json.NewEncoder(resp).Encode(batch)
+}
+
+func (h *WagerHandler) BuyWager(resp http.ResponseWriter, req *http.Request) {
+	buyWagerRequest := &models.BuyWagerRequest{}
+	err := json.NewDecoder(req.Body).Decode(&buyWagerRequest)
+	defer req.Body.Close()
+	if err != nil {
+		resp.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": "unable to parse request",
+		})
+		return
+	}
+	wagerID, ok := req.Context().Value(wagerIDKey).(int)
+	if !ok {
+		resp.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": "wager_id wrong format",
+		})
+		return
+	}
+	purchase, err := h.WagerService.Buy(req.Context(), &models.BuyWagerCommand{
+		WagerID:     wagerID,
+		BuyingPrice: buyWagerRequest.BuyingPrice,
+		BuyerID:     buyWagerRequest.BuyerID,
 	})
+	if err != nil {
+		resp.WriteHeader(statusFromError(err))
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	resp.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(resp).Encode(purchase)
+}
+
+func validatePaginationParam(req *http.Request) error {
+	ctx := req.Context()
+	page, ok := ctx.Value(pageKey).(int)
+	if !ok {
+		return fmt.Errorf("page wrong format")
+	}
+	limit, ok := ctx.Value(limitKey).(int)
+	if !ok {
+		return fmt.Errorf("limit wrong format")
+	}
+	if page <= 0 || limit <= 0 {
+		return fmt.Errorf("`page` must be positive number and `limit` should be greater than 0")
+	}
+	return nil
+}
+
+func (h *WagerHandler) ListWager(resp http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	if err := validatePaginationParam(req); err != nil {
+		resp.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	page, limit := ctx.Value(pageKey).(int), ctx.Value(limitKey).(int)
+	// the pages are counted in filtered wagers, not in wager ids
+	query := &models.ListWagersQuery{
+		Offset: (page - 1) * limit,
+		Limit:  limit,
+	}
+	query.EventID, _ = ctx.Value(eventIDKey).(int)
+	query.MarketID, _ = ctx.Value(marketIDKey).(int)
+	query.OddsFormat, _ = ctx.Value(oddsFormatKey).(models.OddsFormat)
+	if value := req.URL.Query().Get("quote"); value != "" {
+		quote, err := strconv.ParseBool(value)
+		if err != nil {
+			resp.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(resp).Encode(map[string]string{
+				"error": "the quote must be true or false",
+			})
+			return
+		}
+		query.Quote = quote
+	}
+	wagers, err := h.WagerService.List(ctx, query)
+	if err != nil {
+		resp.WriteHeader(statusFromError(err))
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	resp.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(resp).Encode(wagers)
+}
+
+// GetWager returns one wager, its odds are rendered like in ListWager.
+func (h *WagerHandler) GetWager(resp http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	wagerID, _ := ctx.Value(wagerIDKey).(int)
+	oddsFormat, _ := ctx.Value(oddsFormatKey).(models.OddsFormat)
+	wager, err := h.WagerService.Get(ctx, wagerID, oddsFormat)
+	if err != nil {
+		resp.WriteHeader(statusFromError(err))
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	resp.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(resp).Encode(wager)
+}
+
+// RepriceWager sets the asking prices of the wager of the path.
+func (h *WagerHandler) RepriceWager(resp http.ResponseWriter, req *http.Request) {
+	repriceWagerRequest := &models.RepriceWagerRequest{}
+	err := json.NewDecoder(req.Body).Decode(&repriceWagerRequest)
+	defer req.Body.Close()
+	if err != nil {
+		resp.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": "unable to parse request",
+		})
+		return
+	}
+	wagerID, _ := req.Context().Value(wagerIDKey).(int)
+	wager, err := h.WagerService.Reprice(req.Context(), wagerID, repriceWagerRequest)
+	if err != nil {
+		resp.WriteHeader(statusFromError(err))
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	resp.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(resp).Encode(wager)
+}
+
+// PlaceBuyOrder places a standing order buying the wager of the path once its price
+// drops to the limit of the order.
+func (h *WagerHandler) PlaceBuyOrder(resp http.ResponseWriter, req *http.Request) {
+	placeBuyOrderRequest := &models.PlaceBuyOrderRequest{}
+	err := json.NewDecoder(req.Body).Decode(&placeBuyOrderRequest)
+	defer req.Body.Close()
+	if err != nil {
+		resp.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": "unable to parse request",
+		})
+		return
+	}
+	wagerID, _ := req.Context().Value(wagerIDKey).(int)
+	buyOrder, err := h.WagerService.PlaceBuyOrder(req.Context(), wagerID, placeBuyOrderRequest)
+	if err != nil {
+		resp.WriteHeader(statusFromError(err))
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	resp.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(resp).Encode(buyOrder)
+}
+
+func (h *WagerHandler) GetBuyOrder(resp http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	buyOrderID, _ := ctx.Value(buyOrderIDKey).(int)
+	buyOrder, err := h.WagerService.GetBuyOrder(ctx, buyOrderID)
+	if err != nil {
+		resp.WriteHeader(statusFromError(err))
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	resp.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(resp).Encode(buyOrder)
+}
+
+// CancelBuyOrder cancels an open order for its buyer, ?buyer_id= or else the X-Actor-ID
+// header, a filled or expired one can't be.
+func (h *WagerHandler) CancelBuyOrder(resp http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	buyOrderID, _ := ctx.Value(buyOrderIDKey).(int)
+	buyOrder, err := h.WagerService.CancelBuyOrder(ctx, buyOrderID, req.URL.Query().Get("buyer_id"))
+	if err != nil {
+		resp.WriteHeader(statusFromError(err))
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	resp.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(resp).Encode(buyOrder)
+}
+
+// CreateListing puts a part of the stake held in the purchase of the path up for resale.
+func (h *WagerHandler) CreateListing(resp http.ResponseWriter, req *http.Request) {
+	createListingRequest := &models.CreateListingRequest{}
+	err := json.NewDecoder(req.Body).Decode(&createListingRequest)
+	defer req.Body.Close()
+	if err != nil {
+		resp.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": "unable to parse request",
+		})
+		return
+	}
+	purchaseID, _ := req.Context().Value(purchaseIDKey).(int)
+	listing, err := h.WagerService.CreateListing(req.Context(), purchaseID, createListingRequest)
+	if err != nil {
+		resp.WriteHeader(statusFromError(err))
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	resp.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(resp).Encode(listing)
+}
+
+func (h *WagerHandler) GetListing(resp http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	listingID, _ := ctx.Value(listingIDKey).(int)
+	listing, err := h.WagerService.GetListing(ctx, listingID)
+	if err != nil {
+		resp.WriteHeader(statusFromError(err))
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	resp.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(resp).Encode(listing)
+}
+
+// CancelListing takes an open listing off the market for its seller, ?seller_id= or else
+// the X-Actor-ID header, a sold one can't be.
+func (h *WagerHandler) CancelListing(resp http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	listingID, _ := ctx.Value(listingIDKey).(int)
+	listing, err := h.WagerService.CancelListing(ctx, listingID, req.URL.Query().Get("seller_id"))
+	if err != nil {
+		resp.WriteHeader(statusFromError(err))
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	resp.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(resp).Encode(listing)
+}
+
+// BuyListing buys the stake of the listing of the path, the transfer is returned.
+func (h *WagerHandler) BuyListing(resp http.ResponseWriter, req *http.Request) {
+	buyListingRequest := &models.BuyListingRequest{}
+	err := json.NewDecoder(req.Body).Decode(&buyListingRequest)
+	defer req.Body.Close()
+	if err != nil {
+		resp.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": "unable to parse request",
+		})
+		return
+	}
+	listingID, _ := req.Context().Value(listingIDKey).(int)
+	transfer, err := h.WagerService.BuyListing(req.Context(), listingID, buyListingRequest)
+	if err != nil {
+		resp.WriteHeader(statusFromError(err))
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	resp.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(resp).Encode(transfer)
+}
+
+// ListPositions lists what an account holds wager by wager, with the summary of all of it.
+func (h *WagerHandler) ListPositions(resp http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	if err := validatePaginationParam(req); err != nil {
+		resp.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	page, limit := ctx.Value(pageKey).(int), ctx.Value(limitKey).(int)
+	positions, err := h.WagerService.ListPositions(ctx, chi.URLParam(req, "accountID"), page, limit)
+	if err != nil {
+		resp.WriteHeader(statusFromError(err))
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	resp.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(resp).Encode(positions)
+}
+
+// UpsertAccount sets the currency an account pays its purchases in.
+func (h *WagerHandler) UpsertAccount(resp http.ResponseWriter, req *http.Request) {
+	upsertAccountRequest := &models.UpsertAccountRequest{}
+	err := json.NewDecoder(req.Body).Decode(upsertAccountRequest)
+	defer req.Body.Close()
+	if err != nil {
+		resp.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": "unable to parse request",
+		})
+		return
+	}
+	account, err := h.WagerService.UpsertAccount(req.Context(), chi.URLParam(req, "accountID"), upsertAccountRequest)
+	if err != nil {
+		resp.WriteHeader(statusFromError(err))
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	resp.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(resp).Encode(account)
+}
+
+func (h *WagerHandler) GetAccount(resp http.ResponseWriter, req *http.Request) {
+	account, err := h.WagerService.GetAccount(req.Context(), chi.URLParam(req, "accountID"))
+	if err != nil {
+		resp.WriteHeader(statusFromError(err))
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	resp.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(resp).Encode(account)
+}
+
+func (h *WagerHandler) GetLimits(resp http.ResponseWriter, req *http.Request) {
+	limits, err := h.WagerService.GetLimits(req.Context(), chi.URLParam(req, "accountID"))
+	if err != nil {
+		resp.WriteHeader(statusFromError(err))
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	resp.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(resp).Encode(limits)
+}
+
+func (h *WagerHandler) SetSpendLimit(resp http.ResponseWriter, req *http.Request) {
+	setSpendLimitRequest := &models.SetSpendLimitRequest{}
+	err := json.NewDecoder(req.Body).Decode(setSpendLimitRequest)
+	defer req.Body.Close()
+	if err != nil {
+		resp.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": "unable to parse request",
+		})
+		return
+	}
+	limits, err := h.WagerService.SetSpendLimit(req.Context(), chi.URLParam(req, "accountID"), chi.URLParam(req, "period"), setSpendLimitRequest)
+	if err != nil {
+		resp.WriteHeader(statusFromError(err))
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	resp.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(resp).Encode(limits)
+}
+
+func (h *WagerHandler) StartCoolOff(resp http.ResponseWriter, req *http.Request) {
+	coolOffRequest := &models.CoolOffRequest{}
+	err := json.NewDecoder(req.Body).Decode(coolOffRequest)
+	defer req.Body.Close()
+	if err != nil {
+		resp.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": "unable to parse request",
+		})
+		return
+	}
+	restriction, err := h.WagerService.StartCoolOff(req.Context(), chi.URLParam(req, "accountID"), coolOffRequest)
+	if err != nil {
+		resp.WriteHeader(statusFromError(err))
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	resp.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(resp).Encode(restriction)
+}
+
+func (h *WagerHandler) SelfExclude(resp http.ResponseWriter, req *http.Request) {
+	selfExclusionRequest := &models.SelfExclusionRequest{}
+	err := json.NewDecoder(req.Body).Decode(selfExclusionRequest)
+	defer req.Body.Close()
+	if err != nil {
+		resp.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": "unable to parse request",
+		})
+		return
+	}
+	restriction, err := h.WagerService.SelfExclude(req.Context(), chi.URLParam(req, "accountID"), selfExclusionRequest)
+	if err != nil {
+		resp.WriteHeader(statusFromError(err))
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	resp.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(resp).Encode(restriction)
+}
+
+// minPriceHistoryInterval is the smallest bucket allowed when asking for OHLC candles.
+const minPriceHistoryInterval = time.Minute
+
+// parsePriceHistoryQuery reads the optional `from`, `to` (RFC3339) and `interval`
+// (Go duration, e.g. 15m, 1h) query params of the price history endpoint.
+func parsePriceHistoryQuery(req *http.Request) (*models.PriceHistoryQuery, error) {
+	query := &models.PriceHistoryQuery{}
+	if from := strings.TrimPrefix(req.URL.Query().Get("from"), ":"); from != "" {
+		fromTime, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return nil, fmt.Errorf("the from must be a RFC3339 timestamp")
+		}
+		query.From = &fromTime
+	}
+	if to := strings.TrimPrefix(req.URL.Query().Get("to"), ":"); to != "" {
+		toTime, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return nil, fmt.Errorf("the to must be a RFC3339 timestamp")
+		}
+		query.To = &toTime
+	}
+	if query.From != nil && query.To != nil && !query.To.After(*query.From) {
+		return nil, fmt.Errorf("the to must be after the from")
+	}
+	if interval := strings.TrimPrefix(req.URL.Query().Get("interval"), ":"); interval != "" {
+		duration, err := time.ParseDuration(interval)
+		if err != nil || duration < minPriceHistoryInterval {
+			return nil, fmt.Errorf("the interval must be a duration of at least %s", minPriceHistoryInterval)
+		}
+		query.Interval = duration
+	}
+	return query, nil
+}
+
+// GetPriceHistory returns the time series of the current_selling_price of a wager,
+// bucketed into OHLC candles when an interval is given.
+func (h *WagerHandler) GetPriceHistory(resp http.ResponseWriter, req *http.Request) {
+	query, err := parsePriceHistoryQuery(req)
+	if err != nil {
+		resp.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	wagerID, _ := req.Context().Value(wagerIDKey).(int)
+	priceHistory, err := h.WagerService.PriceHistory(req.Context(), wagerID, query)
+	if err != nil {
+		resp.WriteHeader(statusFromError(err))
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	resp.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(resp).Encode(priceHistory)
+}
+
+// parseStreamParams reads the optional `wager_id` filter and the id of the last update
+// the client received, from the `Last-Event-ID` header or the `last_event_id` query param.
+func parseStreamParams(req *http.Request) (wagerID int, lastEventID int64, err error) {
+	if raw := strings.TrimPrefix(req.URL.Query().Get("wager_id"), ":"); raw != "" {
+		wagerID, err = strconv.Atoi(raw)
+		if err != nil || wagerID <= 0 {
+			return 0, 0, fmt.Errorf("the wager_id must be a positive integer")
+		}
+	}
+	raw := req.Header.Get("Last-Event-ID")
+	if raw == "" {
+		raw = req.URL.Query().Get("last_event_id")
+	}
+	if raw != "" {
+		lastEventID, err = strconv.ParseInt(raw, 10, 64)
+		if err != nil || lastEventID < 0 {
+			return 0, 0, fmt.Errorf("the Last-Event-ID must be a positive integer")
+		}
+	}
+	return wagerID, lastEventID, nil
+}
+
+func writeServerSentEvent(resp http.ResponseWriter, update *models.WagerUpdate) error {
+	_, err := fmt.Fprintf(resp, "id: %d\nevent: %s\ndata: %s\n\n", update.ID, update.Type, update.Payload)
+	return err
+}
+
+// StreamWager streams the wager updates as Server-Sent Events. A client resuming with
+// Last-Event-ID first receives the updates it missed from the database.
+func (h *WagerHandler) StreamWager(resp http.ResponseWriter, req *http.Request) {
+	wagerID, lastEventID, err := parseStreamParams(req)
+	if err != nil {
+		resp.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	flusher, ok := resp.(http.Flusher)
+	if !ok {
+		resp.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": "unable to stream wager updates",
+		})
+		return
+	}
+	ctx := req.Context()
+	// subscribe before replaying so nothing committed in between is missed
+	updates, unsubscribe := h.WagerService.Stream.Subscribe(wagerID)
+	defer unsubscribe()
+
+	resp.Header().Set("Content-Type", "text/event-stream")
+	resp.Header().Set("Cache-Control", "no-cache")
+	resp.Header().Set("Connection", "keep-alive")
+	resp.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	if lastEventID > 0 {
+		for {
+			missed, err := h.WagerService.UpdatesAfter(ctx, lastEventID, wagerID, streamReplayLimit)
+			if err != nil {
+				logs.Logger.Errorw("unable to replay wager updates", "error", err)
+				return
+			}
+			for _, update := range missed {
+				if err := writeServerSentEvent(resp, update); err != nil {
+					return
+				}
+				lastEventID = update.ID
+			}
+			flusher.Flush()
+			if len(missed) < int(streamReplayLimit) {
+				break
+			}
+		}
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(resp, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case update, ok := <-updates:
+			if !ok {
+				// dropped for lagging behind, the client reconnects with its Last-Event-ID
+				return
+			}
+			// the updates are committed in the order of their ids, what is below the
+			// last one sent was replayed already
+			if update.ID <= lastEventID {
+				continue
+			}
+			if err := writeServerSentEvent(resp, update); err != nil {
+				return
+			}
+			lastEventID = update.ID
+			flusher.Flush()
+		}
+	}
+}
+
+type EventHandler struct {
+	EventService *EventService
+}
+
+// NewEventHandler registers the event and market routes, it must be called after
+// NewWagerHandler which sets up the middlewares shared by every route.
+func NewEventHandler(mux *chi.Mux, eventService *EventService) {
+	handler := &EventHandler{
+		EventService: eventService,
+	}
+	extractEventID := extractIntURLParamMiddleware("eventID", eventIDKey)
+	extractMarketID := extractIntURLParamMiddleware("marketID", marketIDKey)
+
+	mux.Group(func(r chi.Router) {
+		r.Post("/events", handler.EventService.CreateEvent)
+		r.With(paginateMiddleware).Get("/events", handler.EventService.ListEvent)
+		r.With(extractEventID).Get("/events/{eventID}", handler.EventService.GetEvent)
+		r.With(extractEventID).Patch("/events/{eventID}", handler.EventService.UpdateEventStatus)
+		r.With(extractEventID).Post("/events/{eventID}/markets", handler.EventService.CreateMarket)
+		r.With(extractEventID).Get("/events/{eventID}/markets", handler.EventService.ListMarket)
+		r.With(extractMarketID).Get("/markets/{marketID}", handler.EventService.GetMarket)
+		r.With(extractMarketID).Patch("/markets/{marketID}", handler.EventService.UpdateMarketStatus)
+	})
+}
+
+// NewWebhookHandler registers the webhook routes, only the requests bearing one of
+// tokens are served. It must be called after NewWagerHandler which sets up the
+// middlewares shared by every route.
+func NewWebhookHandler(mux *chi.Mux, webhookService *WebhookService, tokens []string) {
+	extractWebhookID := extractIntURLParamMiddleware("webhookID", webhookIDKey)
+
+	mux.Group(func(r chi.Router) {
+		r.Use(adminAuthMiddleware(tokens))
+		r.Post("/webhooks", webhookService.CreateWebhook)
+		r.With(extractWebhookID).Get("/webhooks/{webhookID}", webhookService.GetWebhook)
+		r.With(extractWebhookID).Patch("/webhooks/{webhookID}", webhookService.UpdateWebhookStatus)
+		r.With(extractWebhookID).Get("/webhooks/{webhookID}/deliveries", webhookService.ListWebhookDeliveries)
+	})
+}
+
+// NewAuditHandler registers the read only admin routes of the audit log, only the
+// requests bearing one of tokens are served. It must be called after NewWagerHandler
+// which sets up the middlewares shared by every route.
+func NewAuditHandler(mux *chi.Mux, auditService *AuditService, tokens []string) {
+	mux.Group(func(r chi.Router) {
+		r.Use(adminAuthMiddleware(tokens))
+		r.Get("/admin/audit-logs", auditService.ListAuditLogs)
+	})
+}
+
+// NewExportHandler registers the admin routes streaming the wagers and the purchases
+// out, only the requests bearing one of tokens are served. It must be called after
+// NewWagerHandler which sets up the middlewares shared by every route.
+func NewExportHandler(mux *chi.Mux, exportService *ExportService, tokens []string) {
+	mux.Group(func(r chi.Router) {
+		r.Use(adminAuthMiddleware(tokens))
+		r.Get("/admin/exports/{kind}", exportService.ExportRows)
+	})
+}
+
+// NewFeeHandler registers the admin route reporting the fees charged on the purchases,
+// only the requests bearing one of tokens are served. It must be called after
+// NewWagerHandler which sets up the middlewares shared by every route.
+func NewFeeHandler(mux *chi.Mux, feeService *FeeService, tokens []string) {
+	mux.Group(func(r chi.Router) {
+		r.Use(adminAuthMiddleware(tokens))
+		r.Get("/admin/reports/fees", feeService.ReportFees)
+	})
+}
+
+// NewCurrencyHandler registers the admin routes of the exchange rates and of the volume
+// report, only the requests bearing one of tokens are served. It must be called after
+// NewWagerHandler which sets up the middlewares shared by every route.
+func NewCurrencyHandler(mux *chi.Mux, currencyService *CurrencyService, tokens []string) {
+	mux.Group(func(r chi.Router) {
+		r.Use(adminAuthMiddleware(tokens))
+		r.Post("/admin/exchange-rates", currencyService.CreateExchangeRate)
+		r.Get("/admin/exchange-rates", currencyService.ListExchangeRates)
+		r.Get("/admin/reports/volume", currencyService.ReportVolume)
+	})
+}
+
+// NewRiskHandler registers the admin route summing up the exposure against the risk
+// limits, only the requests bearing one of tokens are served. It must be called after
+// NewWagerHandler which sets up the middlewares shared by every route.
+func NewRiskHandler(mux *chi.Mux, riskService *RiskService, tokens []string) {
+	mux.Group(func(r chi.Router) {
+		r.Use(adminAuthMiddleware(tokens))
+		r.Get("/admin/risk", riskService.GetRiskSummary)
+	})
+}
+
+// NewSurveillanceHandler registers the admin routes of the trade alerts and of the
+// account links, only the requests bearing one of tokens are served. It must be called
+// after NewWagerHandler which sets up the middlewares shared by every route.
+func NewSurveillanceHandler(mux *chi.Mux, surveillanceService *SurveillanceService, tokens []string) {
+	mux.Group(func(r chi.Router) {
+		r.Use(adminAuthMiddleware(tokens))
+		r.Get("/admin/trade-alerts", surveillanceService.ListTradeAlerts)
+		r.Post("/admin/account-links", surveillanceService.LinkAccounts)
+		r.Get("/admin/account-links", surveillanceService.ListAccountLinks)
+	})
+}
+
+// NewWagerSocketHandler registers the websocket endpoint, it must be called after
+// NewWagerHandler which sets up the middlewares shared by every route.
+func NewWagerSocketHandler(mux *chi.Mux, socket *WagerSocket) {
+	mux.Get("/ws", socket.ServeHTTP)
 }
@@ -0,0 +1,277 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wager-api/internal/models"
+	"github.com/wager-api/libs/logs"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// socketAllWagers is the channel of the updates of every wager, "wager:<id>" is the
+	// channel of the updates of one wager
+	socketAllWagers     = "wagers"
+	socketWagerPrefix   = "wager:"
+	socketWriteWait     = 10 * time.Second
+	socketPongWait      = 60 * time.Second
+	socketPingInterval  = socketPongWait * 9 / 10
+	socketMaxMessage    = 64 * 1024
+	socketSendQueueSize = 256
+	// socketPrincipal is the audit actor of a connection which names no actor
+	socketPrincipal = "websocket"
+)
+
+// WagerSocket serves the websocket API: clients subscribe to wager channels and place
+// or buy wagers with the same logic as the HTTP API.
+type WagerSocket struct {
+	WagerService *WagerService
+	// Tokens are the credentials a connection can authenticate with, no token means
+	// no connection is accepted
+	Tokens   []string
+	upgrader websocket.Upgrader
+}
+
+func NewWagerSocket(wagerService *WagerService, tokens []string) *WagerSocket {
+	return &WagerSocket{
+		WagerService: wagerService,
+		Tokens:       tokens,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  4096,
+			WriteBufferSize: 4096,
+			// connections authenticate with a token, not with cookies
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+	}
+}
+
+// authenticate checks the token of the `Authorization: Bearer` header or of the
+// `token` query param, browsers can't set headers on a websocket.
+func (h *WagerSocket) authenticate(req *http.Request) bool {
+	token := bearerToken(req)
+	if token == "" {
+		token = req.URL.Query().Get("token")
+	}
+	return matchToken(token, h.Tokens)
+}
+
+func (h *WagerSocket) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
+	if !h.authenticate(req) {
+		resp.WriteHeader(http.StatusUnauthorized)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": "unable to authenticate the connection",
+		})
+		return
+	}
+	req = req.WithContext(withAuthenticatedActor(req.Context(), socketPrincipal))
+	ws, err := h.upgrader.Upgrade(resp, req, nil)
+	if err != nil {
+		// the upgrader has already replied to the client
+		return
+	}
+	conn := &socketConn{
+		ws:            ws,
+		service:       h.WagerService,
+		send:          make(chan *models.SocketResponse, socketSendQueueSize),
+		done:          make(chan struct{}),
+		subscriptions: map[string]func(){},
+	}
+	go conn.writePump()
+	conn.readPump(req.Context())
+	conn.close(websocket.CloseNormalClosure, "")
+}
+
+type socketConn struct {
+	ws      *websocket.Conn
+	service *WagerService
+	send    chan *models.SocketResponse
+
+	closeOnce   sync.Once
+	done        chan struct{}
+	closeCode   int
+	closeReason string
+
+	mu            sync.Mutex
+	subscriptions map[string]func()
+}
+
+// close stops the connection once, the write pump sends the close frame.
+func (c *socketConn) close(code int, reason string) {
+	c.closeOnce.Do(func() {
+		c.closeCode, c.closeReason = code, reason
+		close(c.done)
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		for channel, unsubscribe := range c.subscriptions {
+			unsubscribe()
+			delete(c.subscriptions, channel)
+		}
+	})
+}
+
+// enqueue queues a message without blocking, a client which doesn't read its messages
+// fast enough is disconnected so it can't slow down the server.
+func (c *socketConn) enqueue(msg *models.SocketResponse) {
+	select {
+	case <-c.done:
+	case c.send <- msg:
+	default:
+		c.close(websocket.ClosePolicyViolation, "too slow to read the messages")
+	}
+}
+
+func (c *socketConn) writePump() {
+	ticker := time.NewTicker(socketPingInterval)
+	defer func() {
+		ticker.Stop()
+		_ = c.ws.Close()
+	}()
+	for {
+		select {
+		case <-c.done:
+			_ = c.ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(c.closeCode, c.closeReason), time.Now().Add(socketWriteWait))
+			return
+		case msg := <-c.send:
+			_ = c.ws.SetWriteDeadline(time.Now().Add(socketWriteWait))
+			if err := c.ws.WriteJSON(msg); err != nil {
+				c.close(websocket.CloseAbnormalClosure, "")
+				return
+			}
+		case <-ticker.C:
+			if err := c.ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(socketWriteWait)); err != nil {
+				c.close(websocket.CloseAbnormalClosure, "")
+				return
+			}
+		}
+	}
+}
+
+// readPump handles the messages of the client one after another until the connection
+// is closed or the client stops answering the pings.
+func (c *socketConn) readPump(ctx context.Context) {
+	c.ws.SetReadLimit(socketMaxMessage)
+	_ = c.ws.SetReadDeadline(time.Now().Add(socketPongWait))
+	c.ws.SetPongHandler(func(string) error {
+		return c.ws.SetReadDeadline(time.Now().Add(socketPongWait))
+	})
+	for {
+		_, data, err := c.ws.ReadMessage()
+		if err != nil {
+			return
+		}
+		select {
+		case <-c.done:
+			return
+		default:
+		}
+		msg := &models.SocketRequest{}
+		if err := json.Unmarshal(data, msg); err != nil {
+			c.enqueue(&models.SocketResponse{Type: models.SocketError, Error: "unable to parse message"})
+			continue
+		}
+		c.enqueue(c.handle(ctx, msg))
+	}
+}
+
+func (c *socketConn) handle(ctx context.Context, msg *models.SocketRequest) *models.SocketResponse {
+	fail := func(err error) *models.SocketResponse {
+		return &models.SocketResponse{ID: msg.ID, Type: models.SocketError, Channel: msg.Channel, Error: err.Error()}
+	}
+	switch msg.Type {
+	case models.SocketPing:
+		return &models.SocketResponse{ID: msg.ID, Type: models.SocketPong}
+	case models.SocketSubscribe:
+		if err := c.subscribe(msg.Channel); err != nil {
+			return fail(err)
+		}
+		return &models.SocketResponse{ID: msg.ID, Type: models.SocketSubscribed, Channel: msg.Channel}
+	case models.SocketUnsubscribe:
+		c.mu.Lock()
+		if unsubscribe, ok := c.subscriptions[msg.Channel]; ok {
+			unsubscribe()
+			delete(c.subscriptions, msg.Channel)
+		}
+		c.mu.Unlock()
+		return &models.SocketResponse{ID: msg.ID, Type: models.SocketUnsubscribed, Channel: msg.Channel}
+	case models.SocketPlace:
+		placeWagerRequest := &models.PlaceWagerRequest{}
+		if err := json.Unmarshal(msg.Data, placeWagerRequest); err != nil {
+			return fail(fmt.Errorf("unable to parse request"))
+		}
+		wager, err := c.service.Place(ctx, placeWagerRequest)
+		if err != nil {
+			return fail(err)
+		}
+		return &models.SocketResponse{ID: msg.ID, Type: models.SocketPlaced, Data: wager}
+	case models.SocketBuy:
+		buyWagerRequest := &models.SocketBuyWagerRequest{}
+		if err := json.Unmarshal(msg.Data, buyWagerRequest); err != nil {
+			return fail(fmt.Errorf("unable to parse request"))
+		}
+		purchase, err := c.service.Buy(ctx, &models.BuyWagerCommand{
+			WagerID:     buyWagerRequest.WagerID,
+			BuyingPrice: buyWagerRequest.BuyingPrice,
+			BuyerID:     buyWagerRequest.BuyerID,
+		})
+		if err != nil {
+			return fail(err)
+		}
+		return &models.SocketResponse{ID: msg.ID, Type: models.SocketBought, Data: purchase}
+	default:
+		return fail(fmt.Errorf("the type must be one of subscribe, unsubscribe, place, buy, ping"))
+	}
+}
+
+// parseSocketChannel returns the wager id a channel is about, 0 for every wager.
+func parseSocketChannel(channel string) (int, error) {
+	if channel == socketAllWagers {
+		return 0, nil
+	}
+	if strings.HasPrefix(channel, socketWagerPrefix) {
+		wagerID, err := strconv.Atoi(strings.TrimPrefix(channel, socketWagerPrefix))
+		if err == nil && wagerID > 0 {
+			return wagerID, nil
+		}
+	}
+	return 0, fmt.Errorf("the channel must be %s or %s<wager_id>", socketAllWagers, socketWagerPrefix)
+}
+
+func (c *socketConn) subscribe(channel string) error {
+	wagerID, err := parseSocketChannel(channel)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.subscriptions[channel]; ok {
+		return nil
+	}
+	updates, unsubscribe := c.service.Stream.Subscribe(wagerID)
+	c.subscriptions[channel] = unsubscribe
+	go func() {
+		for update := range updates {
+			c.enqueue(&models.SocketResponse{Type: models.SocketUpdate, Channel: channel, Data: update})
+		}
+		select {
+		case <-c.done:
+		default:
+			c.mu.Lock()
+			_, stillSubscribed := c.subscriptions[channel]
+			c.mu.Unlock()
+			if stillSubscribed {
+				// the stream dropped the subscription because the connection lags behind
+				logs.Logger.Infow("closing a websocket lagging behind the wager updates", "channel", channel)
+				c.close(websocket.ClosePolicyViolation, "too slow to read the updates")
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wager-api/internal/models"
+
+	"github.com/gorilla/websocket"
+)
+
+func dialWagerSocket(t *testing.T, server *httptest.Server, header http.Header) (*websocket.Conn, *http.Response, error) {
+	t.Helper()
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+	return websocket.DefaultDialer.Dial(url, header)
+}
+
+func readSocketResponse(t *testing.T, conn *websocket.Conn) *models.SocketResponse {
+	t.Helper()
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	msg := &models.SocketResponse{}
+	assert.NoError(t, conn.ReadJSON(msg))
+	return msg
+}
+
+func Test_WagerSocket_Unauthorized(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(NewWagerSocket(&WagerService{Stream: NewWagerStream()}, []string{"secret"}))
+	defer server.Close()
+
+	_, resp, err := dialWagerSocket(t, server, http.Header{"Authorization": []string{"Bearer wrong"}})
+	assert.Error(t, err)
+	if assert.NotNil(t, resp) {
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	}
+
+	server = httptest.NewServer(NewWagerSocket(&WagerService{Stream: NewWagerStream()}, nil))
+	defer server.Close()
+	_, resp, err = dialWagerSocket(t, server, http.Header{"Authorization": []string{"Bearer secret"}})
+	assert.Error(t, err, "no connection is accepted when no token is configured")
+	if assert.NotNil(t, resp) {
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	}
+}
+
+func Test_WagerSocket_Messages(t *testing.T) {
+	t.Parallel()
+	wagerService := &WagerService{Stream: NewWagerStream()}
+	server := httptest.NewServer(NewWagerSocket(wagerService, []string{"secret"}))
+	defer server.Close()
+	conn, _, err := dialWagerSocket(t, server, http.Header{"Authorization": []string{"Bearer secret"}})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+
+	t.Run("ping", func(t *testing.T) {
+		assert.NoError(t, conn.WriteJSON(&models.SocketRequest{ID: "1", Type: models.SocketPing}))
+		msg := readSocketResponse(t, conn)
+		assert.Equal(t, "1", msg.ID)
+		assert.Equal(t, models.SocketPong, msg.Type)
+	})
+
+	t.Run("unknown channel", func(t *testing.T) {
+		assert.NoError(t, conn.WriteJSON(&models.SocketRequest{ID: "2", Type: models.SocketSubscribe, Channel: "wager:abc"}))
+		msg := readSocketResponse(t, conn)
+		assert.Equal(t, models.SocketError, msg.Type)
+		assert.Equal(t, "the channel must be wagers or wager:<wager_id>", msg.Error)
+	})
+
+	t.Run("place with invalid wager", func(t *testing.T) {
+		assert.NoError(t, conn.WriteJSON(&models.SocketRequest{ID: "3", Type: models.SocketPlace, Data: []byte(`{"total_wager_value": 0}`)}))
+		msg := readSocketResponse(t, conn)
+		assert.Equal(t, "3", msg.ID)
+		assert.Equal(t, models.SocketError, msg.Type)
+		assert.NotEmpty(t, msg.Error)
+	})
+
+	t.Run("buy without wager_id", func(t *testing.T) {
+		assert.NoError(t, conn.WriteJSON(&models.SocketRequest{ID: "4", Type: models.SocketBuy, Data: []byte(`{"buying_price": 10}`)}))
+		msg := readSocketResponse(t, conn)
+		assert.Equal(t, models.SocketError, msg.Type)
+		assert.Equal(t, "the wager_id must be a positive integer", msg.Error)
+	})
+
+	t.Run("subscribe receives updates", func(t *testing.T) {
+		assert.NoError(t, conn.WriteJSON(&models.SocketRequest{ID: "5", Type: models.SocketSubscribe, Channel: "wager:1"}))
+		msg := readSocketResponse(t, conn)
+		assert.Equal(t, models.SocketSubscribed, msg.Type)
+
+		wagerService.Stream.Publish(&models.WagerUpdate{ID: 7, Type: "price-changed", WagerID: 2})
+		wagerService.Stream.Publish(&models.WagerUpdate{ID: 8, Type: "price-changed", WagerID: 1})
+		msg = readSocketResponse(t, conn)
+		assert.Equal(t, models.SocketUpdate, msg.Type)
+		assert.Equal(t, "wager:1", msg.Channel)
+		if data, ok := msg.Data.(map[string]interface{}); assert.True(t, ok) {
+			assert.Equal(t, float64(8), data["id"], "only the updates of the subscribed wager are sent")
+		}
+	})
+}
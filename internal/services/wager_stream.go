@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/internal/models"
+	"github.com/wager-api/internal/repositories"
+	"github.com/wager-api/libs/database"
+	"github.com/wager-api/libs/logs"
+
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+var (
+	// subscriberBufferSize is how many updates a subscriber can lag behind before it is dropped
+	subscriberBufferSize = 64
+	// streamHeartbeatInterval keeps idle connections open through proxies
+	streamHeartbeatInterval = 15 * time.Second
+	// streamReplayLimit is the number of missed updates read at once when a client resumes
+	streamReplayLimit uint32 = 500
+	// listenRetryInterval is the delay before listening again after the connection is lost
+	listenRetryInterval = 5 * time.Second
+)
+
+type streamSubscriber struct {
+	wagerID int
+	updates chan *models.WagerUpdate
+}
+
+// WagerStream fans out the wager updates notified by Postgres to the subscribers
+// of this replica.
+type WagerStream struct {
+	mu          sync.Mutex
+	subscribers map[*streamSubscriber]struct{}
+}
+
+func NewWagerStream() *WagerStream {
+	return &WagerStream{
+		subscribers: map[*streamSubscriber]struct{}{},
+	}
+}
+
+// Subscribe returns a channel receiving the updates of wagerID, or of every wager when
+// wagerID is 0. The channel is closed when the subscriber can't keep up or unsubscribes.
+func (s *WagerStream) Subscribe(wagerID int) (<-chan *models.WagerUpdate, func()) {
+	subscriber := &streamSubscriber{
+		wagerID: wagerID,
+		updates: make(chan *models.WagerUpdate, subscriberBufferSize),
+	}
+	s.mu.Lock()
+	s.subscribers[subscriber] = struct{}{}
+	s.mu.Unlock()
+	return subscriber.updates, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if _, ok := s.subscribers[subscriber]; ok {
+			delete(s.subscribers, subscriber)
+			close(subscriber.updates)
+		}
+	}
+}
+
+// Publish sends update to the interested subscribers without blocking, a subscriber
+// whose buffer is full is dropped so it resumes from the database instead.
+func (s *WagerStream) Publish(update *models.WagerUpdate) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for subscriber := range s.subscribers {
+		if subscriber.wagerID != 0 && subscriber.wagerID != update.WagerID {
+			continue
+		}
+		select {
+		case subscriber.updates <- update:
+		default:
+			delete(s.subscribers, subscriber)
+			close(subscriber.updates)
+		}
+	}
+}
+
+// Listen publishes the notifications of the wager update channel until ctx is done,
+// listening again when the connection is lost.
+func (s *WagerStream) Listen(ctx context.Context, pool *pgxpool.Pool) {
+	for {
+		err := s.listen(ctx, pool)
+		if ctx.Err() != nil {
+			return
+		}
+		logs.Logger.Errorw("stopped listening to wager updates", "error", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(listenRetryInterval):
+		}
+	}
+}
+
+func (s *WagerStream) listen(ctx context.Context, pool *pgxpool.Pool) error {
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("pool.Acquire: %w", err)
+	}
+	defer conn.Release()
+	if _, err := conn.Exec(ctx, "LISTEN "+repositories.WagerUpdateChannel); err != nil {
+		return fmt.Errorf("conn.Exec: %w", err)
+	}
+	for {
+		notification, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return fmt.Errorf("conn.WaitForNotification: %w", err)
+		}
+		update := &models.WagerUpdate{}
+		if err := json.Unmarshal([]byte(notification.Payload), update); err != nil {
+			logs.Logger.Errorw("unable to parse wager update", "error", err, "payload", notification.Payload)
+			continue
+		}
+		s.Publish(update)
+	}
+}
+
+// addWagerUpdate appends an update to the wager feed, it must run in the transaction
+// of the change so the update is only notified once the change is committed.
+func (s *WagerService) addWagerUpdate(ctx context.Context, db database.Ext, updateType string, wagerID pgtype.Int4, payload interface{}) error {
+	update := &entities.WagerUpdate{}
+	database.AllNullEntity(update)
+	if err := update.Payload.Set(payload); err != nil {
+		return err
+	}
+	update.WagerID = wagerID
+	_ = update.UpdateType.Set(updateType)
+	_ = update.CreatedAt.Set(time.Now())
+	return s.WagerUpdateRepo.Create(ctx, db, update)
+}
+
+func convertWagerUpdatePg2Domain(update *entities.WagerUpdate) *models.WagerUpdate {
+	return &models.WagerUpdate{
+		ID:        update.UpdateID.Int,
+		Type:      update.UpdateType.String,
+		WagerID:   int(update.WagerID.Int),
+		Payload:   update.Payload.Bytes,
+		CreatedAt: update.CreatedAt.Time,
+	}
+}
+
+// UpdatesAfter returns at most limit updates following lastID, of wagerID or of every
+// wager when wagerID is 0, so a client can resume a stream it left.
+func (s *WagerService) UpdatesAfter(ctx context.Context, lastID int64, wagerID int, limit uint32) ([]*models.WagerUpdate, error) {
+	var filter pgtype.Int4
+	_ = filter.Set(nil)
+	if wagerID > 0 {
+		_ = filter.Set(wagerID)
+	}
+	updates, err := s.WagerUpdateRepo.ListAfter(ctx, s.DB, database.Int8(lastID), filter, limit)
+	if err != nil {
+		return nil, fmt.Errorf("unable to replay wager updates")
+	}
+	updateModels := make([]*models.WagerUpdate, 0, len(updates))
+	for _, update := range updates {
+		updateModels = append(updateModels, convertWagerUpdatePg2Domain(update))
+	}
+	return updateModels, nil
+}
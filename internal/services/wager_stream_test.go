@@ -0,0 +1,107 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/internal/models"
+	"github.com/wager-api/libs/database"
+	mock_database "github.com/wager-api/mocks/libs/database"
+	mock_repositories "github.com/wager-api/mocks/repositories"
+
+	"github.com/jackc/pgtype"
+)
+
+func Test_WagerStream_Publish(t *testing.T) {
+	t.Parallel()
+	stream := NewWagerStream()
+	all, unsubscribeAll := stream.Subscribe(0)
+	defer unsubscribeAll()
+	wager1, unsubscribeWager1 := stream.Subscribe(1)
+	defer unsubscribeWager1()
+
+	stream.Publish(&models.WagerUpdate{ID: 1, WagerID: 2})
+	stream.Publish(&models.WagerUpdate{ID: 2, WagerID: 1})
+
+	assert.Equal(t, int64(1), (<-all).ID)
+	assert.Equal(t, int64(2), (<-all).ID)
+	assert.Equal(t, int64(2), (<-wager1).ID, "a subscriber only receives the updates of its wager")
+}
+
+func Test_WagerStream_DropSlowSubscriber(t *testing.T) {
+	t.Parallel()
+	stream := NewWagerStream()
+	updates, unsubscribe := stream.Subscribe(0)
+	defer unsubscribe()
+	for i := 0; i <= subscriberBufferSize; i++ {
+		stream.Publish(&models.WagerUpdate{ID: int64(i + 1), WagerID: 1})
+	}
+	received := 0
+	for range updates {
+		received++
+	}
+	assert.Equal(t, subscriberBufferSize, received, "the channel is closed once the buffer overflows")
+}
+
+func Test_StreamWager_Resume(t *testing.T) {
+	t.Parallel()
+	db := &mock_database.Ext{}
+	wagerUpdateRepo := &mock_repositories.MockWagerUpdateRepo{}
+	wagerService := &WagerService{
+		DB:              db,
+		WagerUpdateRepo: wagerUpdateRepo,
+		Stream:          NewWagerStream(),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	wagerID := pgtype.Int4{}
+	_ = wagerID.Set(1)
+	wagerUpdateRepo.On("ListAfter", mock.Anything, db, database.Int8(5), wagerID, streamReplayLimit).Once().Return([]*entities.WagerUpdate{
+		{
+			UpdateID:   database.Int8(6),
+			UpdateType: database.Text(entities.WagerUpdatePurchaseMade),
+			WagerID:    database.Int4(1),
+			Payload:    pgtype.JSONB{Bytes: []byte(`{"wager_id":1}`), Status: pgtype.Present},
+		},
+	}, nil)
+
+	server := httptest.NewServer(http.HandlerFunc((&WagerHandler{WagerService: wagerService}).StreamWager))
+	defer server.Close()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/wagers/stream?wager_id=1", nil)
+	assert.NoError(t, err)
+	req.Header.Set("Last-Event-ID", "5")
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+	readEvent := func() string {
+		lines := []string{}
+		for {
+			line, err := reader.ReadString('\n')
+			if !assert.NoError(t, err) || line == "\n" {
+				return strings.Join(lines, "")
+			}
+			lines = append(lines, line)
+		}
+	}
+	// the missed update is replayed from the database
+	assert.Equal(t, "id: 6\nevent: purchase-made\ndata: {\"wager_id\":1}\n", readEvent())
+
+	// an update already replayed is skipped, a new one is streamed
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		wagerService.Stream.Publish(&models.WagerUpdate{ID: 6, Type: entities.WagerUpdatePurchaseMade, WagerID: 1, Payload: []byte(`{}`)})
+		wagerService.Stream.Publish(&models.WagerUpdate{ID: 7, Type: entities.WagerUpdatePriceChanged, WagerID: 1, Payload: []byte(`{"current_selling_price":40}`)})
+	}()
+	assert.Equal(t, "id: 7\nevent: price-changed\ndata: {\"current_selling_price\":40}\n", readEvent())
+}
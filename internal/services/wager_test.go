@@ -8,11 +8,13 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/wager-api/internal/entities"
 	"github.com/wager-api/internal/models"
+	"github.com/wager-api/internal/repositories"
 	"github.com/wager-api/libs/database"
 	mock_database "github.com/wager-api/mocks/libs/database"
 	mock_repositories "github.com/wager-api/mocks/repositories"
@@ -48,10 +50,28 @@ func Test_validatePlaceWagerReq(t *testing.T) {
 		},
 		{
 			name:        "odds = 0",
-			expectedErr: fmt.Errorf("the odds must be a positive integer above 0"),
+			expectedErr: fmt.Errorf("the decimal odds must be a number greater than 1"),
 			placeWagerReq: &models.PlaceWagerRequest{
 				TotalWagerValue: 10,
-				Odds:            0,
+				Odds:            "0",
+			},
+		},
+		{
+			name:        "unknown odds_format",
+			expectedErr: fmt.Errorf("the odds_format must be one of decimal, fractional, american"),
+			placeWagerReq: &models.PlaceWagerRequest{
+				TotalWagerValue: 10,
+				Odds:            "5/2",
+				OddsFormat:      "asian",
+			},
+		},
+		{
+			name:        "american odds between -100 and +100",
+			expectedErr: fmt.Errorf("the american odds must be a number greater than +100 or lesser than -100"),
+			placeWagerReq: &models.PlaceWagerRequest{
+				TotalWagerValue: 10,
+				Odds:            "+50",
+				OddsFormat:      "american",
 			},
 		},
 		{
@@ -59,7 +79,7 @@ func Test_validatePlaceWagerReq(t *testing.T) {
 			expectedErr: fmt.Errorf("the selling_percentage must be specified as an integer between 1 and 100"),
 			placeWagerReq: &models.PlaceWagerRequest{
 				TotalWagerValue:   10,
-				Odds:              20,
+				Odds:              "20",
 				SellingPercentage: 110,
 			},
 		},
@@ -68,21 +88,43 @@ func Test_validatePlaceWagerReq(t *testing.T) {
 			expectedErr: fmt.Errorf("the selling_price must be a positive decimal value to two decimal places"),
 			placeWagerReq: &models.PlaceWagerRequest{
 				TotalWagerValue:   10,
-				Odds:              20,
+				Odds:              "20",
 				SellingPercentage: 50,
 				SellingPrice:      10.112,
 			},
 		},
+		{
+			name:        "price_decay floor above the selling_price",
+			expectedErr: fmt.Errorf("the price_decay.floor_price must be lesser than selling_price"),
+			placeWagerReq: &models.PlaceWagerRequest{
+				TotalWagerValue:   10,
+				Odds:              "20",
+				SellingPercentage: 50,
+				SellingPrice:      10,
+				PriceDecay:        &models.PriceDecay{Type: "linear", FloorPrice: 12, Duration: "24h"},
+			},
+		},
 		{
 			name:        "selling_price lesser than total_wager_value * (selling_percentage / 100)",
 			expectedErr: fmt.Errorf("selling_price must be greater than total_wager_value * (selling_percentage / 100)"),
 			placeWagerReq: &models.PlaceWagerRequest{
 				TotalWagerValue:   10,
-				Odds:              20,
+				Odds:              "20",
 				SellingPercentage: 50,
 				SellingPrice:      4,
 			},
 		},
+		{
+			name:        "currency which is not an ISO 4217 code",
+			expectedErr: fmt.Errorf("the currency must be an ISO 4217 code of three letters"),
+			placeWagerReq: &models.PlaceWagerRequest{
+				TotalWagerValue:   10,
+				Odds:              "20",
+				SellingPercentage: 50,
+				SellingPrice:      10,
+				Currency:          "dollars",
+			},
+		},
 	}
 	for _, tc := range tests {
 		tc := tc
@@ -98,21 +140,30 @@ func Test_validateBuyWagerReq(t *testing.T) {
 	// t.Parallel()
 	type testcase struct {
 		name        string
-		buyWagerReq *models.BuyWagerRequest
+		buyWagerReq *models.BuyWagerCommand
 		expectedErr error
 	}
 	tests := []testcase{
+		{
+			name:        "wager_id equal to 0",
+			expectedErr: fmt.Errorf("the wager_id must be a positive integer"),
+			buyWagerReq: &models.BuyWagerCommand{
+				BuyingPrice: 20,
+			},
+		},
 		{
 			name:        "buying_price equal to 0",
 			expectedErr: fmt.Errorf("the buying_price must be a positive decimal"),
-			buyWagerReq: &models.BuyWagerRequest{
+			buyWagerReq: &models.BuyWagerCommand{
+				WagerID:     1,
 				BuyingPrice: 0,
 			},
 		},
 		{
 			name:        "buying_price less than 0",
 			expectedErr: fmt.Errorf("the buying_price must be a positive decimal"),
-			buyWagerReq: &models.BuyWagerRequest{
+			buyWagerReq: &models.BuyWagerCommand{
+				WagerID:     1,
 				BuyingPrice: -20,
 			},
 		},
@@ -135,14 +186,14 @@ func Test_validateListWagerParams(t *testing.T) {
 	// reqWithZeroValue
 	ctx := context.Background()
 	reqWithZeroValue := &http.Request{}
-	ctx = context.WithValue(ctx, "limit", 0)
-	ctx = context.WithValue(ctx, "page", 0)
+	ctx = context.WithValue(ctx, limitKey, 0)
+	ctx = context.WithValue(ctx, pageKey, 0)
 	reqWithZeroValue = reqWithZeroValue.WithContext(ctx)
 	//reqWithWrongFormatParam
 	ctxreqWithWrongFormatParam := context.Background()
 	reqWithWrongFormatParam := &http.Request{}
-	ctxreqWithWrongFormatParam = context.WithValue(ctxreqWithWrongFormatParam, "limit", "")
-	ctxreqWithWrongFormatParam = context.WithValue(ctxreqWithWrongFormatParam, "page", "")
+	ctxreqWithWrongFormatParam = context.WithValue(ctxreqWithWrongFormatParam, limitKey, "")
+	ctxreqWithWrongFormatParam = context.WithValue(ctxreqWithWrongFormatParam, pageKey, "")
 	reqWithWrongFormatParam = reqWithZeroValue.WithContext(ctxreqWithWrongFormatParam)
 
 	tests := []testcase{
@@ -160,7 +211,7 @@ func Test_validateListWagerParams(t *testing.T) {
 	for _, tc := range tests {
 		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
-			err := validateListWagerParam(tc.req)
+			err := validatePaginationParam(tc.req)
 			assert.Equal(t, tc.expectedErr, err)
 
 		})
@@ -171,6 +222,7 @@ func Test_PlaceWager(t *testing.T) {
 	t.Parallel()
 
 	db := &mock_database.Ext{}
+	tx := &mock_database.Tx{}
 	wagerRepo := &mock_repositories.MockWagerRepo{}
 	purchaseRepo := &mock_repositories.MockPurchaseRepo{}
 	mockErr := fmt.Errorf("mock-error")
@@ -192,7 +244,9 @@ func Test_PlaceWager(t *testing.T) {
 			jsonReq:        []byte(`{"total_wager_value": 20, "odds": 30,"selling_percentage": 30,"selling_price": 50}`),
 			expectedStatus: http.StatusInternalServerError,
 			setup: func(ctx context.Context) {
-				wagerRepo.On("Create", ctx, db, mock.Anything, mock.Anything).Once().Return(mockErr)
+				db.On("Begin", ctx).Return(tx, nil)
+				tx.On("Rollback", mock.Anything).Return(nil)
+				wagerRepo.On("Create", ctx, tx, mock.Anything).Once().Return(mockErr)
 			},
 		},
 	}
@@ -208,7 +262,7 @@ func Test_PlaceWager(t *testing.T) {
 			tc.setup(context.Background())
 			req := httptest.NewRequest(http.MethodPost, tc.url, bytes.NewBuffer([]byte(tc.jsonReq)))
 			rec := httptest.NewRecorder()
-			http.HandlerFunc(mockWagerHandler.WagerService.PlaceWager).ServeHTTP(rec, req)
+			http.HandlerFunc(mockWagerHandler.PlaceWager).ServeHTTP(rec, req)
 			assert.Equal(t, tc.expectedStatus, rec.Code)
 			data, err := ioutil.ReadAll(rec.Body)
 			assert.NoError(t, err)
@@ -224,9 +278,11 @@ func Test_BuyWager(t *testing.T) {
 	tx := &mock_database.Tx{}
 	wagerRepo := &mock_repositories.MockWagerRepo{}
 	purchaseRepo := &mock_repositories.MockPurchaseRepo{}
+	marketRepo := &mock_repositories.MockMarketRepo{}
+	eventRepo := &mock_repositories.MockEventRepo{}
 	ctx := context.Background()
 	wagerID := 1
-	ctx = context.WithValue(ctx, "wager_id", wagerID)
+	ctx = context.WithValue(ctx, wagerIDKey, wagerID)
 	mockErr := fmt.Errorf("mock-error")
 	testcases := []TestCase{
 		{
@@ -242,6 +298,48 @@ func Test_BuyWager(t *testing.T) {
 				wagerRepo.On("Get", ctx, tx, database.Int4(int32(wagerID))).Once().Return(nil, mockErr)
 			},
 		},
+		{
+			ctx:            ctx,
+			name:           "the market of the wager is closed",
+			expectedResp:   []byte(`{"error":"unable to buy wager: wager sales are closed"}`),
+			url:            "/buy/1",
+			jsonReq:        []byte(`{"buying_price": 20}`),
+			expectedStatus: http.StatusConflict,
+			setup: func(ctx context.Context) {
+				db.On("Begin", ctx).Return(tx, nil)
+				tx.On("Rollback", mock.Anything).Return(nil)
+				wagerRepo.On("Get", ctx, tx, database.Int4(int32(wagerID))).Once().Return(&entities.Wager{
+					WagerID:             database.Int4(int32(wagerID)),
+					MarketID:            database.Int4(1),
+					CurrentSellingPrice: database.Float4(50),
+				}, nil)
+				marketRepo.On("Get", ctx, tx, database.Int4(1)).Once().Return(&entities.Market{
+					MarketID: database.Int4(1),
+					Status:   database.Text(entities.MarketStatusClosed),
+				}, nil)
+			},
+		},
+		{
+			ctx:            ctx,
+			name:           "the buying_price is above the decayed price",
+			expectedResp:   []byte(`{"error":"unable to buy wager: unable to execute: buying_price must be lesser or equal to current_selling_price"}`),
+			url:            "/buy/1",
+			jsonReq:        []byte(`{"buying_price": 20}`),
+			expectedStatus: http.StatusInternalServerError,
+			setup: func(ctx context.Context) {
+				db.On("Begin", ctx).Return(tx, nil)
+				tx.On("Rollback", mock.Anything).Return(nil)
+				wagerRepo.On("Get", ctx, tx, database.Int4(int32(wagerID))).Once().Return(&entities.Wager{
+					WagerID:              database.Int4(int32(wagerID)),
+					SellingPrice:         database.Float4(50),
+					CurrentSellingPrice:  database.Float4(50),
+					PlaceAt:              database.Timestamptz(time.Now().Add(-48 * time.Hour)),
+					DecayType:            database.Text(entities.WagerDecayLinear),
+					DecayFloorPrice:      database.Float4(10),
+					DecayDurationSeconds: database.Int4(24 * 3600),
+				}, nil)
+			},
+		},
 		{
 			// validation request
 			name:           "bad request (violate input condition)",
@@ -261,12 +359,14 @@ func Test_BuyWager(t *testing.T) {
 				DB:           db,
 				WagerRepo:    wagerRepo,
 				PurchaseRepo: purchaseRepo,
+				MarketRepo:   marketRepo,
+				EventRepo:    eventRepo,
 			}
 			mockWagerHandler := WagerHandler{WagerService: wagerService}
 			req := httptest.NewRequest(http.MethodPost, tc.url, bytes.NewBuffer([]byte(tc.jsonReq)))
 			req = req.WithContext(ctx)
 			rec := httptest.NewRecorder()
-			http.HandlerFunc(mockWagerHandler.WagerService.BuyWager).ServeHTTP(rec, req)
+			http.HandlerFunc(mockWagerHandler.BuyWager).ServeHTTP(rec, req)
 			assert.Equal(t, tc.expectedStatus, rec.Code)
 			data, err := ioutil.ReadAll(rec.Body)
 			assert.NoError(t, err)
@@ -288,8 +388,11 @@ func Test_ListWager(t *testing.T) {
 	limit := 10
 	nilWagerID := pgtype.Int4{}
 	_ = nilWagerID.Set(nil)
-	ctx = context.WithValue(ctx, "page", page)
-	ctx = context.WithValue(ctx, "limit", limit)
+	noFilter := repositories.WagerFilter{}
+	_ = noFilter.EventID.Set(nil)
+	_ = noFilter.MarketID.Set(nil)
+	ctx = context.WithValue(ctx, pageKey, page)
+	ctx = context.WithValue(ctx, limitKey, limit)
 	wagers := []*entities.Wager{
 		{
 			WagerID:         database.Int4(1),
@@ -305,12 +408,12 @@ func Test_ListWager(t *testing.T) {
 		{
 			ctx:          ctx,
 			name:         "happy case",
-			expectedResp: []byte(`[{"id":1,"total_wager_value":100,"odds":0,"selling_percentage":0,"selling_price":0,"current_selling_price":0,"percentage_sold":0,"amount_sold":0,"placed_at":null},{"id":2,"total_wager_value":100,"odds":0,"selling_percentage":0,"selling_price":0,"current_selling_price":0,"percentage_sold":0,"amount_sold":0,"placed_at":null}]`),
+			expectedResp: []byte(`[{"id":1,"total_wager_value":100,"odds":0,"odds_format":"decimal","selling_percentage":0,"selling_price":0,"current_selling_price":0,"percentage_sold":0,"amount_sold":0,"placed_at":null},{"id":2,"total_wager_value":100,"odds":0,"odds_format":"decimal","selling_percentage":0,"selling_price":0,"current_selling_price":0,"percentage_sold":0,"amount_sold":0,"placed_at":null}]`),
 			// work in both cases /wagers?page=:4&limit=:4 and /wagers?page=4&limit=4
 			url:            "/wagers?page=:4&limit=:4",
 			expectedStatus: http.StatusOK,
 			setup: func(ctx context.Context) {
-				wagerRepo.On("List", ctx, db, nilWagerID, uint32(limit)).Once().Return(wagers, nil)
+				wagerRepo.On("List", ctx, db, noFilter, nilWagerID, uint32(0), uint32(limit)).Once().Return(wagers, nil)
 			},
 		},
 		{
@@ -321,7 +424,7 @@ func Test_ListWager(t *testing.T) {
 			url:            "/wagers?page=:4&limit=:4",
 			expectedStatus: http.StatusInternalServerError,
 			setup: func(ctx context.Context) {
-				wagerRepo.On("List", ctx, db, nilWagerID, uint32(limit)).Once().Return(nil, mockErr)
+				wagerRepo.On("List", ctx, db, noFilter, nilWagerID, uint32(0), uint32(limit)).Once().Return(nil, mockErr)
 			},
 		},
 	}
@@ -340,7 +443,7 @@ func Test_ListWager(t *testing.T) {
 			req := httptest.NewRequest(http.MethodGet, tc.url, bytes.NewBuffer([]byte(tc.jsonReq)))
 			req = req.WithContext(ctx)
 			rec := httptest.NewRecorder()
-			http.HandlerFunc(mockWagerHandler.WagerService.ListWager).ServeHTTP(rec, req)
+			http.HandlerFunc(mockWagerHandler.ListWager).ServeHTTP(rec, req)
 			assert.Equal(t, tc.expectedStatus, rec.Code)
 			data, err := ioutil.ReadAll(rec.Body)
 			assert.NoError(t, err)
@@ -350,3 +453,128 @@ func Test_ListWager(t *testing.T) {
 		})
 	}
 }
+
+func Test_ListWager_FilteredPage(t *testing.T) {
+	t.Parallel()
+	db := &mock_database.Ext{}
+	wagerRepo := &mock_repositories.MockWagerRepo{}
+	handler := WagerHandler{WagerService: &WagerService{DB: db, WagerRepo: wagerRepo}}
+
+	nilWagerID := pgtype.Int4{}
+	_ = nilWagerID.Set(nil)
+	filter := repositories.WagerFilter{}
+	_ = filter.EventID.Set(3)
+	_ = filter.MarketID.Set(nil)
+	// the second page of the wagers of the event skips the first 4 of them, whatever their ids
+	wagerRepo.On("List", mock.Anything, db, filter, nilWagerID, uint32(4), uint32(4)).Once().Return([]*entities.Wager{
+		{WagerID: database.Int4(42), Odds: database.Float8(2)},
+	}, nil)
+
+	ctx := context.WithValue(context.Background(), pageKey, 2)
+	ctx = context.WithValue(ctx, limitKey, 4)
+	ctx = context.WithValue(ctx, eventIDKey, 3)
+	req := httptest.NewRequest(http.MethodGet, "/wagers?page=2&limit=4&event_id=3", nil).WithContext(ctx)
+	rec := httptest.NewRecorder()
+	http.HandlerFunc(handler.ListWager).ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	wagerRepo.AssertExpectations(t)
+}
+
+func Test_WagerService_List(t *testing.T) {
+	t.Parallel()
+	db := &mock_database.Ext{}
+	wagerRepo := &mock_repositories.MockWagerRepo{}
+	wagerService := &WagerService{DB: db, WagerRepo: wagerRepo}
+	ctx := context.Background()
+
+	filter := repositories.WagerFilter{}
+	_ = filter.EventID.Set(2)
+	_ = filter.MarketID.Set(nil)
+	wagerRepo.On("List", ctx, db, filter, database.Int4(10), uint32(0), uint32(5)).Once().Return([]*entities.Wager{
+		{WagerID: database.Int4(11), Odds: database.Float8(3)},
+	}, nil)
+
+	wagers, err := wagerService.List(ctx, &models.ListWagersQuery{AfterID: 10, Limit: 5, EventID: 2, OddsFormat: models.OddsFormatAmerican})
+	if assert.NoError(t, err) && assert.Len(t, wagers, 1) {
+		assert.Equal(t, 11, wagers[0].ID)
+		assert.Equal(t, models.OddsValue("+200"), wagers[0].Odds)
+	}
+
+	_, err = wagerService.List(ctx, &models.ListWagersQuery{Limit: 0})
+	assert.Equal(t, http.StatusBadRequest, statusFromError(err), "a query without limit is invalid")
+}
+
+func Test_PlaceBatch(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	valid := func() *models.PlaceWagerRequest {
+		return &models.PlaceWagerRequest{TotalWagerValue: 50, Odds: "30", SellingPercentage: 30, SellingPrice: 50}
+	}
+	invalid := &models.PlaceWagerRequest{TotalWagerValue: 0, Odds: "30", SellingPercentage: 30, SellingPrice: 50}
+
+	t.Run("atomic batch with an invalid wager places nothing", func(t *testing.T) {
+		wagerRepo := &mock_repositories.MockWagerRepo{}
+		s := &WagerService{WagerRepo: wagerRepo}
+		batch, err := s.PlaceBatch(ctx, []*models.PlaceWagerRequest{valid(), invalid}, "")
+		assert.EqualError(t, err, "wager 1: the total_wager_value must be a positive integer above 0")
+		assert.Equal(t, http.StatusBadRequest, statusFromError(err))
+		assert.Equal(t, PlaceBatchAtomic, batch.Mode)
+		assert.Equal(t, 0, batch.Placed)
+		assert.Equal(t, 1, batch.Failed)
+		assert.Equal(t, placeBatchStatusAborted, batch.Results[0].Status)
+		assert.Equal(t, placeBatchStatusFailed, batch.Results[1].Status)
+		wagerRepo.AssertNotCalled(t, "CreateBatch", mock.Anything, mock.Anything, mock.Anything)
+	})
+	t.Run("best effort batch places the valid wagers", func(t *testing.T) {
+		db := &mock_database.Ext{}
+		tx := &mock_database.Tx{}
+		wagerRepo := &mock_repositories.MockWagerRepo{}
+		wagerUpdateRepo := &mock_repositories.MockWagerUpdateRepo{}
+		outboxRepo := &mock_repositories.MockOutboxRepo{}
+		auditLogRepo := &mock_repositories.MockAuditLogRepo{}
+		priceHistoryRepo := &mock_repositories.MockPriceHistoryRepo{}
+		db.On("Begin", ctx).Return(tx, nil)
+		tx.On("Commit", mock.Anything).Return(nil)
+		wagerRepo.On("CreateBatch", ctx, tx, mock.MatchedBy(func(wagers []*entities.Wager) bool {
+			return len(wagers) == 2
+		})).Once().Run(func(args mock.Arguments) {
+			for i, wager := range args.Get(2).([]*entities.Wager) {
+				wager.WagerID = database.Int4(int32(i + 10))
+			}
+		}).Return(nil)
+		wagerUpdateRepo.On("Create", ctx, tx, mock.Anything).Twice().Return(nil)
+		outboxRepo.On("Create", ctx, tx, mock.Anything).Twice().Return(nil)
+		auditLogRepo.On("Create", ctx, tx, mock.Anything).Twice().Return(nil)
+		// each wager placed starts its price history at its current selling price
+		priceHistoryRepo.On("Create", ctx, tx, mock.MatchedBy(func(priceHistory *entities.WagerPriceHistory) bool {
+			return (priceHistory.WagerID.Int == 10 || priceHistory.WagerID.Int == 11) && priceHistory.Price.Float == 50
+		})).Twice().Return(nil)
+
+		s := &WagerService{
+			DB:               db,
+			WagerRepo:        wagerRepo,
+			WagerUpdateRepo:  wagerUpdateRepo,
+			OutboxRepo:       outboxRepo,
+			AuditLogRepo:     auditLogRepo,
+			PriceHistoryRepo: priceHistoryRepo,
+		}
+		batch, err := s.PlaceBatch(ctx, []*models.PlaceWagerRequest{valid(), invalid, valid()}, PlaceBatchBestEffort)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, batch.Placed)
+		assert.Equal(t, 1, batch.Failed)
+		assert.Equal(t, placeBatchStatusPlaced, batch.Results[0].Status)
+		assert.Equal(t, 10, batch.Results[0].Wager.ID)
+		assert.Equal(t, "the total_wager_value must be a positive integer above 0", batch.Results[1].Error)
+		assert.Equal(t, 11, batch.Results[2].Wager.ID)
+		wagerRepo.AssertExpectations(t)
+		auditLogRepo.AssertExpectations(t)
+		priceHistoryRepo.AssertExpectations(t)
+	})
+	t.Run("bad batch", func(t *testing.T) {
+		s := &WagerService{}
+		_, err := s.PlaceBatch(ctx, nil, PlaceBatchAtomic)
+		assert.EqualError(t, err, "the batch must hold between 1 and 1000 wagers")
+		_, err = s.PlaceBatch(ctx, []*models.PlaceWagerRequest{valid()}, "some")
+		assert.EqualError(t, err, "the mode must be one of atomic, best_effort")
+	})
+}
@@ -0,0 +1,726 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/internal/models"
+	"github.com/wager-api/internal/repositories"
+	"github.com/wager-api/libs/database"
+	"github.com/wager-api/libs/logs"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+	"go.uber.org/multierr"
+)
+
+const (
+	// WebhookSignatureHeader carries `t=<unix time>,v1=<hex hmac>`, see SignWebhookPayload
+	WebhookSignatureHeader = "X-Wager-Signature"
+	WebhookEventHeader     = "X-Wager-Event"
+	WebhookDeliveryHeader  = "X-Wager-Delivery"
+
+	defaultWebhookBatchSize    = 50
+	defaultWebhookMaxAttempts  = 8
+	defaultWebhookDisableAfter = 20
+	defaultWebhookMinBackoff   = 10 * time.Second
+	defaultWebhookMaxBackoff   = time.Hour
+	defaultWebhookTimeout      = 10 * time.Second
+	defaultWebhookLease        = 10 * time.Minute
+	defaultDeliveryLogLimit    = 50
+	maxDeliveryLogLimit        = 500
+	minWebhookSecretLength     = 16
+	// the response of a receiver is not stored, only read to reuse the connection
+	maxWebhookResponseRead = 4096
+)
+
+// webhookEventTypes lists the events a webhook can subscribe to.
+var webhookEventTypes = map[string]bool{
+	entities.WebhookEventWagerBought: true,
+}
+
+// WebhookService notifies the sellers of the events of their wagers. The deliveries are
+// queued in the transaction of the event and posted by Run, a delivery failing is
+// retried with a backoff and a webhook failing too many times in a row is disabled.
+type WebhookService struct {
+	DB          database.Ext
+	WebhookRepo interface {
+		Create(ctx context.Context, db database.Ext, webhook *entities.Webhook) error
+		Get(ctx context.Context, db database.Ext, webhookID pgtype.Int4, queryEnhancers ...repositories.QueryEnhancer) (*entities.Webhook, error)
+		ListActiveBySeller(ctx context.Context, db database.Ext, sellerID pgtype.Text, eventType pgtype.Text) ([]*entities.Webhook, error)
+		UpdateStatus(ctx context.Context, db database.Ext, webhook *entities.Webhook) (pgconn.CommandTag, error)
+		RecordSuccess(ctx context.Context, db database.Ext, webhookID pgtype.Int4) (pgconn.CommandTag, error)
+		RecordFailure(ctx context.Context, db database.Ext, webhookID pgtype.Int4, disableAfter int32, now pgtype.Timestamptz) (bool, error)
+	}
+	WebhookDeliveryRepo interface {
+		Create(ctx context.Context, db database.Ext, delivery *entities.WebhookDelivery) error
+		ClaimDue(ctx context.Context, db database.Ext, now, lockedUntil pgtype.Timestamptz, limit uint32) ([]*entities.WebhookDelivery, error)
+		Release(ctx context.Context, db database.Ext, deliveryID pgtype.Int8) (pgconn.CommandTag, error)
+		ListByWebhook(ctx context.Context, db database.Ext, webhookID pgtype.Int4, beforeID pgtype.Int8, limit uint32) ([]*entities.WebhookDelivery, error)
+		UpdateAttempt(ctx context.Context, db database.Ext, delivery *entities.WebhookDelivery) (pgconn.CommandTag, error)
+	}
+	AuditLogRepo interface {
+		Create(ctx context.Context, db database.Ext, auditLog *entities.AuditLog) error
+	}
+	Client    *http.Client
+	BatchSize uint32
+	// MaxAttempts is how many times a delivery is tried before it is given up
+	MaxAttempts int32
+	// DisableAfter is how many deliveries in a row a webhook can fail before it is disabled
+	DisableAfter int32
+	MinBackoff   time.Duration
+	MaxBackoff   time.Duration
+	// Lease is how long the deliveries claimed by a dispatcher are left to it, it should
+	// cover the posting of a whole batch
+	Lease time.Duration
+	// AllowPrivateURLs lets the webhooks post to loopback, private and link-local
+	// addresses, for local receivers only
+	AllowPrivateURLs bool
+	// Resolver resolves the hosts of the webhook urls, net.DefaultResolver when nil
+	Resolver interface {
+		LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+	}
+
+	wakeupOnce sync.Once
+	wakeup     chan struct{}
+}
+
+// publicIP tells whether a webhook may reach ip, the loopback, private, link-local,
+// unspecified and multicast addresses are internal hosts.
+func publicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsUnspecified() && !ip.IsMulticast()
+}
+
+// NewWebhookClient returns the client posting the deliveries, it only connects to
+// public addresses so a host resolving to an internal one after its webhook is created
+// is not reached either. It goes through no proxy, which would be connected to instead.
+func NewWebhookClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+				return fmt.Errorf("the address %s is not public", host)
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+			MaxIdleConnsPerHost: 2,
+		},
+	}
+}
+
+// checkWebhookHost resolves the host of a webhook url, every address it resolves to
+// must be public unless AllowPrivateURLs is set.
+func (s *WebhookService) checkWebhookHost(ctx context.Context, rawURL string) error {
+	if s.AllowPrivateURLs {
+		return nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("the url must be an absolute http or https url")
+	}
+	host := u.Hostname()
+	addrs := []net.IPAddr{}
+	if ip := net.ParseIP(host); ip != nil {
+		addrs = append(addrs, net.IPAddr{IP: ip})
+	} else {
+		var resolver interface {
+			LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+		} = net.DefaultResolver
+		if s.Resolver != nil {
+			resolver = s.Resolver
+		}
+		if addrs, err = resolver.LookupIPAddr(ctx, host); err != nil || len(addrs) == 0 {
+			return fmt.Errorf("unable to resolve the url host")
+		}
+	}
+	for _, addr := range addrs {
+		if !publicIP(addr.IP) {
+			return fmt.Errorf("the url must not point to a loopback, private or link-local address")
+		}
+	}
+	return nil
+}
+
+// SignWebhookPayload returns the hex HMAC-SHA256 of `<timestamp>.<body>` with secret,
+// receivers compute it again to check a delivery comes from us and is not replayed.
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = fmt.Fprintf(mac, "%d.", timestamp)
+	_, _ = mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *WebhookService) wakeupChan() chan struct{} {
+	s.wakeupOnce.Do(func() {
+		s.wakeup = make(chan struct{}, 1)
+	})
+	return s.wakeup
+}
+
+// Notify wakes Run up to post the deliveries queued by a transaction which has just
+// been committed, without waiting for the next tick.
+func (s *WebhookService) Notify() {
+	select {
+	case s.wakeupChan() <- struct{}{}:
+	default:
+	}
+}
+
+// Enqueue queues a delivery of an event for every active webhook of the seller
+// subscribed to it, it must run in the transaction of the event.
+func (s *WebhookService) Enqueue(ctx context.Context, db database.Ext, sellerID pgtype.Text, eventType string, payload interface{}) error {
+	if sellerID.Status != pgtype.Present {
+		return nil
+	}
+	webhooks, err := s.WebhookRepo.ListActiveBySeller(ctx, db, sellerID, database.Text(eventType))
+	if err != nil {
+		return fmt.Errorf("unable to list webhooks: %w", err)
+	}
+	now := time.Now()
+	for _, webhook := range webhooks {
+		delivery := &entities.WebhookDelivery{}
+		database.AllNullEntity(delivery)
+		if err := multierr.Combine(
+			delivery.Payload.Set(payload),
+			delivery.EventType.Set(eventType),
+			delivery.Status.Set(entities.WebhookDeliveryStatusPending),
+			delivery.Attempts.Set(0),
+			delivery.NextAttemptAt.Set(now),
+			delivery.CreatedAt.Set(now),
+		); err != nil {
+			return fmt.Errorf("unable to generate webhook delivery: %w", err)
+		}
+		delivery.WebhookID = webhook.WebhookID
+		if err := s.WebhookDeliveryRepo.Create(ctx, db, delivery); err != nil {
+			return fmt.Errorf("unable to create webhook delivery: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *WebhookService) maxAttempts() int32 {
+	if s.MaxAttempts <= 0 {
+		return defaultWebhookMaxAttempts
+	}
+	return s.MaxAttempts
+}
+
+func (s *WebhookService) disableAfter() int32 {
+	if s.DisableAfter <= 0 {
+		return defaultWebhookDisableAfter
+	}
+	return s.DisableAfter
+}
+
+func (s *WebhookService) batchSize() uint32 {
+	if s.BatchSize == 0 {
+		return defaultWebhookBatchSize
+	}
+	return s.BatchSize
+}
+
+func (s *WebhookService) lease() time.Duration {
+	if s.Lease <= 0 {
+		return defaultWebhookLease
+	}
+	return s.Lease
+}
+
+func (s *WebhookService) backoff(attempts int32) time.Duration {
+	minBackoff, maxBackoff := s.MinBackoff, s.MaxBackoff
+	if minBackoff <= 0 {
+		minBackoff = defaultWebhookMinBackoff
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = defaultWebhookMaxBackoff
+	}
+	return exponentialBackoff(attempts, minBackoff, maxBackoff)
+}
+
+// post delivers one event to a webhook, it returns the status of the response when
+// the receiver answered.
+func (s *WebhookService) post(ctx context.Context, webhook *entities.Webhook, delivery *entities.WebhookDelivery, now time.Time) (int, error) {
+	body, err := json.Marshal(&models.WebhookEvent{
+		ID:        delivery.DeliveryID.Int,
+		Type:      delivery.EventType.String,
+		CreatedAt: delivery.CreatedAt.Time,
+		Data:      delivery.Payload.Bytes,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("unable to encode event: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL.String, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("unable to build request: %w", err)
+	}
+	timestamp := now.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, delivery.EventType.String)
+	req.Header.Set(WebhookDeliveryHeader, strconv.FormatInt(delivery.DeliveryID.Int, 10))
+	req.Header.Set(WebhookSignatureHeader, fmt.Sprintf("t=%d,v1=%s", timestamp, SignWebhookPayload(webhook.Secret.String, timestamp, body)))
+	client := s.Client
+	if client == nil && s.AllowPrivateURLs {
+		client = &http.Client{Timeout: defaultWebhookTimeout}
+	} else if client == nil {
+		client = NewWebhookClient(defaultWebhookTimeout)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("unable to post event: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxWebhookResponseRead))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("the receiver answered with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// DispatchOnce posts a batch of due deliveries and returns how many were attempted. The
+// deliveries are claimed for a lease at once and posted outside of any transaction, the
+// outcome of each is recorded in a short transaction of its own.
+func (s *WebhookService) DispatchOnce(ctx context.Context) (int, error) {
+	now := time.Now()
+	deliveries, err := s.WebhookDeliveryRepo.ClaimDue(ctx, s.DB, database.Timestamptz(now), database.Timestamptz(now.Add(s.lease())), s.batchSize())
+	if err != nil {
+		return 0, fmt.Errorf("unable to claim due deliveries: %w", err)
+	}
+	var attempted int
+	webhooks := map[int32]*entities.Webhook{}
+	for _, delivery := range deliveries {
+		webhook, ok := webhooks[delivery.WebhookID.Int]
+		if !ok {
+			webhook, err = s.WebhookRepo.Get(ctx, s.DB, delivery.WebhookID)
+			if err != nil {
+				return attempted, fmt.Errorf("unable to get webhook: %w", err)
+			}
+			webhooks[delivery.WebhookID.Int] = webhook
+		}
+		// disabled by a failure earlier in this batch, the delivery waits for it to be
+		// enabled again
+		if webhook.Status.String != entities.WebhookStatusActive {
+			if _, err := s.WebhookDeliveryRepo.Release(ctx, s.DB, delivery.DeliveryID); err != nil {
+				return attempted, fmt.Errorf("unable to release delivery: %w", err)
+			}
+			continue
+		}
+		now := time.Now()
+		responseStatus, postErr := s.post(ctx, webhook, delivery, now)
+		attempted++
+		disabled, err := s.recordAttempt(ctx, webhook, delivery, responseStatus, postErr, now)
+		if err != nil {
+			return attempted, err
+		}
+		if disabled {
+			logs.Logger.Warnw("disabled a webhook failing too many times in a row", "webhook_id", webhook.WebhookID.Int, "error", postErr)
+			_ = webhook.Status.Set(entities.WebhookStatusDisabled)
+		}
+	}
+	return attempted, nil
+}
+
+// recordAttempt records the outcome of a delivery posted at now along the health of its
+// webhook, it returns whether the webhook got disabled.
+func (s *WebhookService) recordAttempt(ctx context.Context, webhook *entities.Webhook, delivery *entities.WebhookDelivery, responseStatus int, postErr error, now time.Time) (bool, error) {
+	_ = delivery.Attempts.Set(delivery.Attempts.Int + 1)
+	_ = delivery.ResponseStatus.Set(nil)
+	if responseStatus > 0 {
+		_ = delivery.ResponseStatus.Set(responseStatus)
+	}
+	if postErr == nil {
+		_ = delivery.Status.Set(entities.WebhookDeliveryStatusSucceeded)
+		_ = delivery.DeliveredAt.Set(now)
+		_ = delivery.LastError.Set(nil)
+	} else {
+		_ = delivery.LastError.Set(postErr.Error())
+		if delivery.Attempts.Int >= s.maxAttempts() {
+			_ = delivery.Status.Set(entities.WebhookDeliveryStatusFailed)
+		} else {
+			_ = delivery.NextAttemptAt.Set(now.Add(s.backoff(delivery.Attempts.Int - 1)))
+		}
+	}
+	var disabled bool
+	err := database.ExecInTx(ctx, s.DB, func(ctx context.Context, tx pgx.Tx) error {
+		if _, err := s.WebhookDeliveryRepo.UpdateAttempt(ctx, tx, delivery); err != nil {
+			return fmt.Errorf("unable to update delivery: %w", err)
+		}
+		if postErr == nil {
+			if _, err := s.WebhookRepo.RecordSuccess(ctx, tx, webhook.WebhookID); err != nil {
+				return fmt.Errorf("unable to update webhook: %w", err)
+			}
+			return nil
+		}
+		var err error
+		if disabled, err = s.WebhookRepo.RecordFailure(ctx, tx, webhook.WebhookID, s.disableAfter(), database.Timestamptz(now)); err != nil {
+			return fmt.Errorf("unable to update webhook: %w", err)
+		}
+		return nil
+	})
+	return disabled, err
+}
+
+// Run posts the due deliveries every interval, or as soon as Notify is called, until
+// ctx is done.
+func (s *WebhookService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wakeupChan():
+		}
+		for {
+			attempted, err := s.DispatchOnce(ctx)
+			if err != nil {
+				logs.Logger.Errorw("unable to dispatch the webhook deliveries", "error", err)
+				break
+			}
+			if uint32(attempted) < s.batchSize() {
+				break
+			}
+		}
+	}
+}
+
+func validateCreateWebhookReq(req *models.CreateWebhookRequest) error {
+	if strings.TrimSpace(req.SellerID) == "" {
+		return fmt.Errorf("the seller_id must not be empty")
+	}
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("the url must be an absolute http or https url")
+	}
+	if req.Secret != "" && len(req.Secret) < minWebhookSecretLength {
+		return fmt.Errorf("the secret must be at least %d characters long", minWebhookSecretLength)
+	}
+	if len(req.EventTypes) == 0 {
+		return fmt.Errorf("the event_types must not be empty")
+	}
+	for _, eventType := range req.EventTypes {
+		if !webhookEventTypes[eventType] {
+			return fmt.Errorf("the event_types must be among %s", entities.WebhookEventWagerBought)
+		}
+	}
+	return nil
+}
+
+func generateWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+// snapshotWebhook is snapshotEntity without the secret, the audit log must not leak it.
+func snapshotWebhook(webhook *entities.Webhook) (pgtype.JSONB, error) {
+	redacted := *webhook
+	redacted.Secret = pgtype.Text{Status: pgtype.Undefined}
+	return snapshotEntity(&redacted)
+}
+
+func convertWebhookPg2Domain(webhook *entities.Webhook) *models.Webhook {
+	var disabledAt *time.Time
+	if webhook.DisabledAt.Status == pgtype.Present {
+		disabledAt = &webhook.DisabledAt.Time
+	}
+	eventTypes := []string{}
+	_ = webhook.EventTypes.AssignTo(&eventTypes)
+	return &models.Webhook{
+		ID:                  int(webhook.WebhookID.Int),
+		SellerID:            webhook.SellerID.String,
+		URL:                 webhook.URL.String,
+		EventTypes:          eventTypes,
+		Status:              webhook.Status.String,
+		ConsecutiveFailures: int(webhook.ConsecutiveFailures.Int),
+		DisabledAt:          disabledAt,
+		CreatedAt:           webhook.CreatedAt.Time,
+	}
+}
+
+func convertWebhookDeliveryPg2Domain(delivery *entities.WebhookDelivery) *models.WebhookDelivery {
+	var nextAttemptAt, deliveredAt *time.Time
+	if delivery.Status.String == entities.WebhookDeliveryStatusPending {
+		nextAttemptAt = &delivery.NextAttemptAt.Time
+	}
+	if delivery.DeliveredAt.Status == pgtype.Present {
+		deliveredAt = &delivery.DeliveredAt.Time
+	}
+	var responseStatus *int
+	if delivery.ResponseStatus.Status == pgtype.Present {
+		status := int(delivery.ResponseStatus.Int)
+		responseStatus = &status
+	}
+	return &models.WebhookDelivery{
+		ID:             delivery.DeliveryID.Int,
+		WebhookID:      int(delivery.WebhookID.Int),
+		EventType:      delivery.EventType.String,
+		Payload:        delivery.Payload.Bytes,
+		Status:         delivery.Status.String,
+		Attempts:       int(delivery.Attempts.Int),
+		NextAttemptAt:  nextAttemptAt,
+		ResponseStatus: responseStatus,
+		LastError:      delivery.LastError.String,
+		CreatedAt:      delivery.CreatedAt.Time,
+		DeliveredAt:    deliveredAt,
+	}
+}
+
+// CreateWebhook subscribes an endpoint of a seller to events of their wagers, the
+// secret signing the deliveries is only returned in this response.
+func (s *WebhookService) CreateWebhook(resp http.ResponseWriter, req *http.Request) {
+	createWebhookRequest := &models.CreateWebhookRequest{}
+	err := json.NewDecoder(req.Body).Decode(&createWebhookRequest)
+	defer req.Body.Close()
+	if err != nil {
+		resp.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": "unable to parse request",
+		})
+		return
+	}
+	err = validateCreateWebhookReq(createWebhookRequest)
+	if err == nil {
+		err = s.checkWebhookHost(req.Context(), createWebhookRequest.URL)
+	}
+	if err != nil {
+		resp.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	secret := createWebhookRequest.Secret
+	if secret == "" {
+		if secret, err = generateWebhookSecret(); err != nil {
+			resp.WriteHeader(http.StatusInternalServerError)
+			_ = json.NewEncoder(resp).Encode(map[string]string{
+				"error": "unable to generate webhook secret",
+			})
+			return
+		}
+	}
+
+	now := time.Now()
+	webhook := &entities.Webhook{}
+	database.AllNullEntity(webhook)
+	if err = multierr.Combine(
+		webhook.SellerID.Set(strings.TrimSpace(createWebhookRequest.SellerID)),
+		webhook.URL.Set(createWebhookRequest.URL),
+		webhook.Secret.Set(secret),
+		webhook.EventTypes.Set(createWebhookRequest.EventTypes),
+		webhook.Status.Set(entities.WebhookStatusActive),
+		webhook.ConsecutiveFailures.Set(0),
+		webhook.CreatedAt.Set(now),
+		webhook.UpdatedAt.Set(now),
+	); err != nil {
+		resp.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": "unable to generate value for webhook",
+		})
+		return
+	}
+	if err := database.ExecInTx(req.Context(), s.DB, func(ctx context.Context, tx pgx.Tx) error {
+		if err := s.WebhookRepo.Create(ctx, tx, webhook); err != nil {
+			return err
+		}
+		after, err := snapshotWebhook(webhook)
+		if err != nil {
+			return err
+		}
+		return s.AuditLogRepo.Create(ctx, tx, newEntityAuditLog(ctx, entities.AuditActionWebhookCreate, webhook.WebhookID.Int, pgtype.JSONB{Status: pgtype.Null}, after, now))
+	}); err != nil {
+		resp.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": "unable to create webhook",
+		})
+		return
+	}
+	webhookModel := convertWebhookPg2Domain(webhook)
+	webhookModel.Secret = secret
+	resp.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(resp).Encode(webhookModel)
+}
+
+func (s *WebhookService) GetWebhook(resp http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	webhookID, _ := ctx.Value(webhookIDKey).(int)
+	webhook, err := s.WebhookRepo.Get(ctx, s.DB, database.Int4(int32(webhookID)))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			resp.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(resp).Encode(map[string]string{
+				"error": "unable to get webhook: not found",
+			})
+			return
+		}
+		resp.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": "unable to get webhook",
+		})
+		return
+	}
+	resp.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(resp).Encode(convertWebhookPg2Domain(webhook))
+}
+
+// UpdateWebhookStatus disables a webhook or enables it again, typically once its
+// receiver is fixed after it has been disabled automatically.
+func (s *WebhookService) UpdateWebhookStatus(resp http.ResponseWriter, req *http.Request) {
+	updateStatusRequest := &models.UpdateStatusRequest{}
+	err := json.NewDecoder(req.Body).Decode(&updateStatusRequest)
+	defer req.Body.Close()
+	if err != nil {
+		resp.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": "unable to parse request",
+		})
+		return
+	}
+	if updateStatusRequest.Status != entities.WebhookStatusActive && updateStatusRequest.Status != entities.WebhookStatusDisabled {
+		resp.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": "the status must be one of active, disabled",
+		})
+		return
+	}
+	ctx := req.Context()
+	webhookID, _ := ctx.Value(webhookIDKey).(int)
+	var webhook *entities.Webhook
+	if err := database.ExecInTx(ctx, s.DB, func(ctx context.Context, tx pgx.Tx) error {
+		webhook, err = s.WebhookRepo.Get(ctx, tx, database.Int4(int32(webhookID)), repositories.WithUpdateLock())
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return errWebhookNotFound
+			}
+			return fmt.Errorf("unable to get webhook information")
+		}
+		if webhook.Status.String == updateStatusRequest.Status {
+			return nil
+		}
+		before, err := snapshotWebhook(webhook)
+		if err != nil {
+			return fmt.Errorf("unable to snapshot webhook record")
+		}
+		now := time.Now()
+		_ = webhook.Status.Set(updateStatusRequest.Status)
+		if updateStatusRequest.Status == entities.WebhookStatusActive {
+			_ = webhook.ConsecutiveFailures.Set(0)
+			_ = webhook.DisabledAt.Set(nil)
+		} else {
+			_ = webhook.DisabledAt.Set(now)
+		}
+		if _, err := s.WebhookRepo.UpdateStatus(ctx, tx, webhook); err != nil {
+			return fmt.Errorf("unable to update webhook record")
+		}
+		after, err := snapshotWebhook(webhook)
+		if err != nil {
+			return fmt.Errorf("unable to snapshot webhook record")
+		}
+		if err := s.AuditLogRepo.Create(ctx, tx, newEntityAuditLog(ctx, entities.AuditActionWebhookStatus, webhook.WebhookID.Int, before, after, now)); err != nil {
+			return fmt.Errorf("unable to create audit log")
+		}
+		return nil
+	}); err != nil {
+		resp.WriteHeader(statusFromError(err))
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": fmt.Sprintf("unable to update webhook: %s", err),
+		})
+		return
+	}
+	if webhook.Status.String == entities.WebhookStatusActive {
+		// the deliveries queued while it was disabled are due
+		s.Notify()
+	}
+	resp.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(resp).Encode(convertWebhookPg2Domain(webhook))
+}
+
+// ListWebhookDeliveries returns the delivery log of a webhook, latest first:
+// /webhooks/{webhookID}/deliveries?limit=50&before_id=120
+func (s *WebhookService) ListWebhookDeliveries(resp http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	webhookID, _ := ctx.Value(webhookIDKey).(int)
+	limit := defaultDeliveryLogLimit
+	var beforeID pgtype.Int8
+	_ = beforeID.Set(nil)
+	var err error
+	if value := req.URL.Query().Get("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit <= 0 || limit > maxDeliveryLogLimit {
+			resp.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(resp).Encode(map[string]string{
+				"error": fmt.Sprintf("the limit must be an integer between 1 and %d", maxDeliveryLogLimit),
+			})
+			return
+		}
+	}
+	if value := req.URL.Query().Get("before_id"); value != "" {
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || id <= 0 {
+			resp.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(resp).Encode(map[string]string{
+				"error": "the before_id must be a positive integer",
+			})
+			return
+		}
+		_ = beforeID.Set(id)
+	}
+	if _, err := s.WebhookRepo.Get(ctx, s.DB, database.Int4(int32(webhookID))); err != nil {
+		if err == pgx.ErrNoRows {
+			resp.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(resp).Encode(map[string]string{
+				"error": "unable to get webhook: not found",
+			})
+			return
+		}
+		resp.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": "unable to get webhook",
+		})
+		return
+	}
+	deliveries, err := s.WebhookDeliveryRepo.ListByWebhook(ctx, s.DB, database.Int4(int32(webhookID)), beforeID, uint32(limit))
+	if err != nil {
+		resp.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(resp).Encode(map[string]string{
+			"error": "unable to list webhook deliveries",
+		})
+		return
+	}
+	deliveryModels := make([]*models.WebhookDelivery, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		deliveryModels = append(deliveryModels, convertWebhookDeliveryPg2Domain(delivery))
+	}
+	resp.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(resp).Encode(deliveryModels)
+}
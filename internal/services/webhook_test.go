@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/internal/models"
+	"github.com/wager-api/libs/database"
+	mock_database "github.com/wager-api/mocks/libs/database"
+	mock_repositories "github.com/wager-api/mocks/repositories"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgtype"
+)
+
+func Test_validateCreateWebhookReq(t *testing.T) {
+	t.Parallel()
+	valid := func() *models.CreateWebhookRequest {
+		return &models.CreateWebhookRequest{
+			SellerID:   "seller-1",
+			URL:        "https://example.com/hooks",
+			EventTypes: []string{entities.WebhookEventWagerBought},
+		}
+	}
+	assert.NoError(t, validateCreateWebhookReq(valid()))
+
+	req := valid()
+	req.SellerID = " "
+	assert.EqualError(t, validateCreateWebhookReq(req), "the seller_id must not be empty")
+	req = valid()
+	req.URL = "example.com/hooks"
+	assert.EqualError(t, validateCreateWebhookReq(req), "the url must be an absolute http or https url")
+	req = valid()
+	req.Secret = "short"
+	assert.EqualError(t, validateCreateWebhookReq(req), "the secret must be at least 16 characters long")
+	req = valid()
+	req.EventTypes = nil
+	assert.EqualError(t, validateCreateWebhookReq(req), "the event_types must not be empty")
+	req = valid()
+	req.EventTypes = []string{"wager.placed"}
+	assert.EqualError(t, validateCreateWebhookReq(req), "the event_types must be among wager.bought")
+}
+
+type fakeResolver map[string][]string
+
+func (r fakeResolver) LookupIPAddr(_ context.Context, host string) ([]net.IPAddr, error) {
+	addrs := []net.IPAddr{}
+	for _, ip := range r[host] {
+		addrs = append(addrs, net.IPAddr{IP: net.ParseIP(ip)})
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no such host")
+	}
+	return addrs, nil
+}
+
+func Test_WebhookService_checkWebhookHost(t *testing.T) {
+	t.Parallel()
+	s := &WebhookService{Resolver: fakeResolver{
+		"seller.example.com": {"93.184.216.34"},
+		"internal.example":   {"93.184.216.34", "10.0.0.7"},
+	}}
+	ctx := context.Background()
+	assert.NoError(t, s.checkWebhookHost(ctx, "https://seller.example.com/hooks"))
+	assert.NoError(t, s.checkWebhookHost(ctx, "https://93.184.216.34/hooks"))
+	for _, url := range []string{
+		"http://127.0.0.1:8080/hooks",
+		"http://169.254.169.254/latest/meta-data",
+		"http://192.168.1.10/hooks",
+		"http://[::1]/hooks",
+		"http://0.0.0.0/hooks",
+		// one internal address among the public ones is enough
+		"https://internal.example/hooks",
+	} {
+		assert.EqualError(t, s.checkWebhookHost(ctx, url), "the url must not point to a loopback, private or link-local address", url)
+	}
+	assert.EqualError(t, s.checkWebhookHost(ctx, "https://unknown.example/hooks"), "unable to resolve the url host")
+
+	s.AllowPrivateURLs = true
+	assert.NoError(t, s.checkWebhookHost(ctx, "http://127.0.0.1:8080/hooks"))
+}
+
+func Test_NewWebhookClient(t *testing.T) {
+	t.Parallel()
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer receiver.Close()
+	_, err := NewWebhookClient(time.Second).Get(receiver.URL)
+	if assert.Error(t, err, "the client doesn't connect to the loopback") {
+		assert.Contains(t, err.Error(), "is not public")
+	}
+}
+
+func Test_WebhookService_CreateWebhook_PrivateURL(t *testing.T) {
+	t.Parallel()
+	webhookRepo := &mock_repositories.MockWebhookRepo{}
+	s := &WebhookService{WebhookRepo: webhookRepo}
+	rec := httptest.NewRecorder()
+	s.CreateWebhook(rec, httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(
+		`{"seller_id": "seller-1", "url": "http://169.254.169.254/latest/meta-data", "event_types": ["wager.bought"]}`)))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	webhookRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func Test_WebhookService_Enqueue_NoSeller(t *testing.T) {
+	t.Parallel()
+	webhookRepo := &mock_repositories.MockWebhookRepo{}
+	s := &WebhookService{WebhookRepo: webhookRepo}
+	assert.NoError(t, s.Enqueue(context.Background(), &mock_database.Ext{}, pgtype.Text{Status: pgtype.Null}, entities.WebhookEventWagerBought, &models.WagerBought{}))
+	webhookRepo.AssertNotCalled(t, "ListActiveBySeller", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func Test_WebhookService_DispatchOnce(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	const secret = "0123456789abcdef"
+	webhook := func(url string) *entities.Webhook {
+		return &entities.Webhook{
+			WebhookID: database.Int4(1),
+			URL:       database.Text(url),
+			Secret:    database.Text(secret),
+			Status:    database.Text(entities.WebhookStatusActive),
+		}
+	}
+	delivery := func(id int64, attempts int32) *entities.WebhookDelivery {
+		return &entities.WebhookDelivery{
+			DeliveryID: database.Int8(id),
+			WebhookID:  database.Int4(1),
+			EventType:  database.Text(entities.WebhookEventWagerBought),
+			Payload:    pgtype.JSONB{Bytes: []byte(`{"wager_id":1}`), Status: pgtype.Present},
+			Status:     database.Text(entities.WebhookDeliveryStatusPending),
+			Attempts:   database.Int4(attempts),
+		}
+	}
+
+	t.Run("signed delivery succeeds", func(t *testing.T) {
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			var timestamp int64
+			var signature string
+			_, err := fmt.Sscanf(r.Header.Get(WebhookSignatureHeader), "t=%d,v1=%s", &timestamp, &signature)
+			assert.NoError(t, err)
+			assert.Equal(t, SignWebhookPayload(secret, timestamp, body), signature)
+			assert.Equal(t, entities.WebhookEventWagerBought, r.Header.Get(WebhookEventHeader))
+			assert.Equal(t, "7", r.Header.Get(WebhookDeliveryHeader))
+			event := &models.WebhookEvent{}
+			assert.NoError(t, json.Unmarshal(body, event))
+			assert.Equal(t, int64(7), event.ID)
+			assert.JSONEq(t, `{"wager_id":1}`, string(event.Data))
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer receiver.Close()
+		db := &mock_database.Ext{}
+		tx := &mock_database.Tx{}
+		webhookRepo := &mock_repositories.MockWebhookRepo{}
+		deliveryRepo := &mock_repositories.MockWebhookDeliveryRepo{}
+		db.On("Begin", ctx).Return(tx, nil)
+		tx.On("Commit", mock.Anything).Return(nil)
+		// the deliveries are claimed and the webhooks read outside of any transaction
+		deliveryRepo.On("ClaimDue", ctx, db, mock.Anything, mock.MatchedBy(func(lockedUntil pgtype.Timestamptz) bool {
+			return lockedUntil.Time.After(time.Now().Add(defaultWebhookLease - time.Minute))
+		}), uint32(defaultWebhookBatchSize)).Once().Return([]*entities.WebhookDelivery{delivery(7, 0)}, nil)
+		webhookRepo.On("Get", ctx, db, database.Int4(1)).Once().Return(webhook(receiver.URL), nil)
+		deliveryRepo.On("UpdateAttempt", ctx, tx, mock.MatchedBy(func(d *entities.WebhookDelivery) bool {
+			return d.Status.String == entities.WebhookDeliveryStatusSucceeded && d.Attempts.Int == 1 &&
+				d.ResponseStatus.Int == http.StatusOK && d.DeliveredAt.Status == pgtype.Present
+		})).Once().Return(pgconn.CommandTag("UPDATE 1"), nil)
+		webhookRepo.On("RecordSuccess", ctx, tx, database.Int4(1)).Once().Return(pgconn.CommandTag("UPDATE 1"), nil)
+
+		s := &WebhookService{DB: db, WebhookRepo: webhookRepo, WebhookDeliveryRepo: deliveryRepo, AllowPrivateURLs: true}
+		attempted, err := s.DispatchOnce(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, attempted)
+		deliveryRepo.AssertExpectations(t)
+		webhookRepo.AssertExpectations(t)
+	})
+	t.Run("failing webhook is retried then disabled", func(t *testing.T) {
+		calls := 0
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer receiver.Close()
+		db := &mock_database.Ext{}
+		tx := &mock_database.Tx{}
+		webhookRepo := &mock_repositories.MockWebhookRepo{}
+		deliveryRepo := &mock_repositories.MockWebhookDeliveryRepo{}
+		db.On("Begin", ctx).Return(tx, nil)
+		tx.On("Commit", mock.Anything).Return(nil)
+		deliveryRepo.On("ClaimDue", ctx, db, mock.Anything, mock.Anything, uint32(10)).Once().Return([]*entities.WebhookDelivery{delivery(7, 2), delivery(8, 0)}, nil)
+		webhookRepo.On("Get", ctx, db, database.Int4(1)).Once().Return(webhook(receiver.URL), nil)
+		before := time.Now()
+		deliveryRepo.On("UpdateAttempt", ctx, tx, mock.MatchedBy(func(d *entities.WebhookDelivery) bool {
+			// the third attempt waits 4 times the min backoff
+			return d.DeliveryID.Int == 7 && d.Status.String == entities.WebhookDeliveryStatusPending && d.Attempts.Int == 3 &&
+				d.ResponseStatus.Int == http.StatusInternalServerError && !d.NextAttemptAt.Time.Before(before.Add(4*time.Second)) &&
+				d.LastError.String == "the receiver answered with status 500"
+		})).Once().Return(pgconn.CommandTag("UPDATE 1"), nil)
+		webhookRepo.On("RecordFailure", ctx, tx, database.Int4(1), int32(3), mock.Anything).Once().Return(true, nil)
+		deliveryRepo.On("Release", ctx, db, database.Int8(8)).Once().Return(pgconn.CommandTag("UPDATE 1"), nil)
+
+		s := &WebhookService{DB: db, WebhookRepo: webhookRepo, WebhookDeliveryRepo: deliveryRepo, BatchSize: 10, DisableAfter: 3, MinBackoff: time.Second, AllowPrivateURLs: true}
+		attempted, err := s.DispatchOnce(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, attempted, "the deliveries of a webhook disabled during the batch are left pending")
+		assert.Equal(t, 1, calls)
+		deliveryRepo.AssertExpectations(t)
+		webhookRepo.AssertExpectations(t)
+	})
+}
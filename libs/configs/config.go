@@ -29,6 +29,143 @@ type (
 		LogLevel string   `yaml:"log_level" envconfig:"LOG_LEVEL"`
 		Postgres Postgres `yaml:"postgres" envconfig:"POSTGRES"`
 		Address  string   `yaml:"address" envconfig:"ADDRESS"`
+		// GRPCAddress is where the gRPC API listens, empty disables it
+		GRPCAddress string `yaml:"grpc_address" envconfig:"GRPC_ADDRESS"`
+		// MarketCloseInterval is how often the markets of started events are closed
+		MarketCloseInterval time.Duration `yaml:"market_close_interval" envconfig:"MARKET_CLOSE_INTERVAL"`
+		PriceHistory        PriceHistory  `yaml:"price_history" envconfig:"PRICE_HISTORY"`
+		// BuyOrderExpireInterval is how often the buy orders past their expiry are expired
+		BuyOrderExpireInterval time.Duration `yaml:"buy_order_expire_interval" envconfig:"BUY_ORDER_EXPIRE_INTERVAL"`
+		// BuyOrderMatchInterval is how often the buy orders of the decaying wagers are matched
+		BuyOrderMatchInterval time.Duration `yaml:"buy_order_match_interval" envconfig:"BUY_ORDER_MATCH_INTERVAL"`
+		GRPC                  GRPC          `yaml:"grpc" envconfig:"GRPC"`
+		WebSocket             WebSocket     `yaml:"websocket" envconfig:"WEBSOCKET"`
+		OpenAPI               OpenAPI       `yaml:"openapi" envconfig:"OPENAPI"`
+		Outbox                Outbox        `yaml:"outbox" envconfig:"OUTBOX"`
+		Webhook               Webhook       `yaml:"webhook" envconfig:"WEBHOOK"`
+		Admin                 Admin         `yaml:"admin" envconfig:"ADMIN"`
+		Fees                  Fees          `yaml:"fees" envconfig:"FEES"`
+		// BaseCurrency is the ISO 4217 code of the wagers placed without a currency and
+		// the one the volume is reported in, USD when it is empty
+		BaseCurrency        string              `yaml:"base_currency" envconfig:"BASE_CURRENCY"`
+		Risk                Risk                `yaml:"risk" envconfig:"RISK"`
+		ResponsibleGambling ResponsibleGambling `yaml:"responsible_gambling" envconfig:"RESPONSIBLE_GAMBLING"`
+		Reconciliation      Reconciliation      `yaml:"reconciliation" envconfig:"RECONCILIATION"`
+		Surveillance        Surveillance        `yaml:"surveillance" envconfig:"SURVEILLANCE"`
+		Pricing             Pricing             `yaml:"pricing" envconfig:"PRICING"`
+	}
+	Pricing struct {
+		// FairPriceSpread is how far above the expected value of a wager, in percent, its
+		// price is still quoted as fair, 10 when it is 0
+		FairPriceSpread float64 `yaml:"fair_price_spread" envconfig:"FAIR_PRICE_SPREAD"`
+	}
+	// Surveillance are the rules looking for wash trading in the purchases
+	Surveillance struct {
+		// Window is how far back the resell loops and the price walking are looked for
+		Window       time.Duration    `yaml:"window" envconfig:"SURVEILLANCE_WINDOW"`
+		SelfTrade    SurveillanceRule `yaml:"self_trade" envconfig:"SURVEILLANCE_SELF_TRADE"`
+		ResellLoop   SurveillanceRule `yaml:"resell_loop" envconfig:"SURVEILLANCE_RESELL_LOOP"`
+		PriceWalking SurveillanceRule `yaml:"price_walking" envconfig:"SURVEILLANCE_PRICE_WALKING"`
+	}
+	// SurveillanceRule raises alerts on the purchases it flags, Block refuses them too
+	SurveillanceRule struct {
+		Enabled   bool `yaml:"enabled"`
+		Block     bool `yaml:"block"`
+		Threshold int  `yaml:"threshold"`
+	}
+	// Reconciliation checks the aggregates of the wagers against their purchases
+	Reconciliation struct {
+		// Interval is how often the aggregates are checked, 0 disables the job
+		Interval time.Duration `yaml:"interval" envconfig:"RECONCILIATION_INTERVAL"`
+		// Repair sets the drifted aggregates back instead of only reporting them
+		Repair    bool   `yaml:"repair" envconfig:"RECONCILIATION_REPAIR"`
+		BatchSize uint32 `yaml:"batch_size" envconfig:"RECONCILIATION_BATCH_SIZE"`
+	}
+	ResponsibleGambling struct {
+		// LimitIncreaseDelay is how long the increases and the removals of the spend limits
+		// wait before taking effect, 24h when it is 0
+		LimitIncreaseDelay time.Duration `yaml:"limit_increase_delay" envconfig:"LIMIT_INCREASE_DELAY"`
+		// RequireBuyer refuses the purchases without a buyer_id, which escape the limits
+		RequireBuyer bool `yaml:"require_buyer" envconfig:"REQUIRE_BUYER"`
+	}
+	// Risk are the exposure limits, 0 leaves one unbounded
+	Risk struct {
+		MaxSellerExposure float32 `yaml:"max_seller_exposure" envconfig:"MAX_SELLER_EXPOSURE"`
+		MaxMarketVolume   float32 `yaml:"max_market_volume" envconfig:"MAX_MARKET_VOLUME"`
+		MaxPurchase       float32 `yaml:"max_purchase" envconfig:"MAX_PURCHASE"`
+	}
+	// Fees is the schedule of the fees charged on the purchases, Markets overrides it for
+	// the wagers of some markets, keyed by market id
+	Fees struct {
+		FeeSchedule `yaml:",inline"`
+		// VolumeWindow is how far back the volume of a buyer is summed up for the tiers, 0
+		// sums up every purchase
+		VolumeWindow time.Duration         `yaml:"volume_window" envconfig:"FEES_VOLUME_WINDOW"`
+		Markets      map[int32]FeeSchedule `yaml:"markets" ignored:"true"`
+	}
+	FeeSchedule struct {
+		// Percentage is charged on the buying price, Flat on every purchase
+		Percentage float32   `yaml:"percentage" envconfig:"FEES_PERCENTAGE"`
+		Flat       float32   `yaml:"flat" envconfig:"FEES_FLAT"`
+		Tiers      []FeeTier `yaml:"tiers" ignored:"true"`
+	}
+	// FeeTier replaces the percentage once a buyer spent MinVolume
+	FeeTier struct {
+		MinVolume  float32 `yaml:"min_volume"`
+		Percentage float32 `yaml:"percentage"`
+	}
+	Admin struct {
+		// Tokens are the credentials accepted on the admin routes, none disables them
+		Tokens []string `yaml:"tokens" envconfig:"ADMIN_TOKENS"`
+	}
+	Webhook struct {
+		Interval  time.Duration `yaml:"interval" envconfig:"WEBHOOK_INTERVAL"`
+		Timeout   time.Duration `yaml:"timeout" envconfig:"WEBHOOK_TIMEOUT"`
+		BatchSize uint32        `yaml:"batch_size" envconfig:"WEBHOOK_BATCH_SIZE"`
+		// MaxAttempts is how many times a delivery is tried before it is given up
+		MaxAttempts int32 `yaml:"max_attempts" envconfig:"WEBHOOK_MAX_ATTEMPTS"`
+		// DisableAfter is how many deliveries in a row a webhook can fail before it is disabled
+		DisableAfter int32         `yaml:"disable_after" envconfig:"WEBHOOK_DISABLE_AFTER"`
+		MinBackoff   time.Duration `yaml:"min_backoff" envconfig:"WEBHOOK_MIN_BACKOFF"`
+		MaxBackoff   time.Duration `yaml:"max_backoff" envconfig:"WEBHOOK_MAX_BACKOFF"`
+		// Lease is how long a dispatcher has to post the deliveries it claimed before
+		// another one claims them again
+		Lease time.Duration `yaml:"lease" envconfig:"WEBHOOK_LEASE"`
+		// AllowPrivateURLs lets the webhooks post to loopback, private and link-local
+		// addresses, for local receivers only
+		AllowPrivateURLs bool `yaml:"allow_private_urls" envconfig:"WEBHOOK_ALLOW_PRIVATE_URLS"`
+	}
+	Outbox struct {
+		// Publisher is where the outbox events are relayed: stdout, file or http, empty
+		// disables the relay and the events wait in the outbox table
+		Publisher  string        `yaml:"publisher" envconfig:"OUTBOX_PUBLISHER"`
+		FilePath   string        `yaml:"file_path" envconfig:"OUTBOX_FILE_PATH"`
+		URL        string        `yaml:"url" envconfig:"OUTBOX_URL"`
+		Timeout    time.Duration `yaml:"timeout" envconfig:"OUTBOX_TIMEOUT"`
+		Interval   time.Duration `yaml:"interval" envconfig:"OUTBOX_INTERVAL"`
+		BatchSize  uint32        `yaml:"batch_size" envconfig:"OUTBOX_BATCH_SIZE"`
+		MinBackoff time.Duration `yaml:"min_backoff" envconfig:"OUTBOX_MIN_BACKOFF"`
+		MaxBackoff time.Duration `yaml:"max_backoff" envconfig:"OUTBOX_MAX_BACKOFF"`
+		// Lease is how long a relay has to publish the events it claimed before another
+		// one claims them again
+		Lease time.Duration `yaml:"lease" envconfig:"OUTBOX_LEASE"`
+	}
+	OpenAPI struct {
+		// ValidateRequests rejects the requests which don't match the OpenAPI document
+		ValidateRequests bool `yaml:"validate_requests" envconfig:"OPENAPI_VALIDATE_REQUESTS"`
+	}
+	GRPC struct {
+		// Tokens are the credentials accepted on the gRPC API, none rejects every call
+		Tokens []string `yaml:"tokens" envconfig:"GRPC_TOKENS"`
+	}
+	WebSocket struct {
+		// Tokens are the credentials accepted on the websocket API, none disables it
+		Tokens []string `yaml:"tokens" envconfig:"WEBSOCKET_TOKENS"`
+	}
+	PriceHistory struct {
+		// Retention is how long prices are kept, 0 keeps them forever
+		Retention       time.Duration `yaml:"retention" envconfig:"PRICE_HISTORY_RETENTION"`
+		CleanupInterval time.Duration `yaml:"cleanup_interval" envconfig:"PRICE_HISTORY_CLEANUP_INTERVAL"`
 	}
 	Postgres struct {
 		Username        string        `yaml:"username" envconfig:"PDB_USERNAME"`
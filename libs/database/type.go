@@ -26,6 +26,11 @@ func Float4(v float32) pgtype.Float4 {
 	return pgtype.Float4{Float: v, Status: pgtype.Present}
 }
 
+// Float8 converts a Go float64 to pgtype.Float8.
+func Float8(v float64) pgtype.Float8 {
+	return pgtype.Float8{Float: v, Status: pgtype.Present}
+}
+
 // Bool converts a Go bool to pgtype.Bool.
 func Bool(v bool) pgtype.Bool {
 	return pgtype.Bool{Bool: v, Status: pgtype.Present}
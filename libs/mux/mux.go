@@ -18,6 +18,8 @@ func Init() *chi.Mux {
 // InitWithLogger init with zap log param
 func InitWithLogger(logger *zap.Logger) *chi.Mux {
 	router := chi.NewRouter()
+	// the request id is logged and recorded in the audit log, a gateway can set it
+	router.Use(middleware.RequestID)
 	router.Use(ChiLoggerMiddleware(logger))
 	router.Use(render.SetContentType(render.ContentTypeJSON))
 	return router
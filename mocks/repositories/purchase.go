@@ -4,9 +4,11 @@ package mock_repositories
 import (
 	"context"
 
+	"github.com/jackc/pgtype"
 	"github.com/stretchr/testify/mock"
 
 	"github.com/wager-api/internal/entities"
+	"github.com/wager-api/internal/repositories"
 	"github.com/wager-api/libs/database"
 )
 
@@ -17,4 +19,27 @@ type MockPurchaseRepo struct {
 func (r *MockPurchaseRepo) Create(arg1 context.Context, arg2 database.Ext, arg3 *entities.Purchase) error {
 	args := r.Called(arg1, arg2, arg3)
 	return args.Error(0)
+}
+
+func (r *MockPurchaseRepo) SumVolume(arg1 context.Context, arg2 database.Ext, arg3 pgtype.Text, arg4 pgtype.Timestamptz) (pgtype.Float4, error) {
+	args := r.Called(arg1, arg2, arg3, arg4)
+	return args.Get(0).(pgtype.Float4), args.Error(1)
+}
+
+func (r *MockPurchaseRepo) SumSpend(arg1 context.Context, arg2 database.Ext, arg3 pgtype.Text, arg4 pgtype.Timestamptz) ([]*repositories.CurrencySpend, error) {
+	args := r.Called(arg1, arg2, arg3, arg4)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repositories.CurrencySpend), args.Error(1)
+}
+
+func (r *MockPurchaseRepo) ReportVolume(arg1 context.Context, arg2 database.Ext, arg3 pgtype.Timestamptz, arg4 pgtype.Timestamptz, arg5 string, arg6 pgtype.Text) ([]*repositories.VolumeReportRow, error) {
+	args := r.Called(arg1, arg2, arg3, arg4, arg5, arg6)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repositories.VolumeReportRow), args.Error(1)
 }
\ No newline at end of file
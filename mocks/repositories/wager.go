@@ -21,11 +21,20 @@ func (r *MockWagerRepo) Create(arg1 context.Context, arg2 database.Ext, arg3 *en
 	args := r.Called(arg1, arg2, arg3)
 	return args.Error(0)
 }
+func (r *MockWagerRepo) CreateBatch(arg1 context.Context, arg2 database.Ext, arg3 []*entities.Wager) error {
+	args := r.Called(arg1, arg2, arg3)
+	return args.Error(0)
+}
 func (r *MockWagerRepo) Update(arg1 context.Context, arg2 database.Ext, arg3 *entities.Wager) (pgconn.CommandTag,error) {
 	args := r.Called(arg1, arg2, arg3)
 	return args.Get(0).(pgconn.CommandTag), args.Error(1)
 }
 
+func (r *MockWagerRepo) Reprice(arg1 context.Context, arg2 database.Ext, arg3 *entities.Wager) (pgconn.CommandTag, error) {
+	args := r.Called(arg1, arg2, arg3)
+	return args.Get(0).(pgconn.CommandTag), args.Error(1)
+}
+
 func (r *MockWagerRepo) Get(arg1 context.Context, arg2 database.Ext, arg3 pgtype.Int4, arg4 ...repositories.QueryEnhancer) (*entities.Wager, error) {
 	args := r.Called(arg1, arg2, arg3)
 
@@ -35,11 +44,19 @@ func (r *MockWagerRepo) Get(arg1 context.Context, arg2 database.Ext, arg3 pgtype
 	return args.Get(0).(*entities.Wager), args.Error(1)
 }
 
-func (r *MockWagerRepo) List(arg1 context.Context, arg2 database.Ext, arg3 pgtype.Int4, arg4 uint32) ([]*entities.Wager, error) {
-	args := r.Called(arg1, arg2, arg3, arg4)
+func (r *MockWagerRepo) List(arg1 context.Context, arg2 database.Ext, arg3 repositories.WagerFilter, arg4 pgtype.Int4, arg5 uint32, arg6 uint32) ([]*entities.Wager, error) {
+	args := r.Called(arg1, arg2, arg3, arg4, arg5, arg6)
 
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]*entities.Wager), args.Error(1)
-}
\ No newline at end of file
+}
+func (r *MockWagerRepo) ListIDsByMarket(arg1 context.Context, arg2 database.Ext, arg3 pgtype.Int4) ([]int32, error) {
+	args := r.Called(arg1, arg2, arg3)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]int32), args.Error(1)
+}
@@ -0,0 +1,52 @@
+-- event table
+CREATE SEQUENCE IF NOT EXISTS public.event_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+CREATE TABLE IF NOT EXISTS public.event (
+    event_id integer NOT NULL DEFAULT nextval('event_id_seq'),
+    sport TEXT NOT NULL,
+    home_team TEXT NOT NULL,
+    away_team TEXT NOT NULL,
+    start_at timestamp with time zone NOT NULL,
+    status TEXT NOT NULL DEFAULT 'scheduled',
+    created_at timestamp with time zone NOT NULL,
+    updated_at timestamp with time zone NOT NULL,
+    deleted_at timestamp with time zone,
+    CONSTRAINT event_pk PRIMARY KEY (event_id),
+    CONSTRAINT event_status_check CHECK (status IN ('scheduled', 'in_play', 'finished', 'cancelled'))
+);
+ALTER SEQUENCE IF EXISTS event_id_seq OWNED BY event.event_id;
+CREATE INDEX IF NOT EXISTS event_status_start_at_idx ON public.event (status, start_at);
+
+-- market table
+CREATE SEQUENCE IF NOT EXISTS public.market_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+CREATE TABLE IF NOT EXISTS public.market (
+    market_id integer NOT NULL DEFAULT nextval('market_id_seq'),
+    event_id integer NOT NULL,
+    market_type TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'open',
+    created_at timestamp with time zone NOT NULL,
+    updated_at timestamp with time zone NOT NULL,
+    deleted_at timestamp with time zone,
+    CONSTRAINT market_pk PRIMARY KEY (market_id),
+    CONSTRAINT market_event_fk FOREIGN KEY (event_id) REFERENCES public.event(event_id),
+    CONSTRAINT market_status_check CHECK (status IN ('open', 'suspended', 'closed', 'settled'))
+);
+ALTER SEQUENCE IF EXISTS market_id_seq OWNED BY market.market_id;
+CREATE INDEX IF NOT EXISTS market_event_id_idx ON public.market (event_id);
+
+-- a wager is placed on a market
+ALTER TABLE public.wager ADD COLUMN IF NOT EXISTS market_id integer;
+ALTER TABLE public.wager DROP CONSTRAINT IF EXISTS wager_market_fk;
+ALTER TABLE public.wager ADD CONSTRAINT wager_market_fk FOREIGN KEY (market_id) REFERENCES public.market(market_id);
+CREATE INDEX IF NOT EXISTS wager_market_id_idx ON public.wager (market_id);
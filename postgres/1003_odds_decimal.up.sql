@@ -0,0 +1,2 @@
+-- odds are stored in decimal format, the canonical representation every other format converts from
+ALTER TABLE public.wager ALTER COLUMN odds TYPE DOUBLE PRECISION USING odds::DOUBLE PRECISION;
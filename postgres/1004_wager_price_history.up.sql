@@ -0,0 +1,20 @@
+-- wager_price_history table, one row each time the current_selling_price of a wager changes
+CREATE SEQUENCE IF NOT EXISTS public.wager_price_history_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+CREATE TABLE IF NOT EXISTS public.wager_price_history (
+    price_history_id integer NOT NULL DEFAULT nextval('wager_price_history_id_seq'),
+    wager_id integer NOT NULL,
+    price REAL NOT NULL,
+    recorded_at timestamp with time zone NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    CONSTRAINT wager_price_history_pk PRIMARY KEY (price_history_id),
+    CONSTRAINT wager_price_history_wager_fk FOREIGN KEY (wager_id) REFERENCES public.wager(wager_id)
+);
+ALTER SEQUENCE IF EXISTS wager_price_history_id_seq OWNED BY wager_price_history.price_history_id;
+CREATE INDEX IF NOT EXISTS wager_price_history_wager_id_recorded_at_idx ON public.wager_price_history (wager_id, recorded_at);
+CREATE INDEX IF NOT EXISTS wager_price_history_recorded_at_idx ON public.wager_price_history (recorded_at);
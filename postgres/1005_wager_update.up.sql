@@ -0,0 +1,37 @@
+-- wager_update table, the feed of what happened to the wagers (wager-placed, purchase-made, price-changed)
+CREATE SEQUENCE IF NOT EXISTS public.wager_update_id_seq
+    AS bigint
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+CREATE TABLE IF NOT EXISTS public.wager_update (
+    update_id bigint NOT NULL DEFAULT nextval('wager_update_id_seq'),
+    update_type TEXT NOT NULL,
+    wager_id integer NOT NULL,
+    payload JSONB NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    CONSTRAINT wager_update_pk PRIMARY KEY (update_id),
+    CONSTRAINT wager_update_wager_fk FOREIGN KEY (wager_id) REFERENCES public.wager(wager_id)
+);
+ALTER SEQUENCE IF EXISTS wager_update_id_seq OWNED BY wager_update.update_id;
+CREATE INDEX IF NOT EXISTS wager_update_wager_id_idx ON public.wager_update (wager_id, update_id);
+
+-- every new row is broadcast on the wager_updates channel once its transaction commits,
+-- so every replica listening to it can stream it to its clients
+CREATE OR REPLACE FUNCTION public.notify_wager_update() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('wager_updates', json_build_object(
+        'id', NEW.update_id,
+        'type', NEW.update_type,
+        'wager_id', NEW.wager_id,
+        'payload', NEW.payload,
+        'created_at', NEW.created_at
+    )::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+DROP TRIGGER IF EXISTS wager_update_notify ON public.wager_update;
+CREATE TRIGGER wager_update_notify AFTER INSERT ON public.wager_update
+    FOR EACH ROW EXECUTE FUNCTION public.notify_wager_update();
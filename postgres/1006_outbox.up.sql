@@ -0,0 +1,24 @@
+-- outbox table, the domain events written in the transaction of the change they describe
+-- and relayed to the downstream systems in outbox_id order
+CREATE SEQUENCE IF NOT EXISTS public.outbox_id_seq
+    AS bigint
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+CREATE TABLE IF NOT EXISTS public.outbox (
+    outbox_id bigint NOT NULL DEFAULT nextval('outbox_id_seq'),
+    event_type TEXT NOT NULL,
+    wager_id integer NOT NULL,
+    payload JSONB NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    attempts integer NOT NULL DEFAULT 0,
+    next_attempt_at timestamp with time zone NOT NULL,
+    last_error TEXT,
+    sent_at timestamp with time zone,
+    CONSTRAINT outbox_pk PRIMARY KEY (outbox_id),
+    CONSTRAINT outbox_wager_fk FOREIGN KEY (wager_id) REFERENCES public.wager(wager_id)
+);
+ALTER SEQUENCE IF EXISTS outbox_id_seq OWNED BY outbox.outbox_id;
+CREATE INDEX IF NOT EXISTS outbox_pending_idx ON public.outbox (outbox_id) WHERE sent_at IS NULL;
@@ -0,0 +1,54 @@
+-- the seller of a wager is who gets notified when it is bought
+ALTER TABLE public.wager ADD COLUMN IF NOT EXISTS seller_id TEXT;
+CREATE INDEX IF NOT EXISTS wager_seller_idx ON public.wager (seller_id);
+
+-- webhook table, the endpoints sellers subscribe to the events of their wagers with
+CREATE SEQUENCE IF NOT EXISTS public.webhook_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+CREATE TABLE IF NOT EXISTS public.webhook (
+    webhook_id integer NOT NULL DEFAULT nextval('webhook_id_seq'),
+    seller_id TEXT NOT NULL,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    event_types TEXT[] NOT NULL,
+    status TEXT NOT NULL,
+    consecutive_failures integer NOT NULL DEFAULT 0,
+    disabled_at timestamp with time zone,
+    created_at timestamp with time zone NOT NULL,
+    updated_at timestamp with time zone NOT NULL,
+    CONSTRAINT webhook_pk PRIMARY KEY (webhook_id)
+);
+ALTER SEQUENCE IF EXISTS webhook_id_seq OWNED BY webhook.webhook_id;
+CREATE INDEX IF NOT EXISTS webhook_seller_idx ON public.webhook (seller_id) WHERE status = 'active';
+
+-- webhook_delivery table, both the retry queue of the webhooks and their delivery log
+CREATE SEQUENCE IF NOT EXISTS public.webhook_delivery_id_seq
+    AS bigint
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+CREATE TABLE IF NOT EXISTS public.webhook_delivery (
+    delivery_id bigint NOT NULL DEFAULT nextval('webhook_delivery_id_seq'),
+    webhook_id integer NOT NULL,
+    event_type TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    status TEXT NOT NULL,
+    attempts integer NOT NULL DEFAULT 0,
+    next_attempt_at timestamp with time zone NOT NULL,
+    response_status integer,
+    last_error TEXT,
+    created_at timestamp with time zone NOT NULL,
+    delivered_at timestamp with time zone,
+    CONSTRAINT webhook_delivery_pk PRIMARY KEY (delivery_id),
+    CONSTRAINT webhook_delivery_webhook_fk FOREIGN KEY (webhook_id) REFERENCES public.webhook(webhook_id)
+);
+ALTER SEQUENCE IF EXISTS webhook_delivery_id_seq OWNED BY webhook_delivery.delivery_id;
+CREATE INDEX IF NOT EXISTS webhook_delivery_due_idx ON public.webhook_delivery (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS webhook_delivery_webhook_idx ON public.webhook_delivery (webhook_id, delivery_id);
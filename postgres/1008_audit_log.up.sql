@@ -0,0 +1,25 @@
+-- audit_log table, who changed what and when, written in the transaction of the change
+CREATE SEQUENCE IF NOT EXISTS public.audit_log_id_seq
+    AS bigint
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+CREATE TABLE IF NOT EXISTS public.audit_log (
+    audit_id bigint NOT NULL DEFAULT nextval('audit_log_id_seq'),
+    actor TEXT,
+    action TEXT NOT NULL,
+    wager_id integer,
+    purchase_id integer,
+    before JSONB,
+    after JSONB,
+    request_id TEXT,
+    client_ip TEXT,
+    created_at timestamp with time zone NOT NULL,
+    CONSTRAINT audit_log_pk PRIMARY KEY (audit_id)
+);
+ALTER SEQUENCE IF EXISTS audit_log_id_seq OWNED BY audit_log.audit_id;
+CREATE INDEX IF NOT EXISTS audit_log_wager_idx ON public.audit_log (wager_id, audit_id);
+CREATE INDEX IF NOT EXISTS audit_log_actor_idx ON public.audit_log (actor, audit_id);
+CREATE INDEX IF NOT EXISTS audit_log_created_at_idx ON public.audit_log (created_at);
@@ -0,0 +1,28 @@
+-- buy_order table, the standing orders buying a wager once its price drops to a limit
+CREATE SEQUENCE IF NOT EXISTS public.buy_order_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+CREATE TABLE IF NOT EXISTS public.buy_order (
+    buy_order_id integer NOT NULL DEFAULT nextval('buy_order_id_seq'),
+    wager_id integer NOT NULL,
+    buyer_id TEXT,
+    limit_price real NOT NULL,
+    status TEXT NOT NULL,
+    purchase_id integer,
+    expires_at timestamp with time zone NOT NULL,
+    filled_at timestamp with time zone,
+    created_at timestamp with time zone NOT NULL,
+    updated_at timestamp with time zone NOT NULL,
+    CONSTRAINT buy_order_pk PRIMARY KEY (buy_order_id),
+    CONSTRAINT buy_order_wager_fk FOREIGN KEY (wager_id) REFERENCES public.wager(wager_id),
+    CONSTRAINT buy_order_purchase_fk FOREIGN KEY (purchase_id) REFERENCES public.purchase(purchase_id),
+    CONSTRAINT buy_order_status_check CHECK (status IN ('open', 'filled', 'cancelled', 'expired'))
+);
+ALTER SEQUENCE IF EXISTS buy_order_id_seq OWNED BY buy_order.buy_order_id;
+-- the open orders of a wager, the highest limit first
+CREATE INDEX IF NOT EXISTS buy_order_open_idx ON public.buy_order (wager_id, limit_price DESC, buy_order_id) WHERE status = 'open';
+CREATE INDEX IF NOT EXISTS buy_order_expires_at_idx ON public.buy_order (expires_at) WHERE status = 'open';
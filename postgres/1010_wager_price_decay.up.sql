@@ -0,0 +1,8 @@
+-- the optional decay of the price of a wager from its selling_price down to a floor, the
+-- price it has at a time is computed on read, a purchase stores the price it paid
+ALTER TABLE public.wager ADD COLUMN IF NOT EXISTS decay_type TEXT;
+ALTER TABLE public.wager ADD COLUMN IF NOT EXISTS decay_floor_price real;
+ALTER TABLE public.wager ADD COLUMN IF NOT EXISTS decay_duration_seconds integer;
+ALTER TABLE public.wager ADD COLUMN IF NOT EXISTS decay_step_seconds integer;
+ALTER TABLE public.wager DROP CONSTRAINT IF EXISTS wager_decay_type_check;
+ALTER TABLE public.wager ADD CONSTRAINT wager_decay_type_check CHECK (decay_type IN ('linear', 'step'));
@@ -0,0 +1,81 @@
+-- the buyer of a purchase, only the purchases of a known buyer are held and can be resold
+ALTER TABLE public.purchase ADD COLUMN IF NOT EXISTS buyer_id TEXT;
+
+-- stake_holding table, who holds which part of the stake of a purchase
+CREATE SEQUENCE IF NOT EXISTS public.stake_holding_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+CREATE TABLE IF NOT EXISTS public.stake_holding (
+    holding_id integer NOT NULL DEFAULT nextval('stake_holding_id_seq'),
+    purchase_id integer NOT NULL,
+    wager_id integer NOT NULL,
+    holder_id TEXT NOT NULL,
+    stake real NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    updated_at timestamp with time zone NOT NULL,
+    CONSTRAINT stake_holding_pk PRIMARY KEY (holding_id),
+    CONSTRAINT stake_holding_purchase_fk FOREIGN KEY (purchase_id) REFERENCES public.purchase(purchase_id),
+    CONSTRAINT stake_holding_wager_fk FOREIGN KEY (wager_id) REFERENCES public.wager(wager_id),
+    CONSTRAINT stake_holding_purchase_holder_key UNIQUE (purchase_id, holder_id),
+    CONSTRAINT stake_holding_stake_check CHECK (stake >= 0)
+);
+ALTER SEQUENCE IF EXISTS stake_holding_id_seq OWNED BY stake_holding.holding_id;
+CREATE INDEX IF NOT EXISTS stake_holding_wager_id_idx ON public.stake_holding (wager_id);
+
+-- resale_listing table, a part of a held stake put up for resale by its holder
+CREATE SEQUENCE IF NOT EXISTS public.resale_listing_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+CREATE TABLE IF NOT EXISTS public.resale_listing (
+    listing_id integer NOT NULL DEFAULT nextval('resale_listing_id_seq'),
+    purchase_id integer NOT NULL,
+    wager_id integer NOT NULL,
+    seller_id TEXT NOT NULL,
+    stake real NOT NULL,
+    asking_price real NOT NULL,
+    status TEXT NOT NULL,
+    buyer_id TEXT,
+    sold_at timestamp with time zone,
+    created_at timestamp with time zone NOT NULL,
+    updated_at timestamp with time zone NOT NULL,
+    CONSTRAINT resale_listing_pk PRIMARY KEY (listing_id),
+    CONSTRAINT resale_listing_purchase_fk FOREIGN KEY (purchase_id) REFERENCES public.purchase(purchase_id),
+    CONSTRAINT resale_listing_wager_fk FOREIGN KEY (wager_id) REFERENCES public.wager(wager_id),
+    CONSTRAINT resale_listing_status_check CHECK (status IN ('open', 'sold', 'cancelled'))
+);
+ALTER SEQUENCE IF EXISTS resale_listing_id_seq OWNED BY resale_listing.listing_id;
+CREATE INDEX IF NOT EXISTS resale_listing_open_idx ON public.resale_listing (purchase_id, seller_id) WHERE status = 'open';
+
+-- stake_transfer table, the change of holder of a stake sold through a listing
+CREATE SEQUENCE IF NOT EXISTS public.stake_transfer_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+CREATE TABLE IF NOT EXISTS public.stake_transfer (
+    transfer_id integer NOT NULL DEFAULT nextval('stake_transfer_id_seq'),
+    listing_id integer NOT NULL,
+    purchase_id integer NOT NULL,
+    wager_id integer NOT NULL,
+    from_holder_id TEXT NOT NULL,
+    to_holder_id TEXT NOT NULL,
+    stake real NOT NULL,
+    price real NOT NULL,
+    transferred_at timestamp with time zone NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    CONSTRAINT stake_transfer_pk PRIMARY KEY (transfer_id),
+    CONSTRAINT stake_transfer_listing_fk FOREIGN KEY (listing_id) REFERENCES public.resale_listing(listing_id),
+    CONSTRAINT stake_transfer_purchase_fk FOREIGN KEY (purchase_id) REFERENCES public.purchase(purchase_id)
+);
+ALTER SEQUENCE IF EXISTS stake_transfer_id_seq OWNED BY stake_transfer.transfer_id;
+CREATE INDEX IF NOT EXISTS stake_transfer_purchase_id_idx ON public.stake_transfer (purchase_id);
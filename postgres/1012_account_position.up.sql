@@ -0,0 +1,4 @@
+-- the positions of an account are summed up from what it bought and what it resold
+CREATE INDEX IF NOT EXISTS purchase_buyer_id_idx ON public.purchase (buyer_id) WHERE buyer_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS stake_transfer_to_holder_id_idx ON public.stake_transfer (to_holder_id);
+CREATE INDEX IF NOT EXISTS stake_transfer_from_holder_id_idx ON public.stake_transfer (from_holder_id);
@@ -0,0 +1,25 @@
+-- purchase_fee table, the fees charged on a purchase on top of its buying price, one
+-- line per kind of fee
+CREATE SEQUENCE IF NOT EXISTS public.purchase_fee_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+CREATE TABLE IF NOT EXISTS public.purchase_fee (
+    fee_id integer NOT NULL DEFAULT nextval('purchase_fee_id_seq'),
+    purchase_id integer NOT NULL,
+    wager_id integer NOT NULL,
+    market_id integer,
+    fee_type TEXT NOT NULL,
+    rate real NOT NULL,
+    amount real NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    CONSTRAINT purchase_fee_pk PRIMARY KEY (fee_id),
+    CONSTRAINT purchase_fee_purchase_fk FOREIGN KEY (purchase_id) REFERENCES public.purchase(purchase_id),
+    CONSTRAINT purchase_fee_type_check CHECK (fee_type IN ('percentage', 'flat'))
+);
+ALTER SEQUENCE IF EXISTS purchase_fee_id_seq OWNED BY purchase_fee.fee_id;
+CREATE INDEX IF NOT EXISTS purchase_fee_purchase_id_idx ON public.purchase_fee (purchase_id);
+CREATE INDEX IF NOT EXISTS purchase_fee_created_at_idx ON public.purchase_fee (created_at);
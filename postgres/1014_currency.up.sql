@@ -0,0 +1,39 @@
+-- the ISO-4217 currency of the amounts of a wager, the wagers placed before are in USD
+ALTER TABLE public.wager ADD COLUMN IF NOT EXISTS currency TEXT NOT NULL DEFAULT 'USD';
+-- what the buyer of a purchase paid in its own currency, at the exchange rate from the
+-- currency of the wager which is null when they are the same
+ALTER TABLE public.purchase ADD COLUMN IF NOT EXISTS paid_currency TEXT;
+ALTER TABLE public.purchase ADD COLUMN IF NOT EXISTS paid_amount real;
+ALTER TABLE public.purchase ADD COLUMN IF NOT EXISTS exchange_rate double precision;
+
+-- account table, the currency a buyer pays in
+CREATE TABLE IF NOT EXISTS public.account (
+    account_id TEXT NOT NULL,
+    currency TEXT NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    updated_at timestamp with time zone NOT NULL,
+    CONSTRAINT account_pk PRIMARY KEY (account_id)
+);
+
+-- exchange_rate table, 1 base_currency is worth rate quote_currency from effective_from
+-- until the next rate of the pair
+CREATE SEQUENCE IF NOT EXISTS public.exchange_rate_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+CREATE TABLE IF NOT EXISTS public.exchange_rate (
+    rate_id integer NOT NULL DEFAULT nextval('exchange_rate_id_seq'),
+    base_currency TEXT NOT NULL,
+    quote_currency TEXT NOT NULL,
+    rate double precision NOT NULL,
+    effective_from timestamp with time zone NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    CONSTRAINT exchange_rate_pk PRIMARY KEY (rate_id),
+    CONSTRAINT exchange_rate_rate_check CHECK (rate > 0),
+    CONSTRAINT exchange_rate_pair_check CHECK (base_currency <> quote_currency)
+);
+ALTER SEQUENCE IF EXISTS exchange_rate_id_seq OWNED BY exchange_rate.rate_id;
+CREATE INDEX IF NOT EXISTS exchange_rate_pair_idx ON public.exchange_rate (base_currency, quote_currency, effective_from DESC);
@@ -0,0 +1,50 @@
+-- the account a change of the audit log is about, when it isn't about a wager
+ALTER TABLE public.audit_log ADD COLUMN IF NOT EXISTS account_id TEXT;
+CREATE INDEX IF NOT EXISTS audit_log_account_idx ON public.audit_log (account_id, audit_id);
+
+-- spend_limit table, the limits an account set on what it spends per period, the one in
+-- force is the latest effective one. A null amount removes the limit, the increases and
+-- the removals take effect after a delay.
+CREATE SEQUENCE IF NOT EXISTS public.spend_limit_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+CREATE TABLE IF NOT EXISTS public.spend_limit (
+    limit_id integer NOT NULL DEFAULT nextval('spend_limit_id_seq'),
+    account_id TEXT NOT NULL,
+    period TEXT NOT NULL,
+    amount real,
+    effective_from timestamp with time zone NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    CONSTRAINT spend_limit_pk PRIMARY KEY (limit_id),
+    CONSTRAINT spend_limit_period_check CHECK (period IN ('day', 'week', 'month')),
+    CONSTRAINT spend_limit_amount_check CHECK (amount IS NULL OR amount > 0)
+);
+ALTER SEQUENCE IF EXISTS spend_limit_id_seq OWNED BY spend_limit.limit_id;
+CREATE INDEX IF NOT EXISTS spend_limit_account_idx ON public.spend_limit (account_id, period, effective_from DESC);
+
+-- account_restriction table, the cool-off periods and the self-exclusions of an account
+-- which can't buy nor sell until ends_at, never when it is null
+CREATE SEQUENCE IF NOT EXISTS public.account_restriction_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+CREATE TABLE IF NOT EXISTS public.account_restriction (
+    restriction_id integer NOT NULL DEFAULT nextval('account_restriction_id_seq'),
+    account_id TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    starts_at timestamp with time zone NOT NULL,
+    ends_at timestamp with time zone,
+    created_at timestamp with time zone NOT NULL,
+    CONSTRAINT account_restriction_pk PRIMARY KEY (restriction_id),
+    CONSTRAINT account_restriction_kind_check CHECK (kind IN ('cool_off', 'self_exclusion')),
+    CONSTRAINT account_restriction_period_check CHECK (ends_at IS NULL OR ends_at > starts_at)
+);
+ALTER SEQUENCE IF EXISTS account_restriction_id_seq OWNED BY account_restriction.restriction_id;
+CREATE INDEX IF NOT EXISTS account_restriction_account_idx ON public.account_restriction (account_id, ends_at);
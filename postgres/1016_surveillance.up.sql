@@ -0,0 +1,54 @@
+-- account_link table, the accounts known to be held by the same party, a pair is stored
+-- once with the lowest account first
+CREATE SEQUENCE IF NOT EXISTS public.account_link_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+CREATE TABLE IF NOT EXISTS public.account_link (
+    link_id integer NOT NULL DEFAULT nextval('account_link_id_seq'),
+    account_id TEXT NOT NULL,
+    linked_account_id TEXT NOT NULL,
+    reason TEXT,
+    created_at timestamp with time zone NOT NULL,
+    CONSTRAINT account_link_pk PRIMARY KEY (link_id),
+    CONSTRAINT account_link_pair_check CHECK (account_id < linked_account_id),
+    CONSTRAINT account_link_pair_key UNIQUE (account_id, linked_account_id)
+);
+ALTER SEQUENCE IF EXISTS account_link_id_seq OWNED BY account_link.link_id;
+CREATE INDEX IF NOT EXISTS account_link_linked_account_idx ON public.account_link (linked_account_id);
+
+-- trade_alert table, the purchases a surveillance rule flagged, purchase_id is null when
+-- the rule blocked the purchase
+CREATE SEQUENCE IF NOT EXISTS public.trade_alert_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+CREATE TABLE IF NOT EXISTS public.trade_alert (
+    alert_id integer NOT NULL DEFAULT nextval('trade_alert_id_seq'),
+    rule TEXT NOT NULL,
+    wager_id integer NOT NULL,
+    purchase_id integer,
+    buyer_id TEXT,
+    seller_id TEXT,
+    buying_price real NOT NULL,
+    details JSONB,
+    blocked boolean NOT NULL DEFAULT false,
+    created_at timestamp with time zone NOT NULL,
+    CONSTRAINT trade_alert_pk PRIMARY KEY (alert_id),
+    CONSTRAINT trade_alert_wager_fk FOREIGN KEY (wager_id) REFERENCES public.wager(wager_id),
+    CONSTRAINT trade_alert_purchase_fk FOREIGN KEY (purchase_id) REFERENCES public.purchase(purchase_id)
+);
+ALTER SEQUENCE IF EXISTS trade_alert_id_seq OWNED BY trade_alert.alert_id;
+CREATE INDEX IF NOT EXISTS trade_alert_wager_idx ON public.trade_alert (wager_id, alert_id);
+CREATE INDEX IF NOT EXISTS trade_alert_buyer_idx ON public.trade_alert (buyer_id, alert_id);
+CREATE INDEX IF NOT EXISTS trade_alert_seller_idx ON public.trade_alert (seller_id, alert_id);
+
+-- the latest purchases of a wager and the resales of its stakes, looked up by the rules
+CREATE INDEX IF NOT EXISTS purchase_wager_bought_at_idx ON public.purchase (wager_id, bought_at);
+CREATE INDEX IF NOT EXISTS stake_transfer_wager_from_holder_idx ON public.stake_transfer (wager_id, from_holder_id, transferred_at);
@@ -0,0 +1,3 @@
+-- the lease of the outbox events a relay claimed, they are published outside of any
+-- transaction and the other relays leave them alone until locked_until
+ALTER TABLE public.outbox ADD COLUMN IF NOT EXISTS locked_until timestamp with time zone;
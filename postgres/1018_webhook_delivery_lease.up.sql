@@ -0,0 +1,3 @@
+-- the lease of the webhook deliveries a dispatcher claimed, they are posted outside of any
+-- transaction and the other dispatchers leave them alone until locked_until
+ALTER TABLE public.webhook_delivery ADD COLUMN IF NOT EXISTS locked_until timestamp with time zone;
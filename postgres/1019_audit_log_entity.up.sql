@@ -0,0 +1,5 @@
+-- the record an audit entry is about when it is neither a wager nor an account, and
+-- whether the actor was authenticated or only claimed by the request
+ALTER TABLE public.audit_log ADD COLUMN IF NOT EXISTS entity_id TEXT;
+ALTER TABLE public.audit_log ADD COLUMN IF NOT EXISTS actor_verified BOOLEAN NOT NULL DEFAULT false;
+CREATE INDEX IF NOT EXISTS audit_log_entity_idx ON public.audit_log (entity_id, audit_id);
@@ -0,0 +1,4 @@
+-- the resales of the stakes are charged fees as well, the fee lines of a resale keep the
+-- purchase of the stake and reference the transfer, they have no transfer otherwise
+ALTER TABLE public.purchase_fee ADD COLUMN IF NOT EXISTS transfer_id integer REFERENCES public.stake_transfer(transfer_id);
+CREATE INDEX IF NOT EXISTS purchase_fee_transfer_id_idx ON public.purchase_fee (transfer_id);
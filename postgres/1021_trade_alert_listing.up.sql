@@ -0,0 +1,4 @@
+-- the surveillance rules run on the purchases of the listings as well, their alerts
+-- reference the listing instead of a purchase
+ALTER TABLE public.trade_alert ADD COLUMN IF NOT EXISTS listing_id integer REFERENCES public.resale_listing(listing_id);
+CREATE INDEX IF NOT EXISTS trade_alert_listing_idx ON public.trade_alert (listing_id);
@@ -0,0 +1,5 @@
+-- what the buyer of a listing paid in its own currency, at the exchange rate from the
+-- currency of the wager which is null when they are the same
+ALTER TABLE public.stake_transfer ADD COLUMN IF NOT EXISTS paid_currency TEXT;
+ALTER TABLE public.stake_transfer ADD COLUMN IF NOT EXISTS paid_amount real;
+ALTER TABLE public.stake_transfer ADD COLUMN IF NOT EXISTS exchange_rate double precision;